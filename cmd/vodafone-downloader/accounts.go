@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// AccountConfig is one MeinVodafone login of a multi-account config. Each account is
+// processed in its own run with its own browser, state files and invoice email.
+type AccountConfig struct {
+	Name       string `yaml:"name"` // identifies the account in logs, state file names and {account}
+	User       string `yaml:"user"`
+	Pass       string `yaml:"pass"`
+	TOTPSecret string `yaml:"totp_secret"`
+	To         string `yaml:"to"` // recipient of the account's invoice email, defaults to email.to
+}
+
+var accountNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// checkAccounts validates the accounts section: names must be unique and usable in
+// file names.
+func checkAccounts(accounts []AccountConfig) error {
+	seen := map[string]bool{}
+	for i, a := range accounts {
+		if !accountNamePattern.MatchString(a.Name) {
+			return fmt.Errorf("account %d: name %q must consist of letters, digits, - and _", i+1, a.Name)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("account %d: duplicate name %q", i+1, a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return nil
+}
+
+// accountPath inserts the account name before the extension of a state file or
+// appends it to a directory, e.g. "history.json" → "history.anna.json". Paths with an
+// {account} placeholder are expanded instead. Empty paths stay empty.
+func accountPath(path, name string) string {
+	if path == "" {
+		return ""
+	}
+	if strings.Contains(path, "{account}") {
+		return strings.ReplaceAll(path, "{account}", name)
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
+
+// selectAccount turns a multi-account config into the config of the named account:
+// its credentials and recipient replace those of the vodafone and email sections,
+// and state files get the account name, so accounts never share a session, history
+// or checkpoint. Storage paths may contain {account}.
+func (c *Config) selectAccount(name string) error {
+	var account *AccountConfig
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == name {
+			account = &c.Accounts[i]
+		}
+	}
+	if account == nil {
+		return fmt.Errorf("unknown account %q", name)
+	}
+
+	c.Vodafone.User, c.Vodafone.Pass = account.User, account.Pass
+	c.Vodafone.TOTPSecret = account.TOTPSecret
+	if account.To != "" {
+		c.Email.To = account.To
+	}
+	for _, path := range []*string{
+		&c.Vodafone.ProfileDir,
+		&c.Vodafone.CookieFile,
+		&c.History.File,
+		&c.History.Feed,
+		&c.Breaker.File,
+		&c.Checkpoint.File,
+		&c.Email.SentFile,
+		&c.Database.File,
+		&c.CSV.File,
+		&c.Metrics.Textfile,
+	} {
+		*path = accountPath(*path, name)
+	}
+	for i := range c.Storage {
+		c.Storage[i].Path = strings.ReplaceAll(c.Storage[i].Path, "{account}", name)
+	}
+	if c.Metrics.Pushgateway != "" {
+		instance := c.Metrics.Instance
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+		c.Metrics.Instance = instance + "_" + name
+	}
+	return nil
+}
+
+// runAccounts runs the download once per account as a child process with --account,
+// so every account gets its own browser and exit code. The schedule jitter was
+// applied before and args leave out --jitter, so the children start right away. All
+// accounts are run even if one fails; the exit code of the first failed account is
+// returned.
+func runAccounts(accounts []AccountConfig, args []string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		slog.Error("Running accounts failed", "err", err)
+		return exitError
+	}
+	code := 0
+	for _, a := range accounts {
+		slog.Info("Account starting", "account", a.Name)
+		cmd := exec.Command(exe, append(append(globalArgs(), args...), "--account", a.Name)...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			slog.Info("Account done", "account", a.Name)
+			continue
+		case errors.As(err, &exitErr):
+			slog.Error("Account failed", "account", a.Name, "exit_code", exitErr.ExitCode())
+			if code == 0 {
+				code = exitErr.ExitCode()
+			}
+		default:
+			slog.Error("Account failed", "account", a.Name, "err", err)
+			if code == 0 {
+				code = exitError
+			}
+		}
+	}
+	return code
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestCheckAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []AccountConfig
+		wantErr  bool
+	}{
+		{"valid", []AccountConfig{{Name: "anna"}, {Name: "eltern_2"}}, false},
+		{"missing name", []AccountConfig{{User: "anna@example.com"}}, true},
+		{"name with slash", []AccountConfig{{Name: "a/b"}}, true},
+		{"duplicate name", []AccountConfig{{Name: "anna"}, {Name: "anna"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAccounts(tt.accounts); (err != nil) != tt.wantErr {
+				t.Errorf("checkAccounts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAccountPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"history.json", "history.anna.json"},
+		{"state/checkpoint.json", "state/checkpoint.anna.json"},
+		{"chrome-profile", "chrome-profile.anna"},
+		{"state/{account}/cookies.json", "state/anna/cookies.json"},
+	}
+	for _, tt := range tests {
+		if got := accountPath(tt.path, "anna"); got != tt.want {
+			t.Errorf("accountPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSelectAccount(t *testing.T) {
+	cfg := Config{
+		Vodafone: vodafone.Config{User: "shared", Pass: "shared", CookieFile: "cookies.json"},
+		Email:    mailer.Config{To: "default@example.com"},
+		History:  HistoryConfig{File: "history.json"},
+		Storage:  []StorageConfig{{Type: "local", Path: "archive/{account}/{year}"}},
+		Accounts: []AccountConfig{
+			{Name: "anna", User: "anna@example.com", Pass: "a", To: "anna@example.com"},
+			{Name: "ben", User: "ben@example.com", Pass: "b"},
+		},
+	}
+
+	anna := cfg
+	anna.Storage = append([]StorageConfig(nil), cfg.Storage...)
+	if err := anna.selectAccount("anna"); err != nil {
+		t.Fatal(err)
+	}
+	if anna.Vodafone.User != "anna@example.com" || anna.Vodafone.Pass != "a" || anna.Email.To != "anna@example.com" {
+		t.Errorf("credentials not applied: %+v %+v", anna.Vodafone, anna.Email)
+	}
+	if anna.History.File != "history.anna.json" || anna.Vodafone.CookieFile != "cookies.anna.json" {
+		t.Errorf("state files = %q, %q", anna.History.File, anna.Vodafone.CookieFile)
+	}
+	if anna.Storage[0].Path != "archive/anna/{year}" {
+		t.Errorf("storage path = %q", anna.Storage[0].Path)
+	}
+
+	ben := cfg
+	ben.Storage = append([]StorageConfig(nil), cfg.Storage...)
+	if err := ben.selectAccount("ben"); err != nil {
+		t.Fatal(err)
+	}
+	if ben.Email.To != "default@example.com" {
+		t.Errorf("Email.To = %q, want the default recipient", ben.Email.To)
+	}
+
+	if err := cfg.selectAccount("carla"); err == nil {
+		t.Error("selectAccount() accepted an unknown account")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// Defaults for the rolling baseline when not set in config.
+const (
+	defaultAnomalyWindow     = 6
+	defaultAnomalyMinSamples = 3
+)
+
+// anomalyStddevFloor is the smallest standard deviation the z-score is computed with,
+// as a share of the mean. Without it, a flat baseline makes a change of one cent
+// infinitely unusual.
+const anomalyStddevFloor = 0.01
+
+// detectAnomaly compares an invoice amount against the baseline of previous amounts and
+// returns a human-readable reason if it deviates beyond the configured z-score or
+// percentage. Returns an empty string if the amount is unremarkable, unknown, or there
+// is not enough history yet.
+func detectAnomaly(baseline []float64, amount float64, c AnomalyConfig) string {
+	minSamples := c.MinSamples
+	if minSamples <= 0 {
+		minSamples = defaultAnomalyMinSamples
+	}
+	if amount <= 0 || len(baseline) < minSamples || (c.ZScore <= 0 && c.Percent <= 0) {
+		return ""
+	}
+
+	var sum float64
+	for _, v := range baseline {
+		sum += v
+	}
+	mean := sum / float64(len(baseline))
+
+	var variance float64
+	for _, v := range baseline {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(baseline)))
+	deviation := amount - mean
+
+	// Amounts matching the average to the cent are never anomalous
+	if math.Abs(deviation) < 0.005 {
+		return ""
+	}
+
+	direction := "über"
+	if deviation < 0 {
+		direction = "unter"
+	}
+
+	if c.ZScore > 0 {
+		stddev = math.Max(stddev, math.Abs(mean)*anomalyStddevFloor)
+		if stddev > 0 && math.Abs(deviation)/stddev > c.ZScore {
+			return fmt.Sprintf("%s liegt %s %s dem Durchschnitt von %s",
+				provider.FormatAmount(amount), provider.FormatAmount(math.Abs(deviation)), direction, provider.FormatAmount(mean))
+		}
+	}
+	if c.Percent > 0 && mean > 0 {
+		if percent := math.Abs(deviation) / mean * 100; percent > c.Percent {
+			return fmt.Sprintf("%s liegt %.0f %% %s dem Durchschnitt von %s",
+				provider.FormatAmount(amount), percent, direction, provider.FormatAmount(mean))
+		}
+	}
+	return ""
+}
+
+// flagAnomalies checks each invoice against the history baseline and records the reason
+// on the invoice. Invoices are added to the history afterwards.
+func flagAnomalies(invoices []provider.Invoice, h *History, c AnomalyConfig) {
+	window := c.Window
+	if window <= 0 {
+		window = defaultAnomalyWindow
+	}
+	for i := range invoices {
+		invoices[i].Anomaly = detectAnomaly(h.Amounts(invoices[i], window), invoices[i].Amount, c)
+		h.Add(invoices[i])
+	}
+}
+
+// anomalous returns the invoices that were flagged by flagAnomalies.
+func anomalous(invoices []provider.Invoice) []provider.Invoice {
+	var flagged []provider.Invoice
+	for _, inv := range invoices {
+		if inv.Anomaly != "" {
+			flagged = append(flagged, inv)
+		}
+	}
+	return flagged
+}
+
+// buildAnomalyMessage constructs the separate "check this bill" notification.
+// It goes to notify if set, otherwise to the regular invoice recipient.
+func buildAnomalyMessage(m *mailer.Mailer, flagged []provider.Invoice, notify string) *gomail.Message {
+	var body strings.Builder
+	body.WriteString("Folgende Rechnungen weichen ungewöhnlich stark von den Vormonaten ab:\n\n")
+	for _, inv := range flagged {
+		fmt.Fprintf(&body, "%s %s %s: %s\n", inv.Type, inv.MonthName, inv.Year, inv.Anomaly)
+	}
+	return m.AlertMessage(notify, "Vodafone-Rechnung prüfen", body.String())
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"mime"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestDetectAnomaly(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline []float64
+		amount   float64
+		cfg      AnomalyConfig
+		wantFlag bool
+	}{
+		{
+			name:     "within z-score",
+			baseline: []float64{40, 42, 44, 42},
+			amount:   43,
+			cfg:      AnomalyConfig{ZScore: 2},
+		},
+		{
+			name:     "beyond z-score",
+			baseline: []float64{40, 42, 44, 42},
+			amount:   60,
+			cfg:      AnomalyConfig{ZScore: 2},
+			wantFlag: true,
+		},
+		{
+			name:     "flat baseline with change",
+			baseline: []float64{24.98, 24.98, 24.98},
+			amount:   29.98,
+			cfg:      AnomalyConfig{ZScore: 3},
+			wantFlag: true,
+		},
+		{
+			name:     "flat baseline with one cent change",
+			baseline: []float64{24.98, 24.98, 24.98},
+			amount:   24.99,
+			cfg:      AnomalyConfig{ZScore: 3},
+		},
+		{
+			name:     "flat baseline without change",
+			baseline: []float64{24.98, 24.98, 24.98},
+			amount:   24.98,
+			cfg:      AnomalyConfig{ZScore: 3},
+		},
+		{
+			name:     "beyond percentage",
+			baseline: []float64{40, 40, 40},
+			amount:   50,
+			cfg:      AnomalyConfig{Percent: 20},
+			wantFlag: true,
+		},
+		{
+			name:     "within percentage",
+			baseline: []float64{40, 40, 40},
+			amount:   45,
+			cfg:      AnomalyConfig{Percent: 20},
+		},
+		{
+			name:     "drop is flagged too",
+			baseline: []float64{40, 40, 40},
+			amount:   20,
+			cfg:      AnomalyConfig{Percent: 20},
+			wantFlag: true,
+		},
+		{
+			name:     "not enough samples",
+			baseline: []float64{40, 40},
+			amount:   100,
+			cfg:      AnomalyConfig{Percent: 20},
+		},
+		{
+			name:     "custom min samples",
+			baseline: []float64{40, 40},
+			amount:   100,
+			cfg:      AnomalyConfig{Percent: 20, MinSamples: 2},
+			wantFlag: true,
+		},
+		{
+			name:     "no thresholds configured",
+			baseline: []float64{40, 40, 40},
+			amount:   100,
+			cfg:      AnomalyConfig{},
+		},
+		{
+			name:     "unknown amount",
+			baseline: []float64{40, 40, 40},
+			amount:   0,
+			cfg:      AnomalyConfig{Percent: 20, ZScore: 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := detectAnomaly(tc.baseline, tc.amount, tc.cfg)
+			if tc.wantFlag && got == "" {
+				t.Error("expected anomaly, got none")
+			}
+			if !tc.wantFlag && got != "" {
+				t.Errorf("expected no anomaly, got %q", got)
+			}
+		})
+	}
+}
+
+func TestFlagAnomalies(t *testing.T) {
+	h := &History{}
+	for _, month := range []string{"10", "11", "12"} {
+		h.Add(provider.Invoice{Type: "Kabel", Month: month, Year: "2025", Amount: 44.98})
+		h.Add(provider.Invoice{Type: "Mobilfunk", Month: month, Year: "2025", Amount: 24.98})
+	}
+
+	invoices := []provider.Invoice{
+		{Type: "Kabel", Month: "01", Year: "2026", MonthName: "Januar", Amount: 89.96},
+		{Type: "Mobilfunk", Month: "01", Year: "2026", MonthName: "Januar", Amount: 24.98},
+	}
+	flagAnomalies(invoices, h, AnomalyConfig{Percent: 25})
+
+	if invoices[0].Anomaly == "" {
+		t.Error("Kabel should be flagged")
+	}
+	if invoices[1].Anomaly != "" {
+		t.Errorf("Mobilfunk should not be flagged, got %q", invoices[1].Anomaly)
+	}
+	if len(h.Entries) != 8 {
+		t.Errorf("history has %d entries, want 8", len(h.Entries))
+	}
+	if flagged := anomalous(invoices); len(flagged) != 1 || flagged[0].Type != "Kabel" {
+		t.Errorf("anomalous() = %+v, want only Kabel", flagged)
+	}
+}
+
+func TestBuildMessageMarksAnomalies(t *testing.T) {
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+
+	m := sender.BuildMessage([]provider.Invoice{{Type: "Kabel", MonthName: "Januar", Year: "2026", Anomaly: "zu hoch"}}, nil, nil)
+	got := m.GetHeader("Subject")
+	if len(got) != 1 {
+		t.Fatalf("Subject = %v, want one value", got)
+	}
+	// gomail stores non-ASCII headers already encoded
+	subject, err := new(mime.WordDecoder).DecodeHeader(got[0])
+	if err != nil {
+		t.Fatalf("DecodeHeader failed: %v", err)
+	}
+	if subject != "[Prüfen] Deine PDF-Rechnungen von Vodafone" {
+		t.Errorf("Subject = %q, want marked default subject", subject)
+	}
+}
+
+func TestBuildAnomalyMessage(t *testing.T) {
+	flagged := []provider.Invoice{{Type: "Kabel", MonthName: "Januar", Year: "2026", Anomaly: "89.96 € weicht ab"}}
+
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+
+	t.Run("defaults to invoice recipient", func(t *testing.T) {
+		m := buildAnomalyMessage(sender, flagged, "")
+		if got := m.GetHeader("To"); len(got) != 1 || got[0] != "c@d.com" {
+			t.Errorf("To = %v, want [c@d.com]", got)
+		}
+	})
+
+	t.Run("separate recipient", func(t *testing.T) {
+		m := buildAnomalyMessage(sender, flagged, "alerts@d.com")
+		if got := m.GetHeader("To"); len(got) != 1 || got[0] != "alerts@d.com" {
+			t.Errorf("To = %v, want [alerts@d.com]", got)
+		}
+		var buf strings.Builder
+		m.WriteTo(&buf)
+		if !strings.Contains(buf.String(), "Kabel Januar 2026") {
+			t.Errorf("body missing invoice line, got: %s", buf.String())
+		}
+	})
+}
+
+func TestDetectAnomalyReason(t *testing.T) {
+	got := detectAnomaly([]float64{40, 40, 40}, 50, AnomalyConfig{Percent: 20})
+	if want := "50,00 € liegt 25 % über dem Durchschnitt von 40,00 €"; got != want {
+		t.Errorf("reason = %q, want %q", got, want)
+	}
+
+	got = detectAnomaly([]float64{40, 40, 40}, 30, AnomalyConfig{ZScore: 2})
+	if want := "30,00 € liegt 10,00 € unter dem Durchschnitt von 40,00 €"; got != want {
+		t.Errorf("reason = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupItem is a file or directory included in a backup and its name in the tarball.
+// Names are fixed, so a backup can be restored into a config with other paths.
+type backupItem struct {
+	name string
+	path string
+}
+
+// backupItems lists the state of a config: the config file itself, history, journal,
+// login breaker, sent invoices, database, cookies and browser profile and, with
+// archive, the local storage targets. The config file is named config.yaml,
+// config.json or config.toml by format.
+func backupItems(c *Config, archive bool) []backupItem {
+	items := []backupItem{{name: "config" + filepath.Ext(configPath), path: configPath}}
+	for _, item := range []backupItem{
+		{name: "history.json", path: c.History.File},
+		{name: "ledger", path: c.Ledger.File},
+		{name: "login-breaker.json", path: c.Breaker.File},
+		{name: "sent.json", path: c.Email.SentFile},
+		{name: "invoices.db", path: c.Database.File},
+		{name: "cookies.json", path: c.Vodafone.CookieFile},
+		{name: "profile", path: c.Vodafone.ProfileDir},
+	} {
+		if item.path != "" {
+			items = append(items, item)
+		}
+	}
+	if archive {
+		for i, s := range c.Storage {
+			if strings.EqualFold(s.Type, "local") && s.Path != "" {
+				items = append(items, backupItem{name: fmt.Sprintf("archive/%d", i+1), path: localStorageRoot(s.Path)})
+			}
+		}
+	}
+	return items
+}
+
+// writeBackup writes the items as a gzip-compressed tarball, the config file first.
+// Missing items are skipped; symlinks and other special files (such as Chrome's
+// profile locks) are left out. Returns the number of files written.
+func writeBackup(w io.Writer, items []backupItem) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	files := 0
+	for _, item := range items {
+		if _, err := os.Stat(item.path); errors.Is(err, fs.ErrNotExist) {
+			slog.Info("Skipping backup item, file does not exist", "item", item.name, "file", item.path)
+			continue
+		}
+		err := filepath.WalkDir(item.path, func(p string, e fs.DirEntry, err error) error {
+			if err != nil || !e.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(item.path, p)
+			if err != nil {
+				return err
+			}
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    path.Join(item.name, filepath.ToSlash(rel)),
+				Mode:    int64(info.Mode().Perm()),
+				Size:    int64(len(data)),
+				ModTime: info.ModTime(),
+			}); err != nil {
+				return err
+			}
+			files++
+			_, err = tw.Write(data)
+			return err
+		})
+		if err != nil {
+			return files, fmt.Errorf("%s: %v", item.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return files, err
+	}
+	return files, gz.Close()
+}
+
+// restoreBackup extracts a tarball written by writeBackup. The config it contains is
+// restored first, to --config if given, and decides where the other items go.
+// Existing files are only overwritten with force. Returns the number of files restored.
+func restoreBackup(r io.Reader, force bool) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(gz)
+
+	var items []backupItem
+	files := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return files, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return files, err
+		}
+
+		if items == nil {
+			if !strings.HasPrefix(hdr.Name, "config.") || strings.Contains(hdr.Name, "/") {
+				return files, fmt.Errorf("not a backup: config file missing")
+			}
+			c, err := parseConfig(hdr.Name, data)
+			if err != nil {
+				return files, err
+			}
+			items = backupItems(c, true)
+			items[0].name = hdr.Name
+			if configPath == defaultConfigPath {
+				items[0].path = hdr.Name
+			}
+		}
+
+		target, ok := restorePath(items, hdr.Name)
+		if !ok {
+			slog.Info("Skipping restore of item, not configured", "item", hdr.Name)
+			continue
+		}
+		if !force {
+			if _, err := os.Stat(target); err == nil {
+				return files, fmt.Errorf("%s already exists, use --force to overwrite", target)
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return files, err
+		}
+		if err := os.WriteFile(target, data, fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return files, err
+		}
+		os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		files++
+	}
+	if items == nil {
+		return files, fmt.Errorf("not a backup: config file missing")
+	}
+	return files, nil
+}
+
+// restorePath maps a tarball entry to its destination. Entries outside the items or
+// escaping their directory are rejected.
+func restorePath(items []backupItem, name string) (string, bool) {
+	for _, item := range items {
+		if name == item.name {
+			return item.path, true
+		}
+		if rel, ok := strings.CutPrefix(name, item.name+"/"); ok && filepath.IsLocal(filepath.FromSlash(rel)) {
+			return filepath.Join(item.path, filepath.FromSlash(rel)), true
+		}
+	}
+	return "", false
+}
+
+// runBackup implements the "backup" subcommand, which bundles the config and state
+// (and with --archive the locally stored PDFs) into one tarball for moving to a new host.
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("output", "", "output file (default vodafone-backup-<date>.tar.gz)")
+	archive := fs.Bool("archive", false, "include the local storage targets")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	path := *output
+	if path == "" {
+		path = "vodafone-backup-" + time.Now().Format("2006-01-02") + ".tar.gz"
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	files, err := writeBackup(f, backupItems(cfg, *archive))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	slog.Info("Backup written", "files", files, "file", path)
+	return nil
+}
+
+// runRestore implements the "restore" subcommand.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite existing files")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: restore [--force] <backup.tar.gz>")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	files, err := restoreBackup(f, *force)
+	if err != nil {
+		return err
+	}
+	slog.Info("Backup restored", "files", files)
+	return nil
+}
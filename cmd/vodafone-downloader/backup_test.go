@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	src := t.TempDir()
+	os.Chdir(src)
+	config := "history:\n  file: state/history.json\nvodafone:\n  profile_dir: profile\nstorage:\n  - type: local\n    path: archiv/{year}\n"
+	files := map[string]string{
+		"config.yaml":             config,
+		"state/history.json":      `{"entries":[]}`,
+		"profile/Default/Cookies": "cookies",
+		"archiv/2026/02_2026_Rechnung_Vodafone_Kabel.pdf": "%PDF-1.4",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(name), 0700)
+		os.WriteFile(name, []byte(content), 0600)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := writeBackup(&buf, backupItems(cfg, true))
+	if err != nil {
+		t.Fatalf("writeBackup() error: %v", err)
+	}
+	if n != len(files) {
+		t.Errorf("backed up %d files, want %d", n, len(files))
+	}
+	data := buf.Bytes()
+
+	dst := t.TempDir()
+	os.Chdir(dst)
+	if _, err := restoreBackup(bytes.NewReader(data), false); err != nil {
+		t.Fatalf("restoreBackup() error: %v", err)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := restoreBackup(bytes.NewReader(data), false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("restore over existing files: error = %v, want --force hint", err)
+	}
+	if _, err := restoreBackup(bytes.NewReader(data), true); err != nil {
+		t.Errorf("restore with force: %v", err)
+	}
+}
+
+func TestBackupItemsWithoutArchive(t *testing.T) {
+	c := &Config{Storage: []StorageConfig{{Type: "local", Path: "archiv"}}}
+	c.History.File = "history.json"
+	items := backupItems(c, false)
+	if len(items) != 2 || items[0].name != "config.yaml" || items[1].name != "history.json" {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestRestorePath(t *testing.T) {
+	items := []backupItem{{name: "history.json", path: "data/h.json"}, {name: "profile", path: "/var/lib/vd/profile"}}
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "history.json", want: "data/h.json", wantOK: true},
+		{name: "profile/Default/Cookies", want: "/var/lib/vd/profile/Default/Cookies", wantOK: true},
+		{name: "profile/../../etc/passwd"},
+		{name: "ledger"},
+	}
+	for _, tc := range tests {
+		got, ok := restorePath(items, tc.name)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("restorePath(%q) = %q, %v; want %q, %v", tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+const defaultBreakerMaxFailures = 3
+
+// loginBreaker is the persisted state of the login circuit breaker. Once it is open,
+// automatic runs no longer log in, so a wrong password in the config can't get the
+// account locked by daily attempts.
+type loginBreaker struct {
+	path         string
+	Failures     int       `json:"failures"` // consecutive rejected logins
+	LastFailure  time.Time `json:"last_failure,omitempty"`
+	BlockedSince time.Time `json:"blocked_since,omitempty"`
+}
+
+// loadBreaker reads the breaker state at path. A missing file yields a closed breaker.
+func loadBreaker(path string) (*loginBreaker, error) {
+	b := &loginBreaker{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// open reports whether automatic logins are blocked.
+func (b *loginBreaker) open() bool {
+	return !b.BlockedSince.IsZero()
+}
+
+// record updates the state with the outcome of a login and reports whether the
+// breaker has just opened. Only rejected credentials (ErrCredentialsRejected) count;
+// a successful login resets the count, other errors leave it unchanged.
+func (b *loginBreaker) record(err error, maxFailures int, now time.Time) bool {
+	switch {
+	case err == nil:
+		b.Failures, b.LastFailure, b.BlockedSince = 0, time.Time{}, time.Time{}
+	case errors.Is(err, provider.ErrCredentialsRejected):
+		b.Failures++
+		b.LastFailure = now
+		if b.Failures >= maxFailures && !b.open() {
+			b.BlockedSince = now
+			return true
+		}
+	}
+	return false
+}
+
+// err explains why the login was skipped.
+func (b *loginBreaker) err() error {
+	return fmt.Errorf("%w: blocked after %d rejected logins since %s; check the credentials, then run \"login\" or delete %s",
+		provider.ErrLoginFailed, b.Failures, b.BlockedSince.Format("2006-01-02 15:04"), b.path)
+}
+
+// save writes the breaker state back to its file.
+func (b *loginBreaker) save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, data, 0600)
+}
+
+// guardedLogin runs login unless the breaker is open and records its outcome. alert
+// is called when the breaker opens and on every run it blocks.
+func guardedLogin(c BreakerConfig, login func() error, alert func(*loginBreaker)) error {
+	if c.File == "" {
+		return login()
+	}
+	b, err := loadBreaker(c.File)
+	if err != nil {
+		return fmt.Errorf("login breaker: %v", err)
+	}
+	if b.open() {
+		alert(b)
+		return b.err()
+	}
+
+	maxFailures := c.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultBreakerMaxFailures
+	}
+	err = login()
+	tripped := b.record(err, maxFailures, time.Now())
+	if saveErr := b.save(); saveErr != nil {
+		slog.Warn("Saving login breaker failed", "err", saveErr)
+	}
+	if tripped {
+		slog.Error("Login rejected repeatedly, blocking further logins", "failures", b.Failures)
+		alert(b)
+	}
+	return err
+}
+
+// resetBreaker closes the breaker after the operator has logged in by hand.
+func resetBreaker(c BreakerConfig) {
+	if c.File == "" {
+		return
+	}
+	if err := os.Remove(c.File); err == nil {
+		slog.Info("Login breaker reset")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("Resetting login breaker failed", "err", err)
+	}
+}
+
+// buildBreakerMessage constructs the alert sent while the breaker blocks logins.
+func buildBreakerMessage(m *mailer.Mailer, b *loginBreaker, notify string) *gomail.Message {
+	body := fmt.Sprintf("Die Anmeldung bei MeinVodafone wurde %d-mal in Folge abgelehnt, zuletzt am %s.\n\n"+
+		"Damit das Konto nicht gesperrt wird, finden seit %s keine automatischen Anmeldungen mehr statt. "+
+		"Es werden keine Rechnungen abgerufen, bis die Sperre aufgehoben ist.\n\n"+
+		"Bitte die Zugangsdaten in config.yaml prüfen und danach \"vodafone-downloader login\" ausführen oder %s löschen.\n",
+		b.Failures, b.LastFailure.Format("02.01.2006 15:04"), b.BlockedSince.Format("02.01.2006 15:04"), b.path)
+	return m.AlertMessage(notify, "DRINGEND: Vodafone-Anmeldung gesperrt", body)
+}
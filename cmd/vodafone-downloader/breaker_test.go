@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestLoginBreakerRecord(t *testing.T) {
+	now := time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantFailures int
+		wantTripped  bool
+	}{
+		{name: "success resets", failures: 2, err: nil, wantFailures: 0},
+		{name: "rejected counts", failures: 0, err: provider.ErrCredentialsRejected, wantFailures: 1},
+		{name: "rejected trips", failures: 2, err: provider.ErrCredentialsRejected, wantFailures: 3, wantTripped: true},
+		{name: "wrapped rejection counts", failures: 0, err: fmt.Errorf("login: %w", provider.ErrCredentialsRejected), wantFailures: 1},
+		{name: "page unreachable ignored", failures: 2, err: fmt.Errorf("%w: login page not reachable", provider.ErrLoginFailed), wantFailures: 2},
+		{name: "two-factor ignored", failures: 2, err: provider.Err2FARequired, wantFailures: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &loginBreaker{Failures: tc.failures}
+			tripped := b.record(tc.err, 3, now)
+			if tripped != tc.wantTripped {
+				t.Errorf("tripped = %v, want %v", tripped, tc.wantTripped)
+			}
+			if b.Failures != tc.wantFailures {
+				t.Errorf("failures = %d, want %d", b.Failures, tc.wantFailures)
+			}
+			if b.open() != tc.wantTripped {
+				t.Errorf("open = %v, want %v", b.open(), tc.wantTripped)
+			}
+		})
+	}
+}
+
+func TestGuardedLogin(t *testing.T) {
+	c := BreakerConfig{File: filepath.Join(t.TempDir(), "breaker.json"), MaxFailures: 2}
+	logins, alerts := 0, 0
+	login := func() error {
+		logins++
+		return provider.ErrCredentialsRejected
+	}
+	alert := func(*loginBreaker) { alerts++ }
+
+	for i := 0; i < 4; i++ {
+		err := guardedLogin(c, login, alert)
+		if !errors.Is(err, provider.ErrLoginFailed) {
+			t.Fatalf("run %d: error = %v, want ErrLoginFailed", i+1, err)
+		}
+	}
+	if logins != 2 {
+		t.Errorf("logins = %d, want 2 (blocked afterwards)", logins)
+	}
+	if alerts != 3 {
+		t.Errorf("alerts = %d, want 3 (on opening and on each blocked run)", alerts)
+	}
+
+	resetBreaker(c)
+	if _, err := os.Stat(c.File); !os.IsNotExist(err) {
+		t.Errorf("breaker file still exists after reset: %v", err)
+	}
+	if err := guardedLogin(c, func() error { return nil }, alert); err != nil {
+		t.Errorf("login after reset: %v", err)
+	}
+}
+
+func TestGuardedLoginDisabled(t *testing.T) {
+	calls := 0
+	for i := 0; i < 5; i++ {
+		guardedLogin(BreakerConfig{}, func() error { calls++; return provider.ErrCredentialsRejected }, func(*loginBreaker) {
+			t.Error("alert without breaker file")
+		})
+	}
+	if calls != 5 {
+		t.Errorf("logins = %d, want 5", calls)
+	}
+}
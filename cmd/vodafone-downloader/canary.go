@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// canaryResult is the outcome of a canary check.
+type canaryResult struct {
+	LoginErr error
+	Checked  []string           // contracts whose invoice page was opened
+	Failures []provider.Failure // contracts whose invoice page did not load
+}
+
+// ok reports whether the login and every checked invoice page succeeded.
+func (r canaryResult) ok() bool {
+	return r.LoginErr == nil && len(r.Failures) == 0
+}
+
+// canaryContracts returns the contract types to check, defaulting to the ones that
+// are downloaded.
+func canaryContracts(c CanaryConfig, contracts []string) []string {
+	if len(c.Contracts) > 0 {
+		return c.Contracts
+	}
+	return contracts
+}
+
+// buildCanaryMessage reports a failed canary check. It goes to notify if set,
+// otherwise to the regular invoice recipient.
+func buildCanaryMessage(m *mailer.Mailer, r canaryResult, notify string) *gomail.Message {
+	var body strings.Builder
+	body.WriteString("Der Kontrolllauf konnte MeinVodafone nicht vollständig prüfen.\n\n")
+	if r.LoginErr != nil {
+		fmt.Fprintf(&body, "Anmeldung: %v\n", r.LoginErr)
+	}
+	for _, f := range r.Failures {
+		fmt.Fprintf(&body, "%s: %s\n", f.Type, f.Reason)
+	}
+	body.WriteString("\nDer nächste Rechnungsabruf wird voraussichtlich fehlschlagen.\n")
+	msg := m.AlertMessage(notify, "Vodafone-Kontrolllauf fehlgeschlagen", body.String())
+	mailer.AttachScreenshot(msg, r.Failures)
+	return msg
+}
+
+// pingHealthcheck reports the canary outcome to a dead man's switch such as
+// healthchecks.io: the URL itself on success, "<url>/fail" on failure.
+func pingHealthcheck(pingURL string, ok bool) error {
+	if !ok {
+		return pingMonitor(pingURL, pingFail, "")
+	}
+	return pingMonitor(pingURL, "", "")
+}
+
+// runCanary implements the "canary" subcommand: it only logs in and opens the invoice
+// pages, and reports the result via the health ping, the Pushgateway and, on failure,
+// an alert email. Frequent canary runs detect expired credentials or portal changes
+// long before the monthly download.
+func runCanary() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	retry, err := provider.NewRetryPolicy(cfg.Retry)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if err := mailer.CheckDelivery(cfg.Email); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	limitFlags, memoryLimit, err := vodafone.BrowserLimits(cfg.Vodafone)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	timeouts, err := vodafone.NewTimeouts(cfg.Timeouts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	contracts, err := vodafone.Contracts(cfg.Vodafone)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	downloader := vodafone.NewClient(cfg.Vodafone)
+	downloader.DebugDir = orDefault(cfg.Debug.Dir, vodafone.DefaultDebugDir)
+	downloader.DumpHTML = cfg.Debug.DumpHTML
+	sender := mailer.New(cfg.Email, cfg.SMTP)
+	downloader.Retry, sender.Retry = retry, retry
+
+	start := time.Now()
+	ctx, cancel := vodafone.NewBrowserContext(cfg.Chrome.RemoteURL, cfg.Vodafone.ProfileDir, true, timeouts, limitFlags...)
+	defer cancel()
+	ctx = vodafone.WatchMemory(ctx, memoryLimit)
+	if err := vodafone.BlockRequests(ctx, cfg.Vodafone.Block); err != nil {
+		slog.Warn("Request blocking failed", "err", err)
+	}
+
+	var result canaryResult
+	slog.Info("Canary: logging in", "step", provider.StageLogin)
+	login := func() error { return retry.Do(provider.StageLogin, func() error { return downloader.Login(ctx) }) }
+	result.LoginErr = guardedLogin(cfg.Breaker, login, func(b *loginBreaker) {
+		if err := sender.SendMessage(buildBreakerMessage(sender, b, cfg.Breaker.Notify)); err != nil {
+			slog.Warn("Alert failed", "err", err)
+		}
+	})
+	if result.LoginErr == nil {
+		for _, contract := range canaryContracts(cfg.Canary, contracts) {
+			typeName := contractTypeName(contract)
+			slog.Info("Canary: checking invoice page", "contract", typeName, "step", provider.StageNavigation)
+			result.Checked = append(result.Checked, typeName)
+			if f := downloader.CheckInvoicePage(ctx, strings.ToLower(contract), typeName); f != nil {
+				result.Failures = append(result.Failures, *f)
+			}
+		}
+	}
+
+	if cfg.Canary.PingURL != "" {
+		if err := pingHealthcheck(cfg.Canary.PingURL, result.ok()); err != nil {
+			slog.Warn("Health ping failed", "err", err)
+		}
+	}
+	if cfg.Metrics.Pushgateway != "" {
+		metrics := cfg.Metrics
+		metrics.Job = orDefault(metrics.Job, defaultMetricsJob) + "_canary"
+		m := runMetrics{Start: start, End: time.Now(), LoginErr: result.LoginErr, Canary: true, Checked: result.Checked, Failures: result.Failures}
+		if err := pushMetrics(metrics, m); err != nil {
+			slog.Warn("Metrics failed", "err", err)
+		}
+	}
+
+	if result.ok() {
+		slog.Info("Canary: login and invoice pages OK")
+		return nil
+	}
+	if err := sender.SendMessage(buildCanaryMessage(sender, result, cfg.Canary.Notify)); err != nil {
+		slog.Warn("Alert failed", "err", err)
+	}
+	if result.LoginErr != nil {
+		return result.LoginErr
+	}
+	var failed []string
+	for _, f := range result.Failures {
+		failed = append(failed, f.Type)
+	}
+	return fmt.Errorf("invoice page check failed: %s", strings.Join(failed, ", "))
+}
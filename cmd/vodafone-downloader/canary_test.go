@@ -0,0 +1,82 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestCanaryContracts(t *testing.T) {
+	if got := canaryContracts(CanaryConfig{}, vodafone.DefaultContracts); !reflect.DeepEqual(got, []string{"kabel", "mobilfunk"}) {
+		t.Errorf("canaryContracts() = %v, want the downloaded contracts", got)
+	}
+	if got := canaryContracts(CanaryConfig{Contracts: []string{"Kabel"}}, vodafone.DefaultContracts); !reflect.DeepEqual(got, []string{"Kabel"}) {
+		t.Errorf("canaryContracts() = %v, want configured contracts", got)
+	}
+}
+
+func TestBuildCanaryMessage(t *testing.T) {
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+	msg := buildCanaryMessage(sender, canaryResult{
+		Checked:  []string{"Kabel", "Mobilfunk"},
+		Failures: []provider.Failure{{Type: "Kabel", Reason: "invoice page did not load"}},
+	}, "ops@example.com")
+
+	if got := msg.GetHeader("To"); len(got) != 1 || got[0] != "ops@example.com" {
+		t.Errorf("To = %v, want ops@example.com", got)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.GetHeader("Subject")[0])
+	if subject != "Vodafone-Kontrolllauf fehlgeschlagen" {
+		t.Errorf("Subject = %q", subject)
+	}
+	var buf strings.Builder
+	msg.WriteTo(&buf)
+	body := buf.String()
+	if !strings.Contains(body, "Kabel: invoice page did not load") || strings.Contains(body, "Mobilfunk") {
+		t.Errorf("body should list only the failed contract:\n%s", body)
+	}
+}
+
+func TestPingHealthcheck(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	if err := pingHealthcheck(srv.URL+"/ping/abc", true); err != nil {
+		t.Fatalf("pingHealthcheck() error: %v", err)
+	}
+	if err := pingHealthcheck(srv.URL+"/ping/abc/", false); err != nil {
+		t.Fatalf("pingHealthcheck() error: %v", err)
+	}
+	if want := []string{"/ping/abc", "/ping/abc/fail"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestFormatMetricsCanary(t *testing.T) {
+	got := formatMetrics(runMetrics{
+		Canary:   true,
+		Checked:  []string{"Kabel", "Mobilfunk"},
+		Failures: []provider.Failure{{Type: "Kabel"}},
+	})
+	for _, want := range []string{
+		`vodafone_downloader_invoice_page_ok{contract="Kabel"} 0`,
+		`vodafone_downloader_invoice_page_ok{contract="Mobilfunk"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "email_sent") {
+		t.Errorf("canary must not report download metrics:\n%s", got)
+	}
+}
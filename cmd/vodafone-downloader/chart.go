@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	chart "github.com/wcharczuk/go-chart/v2"
+)
+
+// renderSpendChart draws the invoice amounts of the 12 months up to and including end
+// as a PNG line chart with one line per contract. Returns nil if no contract has at
+// least two months of amounts, since a single point doesn't make a trend.
+func renderSpendChart(h *History, end time.Time) ([]byte, error) {
+	end = time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, -11, 0)
+
+	series := map[string]*chart.TimeSeries{}
+	var maxAmount float64
+	for _, e := range h.Entries {
+		period, err := time.Parse("2006-01", e.period())
+		if err != nil || period.Before(start) || period.After(end) || e.Amount <= 0 {
+			continue
+		}
+		s, ok := series[e.Type]
+		if !ok {
+			s = &chart.TimeSeries{Name: e.Type}
+			series[e.Type] = s
+		}
+		s.XValues = append(s.XValues, period)
+		s.YValues = append(s.YValues, e.Amount)
+		if e.Amount > maxAmount {
+			maxAmount = e.Amount
+		}
+	}
+
+	var names []string
+	for name, s := range series {
+		if len(s.XValues) >= 2 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	graph := chart.Chart{
+		Width:  640,
+		Height: 320,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 20, Left: 20, Right: 20, Bottom: 10},
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("01/2006"),
+		},
+		YAxis: chart.YAxis{
+			// A fixed range starting at zero avoids a zero-height range for flat amounts
+			Range: &chart.ContinuousRange{Min: 0, Max: maxAmount * 1.2},
+			ValueFormatter: func(v interface{}) string {
+				return provider.FormatAmount(v.(float64))
+			},
+		},
+	}
+	for _, name := range names {
+		graph.Series = append(graph.Series, *series[name])
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
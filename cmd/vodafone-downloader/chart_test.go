@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestRenderSpendChart(t *testing.T) {
+	h := &History{}
+	for _, month := range []string{"11", "12"} {
+		h.Add(provider.Invoice{Type: "Kabel", Month: month, Year: "2025", Amount: 44.98})
+		h.Add(provider.Invoice{Type: "Mobilfunk", Month: month, Year: "2025", Amount: 24.98})
+	}
+	h.Add(provider.Invoice{Type: "Kabel", Month: "01", Year: "2026", Amount: 49.98})
+
+	data, err := renderSpendChart(h, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("renderSpendChart() error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("chart is not a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 640 || b.Dy() != 320 {
+		t.Errorf("chart size = %dx%d, want 640x320", b.Dx(), b.Dy())
+	}
+}
+
+func TestRenderSpendChartNotEnoughData(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []provider.Invoice
+	}{
+		{name: "empty history"},
+		{
+			name:    "single month per contract",
+			entries: []provider.Invoice{{Type: "Kabel", Month: "01", Year: "2026", Amount: 44.98}, {Type: "Mobilfunk", Month: "01", Year: "2026", Amount: 24.98}},
+		},
+		{
+			name:    "older than 12 months",
+			entries: []provider.Invoice{{Type: "Kabel", Month: "01", Year: "2024", Amount: 44.98}, {Type: "Kabel", Month: "02", Year: "2024", Amount: 44.98}},
+		},
+		{
+			name:    "unknown amounts",
+			entries: []provider.Invoice{{Type: "Kabel", Month: "12", Year: "2025"}, {Type: "Kabel", Month: "01", Year: "2026"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &History{}
+			for _, inv := range tc.entries {
+				h.Add(inv)
+			}
+			data, err := renderSpendChart(h, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("renderSpendChart() error: %v", err)
+			}
+			if data != nil {
+				t.Errorf("expected no chart, got %d bytes", len(data))
+			}
+		})
+	}
+}
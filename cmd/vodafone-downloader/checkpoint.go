@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const defaultCheckpointWindow = 2 * time.Hour
+
+// Stages of a run after the download that are recorded in the checkpoint.
+const (
+	checkpointRecorded = "recorded" // history, Google Sheet and journal updated
+	checkpointStored   = "stored"   // PDFs archived in every storage target
+)
+
+// checkpoint is the progress of an unfinished run. A run started again within the
+// window resumes after the last completed step instead of downloading, recording and
+// storing everything again. A nil checkpoint records nothing.
+type checkpoint struct {
+	path      string
+	Started   time.Time                     `json:"started"`
+	Contracts map[string][]provider.Invoice `json:"contracts"` // contract type -> downloaded invoices
+	Stages    map[string]bool               `json:"stages"`
+}
+
+// loadCheckpoint returns the checkpoint to resume from, or a new one if there is none
+// or it is older than the window. Returns nil if checkpoints are disabled.
+func loadCheckpoint(c CheckpointConfig, now time.Time) (*checkpoint, error) {
+	if c.File == "" {
+		return nil, nil
+	}
+	window := defaultCheckpointWindow
+	if c.Window != "" {
+		var err error
+		if window, err = time.ParseDuration(c.Window); err != nil {
+			return nil, fmt.Errorf("checkpoint.window: %v", err)
+		}
+	}
+
+	fresh := &checkpoint{path: c.File, Started: now, Contracts: map[string][]provider.Invoice{}, Stages: map[string]bool{}}
+	data, err := os.ReadFile(c.File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint{path: c.File}
+	if err := json.Unmarshal(data, cp); err != nil {
+		slog.Warn("Ignoring unreadable checkpoint", "err", err)
+		return fresh, nil
+	}
+	if now.Sub(cp.Started) > window {
+		slog.Info("Ignoring expired checkpoint", "started", cp.Started.Format("2006-01-02 15:04"))
+		return fresh, nil
+	}
+	if cp.Contracts == nil {
+		cp.Contracts = map[string][]provider.Invoice{}
+	}
+	if cp.Stages == nil {
+		cp.Stages = map[string]bool{}
+	}
+	slog.Info("Resuming run", "started", cp.Started.Format("15:04"))
+	return cp, nil
+}
+
+// Done returns the invoices of a contract downloaded before the resume.
+func (cp *checkpoint) Done(contractType string) ([]provider.Invoice, bool) {
+	if cp == nil {
+		return nil, false
+	}
+	invoices, ok := cp.Contracts[contractType]
+	return invoices, ok
+}
+
+// allDone reports whether every given contract was downloaded before the resume.
+func (cp *checkpoint) allDone(contracts []string) bool {
+	if cp == nil {
+		return false
+	}
+	for _, contractType := range contracts {
+		if _, ok := cp.Contracts[contractType]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete records the invoices of a fully downloaded contract.
+func (cp *checkpoint) Complete(contractType string, invoices []provider.Invoice) {
+	if cp == nil {
+		return
+	}
+	cp.Contracts[contractType] = invoices
+	cp.save()
+}
+
+// reached reports whether a stage was completed before the resume.
+func (cp *checkpoint) reached(stage string) bool {
+	return cp != nil && cp.Stages[stage]
+}
+
+// mark records a completed stage. Invoices are updated from results by filename, so
+// that e.g. anomaly flags set in the stage are kept for the resumed email.
+func (cp *checkpoint) mark(stage string, results []provider.Invoice) {
+	if cp == nil {
+		return
+	}
+	byName := map[string]provider.Invoice{}
+	for _, inv := range results {
+		byName[inv.Filename] = inv
+	}
+	for _, invoices := range cp.Contracts {
+		for i, inv := range invoices {
+			if updated, ok := byName[inv.Filename]; ok {
+				invoices[i] = updated
+			}
+		}
+	}
+	cp.Stages[stage] = true
+	cp.save()
+}
+
+// save writes the checkpoint. Errors are logged; a lost checkpoint only costs a
+// complete rerun.
+func (cp *checkpoint) save() {
+	data, err := json.Marshal(cp)
+	if err == nil {
+		err = os.WriteFile(cp.path, data, 0600)
+	}
+	if err != nil {
+		slog.Warn("Saving checkpoint failed", "err", err)
+	}
+}
+
+// clear removes the checkpoint once the run has finished.
+func (cp *checkpoint) clear() {
+	if cp == nil {
+		return
+	}
+	if err := os.Remove(cp.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("Removing checkpoint failed", "err", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	c := CheckpointConfig{File: filepath.Join(t.TempDir(), "checkpoint.json"), Window: "1h"}
+	start := time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC)
+
+	cp, err := loadCheckpoint(c, start)
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error: %v", err)
+	}
+	if _, ok := cp.Done("kabel"); ok || cp.allDone(vodafone.DefaultContracts) {
+		t.Fatal("new checkpoint reports progress")
+	}
+	kabel := []provider.Invoice{{Type: "Kabel", Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", PDFData: []byte("%PDF-1.4")}}
+	cp.Complete("kabel", kabel)
+	cp.mark(checkpointRecorded, []provider.Invoice{{Type: "Kabel", Filename: kabel[0].Filename, Anomaly: "zu hoch", PDFData: kabel[0].PDFData}})
+
+	resumed, err := loadCheckpoint(c, start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error: %v", err)
+	}
+	invoices, ok := resumed.Done("kabel")
+	if !ok || len(invoices) != 1 || string(invoices[0].PDFData) != "%PDF-1.4" {
+		t.Errorf("kabel = %+v, %v", invoices, ok)
+	}
+	if invoices[0].Anomaly != "zu hoch" {
+		t.Errorf("anomaly = %q, want flag from the recorded stage", invoices[0].Anomaly)
+	}
+	if !resumed.reached(checkpointRecorded) || resumed.reached(checkpointStored) {
+		t.Errorf("stages = %v", resumed.Stages)
+	}
+	if resumed.allDone(vodafone.DefaultContracts) {
+		t.Error("allDone with Mobilfunk pending")
+	}
+
+	expired, err := loadCheckpoint(c, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error: %v", err)
+	}
+	if _, ok := expired.Done("kabel"); ok {
+		t.Error("checkpoint outside the window was resumed")
+	}
+
+	resumed.clear()
+	if _, err := os.Stat(c.File); !os.IsNotExist(err) {
+		t.Errorf("checkpoint still exists after clear: %v", err)
+	}
+}
+
+func TestCheckpointDisabled(t *testing.T) {
+	cp, err := loadCheckpoint(CheckpointConfig{}, time.Now())
+	if err != nil || cp != nil {
+		t.Fatalf("loadCheckpoint() = %v, %v; want nil", cp, err)
+	}
+	// A nil checkpoint is safe to use
+	cp.Complete("kabel", nil)
+	cp.mark(checkpointStored, nil)
+	cp.clear()
+	if cp.reached(checkpointStored) || cp.allDone(vodafone.DefaultContracts) {
+		t.Error("nil checkpoint reports progress")
+	}
+
+	if _, err := loadCheckpoint(CheckpointConfig{File: "x", Window: "2 hours"}, time.Now()); err == nil {
+		t.Error("invalid window: expected error")
+	}
+}
+
+func TestDownloadAllSkipsCheckpointedContracts(t *testing.T) {
+	c := CheckpointConfig{File: filepath.Join(t.TempDir(), "checkpoint.json")}
+	cp, _ := loadCheckpoint(c, time.Now())
+	cp.Complete("kabel", []provider.Invoice{{Type: "Kabel", Filename: "k.pdf"}})
+	cp.Complete("mobilfunk", []provider.Invoice{{Type: "Mobilfunk", Filename: "m.pdf"}})
+
+	d := vodafone.NewClient(vodafone.Config{})
+	d.Checkpoint = cp
+	// Any browser access would fail on the cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, failures := d.Download(ctx)
+	if len(results) != 2 || len(failures) != 0 {
+		t.Errorf("results = %+v, failures = %+v", results, failures)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// checkChrome validates the chrome section. A remote Chrome, e.g. a browserless/chrome
+// sidecar, is started by someone else, so settings that need control over the
+// Chrome process are refused with it.
+func checkChrome(c ChromeConfig, v vodafone.Config) error {
+	if c.RemoteURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.RemoteURL)
+	if err != nil || u.Host == "" || (u.Scheme != "ws" && u.Scheme != "wss" && u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("chrome.remote_url must be a ws(s):// or http(s):// URL")
+	}
+	switch {
+	case v.ProfileDir != "":
+		return fmt.Errorf("vodafone.profile_dir needs a local Chrome; use vodafone.cookie_file with chrome.remote_url")
+	case v.MemoryLimit != "" || v.JSHeap != "":
+		return fmt.Errorf("vodafone.memory_limit and vodafone.js_heap need a local Chrome")
+	}
+	return nil
+}
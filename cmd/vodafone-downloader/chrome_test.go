@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestCheckChrome(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ChromeConfig
+		vodafone vodafone.Config
+		wantErr  bool
+	}{
+		{name: "local", vodafone: vodafone.Config{ProfileDir: "chrome-profile", MemoryLimit: "600MB"}},
+		{name: "websocket", config: ChromeConfig{RemoteURL: "ws://chrome:9222"}},
+		{name: "browser endpoint", config: ChromeConfig{RemoteURL: "ws://127.0.0.1:9222/devtools/browser/8a3f"}, vodafone: vodafone.Config{CookieFile: "cookies.json"}},
+		{name: "browserless with token", config: ChromeConfig{RemoteURL: "wss://chrome.example.com?token=abc"}},
+		{name: "no scheme", config: ChromeConfig{RemoteURL: "chrome:9222"}, wantErr: true},
+		{name: "profile dir", config: ChromeConfig{RemoteURL: "ws://chrome:9222"}, vodafone: vodafone.Config{ProfileDir: "chrome-profile"}, wantErr: true},
+		{name: "memory limit", config: ChromeConfig{RemoteURL: "ws://chrome:9222"}, vodafone: vodafone.Config{JSHeap: "256MB"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkChrome(tt.config, tt.vodafone); (err != nil) != tt.wantErr {
+				t.Errorf("checkChrome() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
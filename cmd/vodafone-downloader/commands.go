@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// runVersion implements the "version" subcommand.
+func runVersion() {
+	fmt.Println("vodafone-downloader " + vodafone.Version)
+}
+
+// checkConfig runs every check of the config that a run does before starting Chrome.
+// All problems found are returned, joined.
+func checkConfig(c *Config) error {
+	var errs []error
+	if err := checkAccounts(c.Accounts); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := compileRules(c.Rules); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := vodafone.BlockPatterns(c.Vodafone.Block); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := vodafone.Contracts(c.Vodafone); err != nil {
+		errs = append(errs, err)
+	}
+	if _, _, err := vodafone.BrowserLimits(c.Vodafone); err != nil {
+		errs = append(errs, err)
+	}
+	if err := mailer.CheckDelivery(c.Email); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := mailer.LoadHTMLTemplate(c.Email.HTMLTemplate); err != nil {
+		errs = append(errs, fmt.Errorf("email.html_template: %v", err))
+	}
+	if _, err := mailer.ExpandSubject(c.Email.Subject, nil); err != nil {
+		errs = append(errs, fmt.Errorf("email.subject: %v", err))
+	}
+	if _, err := mailer.ExpandSubject(c.Email.InvoiceSubject, nil); err != nil {
+		errs = append(errs, fmt.Errorf("email.invoice_subject: %v", err))
+	}
+	if err := mailer.CheckSMTP(c.SMTP); err != nil {
+		errs = append(errs, err)
+	}
+	if err := mailer.CheckIMAP(c.Email.IMAP); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkTelegram(c.Telegram); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkSlack(c.Slack); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkNtfy(c.Ntfy); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkPushover(c.Pushover); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkMatrix(c.Matrix); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkMonitoring(c.Monitoring); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkChrome(c.Chrome, c.Vodafone); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := vodafone.NewTimeouts(c.Timeouts); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := newStorageTargets(c); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := provider.NewRetryPolicy(c.Retry); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := parseJitter(c.Schedule.Jitter); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Schedule.Run != "" {
+		if _, err := newDaemon(c.Schedule); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if utf8.RuneCountInString(c.CSV.Delimiter) > 1 {
+		errs = append(errs, fmt.Errorf("csv.delimiter must be a single character"))
+	}
+	if c.Checkpoint.Window != "" {
+		if _, err := time.ParseDuration(c.Checkpoint.Window); err != nil {
+			errs = append(errs, fmt.Errorf("checkpoint.window: %v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runSend implements the "send" subcommand. It emails invoices downloaded before, e.g.
+// with "download", from the local storage targets: those of the most recent month in
+// the history, or of --month/--year. Invoices in email.sent_file are skipped unless
+// --force is given.
+func runSend(args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	monthFlag := fs.String("month", "", "send the invoices of this month (01-12) instead of the most recent ones")
+	yearFlag := fs.String("year", "", "year of --month (default this year)")
+	force := fs.Bool("force", false, "send invoices again that email.sent_file lists as sent")
+	fs.Parse(args)
+
+	month, year, err := parsePeriod(*monthFlag, *yearFlag, time.Now())
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	retry, err := provider.NewRetryPolicy(cfg.Retry)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if err := mailer.CheckDelivery(cfg.Email); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if cfg.History.File == "" {
+		return fmt.Errorf("history.file is not configured")
+	}
+	history, err := loadHistory(cfg.History.File)
+	if err != nil {
+		return err
+	}
+	period := ""
+	if month != "" {
+		period = year + "-" + month
+	}
+	invoices, err := archivedInvoices(history, cfg.Storage, period)
+	if err != nil {
+		return err
+	}
+	sent, err := loadSentState(cfg.Email.SentFile)
+	if err != nil {
+		return fmt.Errorf("email.sent_file: %v", err)
+	}
+	db, err := openInvoiceDB(cfg.Database.File)
+	if err != nil {
+		return fmt.Errorf("database.file: %v", err)
+	}
+	defer db.close()
+	if !*force {
+		if invoices = db.unsent(sent.unsent(invoices)); len(invoices) == 0 {
+			slog.Info("All invoices were sent before, not sending again (use --force to resend)")
+			return nil
+		}
+	}
+	applyRules(rules, invoices)
+
+	var chartPNG []byte
+	if cfg.Email.Chart {
+		if chartPNG, err = renderSpendChart(history, time.Now()); err != nil {
+			slog.Warn("Chart failed", "err", err)
+		}
+	}
+
+	sender := mailer.New(cfg.Email, cfg.SMTP)
+	sender.Retry = retry
+	slog.Info("Sending invoices", "count", len(invoices), "month", invoices[0].Month, "year", invoices[0].Year, "step", provider.StageSend)
+	delivered, err := sender.Send(invoices, nil, nil, chartPNG)
+	if len(delivered) > 0 {
+		if err := sent.record(delivered, time.Now()); err != nil {
+			slog.Warn("Saving sent invoices failed", "err", err)
+		}
+		if err := db.markSent(delivered, time.Now()); err != nil {
+			slog.Warn("Database failed", "err", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("Done: invoices sent", "count", len(invoices))
+	return nil
+}
+
+// backfillMonths returns the months from "YYYY-MM" to "YYYY-MM". An empty to means
+// the month before now, the most recent month in the archive.
+func backfillMonths(from, to string, now time.Time) ([]time.Time, error) {
+	start, err := time.Parse("2006-01", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --from %q, use YYYY-MM", from)
+	}
+	end := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	if to != "" {
+		if end, err = time.Parse("2006-01", to); err != nil {
+			return nil, fmt.Errorf("invalid --to %q, use YYYY-MM", to)
+		}
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("--from %s is after --to %s", start.Format("2006-01"), end.Format("2006-01"))
+	}
+	var months []time.Time
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+	return months, nil
+}
+
+// backfillStops maps the exit codes of a backfill run that fail every following month
+// the same way to their failure class. Logging in again for each of them would only
+// trip the portal's bot detection and the login breaker.
+var backfillStops = map[int]error{
+	exitLoginFailed: provider.ErrLoginFailed,
+	exit2FARequired: provider.Err2FARequired,
+	exitConfig:      ErrConfig,
+}
+
+// backfillStop returns the failure class of a backfill run's error if the backfill
+// has to stop, nil if it continues with the next month.
+func backfillStop(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return backfillStops[exitErr.ExitCode()]
+	}
+	return nil
+}
+
+// runBackfill implements the "backfill" subcommand, which downloads and sends the
+// invoices of several past months from the Rechnungsarchiv. Each month is a run with
+// --month and --year of its own, so a failed month doesn't stop the others; a failed
+// login or a requested code stops the backfill.
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from := fs.String("from", "", "first month, YYYY-MM")
+	to := fs.String("to", "", "last month, YYYY-MM (default last month)")
+	downloadOnly := fs.Bool("download-only", false, "only download and store the invoices, don't email them")
+	fs.Parse(args)
+	if *from == "" {
+		return fmt.Errorf("usage: backfill --from YYYY-MM [--to YYYY-MM] [--download-only]")
+	}
+	months, err := backfillMonths(*from, *to, time.Now())
+	if err != nil {
+		return err
+	}
+	command := "run"
+	if *downloadOnly {
+		command = "download"
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i, m := range months {
+		slog.Info("Backfilling", "month", fmt.Sprintf("%02d", m.Month()), "year", m.Year())
+		cmd := exec.Command(exe, append(globalArgs(), command, "--month", m.Format("01"), "--year", m.Format("2006"))...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			slog.Error("Backfill failed", "month", fmt.Sprintf("%02d", m.Month()), "year", m.Year(), "err", err)
+			if stop := backfillStop(err); stop != nil {
+				return fmt.Errorf("%w: stopped at %s, %d of %d month(s) not backfilled", stop, m.Format("2006-01"), len(months)-i, len(months))
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d month(s) failed", failed, len(months))
+	}
+	return nil
+}
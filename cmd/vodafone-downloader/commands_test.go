@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestBackfillMonths(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    []string
+		wantErr bool
+	}{
+		{name: "up to last month", from: "2025-12", want: []string{"2025-12", "2026-01", "2026-02"}},
+		{name: "explicit range", from: "2025-05", to: "2025-06", want: []string{"2025-05", "2025-06"}},
+		{name: "single month", from: "2026-01", to: "2026-01", want: []string{"2026-01"}},
+		{name: "from after to", from: "2026-02", to: "2026-01", wantErr: true},
+		{name: "invalid from", from: "01/2026", wantErr: true},
+		{name: "invalid to", from: "2026-01", to: "2026-13", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months, err := backfillMonths(tt.from, tt.to, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("backfillMonths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, m := range months {
+				got = append(got, m.Format("2006-01"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("backfillMonths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackfillStop(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{code: exitLoginFailed, want: provider.ErrLoginFailed},
+		{code: exit2FARequired, want: provider.Err2FARequired},
+		{code: exitConfig, want: ErrConfig},
+		{code: exitInvoiceMissing},
+		{code: exitCaptureFailed},
+		{code: exitError},
+	}
+	for _, tt := range tests {
+		err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", tt.code)).Run()
+		if got := backfillStop(err); got != tt.want {
+			t.Errorf("backfillStop(exit %d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+	if got := backfillStop(errors.New("executable not found")); got != nil {
+		t.Errorf("backfillStop() of a start error = %v, want nil", got)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "empty config", cfg: Config{}},
+		{name: "unknown contract", cfg: Config{Vodafone: vodafone.Config{Contracts: []string{"festnetz"}}}, wantErr: "festnetz"},
+		{name: "unknown delivery", cfg: Config{Email: mailer.Config{Delivery: "fax"}}, wantErr: "fax"},
+		{name: "invalid TLS version", cfg: Config{SMTP: mailer.SMTPConfig{TLS: mailer.SMTPTLSConfig{MinVersion: "2.0"}}}, wantErr: "min_version"},
+		{name: "unknown storage", cfg: Config{Storage: []StorageConfig{{Type: "floppy"}}}, wantErr: "floppy"},
+		{name: "invalid jitter", cfg: Config{Schedule: ScheduleConfig{Jitter: "soon"}}, wantErr: "jitter"},
+		{name: "invalid checkpoint window", cfg: Config{Checkpoint: CheckpointConfig{Window: "1 day"}}, wantErr: "checkpoint.window"},
+		{name: "duplicate account", cfg: Config{Accounts: []AccountConfig{{Name: "a"}, {Name: "a"}}}, wantErr: "duplicate"},
+		{name: "all problems", cfg: Config{Email: mailer.Config{Delivery: "fax"}, Schedule: ScheduleConfig{Jitter: "soon"}}, wantErr: "\"fax\"\ninvalid schedule.jitter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkConfig(&tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
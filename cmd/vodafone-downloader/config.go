@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is the config file used without --config.
+const defaultConfigPath = "config.yaml"
+
+// configPath is the config file read by loadConfig, set with --config.
+var configPath = defaultConfigPath
+
+// configFlag removes --config <path> (or --config=<path>) from args. Returns the path
+// ("" if not given) and the remaining arguments.
+func configFlag(args []string) (string, []string, error) {
+	return globalFlag(args, "config")
+}
+
+// globalFlag removes --<name> <value> (or --<name>=<value>) from args, so the flag can
+// be given before or after a subcommand. Returns the value ("" if not given) and the
+// remaining arguments.
+func globalFlag(args []string, name string) (string, []string, error) {
+	var value string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("--%s needs a value", name)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"+name+"=") || strings.HasPrefix(arg, "-"+name+"="):
+			_, value, _ = strings.Cut(arg, "=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return value, rest, nil
+}
+
+// parseConfig decodes a config file in the format given by its extension: YAML
+// (.yaml, .yml), JSON (.json) or TOML (.toml). JSON and TOML use the same keys as
+// YAML; they are decoded generically and passed through the YAML decoder, so the
+// yaml tags of Config apply to every format.
+func parseConfig(name string, data []byte) (*Config, error) {
+	var c Config
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	case ".json", ".toml":
+		doc := map[string]interface{}{}
+		if ext == ".json" {
+			if len(strings.TrimSpace(string(data))) > 0 {
+				if err := json.Unmarshal(data, &doc); err != nil {
+					return nil, err
+				}
+			}
+		} else if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		converted, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(converted, &c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown config format %q, use .yaml, .json or .toml", name, ext)
+	}
+}
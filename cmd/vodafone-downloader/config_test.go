@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestConfigFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPath string
+		wantRest []string
+		wantErr  bool
+	}{
+		{"none", []string{"--now"}, "", []string{"--now"}, false},
+		{"before subcommand", []string{"--config", "/etc/vodafone/config.toml", "export"}, "/etc/vodafone/config.toml", []string{"export"}, false},
+		{"after subcommand", []string{"export", "--config=cfg.json", "--format", "csv"}, "cfg.json", []string{"export", "--format", "csv"}, false},
+		{"single dash", []string{"-config", "cfg.yml"}, "cfg.yml", nil, false},
+		{"missing value", []string{"--config"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, rest, err := configFlag(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if path != tt.wantPath || !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("configFlag() = %q, %q; want %q, %q", path, rest, tt.wantPath, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestGlobalFlag(t *testing.T) {
+	value, rest, err := globalFlag([]string{"send", "--log-format=json", "--log-level", "debug"}, "log-level")
+	if err != nil || value != "debug" || !reflect.DeepEqual(rest, []string{"send", "--log-format=json"}) {
+		t.Errorf("globalFlag() = %q, %q, %v", value, rest, err)
+	}
+	if _, _, err := globalFlag([]string{"--log-format"}, "log-format"); err == nil || err.Error() != "--log-format needs a value" {
+		t.Errorf("globalFlag() error = %v", err)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	want := Config{
+		Vodafone: vodafone.Config{User: "u", Pass: "p", Discover: true, Contracts: []string{"kabel", "dsl"}},
+		SMTP:     mailer.SMTPConfig{Host: "smtp.example.com", Port: "465", TLS: mailer.SMTPTLSConfig{MinVersion: "1.3"}},
+		Email:    mailer.Config{PerInvoice: true},
+		Storage:  []StorageConfig{{Type: "local", Path: "archive/{year}"}},
+	}
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `vodafone:
+  user: u
+  pass: p
+  discover: true
+  contracts: [kabel, dsl]
+smtp:
+  host: smtp.example.com
+  port: "465"
+  tls:
+    min_version: "1.3"
+email:
+  per_invoice: true
+storage:
+  - type: local
+    path: "archive/{year}"
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+  "vodafone": {"user": "u", "pass": "p", "discover": true, "contracts": ["kabel", "dsl"]},
+  "smtp": {"host": "smtp.example.com", "port": "465", "tls": {"min_version": "1.3"}},
+  "email": {"per_invoice": true},
+  "storage": [{"type": "local", "path": "archive/{year}"}]
+}`,
+		},
+		{
+			name: "toml",
+			file: "/etc/vodafone/config.TOML",
+			content: `[vodafone]
+user = "u"
+pass = "p"
+discover = true
+contracts = ["kabel", "dsl"]
+
+[smtp]
+host = "smtp.example.com"
+port = "465"
+tls = { min_version = "1.3" }
+
+[email]
+per_invoice = true
+
+[[storage]]
+type = "local"
+path = "archive/{year}"
+`,
+		},
+		{name: "invalid json", file: "config.json", content: `{"vodafone": `, wantErr: true},
+		{name: "invalid toml", file: "config.toml", content: `[vodafone`, wantErr: true},
+		{name: "unknown format", file: "config.ini", content: `user=u`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfig(tt.file, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(*got, want) {
+				t.Errorf("parseConfig() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const defaultCSVDelimiter = ";"
+
+// csvHeader are the columns of the CSV file, written when the file is created.
+var csvHeader = []string{"Datum", "Vertrag", "Monat", "Betrag", "Rechnungsnummer", "Datei"}
+
+// csvAmount formats an amount for a spreadsheet: with a decimal comma when the
+// delimiter is not a comma, as German spreadsheet programs expect it.
+func csvAmount(amount float64, delimiter rune) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	if delimiter != ',' {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+// appendCSV appends a row per invoice to the CSV file of c, writing the header first
+// if the file is new. The file column is the name the PDF was stored under, e.g.
+// "..._v2.pdf" for a new version. Invoices whose file is already listed, e.g. from an
+// earlier run in the same month, are skipped.
+func appendCSV(c CSVConfig, invoices []provider.Invoice, now time.Time) error {
+	sep := orDefault(c.Delimiter, defaultCSVDelimiter)
+	delimiter, size := utf8.DecodeRuneInString(sep)
+	if size != len(sep) {
+		return fmt.Errorf("csv.delimiter must be a single character")
+	}
+
+	listed := map[string]bool{}
+	data, err := os.ReadFile(c.File)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		r := csv.NewReader(strings.NewReader(string(data)))
+		r.Comma = delimiter
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.File, err)
+		}
+		for _, record := range records {
+			listed[record[len(record)-1]] = true
+		}
+	}
+
+	f, err := os.OpenFile(c.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Comma = delimiter
+	if len(data) == 0 {
+		w.Write(csvHeader)
+	}
+	for _, inv := range invoices {
+		if listed[inv.StoredName()] {
+			continue
+		}
+		w.Write([]string{now.Format("2006-01-02"), inv.Type, inv.Year + "-" + inv.Month, csvAmount(inv.Amount, delimiter), inv.Number, inv.StoredName()})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestAppendCSV(t *testing.T) {
+	now := time.Date(2026, 2, 25, 8, 0, 0, 0, time.Local)
+	kabel := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 39.99, Number: "123456789012", Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf"}
+	mobilfunk := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 1234.5, Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf"}
+
+	tests := []struct {
+		name      string
+		delimiter string
+		runs      [][]provider.Invoice
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "default delimiter",
+			runs: [][]provider.Invoice{{kabel, mobilfunk}},
+			want: "Datum;Vertrag;Monat;Betrag;Rechnungsnummer;Datei\n" +
+				"2026-02-25;Kabel;2026-02;39,99;123456789012;02_2026_Rechnung_Vodafone_Kabel.pdf\n" +
+				"2026-02-25;Mobilfunk;2026-02;1234,50;;02_2026_Rechnung_Vodafone_Mobilfunk.pdf\n",
+		},
+		{
+			name:      "comma delimiter",
+			delimiter: ",",
+			runs:      [][]provider.Invoice{{kabel}},
+			want: "Datum,Vertrag,Monat,Betrag,Rechnungsnummer,Datei\n" +
+				"2026-02-25,Kabel,2026-02,39.99,123456789012,02_2026_Rechnung_Vodafone_Kabel.pdf\n",
+		},
+		{
+			name: "repeated run appends new invoices only",
+			runs: [][]provider.Invoice{{kabel}, {kabel, mobilfunk}},
+			want: "Datum;Vertrag;Monat;Betrag;Rechnungsnummer;Datei\n" +
+				"2026-02-25;Kabel;2026-02;39,99;123456789012;02_2026_Rechnung_Vodafone_Kabel.pdf\n" +
+				"2026-02-25;Mobilfunk;2026-02;1234,50;;02_2026_Rechnung_Vodafone_Mobilfunk.pdf\n",
+		},
+		{
+			name: "corrected invoice stored as a new version",
+			runs: func() [][]provider.Invoice {
+				corrected := kabel
+				corrected.StoredAs, corrected.Version = "02_2026_Rechnung_Vodafone_Kabel_v2.pdf", 2
+				return [][]provider.Invoice{{kabel}, {corrected}}
+			}(),
+			want: "Datum;Vertrag;Monat;Betrag;Rechnungsnummer;Datei\n" +
+				"2026-02-25;Kabel;2026-02;39,99;123456789012;02_2026_Rechnung_Vodafone_Kabel.pdf\n" +
+				"2026-02-25;Kabel;2026-02;39,99;123456789012;02_2026_Rechnung_Vodafone_Kabel_v2.pdf\n",
+		},
+		{
+			name:      "invalid delimiter",
+			delimiter: ";;",
+			runs:      [][]provider.Invoice{{kabel}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CSVConfig{File: filepath.Join(t.TempDir(), "rechnungen.csv"), Delimiter: tt.delimiter}
+			var err error
+			for _, run := range tt.runs {
+				if err = appendCSV(c, run, now); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("appendCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := os.ReadFile(c.File)
+			if string(got) != tt.want {
+				t.Errorf("file =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
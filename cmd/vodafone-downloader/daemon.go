@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+const (
+	defaultDaemonBackoff = time.Hour
+	maxDaemonBackoff     = 24 * time.Hour
+)
+
+// daemon decides when the resident process started with --daemon runs next. Once a
+// run succeeds, the rest of its billing month is skipped; a failed run is retried
+// with a doubling delay, but never past the end of its month.
+type daemon struct {
+	schedule  *cronSchedule
+	base      time.Duration // first retry delay
+	backoff   time.Duration // delay of the next retry
+	jitter    time.Duration // maximum distance of a run from its scheduled time
+	doneMonth string        // "2026-02" once that month's run succeeded
+	lastRun   time.Time     // end of the last scheduled run
+	lastCode  int           // exit code of the last scheduled run
+}
+
+// newDaemon creates the scheduler for c.
+func newDaemon(c ScheduleConfig) (*daemon, error) {
+	if c.Run == "" {
+		return nil, fmt.Errorf("--daemon needs schedule.run")
+	}
+	schedule, err := parseSchedule(c.Run)
+	if err != nil {
+		return nil, err
+	}
+	base := defaultDaemonBackoff
+	if c.RetryBackoff != "" {
+		if base, err = time.ParseDuration(c.RetryBackoff); err != nil || base <= 0 {
+			return nil, fmt.Errorf("invalid schedule.retry_backoff %q", c.RetryBackoff)
+		}
+	}
+	return &daemon{schedule: schedule, base: base, backoff: base}, nil
+}
+
+// scheduled returns the first scheduled time after t outside a completed month.
+func (d *daemon) scheduled(t time.Time) time.Time {
+	for {
+		t = d.schedule.next(t)
+		if t.IsZero() || t.Format("2006-01") != d.doneMonth {
+			return t
+		}
+	}
+}
+
+// jittered moves the run planned for next by a random offset within the jitter
+// window, but not before now.
+func (d *daemon) jittered(next, now time.Time, randN func(time.Duration) time.Duration) time.Time {
+	at := next.Add(jitterOffset(d.jitter, randN))
+	if at.Before(now) {
+		return now
+	}
+	return at
+}
+
+// after records the outcome of the run scheduled at started, which ended at now with
+// the given exit code, and returns when to run next. The month is that of the
+// scheduled time, which the jitter may have moved across the turn of the month.
+func (d *daemon) after(started, now time.Time, code int) time.Time {
+	if code == 0 {
+		d.doneMonth = started.Format("2006-01")
+		d.backoff = d.base
+		return d.scheduled(now)
+	}
+	retry := now.Add(d.backoff)
+	d.backoff = min(2*d.backoff, maxDaemonBackoff)
+	if next := d.scheduled(now); !next.IsZero() && next.Before(retry) {
+		return next
+	}
+	if retry.Format("2006-01") != started.Format("2006-01") {
+		d.backoff = d.base
+		return d.scheduled(now)
+	}
+	return retry
+}
+
+// withoutFlag removes a boolean flag (--name, -name or --name=value) from args.
+func withoutFlag(args []string, name string) []string {
+	var rest []string
+	for _, arg := range args {
+		flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flagName == name {
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest
+}
+
+// answer carries out a chat command and returns the reply. run starts a download or
+// send run with the given arguments and extra environment and returns its exit code.
+// Runs started from the chat don't change the schedule or the retry delay.
+func (d *daemon) answer(cmd telegramCommand, next time.Time, args []string, run func(args, env []string) int) string {
+	switch cmd.Name {
+	case "status":
+		status := "Nächster Lauf: " + next.Format("02.01.2006 15:04")
+		if !d.lastRun.IsZero() {
+			status += fmt.Sprintf("\nLetzter Lauf: %s (Exit-Code %d)", d.lastRun.Format("02.01.2006 15:04"), d.lastCode)
+		}
+		if d.doneMonth != "" {
+			status += "\nErledigt bis: " + d.doneMonth
+		}
+		return status
+	case "fetch":
+		var env []string
+		if len(cmd.Args) > 0 {
+			for _, contract := range cmd.Args {
+				if _, ok := vodafone.ContractTypes[strings.ToLower(contract)]; !ok {
+					return fmt.Sprintf("Unbekannter Vertrag %q", contract)
+				}
+			}
+			env = []string{"VODAFONE_CONTRACTS=" + strings.ToLower(strings.Join(cmd.Args, ","))}
+		}
+		return runReply(run(args, env))
+	case "resend":
+		if len(cmd.Args) != 1 {
+			return "Aufruf: /resend JJJJ-MM"
+		}
+		month, err := time.Parse("2006-01", cmd.Args[0])
+		if err != nil {
+			return fmt.Sprintf("Ungültiger Monat %q, erwartet JJJJ-MM", cmd.Args[0])
+		}
+		return runReply(run([]string{"send", "--month", month.Format("01"), "--year", month.Format("2006"), "--force"}, nil))
+	default:
+		return "Befehle:\n/status – nächster und letzter Lauf\n/fetch [vertrag ...] – Rechnungen jetzt abrufen\n/resend JJJJ-MM – Rechnungen eines Monats erneut senden"
+	}
+}
+
+// runReply describes the exit code of a run started from the chat.
+func runReply(code int) string {
+	if code == 0 {
+		return "Lauf erfolgreich"
+	}
+	return fmt.Sprintf("Lauf fehlgeschlagen (Exit-Code %d)", code)
+}
+
+// runChild runs the program with args and the extra environment variables env and
+// returns its exit code. The error is only set if the program couldn't be started.
+func runChild(exe string, args, env []string) (int, error) {
+	cmd := exec.Command(exe, append(globalArgs(), args...)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return 0, err
+		}
+		slog.Error("Run failed", "exit_code", exitErr.ExitCode())
+		return exitErr.ExitCode(), nil
+	}
+	return 0, nil
+}
+
+// runDaemon keeps the process resident and starts a run with args as a child process
+// whenever the schedule is due, until SIGINT or SIGTERM. The runs are spread within
+// schedule.jitter around the scheduled time; the children start right away. With
+// telegram.commands, the commands posted in the chat are answered between runs. A
+// running download is finished before the daemon exits.
+func runDaemon(c ScheduleConfig, t TelegramConfig, args []string) error {
+	d, err := newDaemon(c)
+	if err != nil {
+		return err
+	}
+	if d.jitter, err = parseJitter(c.Jitter); err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	commands := make(chan telegramCommand)
+	if t.Commands && t.BotToken != "" {
+		go pollTelegramCommands(ctx, t, commands)
+	}
+
+	next := d.scheduled(time.Now())
+	at := d.jittered(next, time.Now(), rand.N[time.Duration])
+	slog.Info("Next run scheduled", "next", at.Format("2006-01-02 15:04"))
+	for {
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Daemon stopped")
+			return nil
+		case cmd := <-commands:
+			timer.Stop()
+			slog.Info("Telegram command", "command", cmd.Name, "args", cmd.Args)
+			var runErr error
+			reply := d.answer(cmd, at, args, func(args, env []string) int {
+				code, err := runChild(exe, args, env)
+				if err != nil {
+					runErr = err
+				}
+				return code
+			})
+			if runErr != nil {
+				return runErr
+			}
+			if err := sendTelegramMessage(t, reply); err != nil {
+				slog.Warn("Telegram reply failed", "err", err)
+			}
+			continue
+		case <-timer.C:
+		}
+
+		code, err := runChild(exe, args, nil)
+		if err != nil {
+			return err
+		}
+		d.lastRun, d.lastCode = time.Now(), code
+		next = d.after(next, time.Now(), code)
+		if next.IsZero() {
+			return fmt.Errorf("schedule.run %q has no further runs", c.Run)
+		}
+		at = d.jittered(next, time.Now(), rand.N[time.Duration])
+		slog.Info("Next run scheduled", "next", at.Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDaemonAfter(t *testing.T) {
+	at := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", s)
+		return t
+	}
+	d, err := newDaemon(ScheduleConfig{Run: "0 8 25-31 * *", RetryBackoff: "2h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		started, ended string
+		code           int
+		want           string
+	}{
+		// Not ready yet: retried after 2h, 4h, then the next scheduled run comes first
+		{"2026-02-25 08:00", "2026-02-25 08:05", 4, "2026-02-25 10:05"},
+		{"2026-02-25 10:05", "2026-02-25 10:10", 4, "2026-02-25 14:10"},
+		{"2026-02-25 14:10", "2026-02-25 14:15", 4, "2026-02-25 22:15"},
+		{"2026-02-25 22:15", "2026-02-25 22:20", 4, "2026-02-26 08:00"},
+		// Success: the rest of February is skipped and the backoff starts over
+		{"2026-02-26 08:00", "2026-02-26 08:03", 0, "2026-03-25 08:00"},
+		{"2026-03-25 08:00", "2026-03-25 08:05", 2, "2026-03-25 10:05"},
+		// A retry is never scheduled into the next month
+		{"2026-03-31 08:00", "2026-03-31 23:00", 1, "2026-04-25 08:00"},
+	}
+	for _, s := range steps {
+		if got := d.after(at(s.started), at(s.ended), s.code); !got.Equal(at(s.want)) {
+			t.Fatalf("after(%s, code %d) = %s, want %s", s.ended, s.code, got.Format("2006-01-02 15:04"), s.want)
+		}
+	}
+}
+
+func TestDaemonJittered(t *testing.T) {
+	next := time.Date(2026, 2, 25, 8, 0, 0, 0, time.UTC)
+	d := &daemon{jitter: 45 * time.Minute}
+	lowest := func(time.Duration) time.Duration { return 0 }
+	highest := func(n time.Duration) time.Duration { return n - 1 }
+
+	if got := d.jittered(next, next.Add(-time.Hour), lowest); !got.Equal(next.Add(-45 * time.Minute)) {
+		t.Errorf("jittered() = %s, want 07:15", got.Format("15:04"))
+	}
+	if got := d.jittered(next, next.Add(-time.Hour), highest); !got.Equal(next.Add(45 * time.Minute)) {
+		t.Errorf("jittered() = %s, want 08:45", got.Format("15:04"))
+	}
+	// A run is never moved into the past
+	now := next.Add(-10 * time.Minute)
+	if got := d.jittered(next, now, lowest); !got.Equal(now) {
+		t.Errorf("jittered() = %s, want now (07:50)", got.Format("15:04"))
+	}
+}
+
+func TestNewDaemon(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ScheduleConfig
+		wantErr bool
+	}{
+		{"daily", ScheduleConfig{Run: "daily at 08:00"}, false},
+		{"missing schedule", ScheduleConfig{}, true},
+		{"invalid backoff", ScheduleConfig{Run: "daily at 08:00", RetryBackoff: "-1h"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newDaemon(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("newDaemon() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithoutFlag(t *testing.T) {
+	got := withoutFlag([]string{"--daemon", "--month", "01", "-daemon=true", "--daemonize"}, "daemon")
+	if want := []string{"--month", "01", "--daemonize"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("withoutFlag() = %q, want %q", got, want)
+	}
+}
+
+func TestDaemonAnswer(t *testing.T) {
+	next := time.Date(2026, 3, 25, 6, 0, 0, 0, time.Local)
+	args := []string{"--email"}
+	tests := []struct {
+		name     string
+		cmd      telegramCommand
+		code     int
+		want     string
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{name: "status", cmd: telegramCommand{Name: "status"}, want: "Nächster Lauf: 25.03.2026 06:00\nLetzter Lauf: 25.02.2026 06:05 (Exit-Code 0)\nErledigt bis: 2026-02"},
+		{name: "fetch", cmd: telegramCommand{Name: "fetch"}, want: "Lauf erfolgreich", wantArgs: []string{"--email"}},
+		{name: "fetch contracts", cmd: telegramCommand{Name: "fetch", Args: []string{"Kabel", "dsl"}}, code: 3, want: "Lauf fehlgeschlagen (Exit-Code 3)", wantArgs: []string{"--email"}, wantEnv: []string{"VODAFONE_CONTRACTS=kabel,dsl"}},
+		{name: "fetch unknown contract", cmd: telegramCommand{Name: "fetch", Args: []string{"festnetz"}}, want: `Unbekannter Vertrag "festnetz"`},
+		{name: "resend", cmd: telegramCommand{Name: "resend", Args: []string{"2026-01"}}, want: "Lauf erfolgreich", wantArgs: []string{"send", "--month", "01", "--year", "2026", "--force"}},
+		{name: "resend bad month", cmd: telegramCommand{Name: "resend", Args: []string{"januar"}}, want: `Ungültiger Monat "januar", erwartet JJJJ-MM`},
+		{name: "resend no month", cmd: telegramCommand{Name: "resend"}, want: "Aufruf: /resend JJJJ-MM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &daemon{doneMonth: "2026-02", lastRun: time.Date(2026, 2, 25, 6, 5, 0, 0, time.Local)}
+			var gotArgs, gotEnv []string
+			got := d.answer(tt.cmd, next, args, func(args, env []string) int {
+				gotArgs, gotEnv = args, env
+				return tt.code
+			})
+			if got != tt.want {
+				t.Errorf("answer() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) || !reflect.DeepEqual(gotEnv, tt.wantEnv) {
+				t.Errorf("run(%q, %q), want run(%q, %q)", gotArgs, gotEnv, tt.wantArgs, tt.wantEnv)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	_ "modernc.org/sqlite"
+)
+
+// invoiceSchema is the table of every invoice downloaded. An invoice is identified by
+// contract, period and the SHA-256 of its PDF, so a corrected invoice for the same
+// month gets a row of its own.
+const invoiceSchema = `CREATE TABLE IF NOT EXISTS invoices (
+	id            INTEGER PRIMARY KEY,
+	type          TEXT NOT NULL,
+	month         TEXT NOT NULL,
+	year          TEXT NOT NULL,
+	amount        REAL NOT NULL DEFAULT 0,
+	number        TEXT NOT NULL DEFAULT '',
+	filename      TEXT NOT NULL,
+	sha256        TEXT NOT NULL,
+	downloaded_at TEXT NOT NULL,
+	sent_at       TEXT,
+	stored_as     TEXT NOT NULL DEFAULT '',
+	version       INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (type, year, month, sha256)
+)`
+
+// invoiceMigrations add the columns of newer versions to an existing invoices table.
+// Columns that exist already are skipped.
+var invoiceMigrations = []string{
+	`ALTER TABLE invoices ADD COLUMN stored_as TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE invoices ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+}
+
+// invoiceDB is the SQLite database in database.file. It keeps the metadata of every
+// downloaded invoice and when it was sent. A nil database records nothing and
+// treats every invoice as unsent.
+type invoiceDB struct {
+	db *sql.DB
+}
+
+// dbInvoice is a row of the invoices table.
+type dbInvoice struct {
+	Type, Month, Year string
+	Amount            float64
+	Number            string
+	Filename          string
+	StoredAs          string // versioned name if another file had Filename
+	Version           int
+	SHA256            string
+	DownloadedAt      time.Time
+	SentAt            time.Time // zero if not sent
+}
+
+// openInvoiceDB opens or creates the database at path. An empty path disables it.
+func openInvoiceDB(path string) (*invoiceDB, error) {
+	if path == "" {
+		return nil, nil
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(invoiceSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, migration := range invoiceMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &invoiceDB{db: db}, nil
+}
+
+// close closes the database.
+func (d *invoiceDB) close() error {
+	if d == nil {
+		return nil
+	}
+	return d.db.Close()
+}
+
+// pdfHash returns the hex SHA-256 of an invoice's PDF.
+func pdfHash(inv provider.Invoice) string {
+	sum := sha256.Sum256(inv.PDFData)
+	return hex.EncodeToString(sum[:])
+}
+
+// record adds the downloaded invoices, or updates amount, number, file name and
+// stored version of those recorded before. Invoices without a PDF are skipped.
+func (d *invoiceDB) record(invoices []provider.Invoice, now time.Time) error {
+	return d.upsert(invoices, now, `INSERT INTO invoices (type, month, year, amount, number, filename, sha256, downloaded_at, stored_as, version)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10)
+		ON CONFLICT (type, year, month, sha256) DO UPDATE SET
+			amount = excluded.amount, number = excluded.number, filename = excluded.filename,
+			stored_as = excluded.stored_as, version = excluded.version`)
+}
+
+// markSent records the invoices as sent at now, adding those not recorded yet.
+func (d *invoiceDB) markSent(invoices []provider.Invoice, now time.Time) error {
+	return d.upsert(invoices, now, `INSERT INTO invoices (type, month, year, amount, number, filename, sha256, downloaded_at, stored_as, version, sent_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?8)
+		ON CONFLICT (type, year, month, sha256) DO UPDATE SET sent_at = excluded.sent_at`)
+}
+
+// upsert runs query for every invoice with a PDF in one transaction.
+func (d *invoiceDB) upsert(invoices []provider.Invoice, now time.Time, query string) error {
+	if d == nil {
+		return nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, inv := range invoices {
+		if len(inv.PDFData) == 0 {
+			continue
+		}
+		if _, err := tx.Exec(query, inv.Type, inv.Month, inv.Year, inv.Amount, inv.Number, inv.Filename,
+			pdfHash(inv), now.UTC().Format(time.RFC3339), inv.StoredAs, inv.Version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// unsent returns the invoices whose PDF wasn't sent before. A corrected invoice for
+// an already sent month has another PDF and is sent again.
+func (d *invoiceDB) unsent(invoices []provider.Invoice) []provider.Invoice {
+	if d == nil {
+		return invoices
+	}
+	var result []provider.Invoice
+	for _, inv := range invoices {
+		var n int
+		err := d.db.QueryRow(`SELECT COUNT(*) FROM invoices WHERE sha256 = ? AND sent_at IS NOT NULL`, pdfHash(inv)).Scan(&n)
+		if err != nil || n == 0 {
+			result = append(result, inv)
+		}
+	}
+	return result
+}
+
+// unrecorded returns the invoices whose PDF isn't in the database yet.
+func (d *invoiceDB) unrecorded(invoices []provider.Invoice) []provider.Invoice {
+	if d == nil {
+		return invoices
+	}
+	var result []provider.Invoice
+	for _, inv := range invoices {
+		var n int
+		err := d.db.QueryRow(`SELECT COUNT(*) FROM invoices WHERE sha256 = ?`, pdfHash(inv)).Scan(&n)
+		if err != nil || n == 0 {
+			result = append(result, inv)
+		}
+	}
+	return result
+}
+
+// list returns the recorded invoices, oldest period first, optionally only those of
+// a contract type and year.
+func (d *invoiceDB) list(typeName, year string) ([]dbInvoice, error) {
+	rows, err := d.db.Query(`SELECT type, month, year, amount, number, filename, stored_as, version, sha256, downloaded_at, COALESCE(sent_at, '')
+		FROM invoices WHERE (?1 = '' OR type = ?1) AND (?2 = '' OR year = ?2)
+		ORDER BY year, month, type, downloaded_at`, typeName, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var invoices []dbInvoice
+	for rows.Next() {
+		var inv dbInvoice
+		var downloaded, sent string
+		if err := rows.Scan(&inv.Type, &inv.Month, &inv.Year, &inv.Amount, &inv.Number, &inv.Filename, &inv.StoredAs, &inv.Version, &inv.SHA256, &downloaded, &sent); err != nil {
+			return nil, err
+		}
+		inv.DownloadedAt, _ = time.Parse(time.RFC3339, downloaded)
+		inv.SentAt, _ = time.Parse(time.RFC3339, sent)
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+// writeInvoiceTable prints the recorded invoices as a table.
+func writeInvoiceTable(w io.Writer, invoices []dbInvoice) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Monat\tVertrag\tBetrag\tRechnungsnr.\tGesendet\tDatei")
+	for _, inv := range invoices {
+		sent := "-"
+		if !inv.SentAt.IsZero() {
+			sent = inv.SentAt.Local().Format("02.01.2006 15:04")
+		}
+		number := inv.Number
+		if number == "" {
+			number = "-"
+		}
+		file := inv.Filename
+		if inv.StoredAs != "" {
+			file = fmt.Sprintf("%s (v%d von %s)", inv.StoredAs, inv.Version, inv.Filename)
+		}
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\t%s\t%s\n", inv.Month, inv.Year, inv.Type, provider.FormatAmount(inv.Amount), number, sent, file)
+	}
+	return tw.Flush()
+}
+
+// runHistory implements the "history" subcommand, which lists the invoices in the
+// database.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	typeName := fs.String("type", "", "only this contract type, e.g. kabel")
+	year := fs.String("year", "", "only this year")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if cfg.Database.File == "" {
+		return fmt.Errorf("database.file is not configured")
+	}
+	db, err := openInvoiceDB(cfg.Database.File)
+	if err != nil {
+		return err
+	}
+	defer db.close()
+
+	filter := ""
+	if *typeName != "" {
+		filter = contractTypeName(*typeName)
+	}
+	invoices, err := db.list(filter, *year)
+	if err != nil {
+		return err
+	}
+	if len(invoices) == 0 {
+		return fmt.Errorf("no invoices recorded")
+	}
+	return writeInvoiceTable(os.Stdout, invoices)
+}
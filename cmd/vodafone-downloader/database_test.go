@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestInvoiceDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoices.db")
+	db, err := openInvoiceDB(path)
+	if err != nil {
+		t.Fatalf("openInvoiceDB() error: %v", err)
+	}
+	defer db.close()
+
+	now := time.Date(2026, 2, 25, 8, 0, 0, 0, time.UTC)
+	kabel := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 39.99, Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", PDFData: []byte("%PDF-kabel")}
+	mobilfunk := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 25, Number: "42", Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+		StoredAs: "02_2026_Rechnung_Vodafone_Mobilfunk_v2.pdf", Version: 2, PDFData: []byte("%PDF-mobil")}
+	noPDF := provider.Invoice{Type: "DSL", Month: "02", Year: "2026"}
+
+	if err := db.record([]provider.Invoice{kabel, mobilfunk, noPDF}, now); err != nil {
+		t.Fatalf("record() error: %v", err)
+	}
+	// Recording again updates the row instead of adding one
+	kabel.Amount = 41.99
+	if err := db.record([]provider.Invoice{kabel}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("record() error: %v", err)
+	}
+	if err := db.markSent([]provider.Invoice{kabel}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("markSent() error: %v", err)
+	}
+
+	if got := db.unsent([]provider.Invoice{kabel, mobilfunk}); len(got) != 1 || got[0].Type != "Mobilfunk" {
+		t.Errorf("unsent() = %+v, want only Mobilfunk", got)
+	}
+	corrected := kabel
+	corrected.PDFData = []byte("%PDF-kabel-korrektur")
+	if got := db.unsent([]provider.Invoice{corrected}); len(got) != 1 {
+		t.Errorf("unsent() of a corrected invoice = %+v, want it unsent", got)
+	}
+
+	invoices, err := db.list("", "")
+	if err != nil {
+		t.Fatalf("list() error: %v", err)
+	}
+	if len(invoices) != 2 {
+		t.Fatalf("list() = %d rows, want 2", len(invoices))
+	}
+	if invoices[0].Type != "Kabel" || invoices[0].Amount != 41.99 || !invoices[0].SentAt.Equal(now.Add(time.Hour)) || invoices[0].SHA256 != pdfHash(kabel) {
+		t.Errorf("list()[0] = %+v", invoices[0])
+	}
+	if !invoices[1].SentAt.IsZero() || invoices[1].Number != "42" || invoices[1].StoredAs != mobilfunk.StoredAs || invoices[1].Version != 2 {
+		t.Errorf("list()[1] = %+v", invoices[1])
+	}
+	if filtered, _ := db.list("Mobilfunk", "2026"); len(filtered) != 1 {
+		t.Errorf("list(Mobilfunk, 2026) = %d rows, want 1", len(filtered))
+	}
+	if filtered, _ := db.list("", "2025"); len(filtered) != 0 {
+		t.Errorf("list(2025) = %d rows, want 0", len(filtered))
+	}
+
+	var buf bytes.Buffer
+	if err := writeInvoiceTable(&buf, invoices); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "02/2026  Kabel") || !strings.Contains(out, "41,99 €") ||
+		!strings.Contains(out, "Mobilfunk_v2.pdf (v2 von 02_2026_Rechnung_Vodafone_Mobilfunk.pdf)") {
+		t.Errorf("writeInvoiceTable() =\n%s", out)
+	}
+}
+
+func TestInvoiceDBMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoices.db")
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The table as created before stored_as and version were added
+	if _, err := old.Exec(`CREATE TABLE invoices (
+		id INTEGER PRIMARY KEY, type TEXT NOT NULL, month TEXT NOT NULL, year TEXT NOT NULL,
+		amount REAL NOT NULL DEFAULT 0, number TEXT NOT NULL DEFAULT '', filename TEXT NOT NULL,
+		sha256 TEXT NOT NULL, downloaded_at TEXT NOT NULL, sent_at TEXT,
+		UNIQUE (type, year, month, sha256))`); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	for range 2 {
+		db, err := openInvoiceDB(path)
+		if err != nil {
+			t.Fatalf("openInvoiceDB() error: %v", err)
+		}
+		inv := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Filename: "k.pdf", StoredAs: "k_v2.pdf", Version: 2, PDFData: []byte("%PDF")}
+		if err := db.record([]provider.Invoice{inv}, time.Now()); err != nil {
+			t.Fatalf("record() error: %v", err)
+		}
+		if got, _ := db.list("", ""); len(got) != 1 || got[0].StoredAs != "k_v2.pdf" {
+			t.Errorf("list() = %+v", got)
+		}
+		db.close()
+	}
+}
+
+func TestNilInvoiceDB(t *testing.T) {
+	db, err := openInvoiceDB("")
+	if err != nil || db != nil {
+		t.Fatalf("openInvoiceDB(\"\") = %v, %v; want nil", db, err)
+	}
+	invoices := []provider.Invoice{{Type: "Kabel", PDFData: []byte("%PDF")}}
+	if got := db.unsent(invoices); len(got) != 1 {
+		t.Errorf("unsent() = %+v, want all invoices", got)
+	}
+	if err := db.record(invoices, time.Now()); err != nil {
+		t.Errorf("record() error: %v", err)
+	}
+	if err := db.markSent(invoices, time.Now()); err != nil {
+		t.Errorf("markSent() error: %v", err)
+	}
+}
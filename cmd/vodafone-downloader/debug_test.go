@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// render returns the raw email as sent.
+func render(msg *gomail.Message) string {
+	var buf strings.Builder
+	msg.WriteTo(&buf)
+	return buf.String()
+}
+
+func TestFailureMessagesAttachScreenshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kabel_navigation.png")
+	if err := os.WriteFile(path, []byte("\x89PNG\r\n\x1a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+	now := time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC)
+	failures := []provider.Failure{{Type: "Kabel", Reason: "invoice page not reachable: timeout", Screenshot: path}}
+
+	for name, msg := range map[string]string{
+		"invoice email": render(sender.BuildMessage(nil, failures, nil)),
+		"missing":       render(buildMissingMessage(sender, []string{"Kabel"}, failures, now, "")),
+		"overdue":       render(buildOverdueMessage(sender, []string{"Kabel"}, failures, now, ExpectConfig{})),
+		"canary":        render(buildCanaryMessage(sender, canaryResult{Failures: failures}, "")),
+	} {
+		if !strings.Contains(msg, filepath.Base(path)) {
+			t.Errorf("%s lacks the screenshot attachment", name)
+		}
+	}
+
+	failures[0].Screenshot = filepath.Join(t.TempDir(), "gone.png")
+	if msg := render(buildMissingMessage(sender, []string{"Kabel"}, failures, now, "")); strings.Contains(msg, "gone.png") {
+		t.Error("missing screenshot attached")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const defaultDocspellHeader = "Docspell-Integration"
+
+// docspellMeta is the upload metadata accepted by Docspell's integration endpoint.
+type docspellMeta struct {
+	Multiple       bool   `json:"multiple"`
+	Direction      string `json:"direction"`
+	Language       string `json:"language"`
+	SkipDuplicates bool   `json:"skipDuplicates"`
+	Folder         string `json:"folder,omitempty"`
+	Tags           struct {
+		Items []string `json:"items"`
+	} `json:"tags"`
+}
+
+// uploadToDocspell sends an invoice PDF to the collective's integration endpoint,
+// tagged with the configured tags ({type}, {month} and {year} are expanded) and the
+// tags added by matching rules. Files the collective already holds are skipped, so
+// re-downloading the current invoice doesn't create another item.
+func uploadToDocspell(c DocspellConfig, inv provider.Invoice) error {
+	meta := docspellMeta{Multiple: false, Direction: "incoming", Language: "deu", SkipDuplicates: true, Folder: c.Folder}
+	meta.Tags.Items = []string{}
+	for _, tag := range slices.Concat(c.Tags, inv.Tags) {
+		meta.Tags.Items = append(meta.Tags.Items, provider.ExpandPlaceholders(tag, inv))
+	}
+	metaJSON, _ := json.Marshal(meta)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("meta", string(metaJSON))
+	fw, err := mw.CreateFormFile("file", inv.Filename)
+	if err != nil {
+		return err
+	}
+	fw.Write(inv.PDFData)
+	mw.Close()
+
+	endpoint := strings.TrimRight(c.URL, "/") + "/api/v1/open/integration/item/" + url.PathEscape(c.Collective)
+	req, err := http.NewRequest(http.MethodPost, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if c.HeaderValue != "" {
+		req.Header.Set(orDefault(c.HeaderName, defaultDocspellHeader), c.HeaderValue)
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Pass)
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Docspell answers 200 with {"success": false, ...} for rejected uploads
+	var result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docspell upload failed: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && !result.Success {
+		return fmt.Errorf("docspell upload rejected: %s", result.Message)
+	}
+	return nil
+}
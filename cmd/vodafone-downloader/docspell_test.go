@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestUploadToDocspell(t *testing.T) {
+	var gotPath, gotHeader, gotFilename, gotRawMeta string
+	var gotMeta docspellMeta
+	var gotPDF []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Get("Docspell-Integration")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm failed: %v", err)
+		}
+		gotRawMeta = r.FormValue("meta")
+		json.Unmarshal([]byte(gotRawMeta), &gotMeta)
+		file, header, err := r.FormFile("file")
+		if err == nil {
+			gotFilename = header.Filename
+			gotPDF, _ = io.ReadAll(file)
+		}
+		w.Write([]byte(`{"success":true,"message":"Files submitted."}`))
+	}))
+	defer srv.Close()
+
+	c := DocspellConfig{
+		URL:         srv.URL + "/",
+		Collective:  "family",
+		HeaderValue: "secret",
+		Tags:        []string{"Vodafone", "Rechnung", "{year}"},
+	}
+
+	err := uploadToDocspell(c, provider.Invoice{
+		Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026",
+		PDFData: []byte("%PDF-kabel"),
+	})
+	if err != nil {
+		t.Fatalf("uploadToDocspell() error: %v", err)
+	}
+
+	if gotPath != "/api/v1/open/integration/item/family" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotHeader != "secret" {
+		t.Errorf("integration header = %q, want secret", gotHeader)
+	}
+	if gotFilename != "02_2026_Rechnung_Vodafone_Kabel.pdf" || string(gotPDF) != "%PDF-kabel" {
+		t.Errorf("file = %q (%q)", gotFilename, gotPDF)
+	}
+	if want := []string{"Vodafone", "Rechnung", "2026"}; len(gotMeta.Tags.Items) != 3 || gotMeta.Tags.Items[2] != want[2] {
+		t.Errorf("tags = %v, want %v", gotMeta.Tags.Items, want)
+	}
+	if gotMeta.Direction != "incoming" {
+		t.Errorf("direction = %q, want incoming", gotMeta.Direction)
+	}
+	if !strings.Contains(gotRawMeta, `"skipDuplicates":true`) {
+		t.Errorf("meta = %s, want skipDuplicates set", gotRawMeta)
+	}
+}
+
+func TestUploadToDocspellErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "http error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+			},
+		},
+		{
+			name: "rejected upload",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"success":false,"message":"Collective not found"}`))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			c := DocspellConfig{URL: srv.URL, Collective: "family", User: "u", Pass: "p"}
+			if err := uploadToDocspell(c, provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF")}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// printDryRun writes what a run would have done with the invoices found: the PDFs it
+// would download, the contracts without an invoice and the emails it would send, one
+// per invoice with perInvoice.
+func printDryRun(w io.Writer, results []provider.Invoice, failures []provider.Failure, m *mailer.Mailer, perInvoice bool) {
+	fmt.Fprintln(w, "Dry run: nothing was downloaded, stored or sent.")
+	if len(results) > 0 {
+		fmt.Fprintln(w, "Would download:")
+		for _, inv := range results {
+			fmt.Fprintf(w, "  %s %s %s", inv.Type, inv.MonthName, inv.Year)
+			if inv.Amount > 0 {
+				fmt.Fprintf(w, ", %s", provider.FormatAmount(inv.Amount))
+			}
+			fmt.Fprintf(w, " → %s\n", inv.Filename)
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(w, "Not available:")
+		for _, f := range failures {
+			fmt.Fprintf(w, "  %s: %s\n", f.Type, f.Reason)
+		}
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No invoices found, no email would be sent.")
+		return
+	}
+
+	groups := [][]provider.Invoice{results}
+	if perInvoice {
+		groups = nil
+		for _, inv := range results {
+			groups = append(groups, []provider.Invoice{inv})
+		}
+	}
+	for _, invoices := range groups {
+		msg := m.BuildMessage(invoices, failures, nil)
+		fmt.Fprintf(w, "Would email %q to %s with %d attachment(s)\n",
+			strings.Join(msg.GetHeader("Subject"), ""), strings.Join(msg.GetHeader("To"), ", "), len(invoices))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestPrintDryRun(t *testing.T) {
+	results := []provider.Invoice{
+		{Type: "Kabel", Month: "02", Year: "2026", MonthName: "Februar", Amount: 39.99, Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf"},
+		{Type: "Mobilfunk", Month: "02", Year: "2026", MonthName: "Februar", Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf"},
+	}
+	failures := []provider.Failure{{Type: "DSL", Reason: "invoice not ready yet: no invoice found on the invoice page"}}
+
+	tests := []struct {
+		name       string
+		email      mailer.Config
+		results    []provider.Invoice
+		want       []string
+		wantEmails int
+	}{
+		{
+			name:       "one email",
+			email:      mailer.Config{From: "a@example.com", To: "b@example.com", Subject: "Rechnungen"},
+			results:    results,
+			want:       []string{"Kabel Februar 2026, 39,99 € → 02_2026_Rechnung_Vodafone_Kabel.pdf", "Mobilfunk Februar 2026 → 02_2026", "DSL: invoice not ready", `Would email "Rechnungen" to b@example.com with 2 attachment(s)`},
+			wantEmails: 1,
+		},
+		{
+			name:       "per invoice",
+			email:      mailer.Config{From: "a@example.com", To: "b@example.com", PerInvoice: true},
+			results:    results,
+			want:       []string{"with 1 attachment(s)"},
+			wantEmails: 2,
+		},
+		{
+			name:  "nothing found",
+			email: mailer.Config{From: "a@example.com", To: "b@example.com"},
+			want:  []string{"No invoices found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printDryRun(&buf, tt.results, failures, mailer.New(tt.email, mailer.SMTPConfig{}), tt.email.PerInvoice)
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			if got := strings.Count(out, "Would email"); got != tt.wantEmails {
+				t.Errorf("%d email(s) listed, want %d:\n%s", got, tt.wantEmails, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyEnv overrides config fields with environment variables, so credentials can be
+// passed to a container instead of being written to config.yaml. The variable of a
+// field is its YAML path in upper case joined by "_", e.g. VODAFONE_USER for
+// vodafone.user or LOGIN_BREAKER_FILE for login_breaker.file. Set variables win over
+// config.yaml, including empty ones; "cmd:" values are resolved afterwards like those
+// from the file. Lists are given comma-separated (VODAFONE_CONTRACTS=kabel,dsl).
+// Fields inside lists and maps, such as accounts and storage, can't be overridden.
+func applyEnv(c *Config, lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(c).Elem(), "", lookup)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnvStruct(field, name+"_", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		if !envSettable(field.Type()) {
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(field, value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// envSettable reports whether a field of type t can be given as a variable.
+func envSettable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// setEnvField parses value into a field of a type accepted by envSettable.
+func setEnvField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestApplyEnv(t *testing.T) {
+	base := func() Config {
+		return Config{
+			Vodafone: vodafone.Config{User: "file-user", Pass: "file-pass"},
+			SMTP:     mailer.SMTPConfig{Host: "smtp.example.com", Port: "587"},
+		}
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c Config) bool
+		wantErr bool
+	}{
+		{
+			name:  "no variables keep the file values",
+			env:   map[string]string{},
+			check: func(c Config) bool { return reflect.DeepEqual(c, base()) },
+		},
+		{
+			name: "variables override the file",
+			env:  map[string]string{"VODAFONE_USER": "env-user", "SMTP_PASS": "env-smtp"},
+			check: func(c Config) bool {
+				return c.Vodafone.User == "env-user" && c.Vodafone.Pass == "file-pass" && c.SMTP.Pass == "env-smtp"
+			},
+		},
+		{
+			name:  "empty variable clears a field",
+			env:   map[string]string{"VODAFONE_PASS": ""},
+			check: func(c Config) bool { return c.Vodafone.Pass == "" },
+		},
+		{
+			name:  "nested section",
+			env:   map[string]string{"SMTP_TLS_MIN_VERSION": "1.3", "LOGIN_BREAKER_FILE": "/state/breaker.json"},
+			check: func(c Config) bool { return c.SMTP.TLS.MinVersion == "1.3" && c.Breaker.File == "/state/breaker.json" },
+		},
+		{
+			name: "bool and list",
+			env:  map[string]string{"VODAFONE_DISCOVER": "true", "VODAFONE_CONTRACTS": "kabel, dsl"},
+			check: func(c Config) bool {
+				return c.Vodafone.Discover && reflect.DeepEqual(c.Vodafone.Contracts, []string{"kabel", "dsl"})
+			},
+		},
+		{
+			name:    "invalid bool",
+			env:     map[string]string{"VODAFONE_DISCOVER": "maybe"},
+			wantErr: true,
+		},
+		{
+			name:  "lists of sections are not overridden",
+			env:   map[string]string{"STORAGE": "x", "ACCOUNTS": "y"},
+			check: func(c Config) bool { return c.Storage == nil && c.Accounts == nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base()
+			err := applyEnv(&c, func(name string) (string, bool) {
+				v, ok := tt.env[name]
+				return v, ok
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(c) {
+				t.Errorf("applyEnv() = %+v", c)
+			}
+		})
+	}
+}
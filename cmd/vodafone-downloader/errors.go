@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// Exit codes of a download run, so cron wrappers and monitoring can tell the failure
+// classes apart.
+const (
+	exitError          = 1
+	exitLoginFailed    = 2
+	exit2FARequired    = 3
+	exitInvoiceMissing = 4
+	exitDelivery       = 5
+	exitBrowserMemory  = 6
+	exitConfig         = 7
+	exitCaptureFailed  = 8
+)
+
+// ErrConfig is wrapped by errors in the config file or the command line, which
+// exit with exitConfig before anything is downloaded.
+var ErrConfig = errors.New("config error")
+
+// exitCode maps an error to the exit code of its failure class.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, provider.Err2FARequired):
+		return exit2FARequired
+	case errors.Is(err, provider.ErrLoginFailed):
+		return exitLoginFailed
+	case errors.Is(err, provider.ErrInvoiceNotReady):
+		return exitInvoiceMissing
+	case errors.Is(err, provider.ErrDelivery):
+		return exitDelivery
+	case errors.Is(err, vodafone.ErrBrowserMemory):
+		return exitBrowserMemory
+	case errors.Is(err, ErrConfig):
+		return exitConfig
+	case errors.Is(err, provider.ErrCaptureFailed):
+		return exitCaptureFailed
+	}
+	return exitError
+}
+
+// invoiceMissing reports whether a run ends with exitInvoiceMissing because it found
+// no invoice at all or an invoice isn't available yet, so scripts and the daemon
+// try the month again instead of taking the run for a success.
+func invoiceMissing(results []provider.Invoice, failures []provider.Failure) bool {
+	for _, f := range failures {
+		if errors.Is(f.Err, provider.ErrInvoiceNotReady) {
+			return true
+		}
+	}
+	return len(results) == 0 && len(failures) == 0
+}
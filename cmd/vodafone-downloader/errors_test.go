@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: fmt.Errorf("%w: timeout", provider.ErrLoginFailed), want: exitLoginFailed},
+		{err: provider.Err2FARequired, want: exit2FARequired},
+		{err: fmt.Errorf("%w: no invoice found on the invoice page", provider.ErrInvoiceNotReady), want: exitInvoiceMissing},
+		{err: fmt.Errorf("%w: connection refused", provider.ErrDelivery), want: exitDelivery},
+		{err: fmt.Errorf("%w: Chrome used 900 MB", vodafone.ErrBrowserMemory), want: exitBrowserMemory},
+		{err: fmt.Errorf("%w: no PDF captured", provider.ErrCaptureFailed), want: exitCaptureFailed},
+		{err: fmt.Errorf("%w: storage[0]: unknown type", ErrConfig), want: exitConfig},
+		{err: errors.New("something else"), want: exitError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			if got := exitCode(tc.err); got != tc.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvoiceMissing(t *testing.T) {
+	invoice := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026"}
+	tests := []struct {
+		name     string
+		results  []provider.Invoice
+		failures []provider.Failure
+		want     bool
+	}{
+		{name: "nothing found", want: true},
+		{name: "invoice found", results: []provider.Invoice{invoice}},
+		{name: "capture failed", failures: []provider.Failure{{Type: "Kabel", Err: provider.ErrCaptureFailed}}},
+		{name: "not in the archive", failures: []provider.Failure{{Type: "Kabel", Err: fmt.Errorf("%w: no invoice for 01/2026 in the archive", provider.ErrInvoiceNotReady)}}, want: true},
+		{name: "one of two not ready", results: []provider.Invoice{invoice}, failures: []provider.Failure{{Type: "DSL", Err: provider.ErrInvoiceNotReady}}, want: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := invoiceMissing(tc.results, tc.failures); got != tc.want {
+				t.Errorf("invoiceMissing() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSubcommandConfigErrorExitCode(t *testing.T) {
+	path := configPath
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { configPath = path })
+
+	err := runExport(nil)
+	if got := exitCode(err); got != exitConfig {
+		t.Errorf("exitCode(%v) = %d, want %d", err, got, exitConfig)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// defaultGraceDays matches the recommendation to run the tool from the 25th onwards,
+// when all invoices of the month should be available.
+const defaultGraceDays = 25
+
+// missingExpected returns the names of the expected contracts that have no invoice for
+// the current month. Within the grace window at the start of the month nothing counts
+// as missing yet, since invoices may simply not be out.
+func missingExpected(results []provider.Invoice, now time.Time, c ExpectConfig) []string {
+	graceDays := c.GraceDays
+	if graceDays <= 0 {
+		graceDays = defaultGraceDays
+	}
+	if now.Day() <= graceDays {
+		return nil
+	}
+
+	var missing []string
+	for _, contract := range c.Contracts {
+		typeName := contractTypeName(contract)
+		if !hasCurrentInvoice(results, typeName, now) {
+			missing = append(missing, typeName)
+		}
+	}
+	return missing
+}
+
+// overdueContracts returns the names of contracts whose invoice for the current month
+// has not appeared although their configured deadline day has been reached.
+func overdueContracts(results []provider.Invoice, now time.Time, deadlines map[string]int) []string {
+	var overdue []string
+	for contract, day := range deadlines {
+		typeName := contractTypeName(contract)
+		if day > 0 && now.Day() >= day && !hasCurrentInvoice(results, typeName, now) {
+			overdue = append(overdue, typeName)
+		}
+	}
+	sort.Strings(overdue)
+	return overdue
+}
+
+// contractTypeName maps a configured contract key (e.g. "kabel") to its display name.
+func contractTypeName(contract string) string {
+	if typeName, ok := vodafone.ContractTypes[strings.ToLower(contract)]; ok {
+		return typeName
+	}
+	return contract
+}
+
+// hasCurrentInvoice reports whether results contain an invoice of the given contract
+// for the month of now.
+func hasCurrentInvoice(results []provider.Invoice, typeName string, now time.Time) bool {
+	month, year := fmt.Sprintf("%02d", now.Month()), fmt.Sprintf("%d", now.Year())
+	for _, inv := range results {
+		// Subscriber invoices are named "<Type> <number>"
+		if (inv.Type == typeName || strings.HasPrefix(inv.Type, typeName+" ")) && inv.Month == month && inv.Year == year {
+			return true
+		}
+	}
+	return false
+}
+
+// buildMissingMessage constructs the alert sent in strict mode when expected invoices
+// are missing, including the failure reasons of this run where known.
+func buildMissingMessage(m *mailer.Mailer, missing []string, failures []provider.Failure, now time.Time, notify string) *gomail.Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Für %s %d fehlen erwartete Rechnungen:\n\n", vodafone.MonthNames[now.Month()], now.Year())
+	for _, name := range missing {
+		reason := "keine Rechnung für den aktuellen Monat gefunden"
+		for _, f := range failures {
+			if f.Type == name {
+				reason = f.Reason
+			}
+		}
+		fmt.Fprintf(&body, "%s: %s\n", name, reason)
+	}
+	body.WriteString("\nBitte Login und Navigation prüfen.\n")
+	msg := m.AlertMessage(notify, "Vodafone-Rechnungen fehlen", body.String())
+	mailer.AttachScreenshot(msg, failures)
+	return msg
+}
+
+// buildOverdueMessage constructs the escalation sent when invoices are past their
+// deadline day. An invoice that late usually means login or navigation is broken
+// rather than Vodafone being slow, so it is worded as an escalation.
+func buildOverdueMessage(m *mailer.Mailer, overdue []string, failures []provider.Failure, now time.Time, c ExpectConfig) *gomail.Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Folgende Rechnungen für %s %d sind trotz Stichtag noch nicht abrufbar:\n\n", vodafone.MonthNames[now.Month()], now.Year())
+	for _, name := range overdue {
+		reason := "keine Rechnung für den aktuellen Monat gefunden"
+		for _, f := range failures {
+			if f.Type == name {
+				reason = f.Reason
+			}
+		}
+		fmt.Fprintf(&body, "%s (fällig bis zum %d.): %s\n", name, deadlineDay(c.Deadlines, name), reason)
+	}
+	body.WriteString("\nVermutlich funktionieren Login oder Navigation nicht mehr. Bitte manuell prüfen.\n")
+	msg := m.AlertMessage(c.Notify, "ESKALATION: Vodafone-Rechnung überfällig", body.String())
+	mailer.AttachScreenshot(msg, failures)
+	return msg
+}
+
+// deadlineDay returns the configured deadline day of a contract by display name.
+func deadlineDay(deadlines map[string]int, typeName string) int {
+	for contract, day := range deadlines {
+		if contractTypeName(contract) == typeName {
+			return day
+		}
+	}
+	return 0
+}
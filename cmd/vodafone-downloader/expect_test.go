@@ -0,0 +1,133 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestMissingExpected(t *testing.T) {
+	lateInMonth := time.Date(2026, 2, 27, 8, 0, 0, 0, time.UTC)
+	earlyInMonth := time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC)
+
+	mobilfunk := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026"}
+	kabelLastMonth := provider.Invoice{Type: "Kabel", Month: "01", Year: "2026"}
+	subscriber := provider.Invoice{Type: "Mobilfunk 0172 1234567", Month: "02", Year: "2026"}
+
+	tests := []struct {
+		name    string
+		results []provider.Invoice
+		now     time.Time
+		cfg     ExpectConfig
+		want    []string
+	}{
+		{
+			name:    "all present",
+			results: []provider.Invoice{mobilfunk, {Type: "Kabel", Month: "02", Year: "2026"}},
+			now:     lateInMonth,
+			cfg:     ExpectConfig{Contracts: []string{"mobilfunk", "kabel"}},
+		},
+		{
+			name:    "archive fallback does not count",
+			results: []provider.Invoice{mobilfunk, kabelLastMonth},
+			now:     lateInMonth,
+			cfg:     ExpectConfig{Contracts: []string{"mobilfunk", "kabel"}},
+			want:    []string{"Kabel"},
+		},
+		{
+			name:    "within default grace window",
+			results: nil,
+			now:     earlyInMonth,
+			cfg:     ExpectConfig{Contracts: []string{"mobilfunk", "kabel"}},
+		},
+		{
+			name:    "custom grace window passed",
+			results: []provider.Invoice{mobilfunk},
+			now:     earlyInMonth,
+			cfg:     ExpectConfig{Contracts: []string{"mobilfunk", "kabel"}, GraceDays: 5},
+			want:    []string{"Kabel"},
+		},
+		{
+			name:    "subscriber invoices count for their contract",
+			results: []provider.Invoice{subscriber},
+			now:     lateInMonth,
+			cfg:     ExpectConfig{Contracts: []string{"Mobilfunk"}},
+		},
+		{
+			name:    "nothing expected",
+			results: nil,
+			now:     lateInMonth,
+			cfg:     ExpectConfig{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := missingExpected(tc.results, tc.now, tc.cfg)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("missingExpected() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildMissingMessage(t *testing.T) {
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+
+	m := buildMissingMessage(sender, []string{"Kabel"}, []provider.Failure{{Type: "Kabel", Reason: "invoice page not reachable: timeout"}},
+		time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC), "ops@d.com")
+
+	if got := m.GetHeader("To"); len(got) != 1 || got[0] != "ops@d.com" {
+		t.Errorf("To = %v, want [ops@d.com]", got)
+	}
+	var buf strings.Builder
+	m.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "Kabel: invoice page not reachable: timeout") {
+		t.Errorf("body missing failure reason:\n%s", buf.String())
+	}
+}
+
+func TestOverdueContracts(t *testing.T) {
+	results := []provider.Invoice{{Type: "Kabel", Month: "02", Year: "2026"}}
+	deadlines := map[string]int{"mobilfunk": 12, "kabel": 15}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []string
+	}{
+		{name: "before deadline", now: time.Date(2026, 2, 11, 8, 0, 0, 0, time.UTC)},
+		{name: "on deadline day", now: time.Date(2026, 2, 12, 8, 0, 0, 0, time.UTC), want: []string{"Mobilfunk"}},
+		{name: "found invoice is never overdue", now: time.Date(2026, 2, 20, 8, 0, 0, 0, time.UTC), want: []string{"Mobilfunk"}},
+		{name: "new month resets", now: time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC), want: []string{"Kabel", "Mobilfunk"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := overdueContracts(results, tc.now, deadlines)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("overdueContracts() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildOverdueMessage(t *testing.T) {
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+
+	m := buildOverdueMessage(sender, []string{"Mobilfunk"}, nil, time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC),
+		ExpectConfig{Deadlines: map[string]int{"mobilfunk": 12}})
+	if got := m.GetHeader("To"); len(got) != 1 || got[0] != "c@d.com" {
+		t.Errorf("To = %v, want [c@d.com]", got)
+	}
+	var buf strings.Builder
+	m.WriteTo(&buf)
+	// The body is quoted-printable encoded because of umlauts
+	if !strings.Contains(buf.String(), "Mobilfunk (f=C3=A4llig bis zum 12.)") {
+		t.Errorf("body missing overdue contract:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// runExport implements the "export" subcommand, which writes the invoice history
+// to a file in the requested format.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "xlsx", "export format (xlsx, json)")
+	output := fs.String("output", "", "output file (default vodafone-rechnungen.<format>)")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if cfg.History.File == "" {
+		return fmt.Errorf("history.file is not configured")
+	}
+	history, err := loadHistory(cfg.History.File)
+	if err != nil {
+		return err
+	}
+	if len(history.Entries) == 0 {
+		return fmt.Errorf("history is empty")
+	}
+
+	path := *output
+	if path == "" {
+		path = "vodafone-rechnungen." + *format
+	}
+
+	var write func(*History, io.Writer) error
+	switch *format {
+	case "xlsx":
+		write = writeXLSX
+	case "json":
+		write = writeJSONFeed
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(history, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	slog.Info("History exported", "count", len(history.Entries), "file", path)
+	return nil
+}
+
+// xlsxHeaders are the column titles of every sheet in the XLSX export.
+var xlsxHeaders = []string{"Zeitraum", "Vertrag", "Betrag", "Rechnungsnummer"}
+
+// writeXLSX writes the history as an Excel workbook with one sheet per year, newest
+// year first. Each row holds period, contract, amount and invoice number.
+func writeXLSX(h *History, w io.Writer) error {
+	byYear := map[string][]HistoryEntry{}
+	var years []string
+	for _, e := range h.Entries {
+		if _, ok := byYear[e.Year]; !ok {
+			years = append(years, e.Year)
+		}
+		byYear[e.Year] = append(byYear[e.Year], e)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(years)))
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	amountFormat := `#,##0.00 "€"`
+	amountStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &amountFormat})
+	if err != nil {
+		return err
+	}
+
+	for i, year := range years {
+		sheet := year
+		if i == 0 {
+			// Reuse the default sheet so the workbook has no empty "Sheet1"
+			if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+
+		if err := f.SetSheetRow(sheet, "A1", &xlsxHeaders); err != nil {
+			return err
+		}
+		f.SetCellStyle(sheet, "A1", "D1", headerStyle)
+		f.SetColWidth(sheet, "A", "A", 12)
+		f.SetColWidth(sheet, "B", "B", 14)
+		f.SetColWidth(sheet, "C", "C", 12)
+		f.SetColWidth(sheet, "D", "D", 20)
+
+		entries := byYear[year]
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Month != entries[j].Month {
+				return entries[i].Month < entries[j].Month
+			}
+			return entries[i].Type < entries[j].Type
+		})
+		for r, e := range entries {
+			row := r + 2
+			var amount interface{}
+			if e.Amount > 0 {
+				amount = e.Amount
+			}
+			cell := fmt.Sprintf("A%d", row)
+			if err := f.SetSheetRow(sheet, cell, &[]interface{}{e.Month + "/" + e.Year, e.Type, amount, e.Number}); err != nil {
+				return err
+			}
+			f.SetCellStyle(sheet, fmt.Sprintf("C%d", row), fmt.Sprintf("C%d", row), amountStyle)
+		}
+	}
+	f.SetActiveSheet(0)
+
+	_, err = f.WriteTo(w)
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	h := &History{}
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "12", Year: "2025", Amount: 24.98, Number: "123456789"})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "01", Year: "2026", Amount: 44.98})
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "01", Year: "2026"})
+
+	var buf bytes.Buffer
+	if err := writeXLSX(h, &buf); err != nil {
+		t.Fatalf("writeXLSX() error: %v", err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer f.Close()
+
+	if got := f.GetSheetList(); len(got) != 2 || got[0] != "2026" || got[1] != "2025" {
+		t.Fatalf("sheets = %v, want [2026 2025]", got)
+	}
+
+	rows, err := f.GetRows("2026")
+	if err != nil {
+		t.Fatalf("GetRows failed: %v", err)
+	}
+	want := [][]string{
+		{"Zeitraum", "Vertrag", "Betrag", "Rechnungsnummer"},
+		{"01/2026", "Kabel", "44,98 €"},
+		{"01/2026", "Mobilfunk"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows %v, want %d", len(rows), rows, len(want))
+	}
+	for i := range want {
+		for j := range want[i] {
+			// Amount cells are formatted by Excel; only check they are present
+			if i > 0 && j == 2 {
+				if rows[i][j] == "" {
+					t.Errorf("row %d amount is empty", i)
+				}
+				continue
+			}
+			if rows[i][j] != want[i][j] {
+				t.Errorf("row %d col %d = %q, want %q", i, j, rows[i][j], want[i][j])
+			}
+		}
+	}
+
+	if v, _ := f.GetCellValue("2025", "D2", excelize.Options{RawCellValue: true}); v != "123456789" {
+		t.Errorf("invoice number = %q, want 123456789", v)
+	}
+	if v, _ := f.GetCellValue("2025", "C2", excelize.Options{RawCellValue: true}); v != "24.98" {
+		t.Errorf("raw amount = %q, want 24.98", v)
+	}
+}
+
+func TestRunExportErrors(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+
+	t.Run("history not configured", func(t *testing.T) {
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("vodafone:\n  user: u\n"), 0644)
+		if err := runExport(nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("history:\n  file: history.json\n"), 0644)
+		if err := runExport(nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		h, _ := loadHistory(filepath.Join(dir, "history.json"))
+		h.Add(provider.Invoice{Type: "Kabel", Month: "01", Year: "2026", Amount: 44.98})
+		h.Save()
+		if err := runExport([]string{"--format", "ods"}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("writes xlsx", func(t *testing.T) {
+		out := filepath.Join(dir, "out.xlsx")
+		if err := runExport([]string{"--output", out}); err != nil {
+			t.Fatalf("runExport() error: %v", err)
+		}
+		if _, err := excelize.OpenFile(out); err != nil {
+			t.Errorf("output is not a valid workbook: %v", err)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+)
+
+// feedVersion is increased whenever a field of the feed changes incompatibly.
+const feedVersion = 1
+
+// jsonFeed is the invoice history as read by dashboards, e.g. with the Grafana
+// Infinity or JSON API datasource. Unlike the history file, its layout is a
+// documented contract and only changes together with feedVersion.
+type jsonFeed struct {
+	Version  int         `json:"version"`
+	Updated  time.Time   `json:"updated"`
+	Invoices []feedEntry `json:"invoices"`
+}
+
+type feedEntry struct {
+	Time     time.Time `json:"time"`   // first day of the period, UTC
+	Period   string    `json:"period"` // "2026-02"
+	Contract string    `json:"contract"`
+	Amount   float64   `json:"amount"`
+	VAT      float64   `json:"vat"`
+	Net      float64   `json:"net"`
+	Number   string    `json:"number"`
+}
+
+// newJSONFeed converts the history into the feed layout, oldest period first.
+func newJSONFeed(h *History, updated time.Time) jsonFeed {
+	f := jsonFeed{Version: feedVersion, Updated: updated.UTC(), Invoices: []feedEntry{}}
+	for _, e := range h.Entries {
+		t, err := time.Parse("2006-01", e.period())
+		if err != nil {
+			continue
+		}
+		f.Invoices = append(f.Invoices, feedEntry{
+			Time:     t,
+			Period:   e.period(),
+			Contract: e.Type,
+			Amount:   e.Amount,
+			VAT:      e.VAT,
+			Net:      math.Round((e.Amount-e.VAT)*100) / 100,
+			Number:   e.Number,
+		})
+	}
+	return f
+}
+
+// writeJSONFeed writes the history as a JSON feed.
+func writeJSONFeed(h *History, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newJSONFeed(h, time.Now()))
+}
+
+// updateFeed rewrites the feed file from the history after a run. Errors are logged,
+// a dashboard export never affects the run.
+func updateFeed(path string, h *History) {
+	data, err := json.MarshalIndent(newJSONFeed(h, time.Now()), "", "  ")
+	if err != nil {
+		slog.Warn("Feed failed", "err", err)
+		return
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		slog.Warn("Feed failed", "err", err)
+	}
+}
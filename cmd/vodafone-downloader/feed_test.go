@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewJSONFeed(t *testing.T) {
+	h := &History{Entries: []HistoryEntry{
+		{Type: "Kabel", Month: "01", Year: "2026", Amount: 39.99, VAT: 6.39, Number: "123"},
+		{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98},
+	}}
+	updated := time.Date(2026, 2, 12, 7, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	f := newJSONFeed(h, updated)
+	if f.Version != feedVersion || !f.Updated.Equal(updated) || f.Updated.Location() != time.UTC {
+		t.Errorf("header = %d %v", f.Version, f.Updated)
+	}
+	want := []feedEntry{
+		{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Period: "2026-01", Contract: "Kabel", Amount: 39.99, VAT: 6.39, Net: 33.6, Number: "123"},
+		{Time: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Period: "2026-02", Contract: "Mobilfunk", Amount: 24.98, Net: 24.98},
+	}
+	if len(f.Invoices) != len(want) {
+		t.Fatalf("invoices = %+v", f.Invoices)
+	}
+	for i := range want {
+		if f.Invoices[i] != want[i] {
+			t.Errorf("invoice %d = %+v, want %+v", i, f.Invoices[i], want[i])
+		}
+	}
+}
+
+func TestWriteJSONFeedLayout(t *testing.T) {
+	var buf bytes.Buffer
+	h := &History{Entries: []HistoryEntry{{Type: "Kabel", Month: "01", Year: "2026", Amount: 39.99}}}
+	if err := writeJSONFeed(h, &buf); err != nil {
+		t.Fatalf("writeJSONFeed() error: %v", err)
+	}
+
+	// Dashboards depend on these keys; renaming one breaks them
+	var doc struct {
+		Version  int                      `json:"version"`
+		Invoices []map[string]interface{} `json:"invoices"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, key := range []string{"time", "period", "contract", "amount", "vat", "net", "number"} {
+		if _, ok := doc.Invoices[0][key]; !ok {
+			t.Errorf("key %q missing: %s", key, buf.String())
+		}
+	}
+}
+
+func TestUpdateFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.json")
+	updateFeed(path, &History{})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f jsonFeed
+	if err := json.Unmarshal(data, &f); err != nil || f.Invoices == nil {
+		t.Errorf("feed = %s, %v; want empty invoices list", data, err)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// driveAPIBase is the Google Drive API host (overridden in tests).
+var driveAPIBase = "https://www.googleapis.com"
+
+const (
+	driveScope      = "https://www.googleapis.com/auth/drive"
+	driveFolderType = "application/vnd.google-apps.folder"
+)
+
+// driveStorage uploads the PDFs into a Google Drive folder, given by its ID. The path
+// below it may contain the {type}, {month} and {year} placeholders; missing folders
+// are created. A file with the same name and content is not uploaded again.
+type driveStorage struct {
+	name  string
+	cfg   StorageConfig
+	token *mailer.OAuth2Token
+}
+
+// newDriveStorage validates the settings of a gdrive storage target.
+func newDriveStorage(c StorageConfig) (*driveStorage, error) {
+	switch {
+	case c.FolderID == "":
+		return nil, fmt.Errorf("gdrive storage needs a folder_id")
+	case c.CredentialsFile == "" && c.RefreshToken == "":
+		return nil, fmt.Errorf("gdrive storage needs a credentials_file or a refresh_token")
+	case c.RefreshToken != "" && c.ClientID == "":
+		return nil, fmt.Errorf("gdrive storage needs the client_id of the refresh_token")
+	}
+	return &driveStorage{name: orDefault(c.Name, "Google Drive"), cfg: c}, nil
+}
+
+func (s *driveStorage) Name() string { return s.name }
+
+// accessToken returns a token for the service account or the refresh token,
+// obtaining a new one once the current one has expired.
+func (s *driveStorage) accessToken() (string, error) {
+	now := time.Now()
+	if s.token.Valid(now) {
+		return s.token.Value, nil
+	}
+	if s.cfg.RefreshToken != "" {
+		token, err := mailer.RefreshOAuth2Token(mailer.SMTPOAuth2Config{ClientID: s.cfg.ClientID, ClientSecret: s.cfg.ClientSecret, RefreshToken: s.cfg.RefreshToken}, now)
+		if err != nil {
+			return "", err
+		}
+		s.token = token
+		return token.Value, nil
+	}
+	value, err := googleAccessToken(s.cfg.CredentialsFile, driveScope)
+	if err != nil {
+		return "", err
+	}
+	// Service account tokens are valid for an hour
+	s.token = &mailer.OAuth2Token{Value: value, Expires: now.Add(time.Hour)}
+	return value, nil
+}
+
+// driveFile is the part of a Drive file resource we need.
+type driveFile struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	MD5Checksum string `json:"md5Checksum"`
+}
+
+func (s *driveStorage) Put(inv provider.Invoice) (string, error) {
+	token, err := s.accessToken()
+	if err != nil {
+		return "", fmt.Errorf("google auth: %v", err)
+	}
+
+	parent := s.cfg.FolderID
+	for _, segment := range strings.Split(strings.Trim(provider.ExpandPlaceholders(s.cfg.Path, inv)+"/"+inv.Folder, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if parent, err = s.folder(token, parent, segment); err != nil {
+			return "", err
+		}
+	}
+
+	sum := md5.Sum(inv.PDFData)
+	checksum := hex.EncodeToString(sum[:])
+	for n := 1; n <= maxVersions; n++ {
+		name := versionedName(inv.Filename, n)
+		existing, err := s.find(token, parent, name, "")
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return name, s.upload(token, parent, name, inv.PDFData)
+		}
+		if existing.MD5Checksum == checksum {
+			// Stored by an earlier run
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no free version below v%d", inv.Filename, maxVersions)
+}
+
+// folder returns the ID of the subfolder name of parent, creating it if needed.
+func (s *driveStorage) folder(token, parent, name string) (string, error) {
+	existing, err := s.find(token, parent, name, driveFolderType)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return existing.ID, nil
+	}
+	body, _ := json.Marshal(map[string]interface{}{"name": name, "mimeType": driveFolderType, "parents": []string{parent}})
+	var created driveFile
+	if err := s.call(token, http.MethodPost, "/drive/v3/files?supportsAllDrives=true", "application/json", body, &created); err != nil {
+		return "", fmt.Errorf("creating folder %s failed: %v", name, err)
+	}
+	return created.ID, nil
+}
+
+// find looks up a file (or, with mimeType, a folder) by name in parent. Returns nil
+// if there is none.
+func (s *driveStorage) find(token, parent, name, mimeType string) (*driveFile, error) {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace
+	q := fmt.Sprintf("name = '%s' and '%s' in parents and trashed = false", quote(name), quote(parent))
+	if mimeType != "" {
+		q += fmt.Sprintf(" and mimeType = '%s'", mimeType)
+	}
+	query := url.Values{
+		"q":                         {q},
+		"fields":                    {"files(id,name,md5Checksum)"},
+		"supportsAllDrives":         {"true"},
+		"includeItemsFromAllDrives": {"true"},
+	}
+	var list struct {
+		Files []driveFile `json:"files"`
+	}
+	if err := s.call(token, http.MethodGet, "/drive/v3/files?"+query.Encode(), "", nil, &list); err != nil {
+		return nil, fmt.Errorf("looking up %s failed: %v", name, err)
+	}
+	if len(list.Files) == 0 {
+		return nil, nil
+	}
+	return &list.Files[0], nil
+}
+
+// upload creates the PDF in parent with a multipart upload of metadata and content.
+func (s *driveStorage) upload(token, parent, name string, data []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	meta, _ := json.Marshal(map[string]interface{}{"name": name, "parents": []string{parent}})
+	part, _ := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=UTF-8"}})
+	part.Write(meta)
+	part, _ = w.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/pdf"}})
+	part.Write(data)
+	w.Close()
+
+	err := s.call(token, http.MethodPost, "/upload/drive/v3/files?uploadType=multipart&supportsAllDrives=true",
+		"multipart/related; boundary="+w.Boundary(), body.Bytes(), nil)
+	if err != nil {
+		return fmt.Errorf("upload failed: %v", err)
+	}
+	return nil
+}
+
+// call sends a Drive API request and decodes the JSON response into out if given.
+func (s *driveStorage) call(token, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, driveAPIBase+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
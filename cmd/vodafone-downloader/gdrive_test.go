@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// fakeDrive is a Drive API keeping files in memory.
+type fakeDrive struct {
+	mu      sync.Mutex
+	files   []fakeDriveFile
+	uploads int
+	tokens  int
+}
+
+type fakeDriveFile struct {
+	id, name, parent, mimeType, md5 string
+}
+
+var driveQuery = regexp.MustCompile(`^name = '(.*)' and '(.*)' in parents and trashed = false(?: and mimeType = '(.*)')?$`)
+
+func newFakeDrive(t *testing.T) (*fakeDrive, string) {
+	d := &fakeDrive{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if r.URL.Path == "/token" {
+			d.tokens++
+			fmt.Fprint(w, `{"access_token":"drive-token","expires_in":3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer drive-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			m := driveQuery.FindStringSubmatch(r.URL.Query().Get("q"))
+			if m == nil {
+				http.Error(w, "bad query", http.StatusBadRequest)
+				return
+			}
+			unquote := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace
+			files := []driveFile{}
+			for _, f := range d.files {
+				if f.name == unquote(m[1]) && f.parent == m[2] && (m[3] == "" || f.mimeType == m[3]) {
+					files = append(files, driveFile{ID: f.id, Name: f.name, MD5Checksum: f.md5})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files":
+			var meta struct {
+				Name     string   `json:"name"`
+				MimeType string   `json:"mimeType"`
+				Parents  []string `json:"parents"`
+			}
+			json.NewDecoder(r.Body).Decode(&meta)
+			f := fakeDriveFile{id: fmt.Sprintf("id-%d", len(d.files)+1), name: meta.Name, parent: meta.Parents[0], mimeType: meta.MimeType}
+			d.files = append(d.files, f)
+			json.NewEncoder(w).Encode(driveFile{ID: f.id, Name: f.name})
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/drive/v3/files":
+			_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			mr := multipart.NewReader(r.Body, params["boundary"])
+			part, _ := mr.NextPart()
+			var meta struct {
+				Name    string   `json:"name"`
+				Parents []string `json:"parents"`
+			}
+			json.NewDecoder(part).Decode(&meta)
+			part, _ = mr.NextPart()
+			data, _ := io.ReadAll(part)
+			sum := md5.Sum(data)
+			f := fakeDriveFile{id: fmt.Sprintf("id-%d", len(d.files)+1), name: meta.Name, parent: meta.Parents[0], mimeType: "application/pdf", md5: hex.EncodeToString(sum[:])}
+			d.files = append(d.files, f)
+			d.uploads++
+			json.NewEncoder(w).Encode(driveFile{ID: f.id, Name: f.name})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	base := driveAPIBase
+	driveAPIBase = srv.URL
+	t.Cleanup(func() { driveAPIBase = base })
+	return d, srv.URL
+}
+
+func TestDriveStorage(t *testing.T) {
+	fake, srvURL := newFakeDrive(t)
+	s, err := newStorage(StorageConfig{Type: "gdrive", FolderID: "root-folder", Path: "Vodafone/{year}", CredentialsFile: writeServiceAccountKey(t, srvURL+"/token")})
+	if err != nil {
+		t.Fatalf("newStorage() error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		inv         provider.Invoice
+		want        string
+		wantUploads int
+	}{
+		{name: "new", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf", wantUploads: 1},
+		{name: "rerun", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf", wantUploads: 1},
+		{name: "changed", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb-2")}, want: "02_2026_Rechnung_Vodafone_Kabel_v2.pdf", wantUploads: 2},
+		{name: "quote in name", inv: provider.Invoice{Filename: "O'Brien.pdf", Year: "2026", PDFData: []byte("%PDF-o")}, want: "O'Brien.pdf", wantUploads: 3},
+		{name: "quote in name rerun", inv: provider.Invoice{Filename: "O'Brien.pdf", Year: "2026", PDFData: []byte("%PDF-o")}, want: "O'Brien.pdf", wantUploads: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Put(tt.inv)
+			if err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Put() = %q, want %q", got, tt.want)
+			}
+			if fake.uploads != tt.wantUploads {
+				t.Errorf("%d uploads, want %d", fake.uploads, tt.wantUploads)
+			}
+		})
+	}
+
+	var folders []string
+	for _, f := range fake.files {
+		if f.mimeType == driveFolderType {
+			folders = append(folders, f.parent+"/"+f.name)
+		}
+	}
+	if len(folders) != 2 || folders[0] != "root-folder/Vodafone" || folders[1] != "id-1/2026" {
+		t.Errorf("folders = %v, want Vodafone/2026 created once", folders)
+	}
+	if fake.tokens != 1 {
+		t.Errorf("token requested %d times, want once", fake.tokens)
+	}
+}
+
+func TestNewDriveStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{name: "service account", config: StorageConfig{Type: "gdrive", FolderID: "f", CredentialsFile: "sa.json"}},
+		{name: "refresh token", config: StorageConfig{Type: "gdrive", FolderID: "f", ClientID: "id", RefreshToken: "rt"}},
+		{name: "no folder", config: StorageConfig{Type: "gdrive", CredentialsFile: "sa.json"}, wantErr: true},
+		{name: "no credentials", config: StorageConfig{Type: "gdrive", FolderID: "f"}, wantErr: true},
+		{name: "refresh token without client", config: StorageConfig{Type: "gdrive", FolderID: "f", RefreshToken: "rt"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newStorage(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("newStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
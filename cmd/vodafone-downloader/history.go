@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// HistoryEntry is one downloaded invoice as recorded in the history file.
+type HistoryEntry struct {
+	Type       string    `json:"type"`
+	Month      string    `json:"month"`
+	Year       string    `json:"year"`
+	Amount     float64   `json:"amount"`
+	Number     string    `json:"number,omitempty"`
+	VAT        float64   `json:"vat,omitempty"`
+	Filename   string    `json:"filename"`
+	StoredAs   string    `json:"stored_as,omitempty"` // versioned name if another file had Filename
+	Version    int       `json:"version,omitempty"`   // version of StoredAs, e.g. 2
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// period returns a sortable "YYYY-MM" key for the entry.
+func (e HistoryEntry) period() string {
+	return e.Year + "-" + e.Month
+}
+
+// History is the list of previously downloaded invoices, persisted as JSON.
+type History struct {
+	path    string
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// loadHistory reads the history file at path. A missing file yields an empty history.
+func loadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Add records an invoice, replacing any earlier entry for the same contract and period
+// so that repeated runs within a month don't skew the baseline.
+func (h *History) Add(inv provider.Invoice) {
+	entry := HistoryEntry{
+		Type:       inv.Type,
+		Month:      inv.Month,
+		Year:       inv.Year,
+		Amount:     inv.Amount,
+		Number:     inv.Number,
+		VAT:        inv.VAT,
+		Filename:   inv.Filename,
+		StoredAs:   inv.StoredAs,
+		Version:    inv.Version,
+		RecordedAt: time.Now(),
+	}
+	for i, e := range h.Entries {
+		if e.Type == entry.Type && e.period() == entry.period() {
+			h.Entries[i] = entry
+			return
+		}
+	}
+	h.Entries = append(h.Entries, entry)
+	sort.SliceStable(h.Entries, func(i, j int) bool {
+		return h.Entries[i].period() < h.Entries[j].period()
+	})
+}
+
+// Has reports whether the invoice was recorded before under the same name, so a
+// corrected version of a recorded month doesn't count as known.
+func (h *History) Has(inv provider.Invoice) bool {
+	for _, e := range h.Entries {
+		if e.Type == inv.Type && e.Month == inv.Month && e.Year == inv.Year {
+			return orDefault(e.StoredAs, e.Filename) == inv.StoredName()
+		}
+	}
+	return false
+}
+
+// Amounts returns the known amounts for a contract type from periods before the given
+// invoice, oldest first, limited to the most recent n entries (n <= 0 means all).
+func (h *History) Amounts(inv provider.Invoice, n int) []float64 {
+	before := inv.Year + "-" + inv.Month
+	var amounts []float64
+	for _, e := range h.Entries {
+		if e.Type == inv.Type && e.period() < before && e.Amount > 0 {
+			amounts = append(amounts, e.Amount)
+		}
+	}
+	if n > 0 && len(amounts) > n {
+		amounts = amounts[len(amounts)-n:]
+	}
+	return amounts
+}
+
+// Save writes the history back to its file.
+func (h *History) Save() error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0600)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestLoadHistoryMissingFile(t *testing.T) {
+	h, err := loadHistory(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatalf("loadHistory() error: %v", err)
+	}
+	if len(h.Entries) != 0 {
+		t.Errorf("got %d entries, want 0", len(h.Entries))
+	}
+}
+
+func TestLoadHistoryInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	os.WriteFile(path, []byte("{not json"), 0600)
+
+	if _, err := loadHistory(path); err == nil {
+		t.Fatal("expected error for invalid JSON, got nil")
+	}
+}
+
+func TestHistoryAddReplacesSamePeriod(t *testing.T) {
+	h := &History{}
+	h.Add(provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 40})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 44.98})
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98})
+
+	if len(h.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(h.Entries))
+	}
+	if h.Entries[0].Amount != 44.98 {
+		t.Errorf("Kabel amount = %v, want 44.98", h.Entries[0].Amount)
+	}
+}
+
+func TestHistoryAmounts(t *testing.T) {
+	h := &History{}
+	// Added out of order to verify entries are kept sorted by period
+	h.Add(provider.Invoice{Type: "Kabel", Month: "01", Year: "2026", Amount: 44})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "11", Year: "2025", Amount: 42})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "12", Year: "2025", Amount: 43})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "10", Year: "2025", Amount: 0}) // unknown amount
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "12", Year: "2025", Amount: 24.98})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 99})
+
+	current := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026"}
+
+	if got, want := h.Amounts(current, 0), []float64{42, 43, 44}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Amounts(all) = %v, want %v", got, want)
+	}
+	if got, want := h.Amounts(current, 2), []float64{43, 44}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Amounts(2) = %v, want %v", got, want)
+	}
+}
+
+func TestHistorySaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	h, _ := loadHistory(path)
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98, Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf"})
+	if err := h.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	reloaded, err := loadHistory(path)
+	if err != nil {
+		t.Fatalf("loadHistory() error: %v", err)
+	}
+	if len(reloaded.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(reloaded.Entries))
+	}
+	e := reloaded.Entries[0]
+	if e.Type != "Mobilfunk" || e.Month != "02" || e.Year != "2026" || e.Amount != 24.98 {
+		t.Errorf("entry = %+v, want Mobilfunk 02/2026 24.98", e)
+	}
+	if e.Filename != "02_2026_Rechnung_Vodafone_Mobilfunk.pdf" {
+		t.Errorf("Filename = %q", e.Filename)
+	}
+}
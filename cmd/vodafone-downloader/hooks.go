@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// runHook runs a hook command through the shell. args are appended to the command
+// line, env is added to the environment, and the output goes to the tool's own.
+func runHook(command string, env []string, args ...string) error {
+	cmd := exec.Command("sh", append([]string{"-c", command + ` "$@"`, "sh"}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q failed: %v", command, err)
+	}
+	return nil
+}
+
+// invoiceEnv describes an invoice to hooks as VODAFONE_* environment variables.
+// The amount is formatted with a decimal point for easy processing.
+func invoiceEnv(inv provider.Invoice) []string {
+	return []string{
+		"VODAFONE_TYPE=" + inv.Type,
+		"VODAFONE_MONTH=" + inv.Month,
+		"VODAFONE_YEAR=" + inv.Year,
+		"VODAFONE_AMOUNT=" + strconv.FormatFloat(inv.Amount, 'f', 2, 64),
+		"VODAFONE_NUMBER=" + inv.Number,
+		"VODAFONE_FILENAME=" + inv.Filename,
+	}
+}
+
+// runInvoiceHook writes the invoice PDF to a temporary file and runs the hook with the
+// file's path as argument. The file is removed afterwards.
+func runInvoiceHook(command string, inv provider.Invoice) error {
+	dir, err := os.MkdirTemp("", "vodafone-hook-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, inv.Filename)
+	if err := os.WriteFile(path, inv.PDFData, 0600); err != nil {
+		return err
+	}
+	return runHook(command, invoiceEnv(inv), path)
+}
+
+// newInvoices returns the invoices no earlier run has seen: not in the history, not
+// sent before and not in the database. A nil history, sent state or database doesn't
+// rule out any invoice.
+func newInvoices(invoices []provider.Invoice, history *History, sent *sentState, db *invoiceDB) []provider.Invoice {
+	var unseen []provider.Invoice
+	for _, inv := range invoices {
+		if history == nil || !history.Has(inv) {
+			unseen = append(unseen, inv)
+		}
+	}
+	return db.unrecorded(sent.unsent(unseen))
+}
+
+// runSummaryEnv describes the outcome of a run to the post_run hook.
+func runSummaryEnv(results []provider.Invoice, failures []provider.Failure) []string {
+	var failed []string
+	for _, f := range failures {
+		failed = append(failed, f.Type)
+	}
+	return []string{
+		"VODAFONE_INVOICES=" + strconv.Itoa(len(results)),
+		"VODAFONE_FAILURES=" + strconv.Itoa(len(failures)),
+		"VODAFONE_FAILED=" + strings.Join(failed, ","),
+	}
+}
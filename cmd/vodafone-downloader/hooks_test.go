@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestRunInvoiceHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.txt")
+	inv := provider.Invoice{
+		Type: "Kabel", Month: "02", Year: "2026", Amount: 44.98, Number: "123456789",
+		Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", PDFData: []byte("%PDF-kabel"),
+	}
+
+	// The hook sees the metadata in its environment and the PDF path as argument
+	hook := `f() { echo "$VODAFONE_TYPE $VODAFONE_MONTH/$VODAFONE_YEAR $VODAFONE_AMOUNT $VODAFONE_NUMBER $(basename "$1") $(cat "$1")" > ` + out + `; }; f`
+	if err := runInvoiceHook(hook, inv); err != nil {
+		t.Fatalf("runInvoiceHook() error: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if want := "Kabel 02/2026 44.98 123456789 02_2026_Rechnung_Vodafone_Kabel.pdf %PDF-kabel\n"; string(data) != want {
+		t.Errorf("hook output = %q, want %q", data, want)
+	}
+}
+
+func TestRunHookFailure(t *testing.T) {
+	err := runHook("exit 3", nil)
+	if err == nil || !strings.Contains(err.Error(), `hook "exit 3" failed`) {
+		t.Errorf("runHook() error = %v, want hook failure", err)
+	}
+}
+
+func TestRunSummaryEnv(t *testing.T) {
+	env := runSummaryEnv([]provider.Invoice{{Type: "Mobilfunk"}}, []provider.Failure{{Type: "Kabel"}})
+	want := []string{"VODAFONE_INVOICES=1", "VODAFONE_FAILURES=1", "VODAFONE_FAILED=Kabel"}
+	if strings.Join(env, " ") != strings.Join(want, " ") {
+		t.Errorf("runSummaryEnv() = %v, want %v", env, want)
+	}
+}
+
+func TestNewInvoices(t *testing.T) {
+	dir := t.TempDir()
+	kabel := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", PDFData: []byte("%PDF-kabel")}
+	mobil := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", PDFData: []byte("%PDF-mobil")}
+	dsl := provider.Invoice{Type: "DSL", Month: "02", Year: "2026", Filename: "02_2026_Rechnung_Vodafone_DSL.pdf", PDFData: []byte("%PDF-dsl")}
+	corrected := kabel
+	corrected.StoredAs, corrected.Version, corrected.PDFData = "02_2026_Rechnung_Vodafone_Kabel_v2.pdf", 2, []byte("%PDF-kabel-v2")
+	invoices := []provider.Invoice{kabel, mobil, dsl, corrected}
+
+	history := &History{}
+	history.Add(kabel)
+	sent, _ := loadSentState(filepath.Join(dir, "sent.json"))
+	sent.record([]provider.Invoice{mobil}, time.Now())
+	db, err := openInvoiceDB(filepath.Join(dir, "invoices.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.close()
+	db.record([]provider.Invoice{dsl}, time.Now())
+
+	var got []string
+	for _, inv := range newInvoices(invoices, history, sent, db) {
+		got = append(got, inv.StoredName())
+	}
+	if want := "02_2026_Rechnung_Vodafone_Kabel_v2.pdf"; strings.Join(got, " ") != want {
+		t.Errorf("newInvoices() = %v, want [%s]", got, want)
+	}
+
+	if got := newInvoices(invoices, nil, nil, nil); len(got) != len(invoices) {
+		t.Errorf("newInvoices() without state = %d invoices, want %d", len(got), len(invoices))
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// importInvoice reads a manually downloaded invoice PDF. Period and contract are taken
+// from the download naming if the file follows it, otherwise from the PDF text;
+// typeName, if set, overrides the contract. The file is renamed to the download naming.
+func importInvoice(path, typeName string) (provider.Invoice, error) {
+	inv, err := invoiceFromFile(path)
+	if err != nil {
+		return provider.Invoice{}, err
+	}
+	if inv.Month == "" || inv.Type == "" {
+		text, err := vodafone.ExtractPDFText(inv.PDFData)
+		if err != nil {
+			return provider.Invoice{}, err
+		}
+		if inv.Month == "" {
+			month, year, ok := vodafone.ParsePDFPeriod(text)
+			if !ok {
+				return provider.Invoice{}, fmt.Errorf("no invoice period found")
+			}
+			t, _ := time.Parse("01", month)
+			inv.Month, inv.Year, inv.MonthName = month, year, vodafone.MonthNames[t.Month()]
+		}
+		if inv.Type == "" {
+			inv.Type = vodafone.ParsePDFContract(text)
+		}
+	}
+	if typeName != "" {
+		inv.Type = contractTypeName(typeName)
+	}
+	if inv.Type == "" {
+		return provider.Invoice{}, fmt.Errorf("contract type not recognized, use --type")
+	}
+	inv.Filename = fmt.Sprintf("%s_%s_Rechnung_Vodafone_%s.pdf", inv.Month, inv.Year, strings.ReplaceAll(inv.Type, " ", "_"))
+	return inv, nil
+}
+
+// findPDFs returns the PDF files below dir in lexical order.
+func findPDFs(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err == nil && !e.IsDir() && strings.EqualFold(filepath.Ext(path), ".pdf") {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+// runImport implements the "import" subcommand. It reads invoice PDFs downloaded
+// before the tool was set up, adds them to the history and the database and copies
+// them into the local storage targets under the download naming, so they take part in
+// reports, anomaly baselines and duplicate detection. The imported invoices are
+// recorded as sent, so that neither "send" nor the next run emails them again.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	typeName := fs.String("type", "", "contract type of all files, e.g. kabel (default: from the PDF)")
+	dryRun := fs.Bool("dry-run", false, "only list what would be imported")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [--type kabel] [--dry-run] <dir>")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	var targets []Storage
+	for _, c := range cfg.Storage {
+		if strings.EqualFold(c.Type, "local") {
+			s, err := newStorage(c)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrConfig, err)
+			}
+			targets = append(targets, s)
+		}
+	}
+	if cfg.History.File == "" && cfg.Database.File == "" && len(targets) == 0 {
+		return fmt.Errorf("neither history.file, database.file nor a local storage target is configured")
+	}
+
+	paths, err := findPDFs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var invoices []provider.Invoice
+	for _, path := range paths {
+		inv, err := importInvoice(path, *typeName)
+		if err != nil {
+			slog.Warn("Skipping file", "file", path, "err", err)
+			continue
+		}
+		slog.Info("Recognized invoice", "file", path, "contract", inv.Type, "month", inv.Month, "year", inv.Year, "amount", inv.Amount)
+		invoices = append(invoices, inv)
+	}
+	if *dryRun || len(invoices) == 0 {
+		slog.Info("Files recognized", "count", len(invoices), "of", len(paths))
+		return nil
+	}
+
+	if cfg.History.File != "" {
+		history, err := loadHistory(cfg.History.File)
+		if err != nil {
+			return err
+		}
+		for _, inv := range invoices {
+			history.Add(inv)
+		}
+		if err := history.Save(); err != nil {
+			return err
+		}
+	}
+	db, err := openInvoiceDB(cfg.Database.File)
+	if err != nil {
+		return fmt.Errorf("database.file: %v", err)
+	}
+	defer db.close()
+	now := time.Now()
+	if err := db.markSent(invoices, now); err != nil {
+		return fmt.Errorf("database.file: %v", err)
+	}
+	sent, err := loadSentState(cfg.Email.SentFile)
+	if err != nil {
+		return fmt.Errorf("email.sent_file: %v", err)
+	}
+	if err := sent.record(invoices, now); err != nil {
+		return fmt.Errorf("email.sent_file: %v", err)
+	}
+	for _, status := range storeInvoices(targets, invoices, nil) {
+		for _, e := range status.Errors {
+			slog.Warn("Import into storage target failed", "target", status.Target, "err", e)
+		}
+	}
+	slog.Info("Files imported", "count", len(invoices), "of", len(paths))
+	return nil
+}
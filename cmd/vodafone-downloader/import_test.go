@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// minimalPDF builds a single-page PDF showing each line of text, with a valid xref table.
+func minimalPDF(lines ...string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 72 720 Td 14 TL\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", line)
+	}
+	content.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestImportInvoice(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, minimalPDF(lines...), 0600)
+		return path
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		typeName     string
+		wantFilename string
+		wantAmount   float64
+		wantErr      bool
+	}{
+		{
+			name:         "period and contract from PDF",
+			path:         write("Rechnung_123.pdf", "Kabel Deutschland", "Rechnungsdatum: 12.02.2026", "Rechnungsbetrag 39,99 EUR"),
+			wantFilename: "02_2026_Rechnung_Vodafone_Kabel.pdf",
+			wantAmount:   39.99,
+		},
+		{
+			name:         "contract from flag",
+			path:         write("scan.pdf", "Rechnungsdatum: 05.01.2026", "Rechnungsbetrag 24,98 EUR"),
+			typeName:     "mobilfunk",
+			wantFilename: "01_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+			wantAmount:   24.98,
+		},
+		{
+			name:         "download naming",
+			path:         write("03_2025_Rechnung_Vodafone_Kabel.pdf", "Rechnungsbetrag 39,99 EUR"),
+			wantFilename: "03_2025_Rechnung_Vodafone_Kabel.pdf",
+			wantAmount:   39.99,
+		},
+		{name: "unknown contract", path: write("x.pdf", "Rechnungsdatum: 05.01.2026"), wantErr: true},
+		{name: "no period", path: write("y.pdf", "Kabel"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inv, err := importInvoice(tc.path, tc.typeName)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", inv)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("importInvoice() error: %v", err)
+			}
+			if inv.Filename != tc.wantFilename || inv.Amount != tc.wantAmount {
+				t.Errorf("got %s %.2f, want %s %.2f", inv.Filename, inv.Amount, tc.wantFilename, tc.wantAmount)
+			}
+		})
+	}
+}
+
+func TestRunImportRecordsSent(t *testing.T) {
+	dir := t.TempDir()
+	pdfs := filepath.Join(dir, "pdfs")
+	os.Mkdir(pdfs, 0700)
+	os.WriteFile(filepath.Join(pdfs, "02_2026_Rechnung_Vodafone_Kabel.pdf"), minimalPDF("Rechnungsbetrag 39,99 EUR"), 0600)
+
+	path := configPath
+	configPath = filepath.Join(dir, "config.yaml")
+	t.Cleanup(func() { configPath = path })
+	os.WriteFile(configPath, []byte(fmt.Sprintf("database:\n  file: %q\nemail:\n  sent_file: %q\n",
+		filepath.Join(dir, "invoices.db"), filepath.Join(dir, "sent.json"))), 0600)
+
+	if err := runImport([]string{pdfs}); err != nil {
+		t.Fatalf("runImport() error: %v", err)
+	}
+
+	inv, err := importInvoice(filepath.Join(pdfs, "02_2026_Rechnung_Vodafone_Kabel.pdf"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openInvoiceDB(filepath.Join(dir, "invoices.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.close()
+	if unsent := db.unsent([]provider.Invoice{inv}); len(unsent) != 0 {
+		t.Errorf("database unsent = %v, want the imported invoice recorded as sent", unsent)
+	}
+	sent, err := loadSentState(filepath.Join(dir, "sent.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsent := sent.unsent([]provider.Invoice{inv}); len(unsent) != 0 {
+		t.Errorf("sent state unsent = %v, want the imported invoice recorded as sent", unsent)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// buildInboxMessage forwards the unread portal messages with their attachments. It goes
+// to notify if set, otherwise to the regular invoice recipient.
+func buildInboxMessage(m *mailer.Mailer, messages []vodafone.InboxMessage, notify string) *gomail.Message {
+	subject := "Neue Nachrichten im MeinVodafone-Postfach"
+	if len(messages) == 1 {
+		subject = "MeinVodafone-Postfach: " + messages[0].Subject
+	}
+
+	var body strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			body.WriteString("\n----------------------------------------\n\n")
+		}
+		fmt.Fprintf(&body, "%s\n", msg.Subject)
+		if msg.Date != "" {
+			fmt.Fprintf(&body, "vom %s\n", msg.Date)
+		}
+		fmt.Fprintf(&body, "\n%s\n", strings.TrimSpace(msg.Text))
+	}
+
+	email := m.AlertMessage(notify, subject, body.String())
+	for _, msg := range messages {
+		for _, a := range msg.Attachments {
+			pdfData := a.PDFData
+			email.Attach(a.Filename, gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(pdfData)
+				return err
+			}))
+		}
+	}
+	return email
+}
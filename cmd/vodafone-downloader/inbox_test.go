@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestBuildInboxMessage(t *testing.T) {
+	sender := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{})
+
+	m := buildInboxMessage(sender, []vodafone.InboxMessage{{
+		Subject:     "Preisänderung zu deinem Vertrag",
+		Date:        "12.02.2026",
+		Text:        "Ab dem 1. April erhöht sich dein monatlicher Grundpreis.",
+		Attachments: []vodafone.InboxAttachment{{Filename: "2026-02-12_Preisaenderung_1.pdf", PDFData: []byte("%PDF")}},
+	}}, "")
+
+	// gomail stores non-ASCII headers already encoded
+	subject, _ := new(mime.WordDecoder).DecodeHeader(m.GetHeader("Subject")[0])
+	if subject != "MeinVodafone-Postfach: Preisänderung zu deinem Vertrag" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if got := m.GetHeader("To"); len(got) != 1 || got[0] != "c@d.com" {
+		t.Errorf("To = %v, want [c@d.com]", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `filename="2026-02-12_Preisaenderung_1.pdf"`) {
+		t.Errorf("attachment missing:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// initConfig is the part of the config the "init" wizard asks for. It is written
+// instead of Config so the new file holds only these settings.
+type initConfig struct {
+	Vodafone struct {
+		User      string   `yaml:"user"`
+		Pass      string   `yaml:"pass"`
+		Contracts []string `yaml:"contracts"`
+	} `yaml:"vodafone"`
+	Email struct {
+		From string `yaml:"from"`
+		To   string `yaml:"to"`
+	} `yaml:"email"`
+	SMTP struct {
+		Host string `yaml:"host"`
+		Port string `yaml:"port"`
+		User string `yaml:"user"`
+		Pass string `yaml:"pass"`
+	} `yaml:"smtp"`
+}
+
+func (c *initConfig) smtp() mailer.SMTPConfig {
+	return mailer.SMTPConfig{Host: c.SMTP.Host, Port: c.SMTP.Port, User: c.SMTP.User, Pass: c.SMTP.Pass}
+}
+
+func (c *initConfig) vodafone() vodafone.Config {
+	return vodafone.Config{User: c.Vodafone.User, Pass: c.Vodafone.Pass, Contracts: c.Vodafone.Contracts}
+}
+
+// prompter asks the questions of the "init" wizard on a terminal.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// readSecret reads a password without echoing it; nil reads a visible line
+	readSecret func() (string, error)
+}
+
+// ask prints question and returns the answer, or def if the answer is empty. check,
+// if set, validates the answer; the question is repeated until it passes.
+func (p *prompter) ask(question, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := p.check(answer, check); err != nil {
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// askSecret asks for a password like ask, without showing it. Passwords can't be
+// empty.
+func (p *prompter) askSecret(question string) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s: ", question)
+		var answer string
+		var err error
+		if p.readSecret != nil {
+			answer, err = p.readSecret()
+			fmt.Fprintln(p.out)
+		} else {
+			answer, err = p.readLine()
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := p.check(answer, required); err == nil {
+			return answer, nil
+		}
+	}
+}
+
+// confirm asks a yes/no question; an empty answer is def.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	options := "y/N"
+	if def {
+		options = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, options)
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes", "j", "ja":
+			return true, nil
+		case "n", "no", "nein":
+			return false, nil
+		}
+	}
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// check runs check on answer and prints why it failed.
+func (p *prompter) check(answer string, check func(string) error) error {
+	if check == nil {
+		return nil
+	}
+	err := check(answer)
+	if err != nil {
+		fmt.Fprintf(p.out, "  %v\n", err)
+	}
+	return err
+}
+
+func required(answer string) error {
+	if answer == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+func emailAddress(answer string) error {
+	if _, err := mail.ParseAddress(answer); err != nil {
+		return fmt.Errorf("not an email address: %q", answer)
+	}
+	return nil
+}
+
+func portNumber(answer string) error {
+	if port, err := strconv.Atoi(answer); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("not a port number: %q", answer)
+	}
+	return nil
+}
+
+func contractList(answer string) error {
+	_, err := vodafone.Contracts(vodafone.Config{Contracts: splitList(answer)})
+	return err
+}
+
+// splitList splits a comma- or space-separated answer.
+func splitList(answer string) []string {
+	return strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// initWizard asks for the Vodafone account, the SMTP server and the recipient,
+// tests the SMTP login with testSMTP and, if wanted, the Vodafone login with
+// testLogin, and returns the config file. A failed test offers to change the
+// settings; they can also be kept, e.g. when the SMTP server is only reachable from
+// where the downloader will run.
+func initWizard(p *prompter, testSMTP func(mailer.SMTPConfig) error, testLogin func(vodafone.Config) error) ([]byte, error) {
+	var c initConfig
+	askVodafone := func() error {
+		fmt.Fprintln(p.out, "\nMeinVodafone account")
+		var err error
+		if c.Vodafone.User, err = p.ask("Username or email", c.Vodafone.User, required); err != nil {
+			return err
+		}
+		if c.Vodafone.Pass, err = p.askSecret("Password"); err != nil {
+			return err
+		}
+		contracts, err := p.ask("Contracts (mobilfunk, kabel, dsl)", strings.Join(vodafone.DefaultContracts, ", "), contractList)
+		c.Vodafone.Contracts = splitList(contracts)
+		return err
+	}
+	askSMTP := func() error {
+		fmt.Fprintln(p.out, "\nSMTP server the invoices are sent through")
+		var err error
+		if c.SMTP.Host, err = p.ask("Host", c.SMTP.Host, required); err != nil {
+			return err
+		}
+		port := c.SMTP.Port
+		if port == "" {
+			port = "587"
+		}
+		if c.SMTP.Port, err = p.ask("Port", port, portNumber); err != nil {
+			return err
+		}
+		if c.SMTP.User, err = p.ask("Username", c.SMTP.User, required); err != nil {
+			return err
+		}
+		c.SMTP.Pass, err = p.askSecret("Password")
+		return err
+	}
+	askEmail := func() error {
+		fmt.Fprintln(p.out, "\nInvoice email")
+		from := c.Email.From
+		if from == "" && emailAddress(c.SMTP.User) == nil {
+			from = c.SMTP.User
+		}
+		var err error
+		if c.Email.From, err = p.ask("Sender address", from, emailAddress); err != nil {
+			return err
+		}
+		c.Email.To, err = p.ask("Recipient address", c.Email.To, emailAddress)
+		return err
+	}
+
+	for _, ask := range []func() error{askVodafone, askSMTP, askEmail} {
+		if err := ask(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		fmt.Fprintf(p.out, "\nTesting the SMTP login at %s:%s... ", c.SMTP.Host, c.SMTP.Port)
+		smtpErr := testSMTP(c.smtp())
+		if smtpErr == nil {
+			fmt.Fprintln(p.out, "ok")
+			break
+		}
+		fmt.Fprintf(p.out, "failed: %v\n", smtpErr)
+		change, err := p.confirm("Change the SMTP settings?", true)
+		if err != nil {
+			return nil, err
+		}
+		if !change {
+			break
+		}
+		if err := askSMTP(); err != nil {
+			return nil, err
+		}
+	}
+
+	test, err := p.confirm("\nTest the Vodafone login now? This starts Chrome", false)
+	if err != nil {
+		return nil, err
+	}
+	for test {
+		fmt.Fprint(p.out, "Logging in... ")
+		loginErr := testLogin(c.vodafone())
+		if loginErr == nil {
+			fmt.Fprintln(p.out, "ok")
+			break
+		}
+		fmt.Fprintf(p.out, "failed: %v\n", loginErr)
+		if test, err = p.confirm("Change the Vodafone account?", true); err != nil {
+			return nil, err
+		}
+		if test {
+			if err := askVodafone(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	data, err := yaml.Marshal(&c)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig("config.yaml", data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConfig(cfg); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// loginSmokeTest logs into MeinVodafone in a headless Chrome.
+func loginSmokeTest(c vodafone.Config) error {
+	ctx, cancel := vodafone.NewBrowserContext("", "", true, vodafone.DefaultTimeouts)
+	defer cancel()
+	return vodafone.NewClient(c).Login(ctx)
+}
+
+// runInit implements the "init" subcommand, which writes a config file for a first
+// run from the answers to a few questions. An existing file is only replaced with
+// --force.
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "replace an existing config file")
+	fs.Parse(args)
+
+	if ext := strings.ToLower(filepath.Ext(configPath)); ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("init writes YAML, not %s", configPath)
+	}
+	if _, err := os.Stat(configPath); err == nil && !*force {
+		return fmt.Errorf("%s exists, use --force to replace it", configPath)
+	}
+
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		p.readSecret = func() (string, error) {
+			pass, err := term.ReadPassword(fd)
+			return string(pass), err
+		}
+	}
+	fmt.Printf("Creating %s. Press Enter to accept the value in brackets.\n", configPath)
+	data, err := initWizard(p, mailer.DialSMTP, loginSmokeTest)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(configPath, data, 0600); err != nil {
+		return err
+	}
+	slog.Info("Config written", "file", configPath)
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestInitWizard(t *testing.T) {
+	tests := []struct {
+		name       string
+		answers    []string
+		smtpErrs   int // SMTP tests that fail before one succeeds
+		wantLogins int
+		want       []string // lines of the written config
+		wantErr    bool
+	}{
+		{
+			name: "defaults",
+			answers: []string{
+				"anna@example.com", "vf-secret", "",
+				"smtp.example.com", "", "anna@example.com", "smtp-secret",
+				"", "rechnungen@example.com",
+				"",
+			},
+			want: []string{"user: anna@example.com", "pass: vf-secret", "- kabel", "- mobilfunk",
+				"host: smtp.example.com", `port: "587"`, "from: anna@example.com", "to: rechnungen@example.com"},
+		},
+		{
+			name: "invalid answers repeated",
+			answers: []string{
+				"", "anna", "", "vf-secret", "festnetz", "dsl",
+				"smtp.example.com", "smtps", "465", "mailer", "smtp-secret",
+				"", "not an address", "Anna <anna@example.com>", "rechnungen@example.com",
+				"y",
+			},
+			wantLogins: 1,
+			want:       []string{"user: anna", "- dsl", `port: "465"`, "from: Anna <anna@example.com>"},
+		},
+		{
+			name: "SMTP settings changed after a failed test",
+			answers: []string{
+				"anna", "vf-secret", "",
+				"smtp.example.com", "", "anna@example.com", "wrong",
+				"", "rechnungen@example.com",
+				"", "mail.example.com", "", "", "smtp-secret",
+				"n",
+			},
+			smtpErrs: 1,
+			want:     []string{"host: mail.example.com", "pass: smtp-secret"},
+		},
+		{
+			name: "failed SMTP test kept",
+			answers: []string{
+				"anna", "vf-secret", "",
+				"smtp.example.com", "", "anna@example.com", "smtp-secret",
+				"", "rechnungen@example.com",
+				"n", "",
+			},
+			smtpErrs: 2,
+			want:     []string{"host: smtp.example.com"},
+		},
+		{
+			name:    "input ends",
+			answers: []string{"anna", "vf-secret"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prompter{in: bufio.NewReader(strings.NewReader(strings.Join(tt.answers, "\n") + "\n")), out: io.Discard}
+			smtpErrs, logins := tt.smtpErrs, 0
+			testSMTP := func(mailer.SMTPConfig) error {
+				if smtpErrs > 0 {
+					smtpErrs--
+					return errors.New("535 authentication failed")
+				}
+				return nil
+			}
+			testLogin := func(c vodafone.Config) error {
+				logins++
+				if c.User == "" || c.Pass == "" {
+					t.Errorf("login test without credentials: %+v", c)
+				}
+				return nil
+			}
+			data, err := initWizard(p, testSMTP, testLogin)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("initWizard() = %s, want error", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("initWizard() error: %v", err)
+			}
+			if logins != tt.wantLogins {
+				t.Errorf("login tests = %d, want %d", logins, tt.wantLogins)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(string(data), line) {
+					t.Errorf("config lacks %q:\n%s", line, data)
+				}
+			}
+			if _, err := parseConfig("config.yaml", data); err != nil {
+				t.Errorf("written config doesn't parse: %v", err)
+			}
+		})
+	}
+}
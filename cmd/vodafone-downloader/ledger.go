@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// Defaults for the plain-text accounting export. Account names may contain the
+// {type}, {month} and {year} placeholders.
+const (
+	defaultLedgerFormat   = "hledger"
+	defaultExpenseAccount = "Expenses:Telekommunikation:{type}"
+	defaultPaymentAccount = "Assets:Bank"
+	defaultLedgerPayee    = "Vodafone"
+	defaultCommodity      = "EUR"
+)
+
+// ledgerDescription identifies an invoice in the journal; it is also used to detect
+// invoices that were already booked by an earlier run.
+func ledgerDescription(inv provider.Invoice) string {
+	return fmt.Sprintf("%s Rechnung %s/%s", inv.Type, inv.Month, inv.Year)
+}
+
+// ledgerEntry renders an invoice as a journal transaction dated on the first day of the
+// invoice month, in hledger or beancount syntax.
+func ledgerEntry(inv provider.Invoice, c LedgerConfig) (string, error) {
+	format := orDefault(c.Format, defaultLedgerFormat)
+	expense := provider.ExpandPlaceholders(orDefault(c.ExpenseAccount, defaultExpenseAccount), inv)
+	payment := provider.ExpandPlaceholders(orDefault(c.PaymentAccount, defaultPaymentAccount), inv)
+	payee := orDefault(c.Payee, defaultLedgerPayee)
+	amount := fmt.Sprintf("%.2f %s", inv.Amount, orDefault(c.Commodity, defaultCommodity))
+	date := fmt.Sprintf("%s-%s-01", inv.Year, inv.Month)
+
+	var b strings.Builder
+	switch format {
+	case "hledger", "ledger":
+		fmt.Fprintf(&b, "%s %s | %s", date, payee, ledgerDescription(inv))
+		if inv.Number != "" {
+			fmt.Fprintf(&b, "  ; rechnung:%s", inv.Number)
+		}
+		fmt.Fprintf(&b, "\n    %-40s  %s\n    %s\n", expense, amount, payment)
+	case "beancount":
+		fmt.Fprintf(&b, "%s * %q %q\n", date, payee, ledgerDescription(inv))
+		if inv.Number != "" {
+			fmt.Fprintf(&b, "  invoice: %q\n", inv.Number)
+		}
+		fmt.Fprintf(&b, "  %-40s  %s\n  %s\n", expense, amount, payment)
+	default:
+		return "", fmt.Errorf("unknown journal format %q", format)
+	}
+	return b.String(), nil
+}
+
+// appendLedger appends a transaction per invoice to the configured journal file.
+// Invoices without a known amount or already present in the journal are skipped.
+func appendLedger(c LedgerConfig, invoices []provider.Invoice) error {
+	existing, err := os.ReadFile(c.File)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	f, err := os.OpenFile(c.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, inv := range invoices {
+		if inv.Amount <= 0 {
+			slog.Info("No amount known, not booked in journal", "contract", inv.Type, "month", inv.Month, "year", inv.Year)
+			continue
+		}
+		if strings.Contains(string(existing), ledgerDescription(inv)) {
+			continue
+		}
+		entry, err := ledgerEntry(inv, c)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString("\n" + entry); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestLedgerEntry(t *testing.T) {
+	inv := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98, Number: "123"}
+
+	tests := []struct {
+		name    string
+		cfg     LedgerConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "hledger defaults",
+			cfg:  LedgerConfig{},
+			want: "2026-02-01 Vodafone | Mobilfunk Rechnung 02/2026  ; rechnung:123\n" +
+				"    Expenses:Telekommunikation:Mobilfunk      24.98 EUR\n" +
+				"    Assets:Bank\n",
+		},
+		{
+			name: "beancount with custom accounts",
+			cfg:  LedgerConfig{Format: "beancount", ExpenseAccount: "Expenses:Internet", PaymentAccount: "Liabilities:CreditCard"},
+			want: "2026-02-01 * \"Vodafone\" \"Mobilfunk Rechnung 02/2026\"\n" +
+				"  invoice: \"123\"\n" +
+				"  Expenses:Internet                         24.98 EUR\n" +
+				"  Liabilities:CreditCard\n",
+		},
+		{
+			name:    "unknown format",
+			cfg:     LedgerConfig{Format: "gnucash"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ledgerEntry(inv, tc.cfg)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ledgerEntry() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("ledgerEntry() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAppendLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vodafone.journal")
+	c := LedgerConfig{File: path}
+
+	invoices := []provider.Invoice{
+		{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98},
+		{Type: "Kabel", Month: "02", Year: "2026"}, // no amount, skipped
+	}
+	if err := appendLedger(c, invoices); err != nil {
+		t.Fatalf("appendLedger() error: %v", err)
+	}
+	// A second run in the same month must not book the invoice twice
+	if err := appendLedger(c, invoices); err != nil {
+		t.Fatalf("appendLedger() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	journal := string(data)
+	if n := strings.Count(journal, "Mobilfunk Rechnung 02/2026"); n != 1 {
+		t.Errorf("Mobilfunk booked %d times, want 1:\n%s", n, journal)
+	}
+	if strings.Contains(journal, "Kabel") {
+		t.Errorf("Kabel without amount should not be booked:\n%s", journal)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Log settings from --log-level and --log-format, passed on to child processes.
+var (
+	logLevel  = "info"
+	logFormat = "text"
+)
+
+// logLevels are the accepted values of --log-level.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// newLogger creates the logger for --log-level and --log-format: logfmt-style text
+// for people, one JSON object per line for log aggregation.
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q (debug, info, warn or error)", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (text or json)", format)
+}
+
+// setupLogging makes the logger for the log settings the default, which also
+// routes output of the standard log package through it.
+func setupLogging() error {
+	logger, err := newLogger(os.Stderr, logLevel, logFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// globalArgs returns the flags a child process needs to use the same config and log
+// settings.
+func globalArgs() []string {
+	return []string{"--config", configPath, "--log-level", logLevel, "--log-format", logFormat}
+}
+
+// fatal logs msg with err at error level and exits with the exit code of err's
+// failure class.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(exitCode(err))
+}
+
+// fatalConfig logs msg with err at error level and exits with exitConfig, for errors
+// in the config or on the command line.
+func fatalConfig(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(exitConfig)
+}
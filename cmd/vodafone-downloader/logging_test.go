@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		want    []string // substrings of the output
+		wantErr bool
+	}{
+		{name: "text", level: "info", format: "text", want: []string{"level=INFO", `msg="Downloading invoice"`, "contract=Kabel", "step=capture"}},
+		{name: "debug hidden at warn", level: "warn", format: "text"},
+		{name: "upper case", level: "DEBUG", format: "JSON", want: []string{`"level":"INFO"`, `"contract":"Kabel"`}},
+		{name: "unknown level", level: "verbose", format: "text", wantErr: true},
+		{name: "unknown format", level: "info", format: "logfmt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := newLogger(&buf, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			logger.Debug("Chrome peak memory", "mb", 412)
+			logger.Info("Downloading invoice", "contract", "Kabel", "month", "02", "year", "2026", "step", provider.StageCapture)
+			out := buf.String()
+			if len(tt.want) == 0 && out != "" {
+				t.Errorf("output = %q, want none", out)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output %q lacks %q", out, w)
+				}
+			}
+			if strings.Contains(out, "Chrome peak memory") && tt.level != "DEBUG" {
+				t.Errorf("debug entry logged at level %s", tt.level)
+			}
+		})
+	}
+}
+
+func TestNewLoggerJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.With("account", "privat").Warn("Storing failed", "target", "S3", "file", "02_2026_Rechnung_Vodafone_Kabel.pdf")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output %q is not a JSON object: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{"level": "WARN", "msg": "Storing failed", "account": "privat", "target": "S3", "file": "02_2026_Rechnung_Vodafone_Kabel.pdf"} {
+		if entry[key] != want {
+			t.Errorf("%s = %v, want %q", key, entry[key], want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// interactiveTimeout is how long the visible browser of "login --interactive" stays open.
+const interactiveTimeout = 15 * time.Minute
+
+// runLogin implements the "login" subcommand. With --interactive it opens a visible
+// browser on the configured profile so that login, two-factor codes and consent
+// dialogs can be completed by hand; the session is then kept in the profile for
+// headless runs once the portal shows the account as logged in. Without it, a
+// headless login is attempted to check the setup. Either way, a successful login
+// resets the login breaker.
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	interactive := fs.Bool("interactive", false, "log in manually in a visible browser")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	timeouts, err := vodafone.NewTimeouts(cfg.Timeouts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+
+	if !*interactive {
+		ctx, cancel := vodafone.NewBrowserContext(cfg.Chrome.RemoteURL, cfg.Vodafone.ProfileDir, true, timeouts)
+		defer cancel()
+		if err := vodafone.NewClient(cfg.Vodafone).Login(ctx); err != nil {
+			return err
+		}
+		slog.Info("Login successful", "step", provider.StageLogin)
+		resetBreaker(cfg.Breaker)
+		return nil
+	}
+
+	if cfg.Chrome.RemoteURL != "" {
+		return fmt.Errorf("%w: login --interactive needs a local Chrome, not chrome.remote_url", ErrConfig)
+	}
+	if cfg.Vodafone.ProfileDir == "" {
+		return fmt.Errorf("%w: vodafone.profile_dir must be set to keep the session", ErrConfig)
+	}
+	timeouts.Total = interactiveTimeout
+	ctx, cancel := vodafone.NewBrowserContext("", cfg.Vodafone.ProfileDir, false, timeouts)
+	defer cancel()
+	if err := chromedp.Run(ctx, chromedp.Navigate(vodafone.LoginURL)); err != nil {
+		return err
+	}
+
+	fmt.Println("Log in in the browser window (including any code or consent dialog),")
+	fmt.Print("then press Enter here to save the session... ")
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		return err
+	}
+	if err := vodafone.NewClient(cfg.Vodafone).CheckSession(ctx); err != nil {
+		return err
+	}
+	slog.Info("Session saved", "dir", cfg.Vodafone.ProfileDir)
+	resetBreaker(cfg.Breaker)
+	return nil
+}
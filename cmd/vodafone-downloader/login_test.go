@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLoginInteractiveNeedsProfileDir(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("vodafone:\n  user: u\n"), 0644)
+
+	err := runLogin([]string{"--interactive"})
+	if err == nil || !strings.Contains(err.Error(), "profile_dir") {
+		t.Errorf("runLogin() error = %v, want profile_dir hint", err)
+	}
+	if got := exitCode(err); got != exitConfig {
+		t.Errorf("exitCode(%v) = %d, want %d", err, got, exitConfig)
+	}
+}
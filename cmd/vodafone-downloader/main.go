@@ -0,0 +1,888 @@
+// Vodafone Invoice Downloader
+// Downloads Vodafone invoices (Mobilfunk/Kabel/DSL) and sends them via email
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+type Config struct {
+	Vodafone   vodafone.Config          `yaml:"vodafone"`
+	Chrome     ChromeConfig             `yaml:"chrome"`
+	Accounts   []AccountConfig          `yaml:"accounts"` // several logins, each processed in its own run
+	Email      mailer.Config            `yaml:"email"`
+	SMTP       mailer.SMTPConfig        `yaml:"smtp"`
+	History    HistoryConfig            `yaml:"history"`
+	Anomaly    AnomalyConfig            `yaml:"anomaly"`
+	Google     GoogleConfig             `yaml:"google"`
+	Sheets     SheetsConfig             `yaml:"sheets"`
+	Ledger     LedgerConfig             `yaml:"ledger"`
+	CSV        CSVConfig                `yaml:"csv"`
+	Docspell   DocspellConfig           `yaml:"docspell"`
+	Paperless  PaperlessConfig          `yaml:"paperless"`
+	Telegram   TelegramConfig           `yaml:"telegram"`
+	Slack      SlackConfig              `yaml:"slack"`
+	Ntfy       NtfyConfig               `yaml:"ntfy"`
+	Pushover   PushoverConfig           `yaml:"pushover"`
+	Matrix     MatrixConfig             `yaml:"matrix"`
+	Monitoring MonitoringConfig         `yaml:"monitoring"`
+	Expect     ExpectConfig             `yaml:"expect"`
+	Storage    []StorageConfig          `yaml:"storage"`
+	Schedule   ScheduleConfig           `yaml:"schedule"`
+	Inbox      InboxConfig              `yaml:"inbox"`
+	Documents  vodafone.DocumentsConfig `yaml:"documents"`
+	Hooks      HooksConfig              `yaml:"hooks"`
+	Retry      provider.RetryConfig     `yaml:"retry"`
+	Rules      []RuleConfig             `yaml:"rules"`
+	Metrics    MetricsConfig            `yaml:"metrics"`
+	Canary     CanaryConfig             `yaml:"canary"`
+	Preflight  PreflightConfig          `yaml:"preflight"`
+	Breaker    BreakerConfig            `yaml:"login_breaker"`
+	Tariff     TariffConfig             `yaml:"tariff"`
+	Checkpoint CheckpointConfig         `yaml:"checkpoint"`
+	Timeouts   vodafone.TimeoutsConfig  `yaml:"timeouts"`
+	Database   DatabaseConfig           `yaml:"database"`
+	Debug      DebugConfig              `yaml:"debug"`
+	Vault      VaultConfig              `yaml:"vault"`
+}
+
+type ChromeConfig struct {
+	RemoteURL string `yaml:"remote_url"` // DevTools endpoint of a running Chrome, e.g. "ws://chrome:9222"
+}
+
+type HistoryConfig struct {
+	File string `yaml:"file"`
+	Feed string `yaml:"feed"` // JSON feed for dashboards, rewritten after each run
+}
+
+type AnomalyConfig struct {
+	Window     int     `yaml:"window"`
+	MinSamples int     `yaml:"min_samples"`
+	ZScore     float64 `yaml:"z_score"`
+	Percent    float64 `yaml:"percent"`
+	Notify     string  `yaml:"notify"`
+}
+
+type GoogleConfig struct {
+	CredentialsFile string `yaml:"credentials_file"`
+}
+
+type SheetsConfig struct {
+	SpreadsheetID string `yaml:"spreadsheet_id"`
+	Range         string `yaml:"range"`
+}
+
+type CSVConfig struct {
+	File      string `yaml:"file"`      // CSV file a row per invoice is appended to
+	Delimiter string `yaml:"delimiter"` // field separator, default ";"
+}
+
+type LedgerConfig struct {
+	File           string `yaml:"file"`
+	Format         string `yaml:"format"`
+	ExpenseAccount string `yaml:"expense_account"`
+	PaymentAccount string `yaml:"payment_account"`
+	Payee          string `yaml:"payee"`
+	Commodity      string `yaml:"commodity"`
+}
+
+type DocspellConfig struct {
+	URL         string   `yaml:"url"`
+	Collective  string   `yaml:"collective"`
+	HeaderName  string   `yaml:"header_name"`
+	HeaderValue string   `yaml:"header_value"`
+	User        string   `yaml:"user"`
+	Pass        string   `yaml:"pass"`
+	Folder      string   `yaml:"folder"`
+	Tags        []string `yaml:"tags"`
+}
+
+type PaperlessConfig struct {
+	URL           string   `yaml:"url"`   // Paperless-ngx base URL
+	Token         string   `yaml:"token"` // API token, instead of user and pass
+	User          string   `yaml:"user"`
+	Pass          string   `yaml:"pass"`
+	Title         string   `yaml:"title"`         // document title, default the filename
+	Correspondent string   `yaml:"correspondent"` // name, created if missing
+	DocumentType  string   `yaml:"document_type"` // name, created if missing
+	Tags          []string `yaml:"tags"`          // names, created if missing
+}
+
+type TelegramConfig struct {
+	BotToken string `yaml:"bot_token"` // from @BotFather, enables sending the PDFs
+	ChatID   string `yaml:"chat_id"`   // user, group or channel, e.g. "123456789" or "@channel"
+	Commands bool   `yaml:"commands"`  // with --daemon, accept /status, /fetch and /resend from the chat
+}
+
+type SlackConfig struct {
+	Token   string `yaml:"token"`   // bot token (xoxb-...) with files:write, enables posting the PDFs
+	Channel string `yaml:"channel"` // channel ID, e.g. "C0123456789"
+}
+
+type NtfyConfig struct {
+	Server        string `yaml:"server"` // default https://ntfy.sh
+	Topic         string `yaml:"topic"`  // enables a push message after each run
+	Token         string `yaml:"token"`  // access token, instead of user and pass
+	User          string `yaml:"user"`
+	Pass          string `yaml:"pass"`
+	StateFile     string `yaml:"state_file"`     // counts failed runs in a row
+	EscalateAfter int    `yaml:"escalate_after"` // failed runs in a row before urgent priority, default 3
+}
+
+type PushoverConfig struct {
+	Token  string `yaml:"token"`  // application API token, enables notifications
+	User   string `yaml:"user"`   // user or group key
+	Device string `yaml:"device"` // device name, default all of the user's devices
+}
+
+type MatrixConfig struct {
+	Homeserver  string `yaml:"homeserver"`   // e.g. https://matrix.example.org, enables run summaries
+	AccessToken string `yaml:"access_token"` // of the account posting the messages
+	RoomID      string `yaml:"room_id"`      // e.g. !abc123:example.org
+	Attach      bool   `yaml:"attach"`       // also post the emailed PDFs
+}
+
+type MonitoringConfig struct {
+	PingURL string `yaml:"ping_url"` // pinged with /start, then on success or with /fail
+}
+
+type DebugConfig struct {
+	Dir      string `yaml:"dir"`       // screenshots of failed steps, default "debug"
+	DumpHTML bool   `yaml:"dump_html"` // also save the HTML and URL of the page
+}
+
+type ExpectConfig struct {
+	Contracts []string       `yaml:"contracts"`  // contract types that must yield an invoice every month
+	GraceDays int            `yaml:"grace_days"` // days into the month before a missing invoice counts
+	Strict    bool           `yaml:"strict"`     // exit non-zero and alert if an expected invoice is missing
+	Deadlines map[string]int `yaml:"deadlines"`  // contract type -> day of month the invoice must exist by
+	Notify    string         `yaml:"notify"`
+}
+
+type HooksConfig struct {
+	PreRun      string `yaml:"pre_run"`      // run before logging in; a failure aborts the run
+	PostInvoice string `yaml:"post_invoice"` // run per invoice with the PDF path as argument
+	PostRun     string `yaml:"post_run"`     // run after the invoices have been sent
+}
+
+type MetricsConfig struct {
+	Pushgateway string `yaml:"pushgateway"` // Pushgateway base URL, e.g. http://pushgateway:9091
+	Textfile    string `yaml:"textfile"`    // .prom file for the node_exporter textfile collector
+	Job         string `yaml:"job"`         // job label, defaults to vodafone_downloader
+	Instance    string `yaml:"instance"`    // instance label, defaults to the hostname
+}
+
+type BreakerConfig struct {
+	File        string `yaml:"file"`         // state file, enables the breaker
+	MaxFailures int    `yaml:"max_failures"` // consecutive rejected logins before blocking, default 3
+	Notify      string `yaml:"notify"`
+}
+
+type TariffConfig struct {
+	Check  bool              `yaml:"check"` // compare the invoice base fee with the tariff price
+	URLs   map[string]string `yaml:"urls"`  // contract type -> contract page URL, default via the services page
+	Notify string            `yaml:"notify"`
+}
+
+type CheckpointConfig struct {
+	File   string `yaml:"file"`   // progress of an unfinished run, enables resuming
+	Window string `yaml:"window"` // how long a run can be resumed, default 2h
+}
+
+type PreflightConfig struct {
+	MinFree string `yaml:"min_free"` // free space required in every output directory, e.g. "500MB"
+}
+
+type CanaryConfig struct {
+	Contracts []string `yaml:"contracts"` // contract types to check, default all
+	PingURL   string   `yaml:"ping_url"`  // pinged on success, <url>/fail on failure
+	Notify    string   `yaml:"notify"`
+}
+
+type RuleConfig struct {
+	If       string   `yaml:"if"`       // e.g. "contract == Kabel and amount > 60"
+	Notify   string   `yaml:"notify"`   // added as Cc to the invoice email
+	Tags     []string `yaml:"tags"`     // added to the Docspell and Paperless tags
+	Priority string   `yaml:"priority"` // high, normal or low
+}
+
+type InboxConfig struct {
+	Forward bool   `yaml:"forward"` // forward unread message center messages
+	URL     string `yaml:"url"`     // message center page, defaults to defaultInboxURL
+	Notify  string `yaml:"notify"`
+}
+
+type DatabaseConfig struct {
+	File string `yaml:"file"` // SQLite database of every downloaded invoice
+}
+
+type ScheduleConfig struct {
+	Jitter       string `yaml:"jitter"`        // maximum distance of a run from its scheduled time, e.g. "45m"
+	Run          string `yaml:"run"`           // when --daemon runs, a cron expression or "daily at 08:00"
+	RetryBackoff string `yaml:"retry_backoff"` // first delay before the daemon retries a failed run, default 1h
+}
+
+type StorageConfig struct {
+	Type       string `yaml:"type"`       // local, webdav, s3, gdrive, sftp or webhook
+	Name       string `yaml:"name"`       // shown in the run summary
+	Path       string `yaml:"path"`       // directory or S3 key prefix, may contain {type}, {month} and {year}
+	URL        string `yaml:"url"`        // WebDAV base URL, S3-compatible endpoint, sftp://host:port or webhook URL
+	User       string `yaml:"user"`       // WebDAV, SFTP or webhook basic auth user, or S3 access key ID
+	Pass       string `yaml:"pass"`       // WebDAV, SFTP or webhook password, S3 secret access key or key_file passphrase
+	Bucket     string `yaml:"bucket"`     // S3 bucket
+	Region     string `yaml:"region"`     // S3 region, default from the AWS config
+	Encryption string `yaml:"encryption"` // S3 server-side encryption: AES256 or aws:kms
+	KMSKeyID   string `yaml:"kms_key_id"` // KMS key for aws:kms, default the bucket's
+
+	FolderID        string `yaml:"folder_id"`        // Google Drive folder the path is below
+	CredentialsFile string `yaml:"credentials_file"` // Google service account key file
+	ClientID        string `yaml:"client_id"`        // Google OAuth client of the refresh token
+	ClientSecret    string `yaml:"client_secret"`
+	RefreshToken    string `yaml:"refresh_token"` // Google OAuth refresh token, instead of a service account
+
+	KeyFile    string `yaml:"key_file"`    // SFTP private key, instead of a password
+	HostKey    string `yaml:"host_key"`    // SFTP server key as in known_hosts, e.g. "ssh-ed25519 AAAA..."
+	KnownHosts string `yaml:"known_hosts"` // known_hosts file used without host_key, default ~/.ssh/known_hosts
+
+	Format string `yaml:"format"` // webhook body: json (default, PDF as base64) or multipart
+	Secret string `yaml:"secret"` // webhook HMAC-SHA256 signing key
+}
+
+func main() {
+	path, args, err := configFlag(os.Args[1:])
+	if err != nil {
+		fatalConfig("Config error", err)
+	}
+	if path != "" {
+		configPath = path
+	}
+	for _, setting := range []struct {
+		name  string
+		value *string
+	}{{"log-level", &logLevel}, {"log-format", &logFormat}} {
+		value, rest, err := globalFlag(args, setting.name)
+		if err != nil {
+			fatalConfig("Invalid flag", err)
+		}
+		if value != "" {
+			*setting.value = value
+		}
+		args = rest
+	}
+	if err := setupLogging(); err != nil {
+		fatalConfig("Invalid flag", err)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	// Subcommands; without one, download and send the invoices
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			if err := runExport(os.Args[2:]); err != nil {
+				fatal("Export failed", err)
+			}
+			return
+		case "report":
+			if err := runReport(os.Args[2:]); err != nil {
+				fatal("Report failed", err)
+			}
+			return
+		case "preview":
+			if err := runPreview(os.Args[2:]); err != nil {
+				fatal("Preview failed", err)
+			}
+			return
+		case "canary":
+			if err := runCanary(); err != nil {
+				slog.Error("Canary failed", "err", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "replay":
+			if err := runReplay(os.Args[2:]); err != nil {
+				fatal("Replay failed", err)
+			}
+			return
+		case "import":
+			if err := runImport(os.Args[2:]); err != nil {
+				fatal("Import failed", err)
+			}
+			return
+		case "backup":
+			if err := runBackup(os.Args[2:]); err != nil {
+				fatal("Backup failed", err)
+			}
+			return
+		case "restore":
+			if err := runRestore(os.Args[2:]); err != nil {
+				fatal("Restore failed", err)
+			}
+			return
+		case "login":
+			if err := runLogin(os.Args[2:]); err != nil {
+				slog.Error("Login failed", "err", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "send":
+			if err := runSend(os.Args[2:]); err != nil {
+				slog.Error("Send failed", "err", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "backfill":
+			if err := runBackfill(os.Args[2:]); err != nil {
+				fatal("Backfill failed", err)
+			}
+			return
+		case "init":
+			if err := runInit(os.Args[2:]); err != nil {
+				fatal("Init failed", err)
+			}
+			return
+		case "validate-config":
+			if err := runValidateConfig(os.Args[2:]); err != nil {
+				fatalConfig("Config error", err)
+			}
+			return
+		case "history":
+			if err := runHistory(os.Args[2:]); err != nil {
+				fatal("History failed", err)
+			}
+			return
+		case "version":
+			runVersion()
+			return
+		}
+	}
+
+	// "run" downloads and sends the invoices, also without a subcommand; "download"
+	// stops before the invoice email, which "send" can deliver later
+	command := "run"
+	if len(os.Args) > 1 && (os.Args[1] == "run" || os.Args[1] == "download") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
+	// --now simulates another date for the month matching and the grace and deadline
+	// checks, e.g. to test the turn of the year. It is meant for testing only.
+	nowFlag := flag.String("now", "", "")
+	recordDir := flag.String("record", "", "record page texts, DOM snapshots and PDFs into this directory for replay")
+	recipientsFile := flag.String("recipients-file", "", "CSV or YAML file with additional recipients of this run's invoice email")
+	monthFlag := flag.String("month", "", "download the invoices of this month (01-12) from the archive instead of the current ones")
+	yearFlag := flag.String("year", "", "year of --month (default this year)")
+	accountFlag := flag.String("account", "", "only process this account of the accounts section")
+	dryRun := flag.Bool("dry-run", false, "log in and find the invoices, but only print what would be downloaded and sent")
+	daemonFlag := flag.Bool("daemon", false, "stay resident and run on schedule.run")
+	force := flag.Bool("force", false, "send invoices again that email.sent_file lists as sent")
+	jitter := flag.Bool("jitter", false, "wait a random time within schedule.jitter before starting, for cron jobs")
+	flag.Parse()
+	now, err := parseNow(*nowFlag)
+	if err != nil {
+		fatalConfig("Invalid --now", err)
+	}
+	month, year, err := parsePeriod(*monthFlag, *yearFlag, now)
+	if err != nil {
+		fatalConfig("Invalid period", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fatalConfig("Config error", err)
+	}
+	if *daemonFlag {
+		// The daemon spreads the runs itself
+		if err := runDaemon(cfg.Schedule, cfg.Telegram, append([]string{command}, withoutFlag(withoutFlag(os.Args[1:], "daemon"), "jitter")...)); err != nil {
+			fatal("Daemon failed", err)
+		}
+		return
+	}
+	if *jitter && !*dryRun {
+		if err := waitForJitter(cfg.Schedule); err != nil {
+			fatalConfig("Config error", err)
+		}
+	}
+	if len(cfg.Accounts) > 0 {
+		if err := checkAccounts(cfg.Accounts); err != nil {
+			fatalConfig("Config error", err)
+		}
+		if *accountFlag == "" {
+			os.Exit(runAccounts(cfg.Accounts, append([]string{command}, withoutFlag(os.Args[1:], "jitter")...)))
+		}
+		if err := cfg.selectAccount(*accountFlag); err != nil {
+			fatalConfig("Config error", err)
+		}
+		slog.SetDefault(slog.Default().With("account", *accountFlag))
+	} else if *accountFlag != "" {
+		fatalConfig("Config error", errors.New("--account needs an accounts section"))
+	}
+	downloader := vodafone.NewClient(cfg.Vodafone)
+	downloader.DebugDir = orDefault(cfg.Debug.Dir, vodafone.DefaultDebugDir)
+	downloader.DumpHTML = cfg.Debug.DumpHTML
+	downloader.Now = func() time.Time { return now }
+	downloader.Month, downloader.Year = month, year
+	downloader.DryRun = *dryRun
+	if *recordDir != "" {
+		if downloader.Session, err = vodafone.NewSessionRecorder(*recordDir, now); err != nil {
+			fatal("Recording failed", err)
+		}
+	}
+	sender := mailer.New(cfg.Email, cfg.SMTP)
+	if *recipientsFile != "" {
+		if sender.Extra, err = mailer.LoadRecipients(*recipientsFile); err != nil {
+			fatalConfig("Invalid --recipients-file", err)
+		}
+		slog.Info("Additional recipients for this run", "file", *recipientsFile, "recipients", strings.Join(sender.Extra, ", "))
+	}
+	// Report every problem of the config at once; the settings below are known to be
+	// valid afterwards
+	var problems []error
+	if command == "run" {
+		problems = checkFields(cfg)
+	}
+	if err := checkConfig(cfg); err != nil {
+		problems = append(problems, unjoin(err)...)
+	}
+	if len(problems) > 0 {
+		fatalConfig("Config error", errors.Join(problems...))
+	}
+	targets, _ := newStorageTargets(cfg)
+	retry, _ := provider.NewRetryPolicy(cfg.Retry)
+	downloader.Retry, sender.Retry = retry, retry
+	rules, _ := compileRules(cfg.Rules)
+	contracts, _ := vodafone.Contracts(cfg.Vodafone)
+	limitFlags, memoryLimit, _ := vodafone.BrowserLimits(cfg.Vodafone)
+	timeouts, _ := vodafone.NewTimeouts(cfg.Timeouts)
+	if command == "download" && len(targets) == 0 {
+		fatalConfig("Config error", errors.New("download needs a storage target to keep the invoices in"))
+	}
+	sent, err := loadSentState(cfg.Email.SentFile)
+	if err != nil {
+		fatalConfig("Config error", fmt.Errorf("email.sent_file: %v", err))
+	}
+	db, err := openInvoiceDB(cfg.Database.File)
+	if err != nil {
+		fatalConfig("Config error", fmt.Errorf("database.file: %v", err))
+	}
+	defer db.close()
+
+	// Tell the dead man's switch the run has begun; aborts before the end are
+	// reported as failures
+	ping := cfg.Monitoring.PingURL != "" && !*dryRun
+	abort := func(err error) {
+		if ping {
+			if err := pingMonitor(cfg.Monitoring.PingURL, pingFail, err.Error()); err != nil {
+				slog.Warn("Health ping failed", "err", err)
+			}
+		}
+		fatal("Aborting", err)
+	}
+	if ping {
+		if err := pingMonitor(cfg.Monitoring.PingURL, pingStart, ""); err != nil {
+			slog.Warn("Health ping failed", "err", err)
+		}
+	}
+
+	if cfg.Hooks.PreRun != "" && !*dryRun {
+		if err := runHook(cfg.Hooks.PreRun, nil); err != nil {
+			abort(err)
+		}
+	}
+
+	if err := preflight(cfg); err != nil {
+		abort(err)
+	}
+
+	// Resume an interrupted run instead of repeating what already succeeded. A dry
+	// run always looks at every contract.
+	var cp *checkpoint
+	if !*dryRun {
+		if cp, err = loadCheckpoint(cfg.Checkpoint, time.Now()); err != nil {
+			fatalConfig("Config error", err)
+		}
+	}
+	if cp != nil {
+		downloader.Checkpoint = cp
+	}
+
+	// Launch headless Chrome and log into Vodafone
+	ctx, cancel := vodafone.NewBrowserContext(cfg.Chrome.RemoteURL, cfg.Vodafone.ProfileDir, true, timeouts, limitFlags...)
+	defer func() { cancel() }()
+	ctx = vodafone.WatchMemory(ctx, memoryLimit)
+	if err := vodafone.BlockRequests(ctx, cfg.Vodafone.Block); err != nil {
+		slog.Warn("Request blocking failed", "err", err)
+	}
+
+	// Report the outcome to the Pushgateway and the textfile collector, if configured
+	start := time.Now()
+	push := func(m runMetrics) {
+		if *dryRun {
+			return
+		}
+		m.Start, m.End = start, time.Now()
+		if cfg.Metrics.Pushgateway != "" {
+			if err := pushMetrics(cfg.Metrics, m); err != nil {
+				slog.Warn("Metrics failed", "err", err)
+			}
+		}
+		if cfg.Metrics.Textfile != "" {
+			if err := writeMetricsFile(cfg.Metrics.Textfile, m); err != nil {
+				slog.Warn("Metrics file failed", "err", err)
+			}
+		}
+		if cfg.Ntfy.Topic != "" {
+			if err := notifyNtfy(cfg.Ntfy, m); err != nil {
+				slog.Warn("ntfy failed", "err", err)
+			}
+		}
+		if cfg.Pushover.Token != "" && runFailed(m) {
+			if err := notifyPushoverFailure(cfg.Pushover, m); err != nil {
+				slog.Warn("Pushover failed", "err", err)
+			}
+		}
+		if cfg.Matrix.Homeserver != "" {
+			if err := notifyMatrix(cfg.Matrix, m); err != nil {
+				slog.Warn("Matrix failed", "err", err)
+			}
+		}
+		if ping {
+			if err := pingRunOutcome(cfg.Monitoring.PingURL, m); err != nil {
+				slog.Warn("Health ping failed", "err", err)
+			}
+		}
+	}
+
+	// Login, discovery and download go through the Provider interface; the tariff
+	// check, the inbox and the documents are MeinVodafone features of the client
+	var isp provider.Provider = downloader
+	login := func() error { return retry.Do(provider.StageLogin, func() error { return isp.Login(ctx) }) }
+	alert := func(b *loginBreaker) {
+		if err := sender.SendMessage(buildBreakerMessage(sender, b, cfg.Breaker.Notify)); err != nil {
+			slog.Warn("Alert failed", "err", err)
+		}
+	}
+	if !cfg.Vodafone.Discover && cp.allDone(contracts) && !cfg.Inbox.Forward && !cfg.Documents.Payments && !cfg.Documents.Prices && !cfg.Tariff.Check {
+		slog.Info("All invoices downloaded before, skipping login")
+	} else {
+		slog.Info("Logging in", "step", provider.StageLogin)
+		if err := guardedLogin(cfg.Breaker, login, alert); err != nil {
+			slog.Error("Aborting", "step", provider.StageLogin, "err", err)
+			push(runMetrics{LoginErr: err})
+			cancel()
+			os.Exit(exitCode(err))
+		}
+	}
+
+	// After a crash, start a new browser and log in again; with vodafone.profile_dir
+	// the saved session is reused
+	downloader.Relaunch = func() (context.Context, error) {
+		cancel()
+		ctx, cancel = vodafone.NewBrowserContext(cfg.Chrome.RemoteURL, cfg.Vodafone.ProfileDir, true, timeouts, limitFlags...)
+		ctx = vodafone.WatchMemory(ctx, memoryLimit)
+		if err := vodafone.BlockRequests(ctx, cfg.Vodafone.Block); err != nil {
+			slog.Warn("Request blocking failed", "err", err)
+		}
+		return ctx, guardedLogin(cfg.Breaker, login, alert)
+	}
+
+	if cfg.Vodafone.Discover {
+		if err := isp.Discover(ctx, contracts); err != nil {
+			slog.Warn("Contract discovery failed, using the configured contract types", "err", err)
+		}
+	}
+
+	targetMonth := fmt.Sprintf("%s %d", vodafone.MonthNames[now.Month()], now.Year())
+	if month != "" {
+		m, _ := strconv.Atoi(month)
+		targetMonth = fmt.Sprintf("%s %s (archive)", vodafone.MonthNames[time.Month(m)], year)
+	}
+	slog.Info("Looking for invoices", "period", targetMonth)
+
+	results, failures := isp.Download(ctx)
+	applyRules(rules, results)
+	if *dryRun {
+		printDryRun(os.Stdout, results, failures, sender, cfg.Email.PerInvoice)
+		return
+	}
+	if cfg.Tariff.Check {
+		downloader.CheckTariffs(ctx, results, cfg.Tariff.URLs)
+	}
+
+	// Forward price changes and contract notices from the portal's message center
+	if cfg.Inbox.Forward {
+		messages, err := downloader.FetchInboxMessages(ctx, cfg.Inbox.URL)
+		if err != nil {
+			slog.Warn("Inbox failed", "err", err)
+		}
+		if len(messages) > 0 {
+			slog.Info("Forwarding inbox messages", "count", len(messages))
+			if err := sender.SendMessage(buildInboxMessage(sender, messages, cfg.Inbox.Notify)); err != nil {
+				slog.Warn("Forwarding inbox failed", "err", err)
+			}
+		}
+	}
+
+	// Payment documents and price information are only archived, not mailed
+	var documents []provider.Invoice
+	if cfg.Documents.Payments || cfg.Documents.Prices {
+		if documents, err = downloader.DownloadDocuments(ctx, cfg.Documents, now); err != nil {
+			slog.Warn("Documents failed", "err", err)
+		}
+	}
+	if err := downloader.Session.Save(); err != nil {
+		slog.Warn("Recording failed", "err", err)
+	}
+
+	// Archive the PDFs in every configured storage target, before the invoices are
+	// recorded with the names they were stored under
+	var stored []mailer.StorageStatus
+	if (len(results) > 0 || len(documents) > 0) && !cp.reached(checkpointStored) {
+		stored = storeInvoices(targets, append(results, documents...), retry)
+		applyVersions(results, stored)
+		if storedAll(stored) {
+			cp.mark(checkpointStored, results)
+		}
+	}
+
+	// Hooks, history, sheet and journal run once per invoice, also across a resume
+	var history *History
+	if !cp.reached(checkpointRecorded) {
+		// The hook sees each invoice once, not on every run that downloads it again
+		if cfg.Hooks.PostInvoice != "" {
+			var known *History
+			if cfg.History.File != "" {
+				if known, err = loadHistory(cfg.History.File); err != nil {
+					slog.Warn("History unavailable", "err", err)
+				}
+			}
+			for _, inv := range newInvoices(results, known, sent, db) {
+				if err := runInvoiceHook(cfg.Hooks.PostInvoice, inv); err != nil {
+					slog.Warn("Post-invoice hook failed", "file", inv.Filename, "contract", inv.Type, "month", inv.Month, "year", inv.Year, "err", err)
+				}
+			}
+		}
+
+		// Compare amounts against previous months and remember this run's invoices
+		if cfg.History.File != "" && len(results) > 0 {
+			history = recordHistory(cfg.History, cfg.Anomaly, results)
+		}
+
+		// Append the invoices to the configured Google Sheet
+		if cfg.Sheets.SpreadsheetID != "" && len(results) > 0 {
+			slog.Info("Updating Google Sheet")
+			if err := appendToSheet(cfg.Google, cfg.Sheets, results); err != nil {
+				slog.Warn("Google Sheet failed", "err", err)
+			}
+		}
+
+		// Book the invoices in the plain-text accounting journal
+		if cfg.Ledger.File != "" && len(results) > 0 {
+			if err := appendLedger(cfg.Ledger, results); err != nil {
+				slog.Warn("Journal failed", "err", err)
+			}
+		}
+
+		// Track the spend in a spreadsheet-friendly CSV file
+		if cfg.CSV.File != "" && len(results) > 0 {
+			if err := appendCSV(cfg.CSV, results, now); err != nil {
+				slog.Warn("CSV failed", "err", err)
+			}
+		}
+
+		if err := db.record(results, time.Now()); err != nil {
+			slog.Warn("Database failed", "err", err)
+		}
+		cp.mark(checkpointRecorded, results)
+	} else if cfg.History.File != "" && cfg.Email.Chart {
+		if history, err = loadHistory(cfg.History.File); err != nil {
+			slog.Warn("History unavailable", "err", err)
+		}
+	}
+
+	var chartPNG []byte
+	if history != nil && cfg.Email.Chart {
+		if chartPNG, err = renderSpendChart(history, now); err != nil {
+			slog.Warn("Chart failed", "err", err)
+		}
+	}
+
+	// Send all found invoices as email attachments, except those sent by an earlier run
+	toSend := results
+	if !*force {
+		toSend = db.unsent(sent.unsent(results))
+	}
+	var emailErr error
+	switch {
+	case command == "download":
+		slog.Info("Done: invoices downloaded, not sending", "count", len(results))
+	case len(toSend) > 0:
+		slog.Info("Sending email", "count", len(toSend), "step", provider.StageSend)
+		var delivered []provider.Invoice
+		if delivered, emailErr = sender.Send(toSend, failures, stored, chartPNG); emailErr != nil {
+			slog.Error("Email failed", "step", provider.StageSend, "sent", len(delivered), "count", len(toSend), "err", emailErr)
+		} else {
+			slog.Info("Done: invoices sent", "count", len(toSend))
+		}
+		// Invoices that went out before another email failed are not sent again
+		if len(delivered) > 0 {
+			if err := sent.record(delivered, time.Now()); err != nil {
+				slog.Warn("Saving sent invoices failed", "err", err)
+			}
+			if err := db.markSent(delivered, time.Now()); err != nil {
+				slog.Warn("Database failed", "err", err)
+			}
+		}
+		// Chats get the invoices recorded as sent, so the retry after a failed email
+		// doesn't post them again
+		if len(delivered) > 0 {
+			if cfg.Telegram.BotToken != "" {
+				if err := sendTelegram(cfg.Telegram, delivered, retry); err != nil {
+					slog.Warn("Telegram failed", "err", err)
+				} else {
+					slog.Info("Invoices sent to Telegram", "count", len(delivered))
+				}
+			}
+			if cfg.Slack.Token != "" {
+				if err := sendSlack(cfg.Slack, delivered, retry); err != nil {
+					slog.Warn("Slack failed", "err", err)
+				} else {
+					slog.Info("Invoices posted to Slack", "count", len(delivered))
+				}
+			}
+			if cfg.Pushover.Token != "" {
+				if err := notifyPushoverInvoices(cfg.Pushover, delivered); err != nil {
+					slog.Warn("Pushover failed", "err", err)
+				}
+			}
+			if cfg.Matrix.Homeserver != "" && cfg.Matrix.Attach {
+				if err := sendMatrixFiles(cfg.Matrix, delivered); err != nil {
+					slog.Warn("Matrix failed", "err", err)
+				}
+			}
+		}
+	case len(results) > 0:
+		slog.Info("All invoices were sent before, not sending again (use --force to resend)", "count", len(results))
+	default:
+		slog.Info("No invoices found")
+	}
+	if emailErr == nil && (command != "download" || storedAll(stored)) {
+		cp.clear()
+	}
+
+	if flagged := anomalous(results); len(flagged) > 0 {
+		slog.Info("Sending alert for unusual invoices", "count", len(flagged))
+		if err := sender.SendMessage(buildAnomalyMessage(sender, flagged, cfg.Anomaly.Notify)); err != nil {
+			slog.Warn("Alert failed", "err", err)
+		}
+	}
+
+	if flagged := tariffMismatches(results); len(flagged) > 0 {
+		slog.Info("Sending alert for invoices not matching the tariff", "count", len(flagged))
+		if err := sender.SendMessage(buildTariffMessage(sender, flagged, cfg.Tariff.Notify)); err != nil {
+			slog.Warn("Alert failed", "err", err)
+		}
+	}
+
+	if cfg.Hooks.PostRun != "" {
+		if err := runHook(cfg.Hooks.PostRun, runSummaryEnv(results, failures)); err != nil {
+			slog.Warn("Post-run hook failed", "err", err)
+		}
+	}
+	push(runMetrics{Invoices: results, Failures: failures, EmailErr: emailErr})
+
+	// Escalate invoices that are past their deadline day. Deadlines and expected
+	// invoices concern the current month, so they are skipped for a selected one.
+	if overdue := overdueContracts(results, now, cfg.Expect.Deadlines); month == "" && len(overdue) > 0 {
+		slog.Warn("Invoices overdue, sending escalation", "contracts", strings.Join(overdue, ", "))
+		if err := sender.SendMessage(buildOverdueMessage(sender, overdue, failures, now, cfg.Expect)); err != nil {
+			slog.Warn("Escalation failed", "err", err)
+		}
+	}
+
+	// Make sure silent breakage can't go unnoticed for months
+	if missing := missingExpected(results, now, cfg.Expect); month == "" && len(missing) > 0 {
+		slog.Warn("Expected invoices missing", "contracts", strings.Join(missing, ", "))
+		if cfg.Expect.Strict {
+			if err := sender.SendMessage(buildMissingMessage(sender, missing, failures, now, cfg.Expect.Notify)); err != nil {
+				slog.Warn("Alert failed", "err", err)
+			}
+			cancel()
+			os.Exit(exitCode(provider.ErrInvoiceNotReady))
+		}
+	}
+
+	if invoiceMissing(results, failures) {
+		cancel()
+		os.Exit(exitInvoiceMissing)
+	}
+	if emailErr != nil {
+		cancel()
+		os.Exit(exitCode(emailErr))
+	}
+	if err := context.Cause(ctx); errors.Is(err, vodafone.ErrBrowserMemory) {
+		slog.Error("Aborted", "err", err)
+		cancel()
+		os.Exit(exitCode(err))
+	}
+	for _, f := range failures {
+		if errors.Is(f.Err, provider.ErrCaptureFailed) {
+			cancel()
+			os.Exit(exitCaptureFailed)
+		}
+	}
+}
+
+// recordHistory flags unusual amounts using the history file and adds the invoices to it,
+// then updates the JSON feed if configured.
+// History errors are logged but never stop the invoices from being sent; in that case
+// nil is returned.
+func recordHistory(c HistoryConfig, anomaly AnomalyConfig, results []provider.Invoice) *History {
+	history, err := loadHistory(c.File)
+	if err != nil {
+		slog.Warn("History unavailable", "err", err)
+		return nil
+	}
+	flagAnomalies(results, history, anomaly)
+	for _, inv := range anomalous(results) {
+		slog.Warn("Invoice looks unusual", "contract", inv.Type, "month", inv.Month, "year", inv.Year, "reason", inv.Anomaly)
+	}
+	if err := history.Save(); err != nil {
+		slog.Warn("Saving history failed", "err", err)
+	}
+	if c.Feed != "" {
+		updateFeed(c.Feed, history)
+	}
+	return history
+}
+
+// loadConfig reads the config file (config.yaml in the working directory unless
+// --config is given), applies the environment overrides and resolves its secrets.
+func loadConfig() (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseConfig(configPath, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEnv(c, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
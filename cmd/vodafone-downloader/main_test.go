@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// Save and restore working directory
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	t.Run("valid config", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Chdir(dir)
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+vodafone:
+  user: "testuser"
+  pass: "testpass"
+email:
+  from: "a@b.com"
+  to: "c@d.com"
+  subject: "Test Subject"
+smtp:
+  host: "smtp.test.com"
+  port: "465"
+  user: "smtpuser"
+  pass: "smtppass"
+`), 0644)
+
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("loadConfig() error: %v", err)
+		}
+		if cfg.Vodafone.User != "testuser" {
+			t.Errorf("Vodafone.User = %q, want %q", cfg.Vodafone.User, "testuser")
+		}
+		if cfg.Vodafone.Pass != "testpass" {
+			t.Errorf("Vodafone.Pass = %q, want %q", cfg.Vodafone.Pass, "testpass")
+		}
+		if cfg.Email.From != "a@b.com" {
+			t.Errorf("Email.From = %q, want %q", cfg.Email.From, "a@b.com")
+		}
+		if cfg.Email.To != "c@d.com" {
+			t.Errorf("Email.To = %q, want %q", cfg.Email.To, "c@d.com")
+		}
+		if cfg.Email.Subject != "Test Subject" {
+			t.Errorf("Email.Subject = %q, want %q", cfg.Email.Subject, "Test Subject")
+		}
+		if cfg.SMTP.Host != "smtp.test.com" {
+			t.Errorf("SMTP.Host = %q, want %q", cfg.SMTP.Host, "smtp.test.com")
+		}
+		if cfg.SMTP.Port != "465" {
+			t.Errorf("SMTP.Port = %q, want %q", cfg.SMTP.Port, "465")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Chdir(dir)
+
+		if _, err := loadConfig(); err == nil {
+			t.Fatal("expected error for missing config file, got nil")
+		}
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Chdir(dir)
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`{{{invalid`), 0644)
+
+		if _, err := loadConfig(); err == nil {
+			t.Fatal("expected error for invalid YAML, got nil")
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Chdir(dir)
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(""), 0644)
+
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("loadConfig() error on empty file: %v", err)
+		}
+		// All fields should be zero values
+		if cfg.Vodafone.User != "" {
+			t.Errorf("Vodafone.User = %q, want empty", cfg.Vodafone.User)
+		}
+	})
+
+	t.Run("partial config", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Chdir(dir)
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+vodafone:
+  user: "onlyuser"
+`), 0644)
+
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("loadConfig() error: %v", err)
+		}
+		if cfg.Vodafone.User != "onlyuser" {
+			t.Errorf("Vodafone.User = %q, want %q", cfg.Vodafone.User, "onlyuser")
+		}
+		if cfg.Vodafone.Pass != "" {
+			t.Errorf("Vodafone.Pass = %q, want empty", cfg.Vodafone.Pass)
+		}
+		if cfg.SMTP.Host != "" {
+			t.Errorf("SMTP.Host = %q, want empty", cfg.SMTP.Host)
+		}
+	})
+}
+
+func TestLoadConfigInvoiceURLs(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+vodafone:
+  user: "u"
+  invoice_urls:
+    kabel: "https://www.vodafone.de/meinvodafone/services/kabel/rechnungen"
+`), 0644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if got := cfg.Vodafone.InvoiceURLs["kabel"]; got != "https://www.vodafone.de/meinvodafone/services/kabel/rechnungen" {
+		t.Errorf("InvoiceURLs[kabel] = %q", got)
+	}
+	if got := cfg.Vodafone.InvoiceURLs["mobilfunk"]; got != "" {
+		t.Errorf("InvoiceURLs[mobilfunk] = %q, want empty", got)
+	}
+}
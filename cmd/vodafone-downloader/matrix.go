@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// matrixTxn numbers the events sent by this process; with the start time it makes
+// transaction IDs unique, so a retried request isn't posted twice.
+var matrixTxn atomic.Int64
+
+// checkMatrix validates the matrix section.
+func checkMatrix(c MatrixConfig) error {
+	if c.Homeserver == "" {
+		return nil
+	}
+	switch {
+	case !strings.HasPrefix(c.Homeserver, "https://") && !strings.HasPrefix(c.Homeserver, "http://"):
+		return fmt.Errorf("matrix.homeserver must be an http(s) URL")
+	case c.AccessToken == "":
+		return fmt.Errorf("matrix needs an access_token")
+	case !strings.HasPrefix(c.RoomID, "!"):
+		return fmt.Errorf("matrix.room_id must be a room ID like !abc:example.org")
+	}
+	return nil
+}
+
+// matrixRunSummary describes the outcome of a run.
+func matrixRunSummary(m runMetrics) string {
+	if runFailed(m) {
+		return "Vodafone: Abruf fehlgeschlagen\n" + runFailureText(m)
+	}
+	return fmt.Sprintf("Vodafone: %d Rechnung(en) abgerufen\n%s", len(m.Invoices), runSuccessText(m))
+}
+
+// notifyMatrix posts the summary of a run to the room.
+func notifyMatrix(c MatrixConfig, m runMetrics) error {
+	return sendMatrixEvent(c, map[string]interface{}{"msgtype": "m.text", "body": matrixRunSummary(m)})
+}
+
+// sendMatrixFiles uploads each invoice PDF to the homeserver's media repository and
+// posts it to the room as a file. A failed file doesn't stop the others.
+func sendMatrixFiles(c MatrixConfig, invoices []provider.Invoice) error {
+	var errs []error
+	for _, inv := range invoices {
+		if err := sendMatrixFile(c, inv); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", inv.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sendMatrixFile(c MatrixConfig, inv provider.Invoice) error {
+	var upload struct {
+		ContentURI string `json:"content_uri"`
+	}
+	path := "/_matrix/media/v3/upload?filename=" + url.QueryEscape(inv.Filename)
+	if err := matrixCall(c, http.MethodPost, path, "application/pdf", inv.PDFData, &upload); err != nil {
+		return fmt.Errorf("upload failed: %v", err)
+	}
+	return sendMatrixEvent(c, map[string]interface{}{
+		"msgtype":  "m.file",
+		"body":     invoiceCaption(inv),
+		"filename": inv.Filename,
+		"url":      upload.ContentURI,
+		"info":     map[string]interface{}{"mimetype": "application/pdf", "size": len(inv.PDFData)},
+	})
+}
+
+// sendMatrixEvent sends an m.room.message event to the room.
+func sendMatrixEvent(c MatrixConfig, content map[string]interface{}) error {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	txn := fmt.Sprintf("vodafone-%d-%d", time.Now().UnixNano(), matrixTxn.Add(1))
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(c.RoomID) + "/send/m.room.message/" + txn
+	return matrixCall(c, http.MethodPut, path, "application/json", body, nil)
+}
+
+// matrixCall sends a client-server API request and decodes the JSON response into
+// out if given. Errors carry Matrix's errcode and message, e.g. M_FORBIDDEN.
+func matrixCall(c MatrixConfig, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimRight(c.Homeserver, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var matrixErr struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if json.Unmarshal(raw, &matrixErr) == nil && matrixErr.ErrCode != "" {
+			return fmt.Errorf("%s: %s: %s", resp.Status, matrixErr.ErrCode, matrixErr.Error)
+		}
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
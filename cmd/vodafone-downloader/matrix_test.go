@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// fakeMatrix is a homeserver recording uploads and room messages.
+type fakeMatrix struct {
+	uploads  map[string]string // filename -> content
+	messages []map[string]interface{}
+	txns     map[string]bool
+}
+
+func newFakeMatrix(t *testing.T) (*fakeMatrix, string) {
+	f := &fakeMatrix{uploads: map[string]string{}, txns: map[string]bool{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer syt_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token passed."}`)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/_matrix/media/v3/upload":
+			data, _ := io.ReadAll(r.Body)
+			name := r.URL.Query().Get("filename")
+			f.uploads[name] = string(data)
+			fmt.Fprintf(w, `{"content_uri":"mxc://example.org/%s"}`, name)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/"):
+			txn := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			if f.txns[txn] {
+				t.Errorf("transaction ID %s reused", txn)
+			}
+			f.txns[txn] = true
+			var content map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&content)
+			f.messages = append(f.messages, content)
+			fmt.Fprintf(w, `{"event_id":"$%d"}`, len(f.messages))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errcode":"M_UNRECOGNIZED","error":"Unrecognized request"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func TestNotifyMatrix(t *testing.T) {
+	fake, srvURL := newFakeMatrix(t)
+	c := MatrixConfig{Homeserver: srvURL + "/", AccessToken: "syt_test", RoomID: "!room:example.org"}
+
+	runs := []runMetrics{
+		{Invoices: []provider.Invoice{{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98}}},
+		{Failures: []provider.Failure{{Type: "Mobilfunk", Reason: "Rechnung noch nicht verfügbar"}}},
+	}
+	for _, m := range runs {
+		if err := notifyMatrix(c, m); err != nil {
+			t.Fatalf("notifyMatrix() error: %v", err)
+		}
+	}
+	want := []string{
+		"Vodafone: 1 Rechnung(en) abgerufen\nKabel: Februar 2026 — 24,98 €",
+		"Vodafone: Abruf fehlgeschlagen\nMobilfunk: Rechnung noch nicht verfügbar",
+	}
+	for i, msg := range fake.messages {
+		if msg["msgtype"] != "m.text" || msg["body"] != want[i] {
+			t.Errorf("message %d = %v, want %q", i+1, msg, want[i])
+		}
+	}
+
+	c.AccessToken = "wrong"
+	if err := notifyMatrix(c, runs[0]); err == nil || !strings.Contains(err.Error(), "M_UNKNOWN_TOKEN") {
+		t.Errorf("notifyMatrix() error = %v, want M_UNKNOWN_TOKEN", err)
+	}
+}
+
+func TestSendMatrixFiles(t *testing.T) {
+	fake, srvURL := newFakeMatrix(t)
+	c := MatrixConfig{Homeserver: srvURL, AccessToken: "syt_test", RoomID: "!room:example.org", Attach: true}
+	invoices := []provider.Invoice{
+		{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98, PDFData: []byte("%PDF-kabel")},
+		{Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", Type: "Mobilfunk", MonthName: "Februar", Year: "2026", PDFData: []byte("%PDF-mobil")},
+	}
+	if err := sendMatrixFiles(c, invoices); err != nil {
+		t.Fatalf("sendMatrixFiles() error: %v", err)
+	}
+	if len(fake.uploads) != 2 || fake.uploads["02_2026_Rechnung_Vodafone_Kabel.pdf"] != "%PDF-kabel" {
+		t.Errorf("uploads = %v", fake.uploads)
+	}
+	if len(fake.messages) != 2 {
+		t.Fatalf("%d messages, want 2", len(fake.messages))
+	}
+	msg := fake.messages[0]
+	if msg["msgtype"] != "m.file" || msg["url"] != "mxc://example.org/02_2026_Rechnung_Vodafone_Kabel.pdf" ||
+		msg["body"] != "Kabel: Februar 2026 — 24,98 €" || msg["filename"] != "02_2026_Rechnung_Vodafone_Kabel.pdf" {
+		t.Errorf("file message = %v", msg)
+	}
+	if info, _ := msg["info"].(map[string]interface{}); info["mimetype"] != "application/pdf" || info["size"] != float64(10) {
+		t.Errorf("file info = %v", msg["info"])
+	}
+}
+
+func TestCheckMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  MatrixConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: MatrixConfig{}},
+		{name: "valid", config: MatrixConfig{Homeserver: "https://matrix.example.org", AccessToken: "syt_x", RoomID: "!abc:example.org"}},
+		{name: "no scheme", config: MatrixConfig{Homeserver: "matrix.example.org", AccessToken: "syt_x", RoomID: "!abc:example.org"}, wantErr: true},
+		{name: "no token", config: MatrixConfig{Homeserver: "https://matrix.example.org", RoomID: "!abc:example.org"}, wantErr: true},
+		{name: "room alias", config: MatrixConfig{Homeserver: "https://matrix.example.org", AccessToken: "syt_x", RoomID: "#family:example.org"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMatrix(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkMatrix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
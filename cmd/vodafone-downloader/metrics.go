@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const defaultMetricsJob = "vodafone_downloader"
+
+// runMetrics is the outcome of a run as pushed to the Pushgateway.
+type runMetrics struct {
+	Start    time.Time
+	End      time.Time
+	LoginErr error
+	Canary   bool     // a canary check: only login and invoice pages
+	Checked  []string // canary: contracts whose invoice page was opened
+	Invoices []provider.Invoice
+	Failures []provider.Failure
+	EmailErr error
+}
+
+// formatMetrics renders the run outcome in the Prometheus text exposition format.
+func formatMetrics(m runMetrics) string {
+	var b strings.Builder
+	gauge := func(name, help string, samples ...string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, s := range samples {
+			fmt.Fprintf(&b, "%s%s\n", name, s)
+		}
+	}
+	boolValue := func(ok bool) string {
+		if ok {
+			return " 1"
+		}
+		return " 0"
+	}
+
+	gauge("vodafone_downloader_last_run_timestamp_seconds", "End of the last run.", fmt.Sprintf(" %d", m.End.Unix()))
+	gauge("vodafone_downloader_run_duration_seconds", "Duration of the last run.", fmt.Sprintf(" %.1f", m.End.Sub(m.Start).Seconds()))
+	gauge("vodafone_downloader_login_ok", "Whether the last run could log in.", boolValue(m.LoginErr == nil))
+	if m.LoginErr != nil {
+		return b.String()
+	}
+
+	if m.Canary {
+		failed := map[string]bool{}
+		for _, f := range m.Failures {
+			failed[f.Type] = true
+		}
+		var pages []string
+		for _, typeName := range m.Checked {
+			pages = append(pages, fmt.Sprintf(`{contract="%s"}%s`, metricLabel(typeName), boolValue(!failed[typeName])))
+		}
+		gauge("vodafone_downloader_invoice_page_ok", "Whether the invoice page of the contract loaded.", pages...)
+		return b.String()
+	}
+
+	gauge("vodafone_downloader_invoices_downloaded", "Invoices downloaded in the last run.", fmt.Sprintf(" %d", len(m.Invoices)))
+	gauge("vodafone_downloader_failures", "Contracts without an invoice in the last run.", fmt.Sprintf(" %d", len(m.Failures)))
+	gauge("vodafone_downloader_email_sent", "Whether the invoice email was sent.", boolValue(m.EmailErr == nil))
+
+	var amounts []string
+	for _, inv := range m.Invoices {
+		if inv.Amount > 0 {
+			amounts = append(amounts, fmt.Sprintf(`{contract="%s"} %.2f`, metricLabel(inv.Type), inv.Amount))
+		}
+	}
+	sort.Strings(amounts)
+	if len(amounts) > 0 {
+		gauge("vodafone_downloader_invoice_amount_euros", "Amount of the invoice downloaded in the last run.", amounts...)
+	}
+	return b.String()
+}
+
+// metricLabel escapes a label value for the text exposition format.
+func metricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// pushMetrics replaces the metrics of this job and instance on the Pushgateway, so a
+// cron-started run can be monitored without a resident HTTP server.
+func pushMetrics(c MetricsConfig, m runMetrics) error {
+	instance := c.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	endpoint := strings.TrimRight(c.Pushgateway, "/") + "/metrics/job/" + url.PathEscape(orDefault(c.Job, defaultMetricsJob))
+	if instance != "" {
+		endpoint += "/instance/" + url.PathEscape(instance)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, strings.NewReader(formatMetrics(m)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushgateway not reachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("pushgateway rejected metrics: %s", resp.Status)
+	}
+	return nil
+}
+
+// writeMetricsFile writes the run outcome for the node_exporter textfile collector.
+func writeMetricsFile(path string, m runMetrics) error {
+	return fsutil.WriteFileAtomic(path, []byte(formatMetrics(m)), 0644)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	start := time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC)
+	m := runMetrics{
+		Start:    start,
+		End:      start.Add(95 * time.Second),
+		Invoices: []provider.Invoice{{Type: "Mobilfunk", Amount: 24.98}, {Type: "Kabel"}},
+		Failures: []provider.Failure{{Type: "Kabel", Reason: "timeout"}},
+		EmailErr: errors.New("connection refused"),
+	}
+
+	got := formatMetrics(m)
+	for _, want := range []string{
+		"# TYPE vodafone_downloader_last_run_timestamp_seconds gauge\n",
+		"vodafone_downloader_last_run_timestamp_seconds 1770876095\n",
+		"vodafone_downloader_run_duration_seconds 95.0\n",
+		"vodafone_downloader_login_ok 1\n",
+		"vodafone_downloader_invoices_downloaded 2\n",
+		"vodafone_downloader_failures 1\n",
+		"vodafone_downloader_email_sent 0\n",
+		`vodafone_downloader_invoice_amount_euros{contract="Mobilfunk"} 24.98` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `contract="Kabel"`) {
+		t.Errorf("invoice without amount must not be reported:\n%s", got)
+	}
+}
+
+func TestFormatMetricsLoginFailed(t *testing.T) {
+	got := formatMetrics(runMetrics{LoginErr: provider.ErrLoginFailed})
+	if !strings.Contains(got, "vodafone_downloader_login_ok 0\n") {
+		t.Errorf("metrics missing failed login:\n%s", got)
+	}
+	if strings.Contains(got, "invoices_downloaded") {
+		t.Errorf("download metrics reported after failed login:\n%s", got)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.EscapedPath()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := pushMetrics(MetricsConfig{Pushgateway: srv.URL + "/", Instance: "nas 1"}, runMetrics{})
+	if err != nil {
+		t.Fatalf("pushMetrics() error: %v", err)
+	}
+	if gotMethod != http.MethodPut {
+		t.Errorf("method = %s, want PUT", gotMethod)
+	}
+	if gotPath != "/metrics/job/vodafone_downloader/instance/nas%201" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if !strings.Contains(gotBody, "vodafone_downloader_login_ok 1") {
+		t.Errorf("body = %q", gotBody)
+	}
+}
+
+func TestPushMetricsRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := pushMetrics(MetricsConfig{Pushgateway: srv.URL}, runMetrics{}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("pushMetrics() error = %v, want 400", err)
+	}
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vodafone.prom")
+	os.WriteFile(path, []byte("stale"), 0644)
+
+	if err := writeMetricsFile(path, runMetrics{Invoices: []provider.Invoice{{Type: "Kabel"}}}); err != nil {
+		t.Fatalf("writeMetricsFile() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "vodafone_downloader_invoices_downloaded 1\n") {
+		t.Errorf("file = %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
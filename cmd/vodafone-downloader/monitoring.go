@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Signals appended to a ping URL, as understood by healthchecks.io and compatible
+// services. The bare URL signals success.
+const (
+	pingStart = "start"
+	pingFail  = "fail"
+)
+
+// pingClient keeps an unreachable monitoring service from holding up a run.
+var pingClient = &http.Client{Timeout: 10 * time.Second}
+
+// checkMonitoring validates the monitoring section.
+func checkMonitoring(c MonitoringConfig) error {
+	if c.PingURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(c.PingURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("monitoring.ping_url must be an http(s) URL")
+	}
+	return nil
+}
+
+// pingMonitor signals a dead man's switch such as healthchecks.io: "start" when a
+// run begins, "fail" when it failed, nothing for success. The body shows up in the
+// service's event log, e.g. the reasons of a failure.
+func pingMonitor(pingURL, signal, body string) error {
+	u, err := url.Parse(pingURL)
+	if err != nil {
+		return err
+	}
+	if signal != "" {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + signal
+	}
+	resp, err := pingClient.Post(u.String(), "text/plain; charset=utf-8", strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("health ping rejected: %s", resp.Status)
+	}
+	return nil
+}
+
+// pingRunOutcome reports the end of a run: success, or failure with what went wrong.
+func pingRunOutcome(pingURL string, m runMetrics) error {
+	if runFailed(m) {
+		return pingMonitor(pingURL, pingFail, runFailureText(m))
+	}
+	return pingMonitor(pingURL, "", runSuccessText(m))
+}
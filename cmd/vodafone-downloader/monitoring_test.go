@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestPingRunOutcome(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+	}))
+	defer srv.Close()
+
+	pingURL := srv.URL + "/ping/abc?rid=1"
+	if err := pingMonitor(pingURL, pingStart, ""); err != nil {
+		t.Fatalf("pingMonitor() error: %v", err)
+	}
+	runs := []runMetrics{
+		{Invoices: []provider.Invoice{{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98}}},
+		{EmailErr: errors.New("connection refused")},
+	}
+	for _, m := range runs {
+		if err := pingRunOutcome(pingURL, m); err != nil {
+			t.Fatalf("pingRunOutcome() error: %v", err)
+		}
+	}
+
+	want := []string{
+		"POST /ping/abc/start?rid=1 ",
+		"POST /ping/abc?rid=1 Kabel: Februar 2026 — 24,98 €",
+		"POST /ping/abc/fail?rid=1 E-Mail: connection refused",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pings = %q, want %q", got, want)
+	}
+}
+
+func TestPingMonitorRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if err := pingMonitor(srv.URL+"/ping/unknown", pingStart, ""); err == nil {
+		t.Error("pingMonitor() succeeded for a rejected ping")
+	}
+}
+
+func TestCheckMonitoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  MonitoringConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: MonitoringConfig{}},
+		{name: "healthchecks.io", config: MonitoringConfig{PingURL: "https://hc-ping.com/eb095278-f28d-448d-87fb-7b75c171a6aa"}},
+		{name: "no scheme", config: MonitoringConfig{PingURL: "hc-ping.com/abc"}, wantErr: true},
+		{name: "other scheme", config: MonitoringConfig{PingURL: "ftp://hc-ping.com/abc"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMonitoring(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkMonitoring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
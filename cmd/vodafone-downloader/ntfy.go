@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+)
+
+const (
+	defaultNtfyServer        = "https://ntfy.sh"
+	defaultNtfyEscalateAfter = 3
+)
+
+// ntfy message priorities, see https://docs.ntfy.sh/publish/#message-priority
+const (
+	ntfyPriorityLow    = 2
+	ntfyPriorityHigh   = 4
+	ntfyPriorityUrgent = 5
+)
+
+// ntfyMessage is the JSON body ntfy accepts at the server root.
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// ntfyState counts failed runs in a row, so repeated failures are escalated.
+type ntfyState struct {
+	Failures int `json:"failures"`
+}
+
+// checkNtfy validates the ntfy section.
+func checkNtfy(c NtfyConfig) error {
+	if c.Topic == "" {
+		return nil
+	}
+	if strings.Contains(c.Topic, "/") {
+		return fmt.Errorf("ntfy.topic must not contain a slash")
+	}
+	if c.EscalateAfter < 0 {
+		return fmt.Errorf("ntfy.escalate_after must not be negative")
+	}
+	return nil
+}
+
+// runFailed reports whether a run counts as failed: the login or the email failed
+// or a contract yielded no invoice.
+func runFailed(m runMetrics) bool {
+	return m.LoginErr != nil || m.EmailErr != nil || len(m.Failures) > 0
+}
+
+// ntfyRunMessage summarizes a run. Successful runs are sent with low priority,
+// failed ones with high priority, and from the escalate_after-th failed run in a
+// row on with urgent priority.
+func ntfyRunMessage(c NtfyConfig, m runMetrics, failedRuns int) ntfyMessage {
+	msg := ntfyMessage{Topic: c.Topic}
+	if !runFailed(m) {
+		msg.Title = fmt.Sprintf("Vodafone: %d Rechnung(en) abgerufen", len(m.Invoices))
+		msg.Priority = ntfyPriorityLow
+		msg.Tags = []string{"white_check_mark"}
+		msg.Message = runSuccessText(m)
+		return msg
+	}
+
+	msg.Title = "Vodafone: Abruf fehlgeschlagen"
+	msg.Priority = ntfyPriorityHigh
+	msg.Tags = []string{"warning"}
+	escalateAfter := c.EscalateAfter
+	if escalateAfter == 0 {
+		escalateAfter = defaultNtfyEscalateAfter
+	}
+	if failedRuns >= escalateAfter {
+		msg.Title = fmt.Sprintf("Vodafone: Abruf %d-mal in Folge fehlgeschlagen", failedRuns)
+		msg.Priority = ntfyPriorityUrgent
+		msg.Tags = []string{"rotating_light"}
+	}
+	msg.Message = runFailureText(m)
+	return msg
+}
+
+// runSuccessText lists the invoices of a successful run, one line each.
+func runSuccessText(m runMetrics) string {
+	var lines []string
+	for _, inv := range m.Invoices {
+		lines = append(lines, invoiceCaption(inv))
+	}
+	if len(lines) == 0 {
+		return "Keine neuen Rechnungen"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runFailureText lists what went wrong in a failed run, one line each.
+func runFailureText(m runMetrics) string {
+	var lines []string
+	if m.LoginErr != nil {
+		lines = append(lines, "Login: "+m.LoginErr.Error())
+	}
+	for _, f := range m.Failures {
+		lines = append(lines, f.Type+": "+f.Reason)
+	}
+	if m.EmailErr != nil {
+		lines = append(lines, "E-Mail: "+m.EmailErr.Error())
+	}
+	if len(m.Invoices) > 0 {
+		lines = append(lines, fmt.Sprintf("%d Rechnung(en) trotzdem abgerufen", len(m.Invoices)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notifyNtfy publishes the outcome of a run. With a state_file the failed runs in a
+// row are counted across runs; without one every failure has high priority.
+func notifyNtfy(c NtfyConfig, m runMetrics) error {
+	state := &ntfyState{}
+	if c.StateFile != "" {
+		data, err := os.ReadFile(c.StateFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, state); err != nil {
+				return fmt.Errorf("%s: %v", c.StateFile, err)
+			}
+		}
+	}
+	if runFailed(m) {
+		state.Failures++
+	} else {
+		state.Failures = 0
+	}
+	if c.StateFile != "" {
+		data, _ := json.Marshal(state)
+		if err := os.WriteFile(c.StateFile, data, 0600); err != nil {
+			return err
+		}
+	}
+
+	body, err := json.Marshal(ntfyRunMessage(c, m, state.Failures))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(orDefault(c.Server, defaultNtfyServer), "/"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Pass)
+	}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ntfy rejected the message: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
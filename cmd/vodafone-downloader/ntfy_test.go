@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestNtfyRunMessage(t *testing.T) {
+	c := NtfyConfig{Topic: "vodafone"}
+	kabel := provider.Invoice{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98}
+	tests := []struct {
+		name         string
+		m            runMetrics
+		failedRuns   int
+		wantTitle    string
+		wantMessage  string
+		wantPriority int
+	}{
+		{name: "success", m: runMetrics{Invoices: []provider.Invoice{kabel}},
+			wantTitle: "Vodafone: 1 Rechnung(en) abgerufen", wantMessage: "Kabel: Februar 2026 — 24,98 €", wantPriority: ntfyPriorityLow},
+		{name: "nothing new", m: runMetrics{},
+			wantTitle: "Vodafone: 0 Rechnung(en) abgerufen", wantMessage: "Keine neuen Rechnungen", wantPriority: ntfyPriorityLow},
+		{name: "partial failure", m: runMetrics{Invoices: []provider.Invoice{kabel}, Failures: []provider.Failure{{Type: "Mobilfunk", Reason: "Rechnung noch nicht verfügbar"}}}, failedRuns: 1,
+			wantTitle: "Vodafone: Abruf fehlgeschlagen", wantMessage: "Mobilfunk: Rechnung noch nicht verfügbar\n1 Rechnung(en) trotzdem abgerufen", wantPriority: ntfyPriorityHigh},
+		{name: "login failed", m: runMetrics{LoginErr: provider.ErrLoginFailed}, failedRuns: 2,
+			wantTitle: "Vodafone: Abruf fehlgeschlagen", wantMessage: "Login: " + provider.ErrLoginFailed.Error(), wantPriority: ntfyPriorityHigh},
+		{name: "escalated", m: runMetrics{EmailErr: errors.New("connection refused")}, failedRuns: 3,
+			wantTitle: "Vodafone: Abruf 3-mal in Folge fehlgeschlagen", wantMessage: "E-Mail: connection refused", wantPriority: ntfyPriorityUrgent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ntfyRunMessage(c, tt.m, tt.failedRuns)
+			if got.Topic != "vodafone" || got.Title != tt.wantTitle || got.Message != tt.wantMessage || got.Priority != tt.wantPriority {
+				t.Errorf("ntfyRunMessage() = %+v, want %q / %q / priority %d", got, tt.wantTitle, tt.wantMessage, tt.wantPriority)
+			}
+		})
+	}
+}
+
+func TestNotifyNtfy(t *testing.T) {
+	var got []ntfyMessage
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		var msg ntfyMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg)
+		w.Write([]byte(`{"id":"abc","event":"message"}`))
+	}))
+	defer srv.Close()
+
+	c := NtfyConfig{Server: srv.URL + "/", Topic: "vodafone", Token: "tk_test", StateFile: filepath.Join(t.TempDir(), "ntfy.json"), EscalateAfter: 2}
+	failed := runMetrics{LoginErr: provider.ErrLoginFailed}
+	runs := []struct {
+		m            runMetrics
+		wantPriority int
+	}{
+		{failed, ntfyPriorityHigh},
+		{failed, ntfyPriorityUrgent},
+		{failed, ntfyPriorityUrgent},
+		{runMetrics{}, ntfyPriorityLow},
+		{failed, ntfyPriorityHigh}, // the success reset the count
+	}
+	for i, run := range runs {
+		if err := notifyNtfy(c, run.m); err != nil {
+			t.Fatalf("run %d: notifyNtfy() error: %v", i+1, err)
+		}
+		if got[i].Priority != run.wantPriority {
+			t.Errorf("run %d: priority %d, want %d", i+1, got[i].Priority, run.wantPriority)
+		}
+	}
+	if gotAuth != "Bearer tk_test" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+
+	// Without a state file there is no count across runs
+	c.StateFile = ""
+	notifyNtfy(c, failed)
+	notifyNtfy(c, failed)
+	if p := got[len(got)-1].Priority; p != ntfyPriorityHigh {
+		t.Errorf("priority without state file = %d, want %d", p, ntfyPriorityHigh)
+	}
+}
+
+func TestNotifyNtfyRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "phil" || pass != "right" {
+			http.Error(w, `{"code":40101,"error":"unauthorized"}`, http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	err := notifyNtfy(NtfyConfig{Server: srv.URL, Topic: "vodafone", User: "phil", Pass: "wrong"}, runMetrics{})
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("notifyNtfy() error = %v, want unauthorized", err)
+	}
+	if err := notifyNtfy(NtfyConfig{Server: srv.URL, Topic: "vodafone", User: "phil", Pass: "right"}, runMetrics{}); err != nil {
+		t.Errorf("notifyNtfy() error: %v", err)
+	}
+}
+
+func TestCheckNtfy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  NtfyConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: NtfyConfig{}},
+		{name: "topic", config: NtfyConfig{Topic: "vodafone-rechnungen"}},
+		{name: "slash in topic", config: NtfyConfig{Topic: "ntfy.sh/vodafone"}, wantErr: true},
+		{name: "negative escalation", config: NtfyConfig{Topic: "vodafone", EscalateAfter: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkNtfy(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkNtfy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// paperlessStorage hands the PDFs to Paperless-ngx, which OCRs and archives them. The
+// correspondent, document type and tags are given by name ({type}, {month} and {year}
+// are expanded) and created in Paperless when missing.
+type paperlessStorage struct {
+	cfg PaperlessConfig
+	ids map[string]int // "tags/Vodafone" -> ID, looked up once per run
+}
+
+// newPaperlessStorage validates the paperless section.
+func newPaperlessStorage(c PaperlessConfig) (*paperlessStorage, error) {
+	if c.Token == "" && c.User == "" {
+		return nil, fmt.Errorf("paperless needs a token or a user")
+	}
+	return &paperlessStorage{cfg: c, ids: map[string]int{}}, nil
+}
+
+func (*paperlessStorage) Name() string { return "Paperless" }
+
+// Put uploads the PDF unless Paperless already holds a document with the same
+// checksum, in which case that document's file name is returned. Paperless would only
+// reject the duplicate in its background consumption, as a failed task.
+func (s *paperlessStorage) Put(inv provider.Invoice) (string, error) {
+	var existing struct {
+		Results []struct {
+			OriginalFileName string `json:"original_file_name"`
+		} `json:"results"`
+	}
+	sum := md5.Sum(inv.PDFData)
+	if err := s.call(http.MethodGet, "/api/documents/?checksum__iexact="+hex.EncodeToString(sum[:]), "", nil, &existing); err != nil {
+		return "", fmt.Errorf("paperless: looking up the document failed: %v", err)
+	}
+	if len(existing.Results) > 0 {
+		return orDefault(existing.Results[0].OriginalFileName, inv.Filename), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if s.cfg.Title != "" {
+		mw.WriteField("title", provider.ExpandPlaceholders(s.cfg.Title, inv))
+	}
+	if s.cfg.Correspondent != "" {
+		id, err := s.lookup("correspondents", provider.ExpandPlaceholders(s.cfg.Correspondent, inv))
+		if err != nil {
+			return "", err
+		}
+		mw.WriteField("correspondent", strconv.Itoa(id))
+	}
+	if s.cfg.DocumentType != "" {
+		id, err := s.lookup("document_types", provider.ExpandPlaceholders(s.cfg.DocumentType, inv))
+		if err != nil {
+			return "", err
+		}
+		mw.WriteField("document_type", strconv.Itoa(id))
+	}
+	for _, tag := range slices.Concat(s.cfg.Tags, inv.Tags) {
+		id, err := s.lookup("tags", provider.ExpandPlaceholders(tag, inv))
+		if err != nil {
+			return "", err
+		}
+		mw.WriteField("tags", strconv.Itoa(id))
+	}
+	fw, err := mw.CreateFormFile("document", inv.Filename)
+	if err != nil {
+		return "", err
+	}
+	fw.Write(inv.PDFData)
+	mw.Close()
+
+	if err := s.call(http.MethodPost, "/api/documents/post_document/", mw.FormDataContentType(), body.Bytes(), nil); err != nil {
+		return "", fmt.Errorf("paperless upload failed: %v", err)
+	}
+	return inv.Filename, nil
+}
+
+// lookup returns the ID of the correspondent, document type or tag (kind is the API
+// collection) called name, creating it if there is none. Created objects don't
+// auto-match other documents.
+func (s *paperlessStorage) lookup(kind, name string) (int, error) {
+	key := kind + "/" + name
+	if id, ok := s.ids[key]; ok {
+		return id, nil
+	}
+	var list struct {
+		Results []struct {
+			ID int `json:"id"`
+		} `json:"results"`
+	}
+	if err := s.call(http.MethodGet, "/api/"+kind+"/?name__iexact="+url.QueryEscape(name), "", nil, &list); err != nil {
+		return 0, fmt.Errorf("paperless: looking up %s %q failed: %v", kind, name, err)
+	}
+	if len(list.Results) > 0 {
+		s.ids[key] = list.Results[0].ID
+		return list.Results[0].ID, nil
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"name": name, "matching_algorithm": 0})
+	var created struct {
+		ID int `json:"id"`
+	}
+	if err := s.call(http.MethodPost, "/api/"+kind+"/", "application/json", body, &created); err != nil {
+		return 0, fmt.Errorf("paperless: creating %s %q failed: %v", kind, name, err)
+	}
+	s.ids[key] = created.ID
+	return created.ID, nil
+}
+
+// call sends a Paperless API request and decodes the JSON response into out if given.
+func (s *paperlessStorage) call(method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimRight(s.cfg.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	} else {
+		req.SetBasicAuth(s.cfg.User, s.cfg.Pass)
+	}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// fakePaperless is a Paperless-ngx API keeping correspondents, document types and
+// tags in memory and recording uploaded documents.
+type fakePaperless struct {
+	mu        sync.Mutex
+	objects   map[string][]string // kind -> names, ID is the index + 1
+	lookups   int
+	documents []map[string][]string
+	pdfs      []string
+	checksums map[string]string // MD5 -> original file name
+}
+
+func newFakePaperless(t *testing.T, auth string) (*fakePaperless, string) {
+	p := &fakePaperless{objects: map[string][]string{"correspondents": {"Vodafone"}}, checksums: map[string]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if r.Header.Get("Authorization") != auth {
+			http.Error(w, `{"detail":"Invalid token."}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/api/documents/post_document/" {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm failed: %v", err)
+			}
+			file, header, err := r.FormFile("document")
+			if err != nil {
+				http.Error(w, "no document", http.StatusBadRequest)
+				return
+			}
+			pdf, _ := io.ReadAll(file)
+			p.documents = append(p.documents, r.MultipartForm.Value)
+			p.pdfs = append(p.pdfs, string(pdf))
+			sum := md5.Sum(pdf)
+			p.checksums[hex.EncodeToString(sum[:])] = header.Filename
+			fmt.Fprint(w, `"b2c1a6e4-task"`)
+			return
+		}
+		if r.URL.Path == "/api/documents/" {
+			results := []map[string]interface{}{}
+			if name, ok := p.checksums[strings.ToLower(r.URL.Query().Get("checksum__iexact"))]; ok {
+				results = append(results, map[string]interface{}{"id": 1, "original_file_name": name})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+			return
+		}
+		kind := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+		switch r.Method {
+		case http.MethodGet:
+			p.lookups++
+			results := []map[string]interface{}{}
+			for i, name := range p.objects[kind] {
+				if strings.EqualFold(name, r.URL.Query().Get("name__iexact")) {
+					results = append(results, map[string]interface{}{"id": i + 1, "name": name})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+		case http.MethodPost:
+			var obj struct {
+				Name              string `json:"name"`
+				MatchingAlgorithm *int   `json:"matching_algorithm"`
+			}
+			json.NewDecoder(r.Body).Decode(&obj)
+			if obj.MatchingAlgorithm == nil || *obj.MatchingAlgorithm != 0 {
+				t.Errorf("%s %q created with auto matching", kind, obj.Name)
+			}
+			p.objects[kind] = append(p.objects[kind], obj.Name)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id":%d,"name":%q}`, len(p.objects[kind]), obj.Name)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return p, srv.URL
+}
+
+func TestPaperlessStorage(t *testing.T) {
+	fake, srvURL := newFakePaperless(t, "Token secret")
+	targets, err := newStorageTargets(&Config{Paperless: PaperlessConfig{
+		URL:           srvURL + "/",
+		Token:         "secret",
+		Title:         "Vodafone {type} {month}/{year}",
+		Correspondent: "vodafone",
+		DocumentType:  "Rechnung",
+		Tags:          []string{"Vodafone", "{year}"},
+	}})
+	if err != nil {
+		t.Fatalf("newStorageTargets() error: %v", err)
+	}
+	if len(targets) != 1 || targets[0].Name() != "Paperless" {
+		t.Fatalf("targets = %v, want Paperless", targets)
+	}
+
+	invoices := []provider.Invoice{
+		{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", PDFData: []byte("%PDF-kabel"), Tags: []string{"Prüfen"}},
+		{Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", Type: "Mobilfunk", Month: "02", Year: "2026", PDFData: []byte("%PDF-mobil")},
+	}
+	for _, inv := range invoices {
+		got, err := targets[0].Put(inv)
+		if err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		if got != inv.Filename {
+			t.Errorf("Put() = %q, want %q", got, inv.Filename)
+		}
+	}
+
+	if len(fake.documents) != 2 {
+		t.Fatalf("%d documents uploaded, want 2", len(fake.documents))
+	}
+	want := map[string][]string{
+		"title":         {"Vodafone Kabel 02/2026"},
+		"correspondent": {"1"},
+		"document_type": {"1"},
+		"tags":          {"1", "2", "3"},
+	}
+	if !reflect.DeepEqual(fake.documents[0], want) {
+		t.Errorf("first upload = %v, want %v", fake.documents[0], want)
+	}
+	if tags := fake.documents[1]["tags"]; !reflect.DeepEqual(tags, []string{"1", "2"}) {
+		t.Errorf("second upload tags = %v, want [1 2]", tags)
+	}
+	if fake.pdfs[1] != "%PDF-mobil" {
+		t.Errorf("second upload = %q", fake.pdfs[1])
+	}
+	if want := []string{"Vodafone", "2026", "Prüfen"}; !reflect.DeepEqual(fake.objects["tags"], want) {
+		t.Errorf("tags = %v, want %v", fake.objects["tags"], want)
+	}
+	// correspondent, document type and three tags, each looked up once
+	if fake.lookups != 5 {
+		t.Errorf("%d lookups, want 5", fake.lookups)
+	}
+
+	// A rerun finds the document by its checksum and doesn't upload it again
+	renamed := invoices[0]
+	renamed.Filename = "other.pdf"
+	got, err := targets[0].Put(renamed)
+	if err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got != invoices[0].Filename {
+		t.Errorf("Put() = %q, want the existing %q", got, invoices[0].Filename)
+	}
+	if len(fake.documents) != 2 {
+		t.Errorf("%d documents uploaded after rerun, want 2", len(fake.documents))
+	}
+}
+
+func TestPaperlessStorageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PaperlessConfig
+		wantErr string
+	}{
+		{name: "wrong token", config: PaperlessConfig{Token: "wrong"}, wantErr: "401"},
+		{name: "wrong token with tags", config: PaperlessConfig{Token: "wrong", Tags: []string{"Vodafone"}}, wantErr: "looking up the document"},
+		{name: "basic auth", config: PaperlessConfig{User: "paperless", Pass: "secret"}},
+	}
+	_, srvURL := newFakePaperless(t, "Basic cGFwZXJsZXNzOnNlY3JldA==")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.URL = srvURL
+			s, err := newPaperlessStorage(tt.config)
+			if err != nil {
+				t.Fatalf("newPaperlessStorage() error: %v", err)
+			}
+			_, err = s.Put(provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF")})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Put() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Put() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := newPaperlessStorage(PaperlessConfig{URL: srvURL}); err == nil {
+		t.Error("newPaperlessStorage() without credentials succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// preflightDirs returns the directories a run writes to: local storage targets, the
+// history and ledger files, the local mailbox and the browser profile.
+func preflightDirs(c *Config) []string {
+	var dirs []string
+	for _, s := range c.Storage {
+		if strings.EqualFold(s.Type, "local") && s.Path != "" {
+			dirs = append(dirs, localStorageRoot(s.Path))
+		}
+	}
+	for _, file := range []string{c.History.File, c.Ledger.File, c.Email.SentFile, c.Database.File, c.CSV.File} {
+		if file != "" {
+			dirs = append(dirs, filepath.Dir(file))
+		}
+	}
+	switch strings.ToLower(c.Email.Delivery) {
+	case "maildir":
+		dirs = append(dirs, filepath.Clean(c.Email.Mailbox))
+	case "mbox":
+		dirs = append(dirs, filepath.Dir(c.Email.Mailbox))
+	}
+	if c.Vodafone.ProfileDir != "" {
+		dirs = append(dirs, filepath.Clean(c.Vodafone.ProfileDir))
+	}
+	return dirs
+}
+
+// existingAncestor returns dir or its nearest parent that exists, which is where
+// missing directories will be created.
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// checkDir verifies that files can be created in dir (or the parent it will be
+// created in) and, if minFree is non-zero, that enough space is left there.
+func checkDir(dir string, minFree uint64) error {
+	base := existingAncestor(dir)
+	f, err := os.CreateTemp(base, ".vodafone-preflight-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	if minFree == 0 {
+		return nil
+	}
+	free, ok := freeSpace(base)
+	if ok && free < minFree {
+		return fmt.Errorf("%s has only %d MB free, %d MB required", dir, free>>20, minFree>>20)
+	}
+	return nil
+}
+
+// preflight checks every directory the run writes to before the browser is started,
+// so a full or read-only disk fails the run early instead of after the download.
+func preflight(c *Config) error {
+	var minFree uint64
+	if c.Preflight.MinFree != "" {
+		var err error
+		if minFree, err = vodafone.ParseSize(c.Preflight.MinFree); err != nil {
+			return fmt.Errorf("preflight.min_free: %v", err)
+		}
+	}
+	for _, dir := range preflightDirs(c) {
+		if err := checkDir(dir, minFree); err != nil {
+			return fmt.Errorf("preflight failed: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestPreflightDirs(t *testing.T) {
+	c := &Config{
+		Storage: []StorageConfig{
+			{Type: "local", Path: "/srv/rechnungen/{year}/{type}"},
+			{Type: "local", Path: "/mnt/backup/"},
+			{Type: "webdav", URL: "https://cloud.example.com", Path: "/Rechnungen"},
+		},
+		History:  HistoryConfig{File: "/var/lib/vodafone/history.json"},
+		Email:    mailer.Config{Delivery: "mbox", Mailbox: "/var/mail/rechnungen"},
+		Vodafone: vodafone.Config{ProfileDir: "/var/lib/vodafone/chrome"},
+	}
+
+	want := []string{"/srv/rechnungen", "/mnt/backup", "/var/lib/vodafone", "/var/mail", "/var/lib/vodafone/chrome"}
+	if got := preflightDirs(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("preflightDirs() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkDir(filepath.Join(dir, "2026", "Kabel"), 0); err != nil {
+		t.Errorf("checkDir() for a missing subdirectory error: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("checkDir() left files behind: %v", entries)
+	}
+
+	if _, ok := freeSpace(dir); ok {
+		if err := checkDir(dir, 1<<62); err == nil || !strings.Contains(err.Error(), "MB free") {
+			t.Errorf("checkDir() error = %v, want free space error", err)
+		}
+	}
+
+	readOnly := filepath.Join(dir, "ro")
+	os.Mkdir(readOnly, 0500)
+	if os.Getuid() != 0 {
+		if err := checkDir(readOnly, 0); err == nil || !strings.Contains(err.Error(), "not writable") {
+			t.Errorf("checkDir() error = %v, want not writable", err)
+		}
+	}
+}
+
+func TestPreflightInvalidMinFree(t *testing.T) {
+	if err := preflight(&Config{Preflight: PreflightConfig{MinFree: "lots"}}); err == nil {
+		t.Error("expected error for invalid min_free, got nil")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// invoiceFilenamePattern matches the names given to downloaded invoices, e.g.
+// "02_2026_Rechnung_Vodafone_Kabel.pdf" or "02_2026_Rechnungsseite_Vodafone_Kabel.pdf".
+var invoiceFilenamePattern = regexp.MustCompile(`^(\d{2})_(\d{4})_Rechnung(seite)?_Vodafone_(.+)\.pdf$`)
+
+// invoiceFromFile reads an invoice PDF from disk. Period and contract are taken from
+// the file name if it follows the download naming, amount and number from the PDF.
+func invoiceFromFile(path string) (provider.Invoice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return provider.Invoice{}, err
+	}
+	inv := provider.Invoice{Filename: filepath.Base(path), PDFData: data}
+	if m := invoiceFilenamePattern.FindStringSubmatch(inv.Filename); m != nil {
+		month, _ := time.Parse("01", m[1])
+		inv.Month, inv.Year, inv.MonthName = m[1], m[2], vodafone.MonthNames[month.Month()]
+		inv.Fallback = m[3] != ""
+		inv.Type = strings.ReplaceAll(m[4], "_", " ")
+	}
+	vodafone.ApplyPDFDetails(&inv)
+	return inv, nil
+}
+
+// archivedInvoices loads the invoices of a period ("YYYY-MM") in the history from the
+// local storage targets. An empty period selects the most recent one.
+func archivedInvoices(h *History, storage []StorageConfig, period string) ([]provider.Invoice, error) {
+	if len(h.Entries) == 0 {
+		return nil, fmt.Errorf("history is empty")
+	}
+	if period == "" {
+		period = h.Entries[len(h.Entries)-1].period()
+	}
+
+	var invoices []provider.Invoice
+	for _, e := range h.Entries {
+		if e.period() != period {
+			continue
+		}
+		meta := provider.Invoice{Type: e.Type, Month: e.Month, Year: e.Year}
+		found := false
+		for _, s := range storage {
+			if !strings.EqualFold(s.Type, "local") {
+				continue
+			}
+			inv, err := invoiceFromFile(filepath.Join(provider.ExpandPlaceholders(s.Path, meta), e.Filename))
+			if err != nil {
+				continue
+			}
+			inv.Type = e.Type
+			if inv.Number == "" {
+				inv.Number = e.Number
+			}
+			invoices = append(invoices, inv)
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("%s not found in a local storage target", e.Filename)
+		}
+	}
+	if len(invoices) == 0 {
+		return nil, fmt.Errorf("no invoices of %s in the history", period)
+	}
+	return invoices, nil
+}
+
+// runPreview implements the "preview" subcommand. It builds the invoice email for the
+// given PDF files, or for the most recently archived invoices, and writes it to an
+// .eml file instead of sending it, so it can be opened in a mail client.
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	output := fs.String("output", "vorschau.eml", "output file")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+
+	var history *History
+	if cfg.History.File != "" {
+		if history, err = loadHistory(cfg.History.File); err != nil {
+			return err
+		}
+	}
+
+	var invoices []provider.Invoice
+	if fs.NArg() > 0 {
+		for _, path := range fs.Args() {
+			inv, err := invoiceFromFile(path)
+			if err != nil {
+				return err
+			}
+			invoices = append(invoices, inv)
+		}
+	} else {
+		if history == nil {
+			return fmt.Errorf("no invoice files given and history.file is not configured")
+		}
+		if invoices, err = archivedInvoices(history, cfg.Storage, ""); err != nil {
+			return err
+		}
+	}
+	applyRules(rules, invoices)
+
+	var chartPNG []byte
+	if cfg.Email.Chart && history != nil {
+		if chartPNG, err = renderSpendChart(history, time.Now()); err != nil {
+			slog.Warn("Chart failed", "err", err)
+		}
+	}
+
+	msg := mailer.New(cfg.Email, cfg.SMTP).Compose(invoices, nil, nil, chartPNG)
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	slog.Info("Preview written", "count", len(invoices), "file", *output)
+	return nil
+}
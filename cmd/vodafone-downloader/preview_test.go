@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestInvoiceFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "02_2026_Rechnung_Vodafone_Kabel.pdf")
+	os.WriteFile(path, minimalPDF("Rechnungsnummer: 987654321", "Rechnungsbetrag 44,98 EUR"), 0600)
+
+	inv, err := invoiceFromFile(path)
+	if err != nil {
+		t.Fatalf("invoiceFromFile() error: %v", err)
+	}
+	if inv.Type != "Kabel" || inv.Month != "02" || inv.Year != "2026" || inv.MonthName != "Februar" {
+		t.Errorf("period = %s %s/%s (%s), want Kabel 02/2026 (Februar)", inv.Type, inv.Month, inv.Year, inv.MonthName)
+	}
+	if inv.Amount != 44.98 || inv.Number != "987654321" {
+		t.Errorf("amount = %v, number = %q, want details from the PDF", inv.Amount, inv.Number)
+	}
+
+	other := filepath.Join(dir, "scan.pdf")
+	os.WriteFile(other, []byte("%PDF"), 0600)
+	if inv, err := invoiceFromFile(other); err != nil || inv.Filename != "scan.pdf" || inv.Type != "" {
+		t.Errorf("invoiceFromFile(scan.pdf) = %+v, %v", inv, err)
+	}
+
+	if _, err := invoiceFromFile(filepath.Join(dir, "missing.pdf")); err == nil {
+		t.Error("expected error for missing file, got nil")
+	}
+}
+
+func TestArchivedInvoices(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "2026"), 0700)
+	os.WriteFile(filepath.Join(dir, "2026", "02_2026_Rechnung_Vodafone_Kabel.pdf"), []byte("%PDF-kabel"), 0600)
+
+	h := &History{}
+	h.Add(provider.Invoice{Type: "Kabel", Month: "01", Year: "2026", Filename: "01_2026_Rechnung_Vodafone_Kabel.pdf"})
+	h.Add(provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Number: "42", Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf"})
+	storage := []StorageConfig{
+		{Type: "webdav", URL: "https://cloud.example.com"},
+		{Type: "local", Path: filepath.Join(dir, "{year}")},
+	}
+
+	invoices, err := archivedInvoices(h, storage, "")
+	if err != nil {
+		t.Fatalf("archivedInvoices() error: %v", err)
+	}
+	if len(invoices) != 1 || invoices[0].Month != "02" || string(invoices[0].PDFData) != "%PDF-kabel" || invoices[0].Number != "42" {
+		t.Errorf("archivedInvoices() = %+v, want the February invoice", invoices)
+	}
+
+	h.Add(provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf"})
+	if _, err := archivedInvoices(h, storage, ""); err == nil || !strings.Contains(err.Error(), "Mobilfunk") {
+		t.Errorf("archivedInvoices() error = %v, want missing Mobilfunk file", err)
+	}
+	if _, err := archivedInvoices(h, storage, "2026-03"); err == nil || !strings.Contains(err.Error(), "no invoices") {
+		t.Errorf("archivedInvoices(2026-03) error = %v, want no invoices", err)
+	}
+}
+
+func TestRunPreview(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile("config.yaml", []byte("email:\n  from: a@b.com\n  to: c@d.com\n"), 0644)
+	os.WriteFile("02_2026_Rechnung_Vodafone_Kabel.pdf", []byte("%PDF-kabel"), 0600)
+
+	if err := runPreview([]string{"--output", "out.eml", "02_2026_Rechnung_Vodafone_Kabel.pdf"}); err != nil {
+		t.Fatalf("runPreview() error: %v", err)
+	}
+	eml, err := os.ReadFile("out.eml")
+	if err != nil {
+		t.Fatalf("preview not written: %v", err)
+	}
+	for _, want := range []string{"To: c@d.com", "Kabel: Februar 2026", `filename="02_2026_Rechnung_Vodafone_Kabel.pdf"`} {
+		if !strings.Contains(string(eml), want) {
+			t.Errorf("preview missing %q", want)
+		}
+	}
+
+	if err := runPreview(nil); err == nil || !strings.Contains(err.Error(), "history.file") {
+		t.Errorf("runPreview() without files error = %v, want history hint", err)
+	}
+}
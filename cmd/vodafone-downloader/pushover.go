@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// pushoverAPIBase is the Pushover API endpoint (overridden in tests).
+var pushoverAPIBase = "https://api.pushover.net"
+
+// pushoverMaxAttachment is the largest attachment Pushover accepts.
+const pushoverMaxAttachment = 5 << 20
+
+// pushoverPriorityHigh bypasses the user's quiet hours.
+const pushoverPriorityHigh = 1
+
+// pushoverMessage is a Pushover notification with an optional attachment.
+type pushoverMessage struct {
+	Title      string
+	Message    string
+	Priority   int
+	Filename   string
+	Attachment []byte
+}
+
+// checkPushover validates the pushover section.
+func checkPushover(c PushoverConfig) error {
+	if c.Token == "" {
+		return nil
+	}
+	if c.User == "" {
+		return fmt.Errorf("pushover needs a user key")
+	}
+	return nil
+}
+
+// notifyPushoverInvoices sends one notification per invoice with the PDF attached
+// if it is small enough. A failed notification doesn't stop the others.
+func notifyPushoverInvoices(c PushoverConfig, invoices []provider.Invoice) error {
+	var errs []error
+	for _, inv := range invoices {
+		msg := pushoverMessage{Title: "Vodafone-Rechnung " + inv.Type, Message: invoiceCaption(inv)}
+		if len(inv.PDFData) <= pushoverMaxAttachment {
+			msg.Filename, msg.Attachment = inv.Filename, inv.PDFData
+		}
+		if err := sendPushover(c, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", inv.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyPushoverFailure reports a failed run with high priority and the latest
+// screenshot of a failed step attached.
+func notifyPushoverFailure(c PushoverConfig, m runMetrics) error {
+	msg := pushoverMessage{Title: "Vodafone: Abruf fehlgeschlagen", Message: runFailureText(m), Priority: pushoverPriorityHigh}
+	if path := provider.LatestScreenshot(m.Failures); path != "" {
+		if png, err := os.ReadFile(path); err == nil && len(png) <= pushoverMaxAttachment {
+			msg.Filename, msg.Attachment = filepath.Base(path), png
+		}
+	}
+	return sendPushover(c, msg)
+}
+
+// sendPushover posts msg. Pushover's apps only display image attachments, so if the
+// PDF is refused, the notification is sent again without it.
+func sendPushover(c PushoverConfig, msg pushoverMessage) error {
+	err := postPushover(c, msg)
+	var rejected pushoverError
+	if msg.Attachment != nil && errors.As(err, &rejected) && rejected.status == http.StatusBadRequest {
+		msg.Filename, msg.Attachment = "", nil
+		err = postPushover(c, msg)
+	}
+	return err
+}
+
+// pushoverError is a request Pushover answered with an error status.
+type pushoverError struct {
+	status int
+	errors []string
+}
+
+func (e pushoverError) Error() string {
+	return fmt.Sprintf("pushover: %d %s", e.status, strings.Join(e.errors, "; "))
+}
+
+func postPushover(c PushoverConfig, msg pushoverMessage) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("token", c.Token)
+	mw.WriteField("user", c.User)
+	if c.Device != "" {
+		mw.WriteField("device", c.Device)
+	}
+	mw.WriteField("title", msg.Title)
+	mw.WriteField("message", msg.Message)
+	if msg.Priority != 0 {
+		mw.WriteField("priority", fmt.Sprint(msg.Priority))
+	}
+	if msg.Attachment != nil {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="attachment"; filename=%q`, msg.Filename))
+		header.Set("Content-Type", http.DetectContentType(msg.Attachment))
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		part.Write(msg.Attachment)
+	}
+	mw.Close()
+
+	resp, err := httpclient.Client.Post(pushoverAPIBase+"/1/messages.json", mw.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Status int      `json:"status"`
+		Errors []string `json:"errors"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK || result.Status != 1 {
+		return pushoverError{status: resp.StatusCode, errors: result.Errors}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// pushoverRequest is a message as received by the fake Pushover API.
+type pushoverRequest struct {
+	fields     map[string]string
+	attachment string
+	mimeType   string
+}
+
+// newFakePushover records messages. With rejectPDF it refuses PDF attachments the
+// way Pushover refuses attachments that aren't images.
+func newFakePushover(t *testing.T, rejectPDF bool) *[]pushoverRequest {
+	var got []pushoverRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm failed: %v", err)
+		}
+		req := pushoverRequest{fields: map[string]string{}}
+		for k, v := range r.MultipartForm.Value {
+			req.fields[k] = v[0]
+		}
+		if file, header, err := r.FormFile("attachment"); err == nil {
+			data, _ := io.ReadAll(file)
+			req.attachment, req.mimeType = string(data), header.Header.Get("Content-Type")
+		}
+		got = append(got, req)
+		switch {
+		case req.fields["token"] != "app-token":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"token":"invalid","errors":["application token is invalid"],"status":0}`)
+		case rejectPDF && req.attachment != "":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"attachment":"invalid","errors":["attachment must be an image"],"status":0}`)
+		default:
+			fmt.Fprint(w, `{"status":1,"request":"abc"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	base := pushoverAPIBase
+	pushoverAPIBase = srv.URL
+	t.Cleanup(func() { pushoverAPIBase = base })
+	return &got
+}
+
+func TestNotifyPushoverInvoices(t *testing.T) {
+	c := PushoverConfig{Token: "app-token", User: "user-key"}
+	invoices := []provider.Invoice{
+		{Filename: "kabel.pdf", Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98, PDFData: []byte("%PDF-kabel")},
+		{Filename: "big.pdf", Type: "DSL", MonthName: "Februar", Year: "2026", PDFData: bytes.Repeat([]byte("x"), pushoverMaxAttachment+1)},
+	}
+
+	tests := []struct {
+		name      string
+		rejectPDF bool
+		wantSent  int
+	}{
+		{name: "attached", wantSent: 2},
+		{name: "attachment refused", rejectPDF: true, wantSent: 3}, // kabel.pdf sent again without the PDF
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newFakePushover(t, tt.rejectPDF)
+			if err := notifyPushoverInvoices(c, invoices); err != nil {
+				t.Fatalf("notifyPushoverInvoices() error: %v", err)
+			}
+			if len(*got) != tt.wantSent {
+				t.Fatalf("%d requests, want %d", len(*got), tt.wantSent)
+			}
+			first := (*got)[0]
+			if first.fields["user"] != "user-key" || first.fields["title"] != "Vodafone-Rechnung Kabel" || first.fields["message"] != "Kabel: Februar 2026 — 24,98 €" {
+				t.Errorf("first message = %v", first.fields)
+			}
+			if first.attachment != "%PDF-kabel" || first.mimeType != "application/pdf" {
+				t.Errorf("first attachment = %q (%s)", first.attachment, first.mimeType)
+			}
+			for _, req := range (*got)[1:] {
+				if req.attachment != "" {
+					t.Errorf("%s sent with attachment", req.fields["title"])
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyPushoverFailure(t *testing.T) {
+	got := newFakePushover(t, false)
+	m := runMetrics{Failures: []provider.Failure{{Type: "Mobilfunk", Reason: "Rechnung noch nicht verfügbar"}}}
+	if err := notifyPushoverFailure(PushoverConfig{Token: "app-token", User: "user-key", Device: "phone"}, m); err != nil {
+		t.Fatalf("notifyPushoverFailure() error: %v", err)
+	}
+	want := map[string]string{"token": "app-token", "user": "user-key", "device": "phone", "title": "Vodafone: Abruf fehlgeschlagen",
+		"message": "Mobilfunk: Rechnung noch nicht verfügbar", "priority": "1"}
+	if len(*got) != 1 || fmt.Sprint((*got)[0].fields) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want %v", *got, want)
+	}
+
+	png := filepath.Join(t.TempDir(), "kabel_navigation.png")
+	if err := os.WriteFile(png, []byte("\x89PNG\r\n\x1a\nscreenshot"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.Failures[0].Screenshot = png
+	if err := notifyPushoverFailure(PushoverConfig{Token: "app-token", User: "user-key"}, m); err != nil {
+		t.Fatalf("notifyPushoverFailure() error: %v", err)
+	}
+	if last := (*got)[len(*got)-1]; last.attachment != "\x89PNG\r\n\x1a\nscreenshot" || last.mimeType != "image/png" {
+		t.Errorf("screenshot attachment = %q (%s)", last.attachment, last.mimeType)
+	}
+
+	err := notifyPushoverFailure(PushoverConfig{Token: "wrong", User: "user-key"}, m)
+	if err == nil || !strings.Contains(err.Error(), "application token is invalid") {
+		t.Errorf("notifyPushoverFailure() error = %v, want the API error", err)
+	}
+}
+
+func TestCheckPushover(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PushoverConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: PushoverConfig{}},
+		{name: "valid", config: PushoverConfig{Token: "app-token", User: "user-key"}},
+		{name: "no user", config: PushoverConfig{Token: "app-token"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPushover(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkPushover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
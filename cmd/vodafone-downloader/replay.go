@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+// replayResult is what the "replay" subcommand prints for comparison between runs.
+type replayResult struct {
+	Invoices []replayInvoice `json:"invoices"`
+	Failures []replayFailure `json:"failures"`
+	Missing  []string        `json:"missing,omitempty"`
+}
+
+type replayInvoice struct {
+	Type     string                 `json:"type"`
+	Filename string                 `json:"filename"`
+	Month    string                 `json:"month"`
+	Year     string                 `json:"year"`
+	Amount   float64                `json:"amount"`
+	VAT      float64                `json:"vat,omitempty"`
+	BaseFee  float64                `json:"base_fee,omitempty"`
+	Tariff   string                 `json:"tariff,omitempty"`
+	Number   string                 `json:"number,omitempty"`
+	Extras   []provider.ExtraCharge `json:"extras,omitempty"`
+	Fallback bool                   `json:"fallback,omitempty"`
+	Notify   []string               `json:"notify,omitempty"`
+	Tags     []string               `json:"tags,omitempty"`
+	Priority string                 `json:"priority,omitempty"`
+	Size     int                    `json:"size"`
+}
+
+type replayFailure struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// newReplayResult collects the outcome of a replayed run in a stable order.
+func newReplayResult(invoices []provider.Invoice, failures []provider.Failure, missing []string) replayResult {
+	r := replayResult{Invoices: []replayInvoice{}, Failures: []replayFailure{}, Missing: missing}
+	for _, inv := range invoices {
+		r.Invoices = append(r.Invoices, replayInvoice{
+			Type: inv.Type, Filename: inv.Filename, Month: inv.Month, Year: inv.Year,
+			Amount: inv.Amount, VAT: inv.VAT, BaseFee: inv.BaseFee, Number: inv.Number, Extras: inv.Extras,
+			Tariff: inv.Tariff, Fallback: inv.Fallback, Notify: inv.Notify, Tags: inv.Tags, Priority: inv.Priority,
+			Size: len(inv.PDFData),
+		})
+	}
+	for _, f := range failures {
+		r.Failures = append(r.Failures, replayFailure{Type: f.Type, Reason: f.Reason})
+	}
+	sort.Slice(r.Invoices, func(i, j int) bool { return r.Invoices[i].Filename < r.Invoices[j].Filename })
+	sort.Slice(r.Failures, func(i, j int) bool { return r.Failures[i].Type < r.Failures[j].Type })
+	return r
+}
+
+// runReplay implements the "replay" subcommand. It runs the invoice download, the
+// rules, the tariff check and the expected-invoice check against a bundle recorded
+// with --record instead of the portal and prints the outcome as JSON. Nothing is
+// stored or sent; with --output the invoice email is written to an .eml file.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	output := fs.String("output", "", "write the invoice email to this .eml file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: replay [--output file.eml] <bundle>")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	s, err := vodafone.OpenSession(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	downloader := vodafone.NewClient(cfg.Vodafone)
+	downloader.Session = s
+	downloader.Now = s.Now
+	results, failures := downloader.Download(context.Background())
+	applyRules(rules, results)
+	if cfg.Tariff.Check {
+		downloader.CheckTariffs(context.Background(), results, cfg.Tariff.URLs)
+	}
+	missing := missingExpected(results, s.Now(), cfg.Expect)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(newReplayResult(results, failures, missing)); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return nil
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := mailer.New(cfg.Email, cfg.SMTP).Compose(results, failures, nil, nil).WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
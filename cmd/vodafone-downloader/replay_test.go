@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestReplayDownload(t *testing.T) {
+	// A bundle as recorded by --record: Kabel has a current invoice, the Mobilfunk
+	// PDF could not be captured.
+	dir := t.TempDir()
+	files := map[string]string{
+		"manifest.json": `{"version": "1.7.0", "now": "2026-02-12T06:00:00Z", "steps": {
+			"kabel": {"text": "kabel.txt"},
+			"kabel_current": {"pdf": "kabel_current.pdf"},
+			"mobilfunk": {"text": "mobilfunk.txt"},
+			"mobilfunk_archive": {"error": "no PDF captured"}}}`,
+		"kabel.txt":         "Aktuelle Rechnung Februar 2026\nBetrag 39,99 €\nRechnungsarchiv\nJanuar\n04.01.2026 39,99 €",
+		"kabel_current.pdf": "%PDF-1.4",
+		"mobilfunk.txt":     "Aktuelle Rechnung Januar 2026\nRechnungsarchiv\nJanuar\n04.01.2026 24,98 €",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := vodafone.OpenSession(dir)
+	if err != nil {
+		t.Fatalf("openSession() error: %v", err)
+	}
+	d := vodafone.NewClient(vodafone.Config{})
+	d.Session = s
+	d.Now = s.Now
+
+	results, failures := d.Download(context.Background())
+	r := newReplayResult(results, failures, nil)
+	if len(r.Invoices) != 1 || r.Invoices[0].Filename != "02_2026_Rechnung_Vodafone_Kabel.pdf" || r.Invoices[0].Amount != 39.99 {
+		t.Errorf("invoices = %+v", r.Invoices)
+	}
+	if len(r.Failures) != 1 || r.Failures[0].Type != "Mobilfunk" || !errors.Is(failures[0].Err, provider.ErrCaptureFailed) {
+		t.Errorf("failures = %+v", failures)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// VATQuarter sums the invoices of one calendar quarter for the
+// Umsatzsteuervoranmeldung.
+type VATQuarter struct {
+	Quarter    string // e.g. "2026-Q1"
+	Net        float64
+	VAT        float64
+	Gross      float64
+	Invoices   int
+	WithoutVAT int // invoices whose PDF showed no VAT amount
+}
+
+var quarterPattern = regexp.MustCompile(`^\d{4}-Q[1-4]$`)
+
+// runReport implements the "report" subcommand. "report --vat" prints net, VAT and
+// gross per quarter from the invoice history; --quarter limits it to one quarter.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	vat := fs.Bool("vat", false, "summarize net, VAT and gross per quarter")
+	quarter := fs.String("quarter", "", "only this quarter, e.g. 2026-Q1")
+	fs.Parse(args)
+
+	if !*vat {
+		return fmt.Errorf("no report selected, use --vat")
+	}
+	if *quarter != "" && !quarterPattern.MatchString(*quarter) {
+		return fmt.Errorf("invalid quarter %q, want e.g. 2026-Q1", *quarter)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if cfg.History.File == "" {
+		return fmt.Errorf("history.file is not configured")
+	}
+	history, err := loadHistory(cfg.History.File)
+	if err != nil {
+		return err
+	}
+
+	quarters := vatSummary(history.Entries, *quarter)
+	if len(quarters) == 0 {
+		return fmt.Errorf("no invoices recorded for %s", orDefault(*quarter, "any quarter"))
+	}
+	return writeVATReport(os.Stdout, quarters)
+}
+
+// quarterOf returns the calendar quarter of a history entry, e.g. "2026-Q1".
+func quarterOf(e HistoryEntry) string {
+	month, _ := strconv.Atoi(e.Month)
+	return fmt.Sprintf("%s-Q%d", e.Year, (month+2)/3)
+}
+
+// vatSummary groups the history by quarter, oldest first. If quarter is set, only
+// that quarter is returned. Invoices without a VAT amount count fully towards gross
+// and net and are reported in WithoutVAT.
+func vatSummary(entries []HistoryEntry, quarter string) []VATQuarter {
+	byQuarter := map[string]*VATQuarter{}
+	for _, e := range entries {
+		q := quarterOf(e)
+		if quarter != "" && q != quarter {
+			continue
+		}
+		sum, ok := byQuarter[q]
+		if !ok {
+			sum = &VATQuarter{Quarter: q}
+			byQuarter[q] = sum
+		}
+		sum.Invoices++
+		sum.Gross += e.Amount
+		sum.VAT += e.VAT
+		sum.Net += e.Amount - e.VAT
+		if e.VAT == 0 {
+			sum.WithoutVAT++
+		}
+	}
+
+	var quarters []VATQuarter
+	for _, sum := range byQuarter {
+		quarters = append(quarters, *sum)
+	}
+	sort.Slice(quarters, func(i, j int) bool { return quarters[i].Quarter < quarters[j].Quarter })
+	return quarters
+}
+
+// writeVATReport prints the quarterly summary as a table.
+func writeVATReport(w io.Writer, quarters []VATQuarter) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Quartal\tRechnungen\tNetto\tUSt.\tBrutto\t")
+	var missing int
+	for _, q := range quarters {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t\n", q.Quarter, q.Invoices, provider.FormatAmount(q.Net), provider.FormatAmount(q.VAT), provider.FormatAmount(q.Gross))
+		missing += q.WithoutVAT
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if missing > 0 {
+		_, err := fmt.Fprintf(w, "\n%d Rechnung(en) ohne USt.-Angabe, als Netto gezählt\n", missing)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestQuarterOf(t *testing.T) {
+	tests := []struct {
+		month string
+		want  string
+	}{
+		{"01", "2026-Q1"}, {"03", "2026-Q1"}, {"04", "2026-Q2"}, {"09", "2026-Q3"}, {"10", "2026-Q4"}, {"12", "2026-Q4"},
+	}
+	for _, tc := range tests {
+		if got := quarterOf(HistoryEntry{Month: tc.month, Year: "2026"}); got != tc.want {
+			t.Errorf("quarterOf(%s) = %s, want %s", tc.month, got, tc.want)
+		}
+	}
+}
+
+func TestVATSummary(t *testing.T) {
+	entries := []HistoryEntry{
+		{Type: "Kabel", Month: "12", Year: "2025", Amount: 44.98, VAT: 7.18},
+		{Type: "Mobilfunk", Month: "01", Year: "2026", Amount: 23.79, VAT: 3.80},
+		{Type: "Kabel", Month: "01", Year: "2026", Amount: 44.98, VAT: 7.18},
+		{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 20.00},
+	}
+
+	got := vatSummary(entries, "")
+	if len(got) != 2 || got[0].Quarter != "2025-Q4" || got[1].Quarter != "2026-Q1" {
+		t.Fatalf("vatSummary() = %+v, want 2025-Q4 and 2026-Q1", got)
+	}
+	q1 := got[1]
+	if q1.Invoices != 3 || q1.WithoutVAT != 1 {
+		t.Errorf("Invoices = %d, WithoutVAT = %d, want 3 and 1", q1.Invoices, q1.WithoutVAT)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Gross", q1.Gross, 88.77},
+		{"VAT", q1.VAT, 10.98},
+		{"Net", q1.Net, 77.79},
+	} {
+		if math.Abs(c.got-c.want) > 0.001 {
+			t.Errorf("%s = %.2f, want %.2f", c.name, c.got, c.want)
+		}
+	}
+
+	if got := vatSummary(entries, "2025-Q4"); len(got) != 1 || got[0].Invoices != 1 {
+		t.Errorf("vatSummary(2025-Q4) = %+v, want one invoice", got)
+	}
+	if got := vatSummary(entries, "2026-Q2"); len(got) != 0 {
+		t.Errorf("vatSummary(2026-Q2) = %+v, want none", got)
+	}
+}
+
+func TestWriteVATReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeVATReport(&buf, []VATQuarter{
+		{Quarter: "2026-Q1", Net: 77.79, VAT: 10.98, Gross: 88.77, Invoices: 3, WithoutVAT: 1},
+	})
+	if err != nil {
+		t.Fatalf("writeVATReport() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Quartal", "2026-Q1", "77,79 €", "10,98 €", "88,77 €", "1 Rechnung(en) ohne USt.-Angabe"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunReportValidatesFlags(t *testing.T) {
+	if err := runReport(nil); err == nil || !strings.Contains(err.Error(), "--vat") {
+		t.Errorf("runReport() error = %v, want --vat hint", err)
+	}
+	if err := runReport([]string{"--vat", "--quarter", "Q1/2026"}); err == nil || !strings.Contains(err.Error(), "invalid quarter") {
+		t.Errorf("runReport() error = %v, want invalid quarter", err)
+	}
+}
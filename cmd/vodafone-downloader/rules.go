@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// rule is a compiled entry of the rules section: all conditions must hold for its
+// actions to apply to an invoice.
+type rule struct {
+	RuleConfig
+	conditions []condition
+}
+
+// condition compares one invoice field with a value, e.g. amount > 60.
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+var conditionPattern = regexp.MustCompile(`^(\w+)\s*(==|!=|>=|<=|>|<)\s*(.+)$`)
+
+// ruleFields are the invoice fields a condition can test. Numeric fields are compared
+// as numbers, the others as case-insensitive text (only == and !=).
+var ruleFields = map[string]bool{"contract": false, "number": false, "amount": true, "vat": true, "month": true, "year": true}
+
+// compileRules parses the conditions of the rules section, e.g.
+// "contract == Kabel and amount > 60".
+func compileRules(configs []RuleConfig) ([]rule, error) {
+	var rules []rule
+	for i, c := range configs {
+		r := rule{RuleConfig: c}
+		if strings.TrimSpace(c.If) == "" {
+			return nil, fmt.Errorf("rule %d: empty condition", i+1)
+		}
+		for _, clause := range strings.Split(c.If, " and ") {
+			m := conditionPattern.FindStringSubmatch(strings.TrimSpace(clause))
+			if m == nil {
+				return nil, fmt.Errorf("rule %d: invalid condition %q", i+1, clause)
+			}
+			cond := condition{field: strings.ToLower(m[1]), op: m[2], value: strings.Trim(strings.TrimSpace(m[3]), `"'`)}
+			numeric, ok := ruleFields[cond.field]
+			if !ok {
+				return nil, fmt.Errorf("rule %d: unknown field %q", i+1, m[1])
+			}
+			if numeric {
+				if _, err := ruleNumber(cond.value); err != nil {
+					return nil, fmt.Errorf("rule %d: %s needs a number, got %q", i+1, cond.field, cond.value)
+				}
+			} else if cond.op != "==" && cond.op != "!=" {
+				return nil, fmt.Errorf("rule %d: %s only supports == and !=", i+1, cond.field)
+			}
+			r.conditions = append(r.conditions, cond)
+		}
+		switch c.Priority {
+		case "", "high", "normal", "low":
+		default:
+			return nil, fmt.Errorf("rule %d: unknown priority %q", i+1, c.Priority)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ruleNumber parses a number written either way, e.g. "60", "59.99" or "59,99".
+func ruleNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+}
+
+// match reports whether the invoice satisfies the condition.
+func (c condition) match(inv provider.Invoice) bool {
+	var text string
+	var number float64
+	switch c.field {
+	case "contract":
+		text = inv.Type
+	case "number":
+		text = inv.Number
+	case "amount":
+		number = inv.Amount
+	case "vat":
+		number = inv.VAT
+	case "month":
+		number, _ = ruleNumber(inv.Month)
+	case "year":
+		number, _ = ruleNumber(inv.Year)
+	}
+
+	if !ruleFields[c.field] {
+		equal := strings.EqualFold(text, c.value)
+		return equal == (c.op == "==")
+	}
+	value, _ := ruleNumber(c.value)
+	switch c.op {
+	case "==":
+		return number == value
+	case "!=":
+		return number != value
+	case ">":
+		return number > value
+	case ">=":
+		return number >= value
+	case "<":
+		return number < value
+	}
+	return number <= value
+}
+
+// applyRules evaluates the rules against every invoice and records the actions of the
+// matching ones: additional recipients, storage tags and the email priority.
+func applyRules(rules []rule, invoices []provider.Invoice) {
+	for i := range invoices {
+		inv := &invoices[i]
+		for _, r := range rules {
+			matched := true
+			for _, c := range r.conditions {
+				matched = matched && c.match(*inv)
+			}
+			if !matched {
+				continue
+			}
+			if r.Notify != "" {
+				inv.Notify = append(inv.Notify, r.Notify)
+			}
+			inv.Tags = append(inv.Tags, r.Tags...)
+			if r.Priority != "" && (inv.Priority == "" || priorityRank[r.Priority] > priorityRank[inv.Priority]) {
+				inv.Priority = r.Priority
+			}
+		}
+	}
+}
+
+// priorityRank orders the priorities; unset counts as normal.
+var priorityRank = map[string]int{"low": 0, "": 1, "normal": 1, "high": 2}
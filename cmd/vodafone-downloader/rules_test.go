@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestCompileRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RuleConfig
+		wantErr bool
+	}{
+		{name: "contract and amount", rules: []RuleConfig{{If: "contract == Kabel and amount > 60", Notify: "x@example.com"}}},
+		{name: "quoted value and comma decimal", rules: []RuleConfig{{If: `contract != "Mobilfunk" and amount <= 59,99`}}},
+		{name: "month", rules: []RuleConfig{{If: "month == 12", Priority: "high"}}},
+		{name: "empty condition", rules: []RuleConfig{{Notify: "x@example.com"}}, wantErr: true},
+		{name: "no operator", rules: []RuleConfig{{If: "contract Kabel"}}, wantErr: true},
+		{name: "unknown field", rules: []RuleConfig{{If: "tariff == Red"}}, wantErr: true},
+		{name: "amount not a number", rules: []RuleConfig{{If: "amount > viel"}}, wantErr: true},
+		{name: "text compared by size", rules: []RuleConfig{{If: "contract > Kabel"}}, wantErr: true},
+		{name: "unknown priority", rules: []RuleConfig{{If: "month == 1", Priority: "urgent"}}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compileRules(tc.rules)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("compileRules() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rules, err := compileRules([]RuleConfig{
+		{If: "contract == kabel and amount > 60", Notify: "partner@example.com", Tags: []string{"teuer"}, Priority: "high"},
+		{If: "month == 12", Notify: "steuerberater@example.com", Tags: []string{"Jahresabschluss"}},
+		{If: "contract == Mobilfunk", Priority: "low"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoices := []provider.Invoice{
+		{Type: "Kabel", Month: "12", Year: "2025", Amount: 64.98},
+		{Type: "Kabel", Month: "11", Year: "2025", Amount: 44.98},
+		{Type: "Mobilfunk", Month: "12", Year: "2025", Amount: 24.98},
+	}
+
+	applyRules(rules, invoices)
+
+	want := []provider.Invoice{
+		{Type: "Kabel", Month: "12", Year: "2025", Amount: 64.98,
+			Notify: []string{"partner@example.com", "steuerberater@example.com"}, Tags: []string{"teuer", "Jahresabschluss"}, Priority: "high"},
+		{Type: "Kabel", Month: "11", Year: "2025", Amount: 44.98},
+		{Type: "Mobilfunk", Month: "12", Year: "2025", Amount: 24.98,
+			Notify: []string{"steuerberater@example.com"}, Tags: []string{"Jahresabschluss"}, Priority: "low"},
+	}
+	if !reflect.DeepEqual(invoices, want) {
+		t.Errorf("applyRules() =\n%+v\nwant\n%+v", invoices, want)
+	}
+
+	msg := mailer.New(mailer.Config{From: "a@b.com", To: "c@d.com"}, mailer.SMTPConfig{}).BuildMessage(invoices, nil, nil)
+	if got := msg.GetHeader("Cc"); !reflect.DeepEqual(got, []string{"partner@example.com", "steuerberater@example.com"}) {
+		t.Errorf("Cc = %v", got)
+	}
+}
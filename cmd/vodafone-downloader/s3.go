@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// s3Encryptions are the accepted values of a storage target's encryption.
+var s3Encryptions = map[string]s3types.ServerSideEncryption{
+	"":        "",
+	"aes256":  s3types.ServerSideEncryptionAes256,
+	"aws:kms": s3types.ServerSideEncryptionAwsKms,
+}
+
+// s3Storage uploads the PDFs to an S3 bucket or an S3-compatible service such as
+// MinIO or Backblaze B2. Keys are the prefix, which may contain the {type}, {month}
+// and {year} placeholders, and the filename, e.g. "rechnungen/2026/02_2026_...pdf".
+// The SHA-256 of each PDF is kept in the object metadata, so a rerun recognizes
+// its uploads without downloading them.
+type s3Storage struct {
+	name   string
+	cfg    StorageConfig
+	client *s3.Client // created on the first upload
+}
+
+// newS3Storage validates the settings of an s3 storage target.
+func newS3Storage(c StorageConfig) (*s3Storage, error) {
+	if c.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage needs a bucket")
+	}
+	if _, ok := s3Encryptions[strings.ToLower(c.Encryption)]; !ok {
+		return nil, fmt.Errorf("unknown s3 encryption %q", c.Encryption)
+	}
+	return &s3Storage{name: orDefault(c.Name, "S3"), cfg: c}, nil
+}
+
+func (s *s3Storage) Name() string { return s.name }
+
+// connect creates the client. Credentials are user and pass if given, otherwise
+// the standard AWS sources. A custom endpoint is addressed path-style and only
+// gets the checksums S3-compatible services support.
+func (s *s3Storage) connect(ctx context.Context) error {
+	var opts []func(*awsconfig.LoadOptions) error
+	if s.cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(s.cfg.Region))
+	}
+	if s.cfg.User != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(s.cfg.User, s.cfg.Pass, "")))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	s.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if s.cfg.URL != "" {
+			o.BaseEndpoint = aws.String(s.cfg.URL)
+			o.UsePathStyle = true
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
+	return nil
+}
+
+func (s *s3Storage) Put(inv provider.Invoice) (string, error) {
+	ctx := context.Background()
+	if s.client == nil {
+		if err := s.connect(ctx); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(inv.PDFData)
+	hash := hex.EncodeToString(sum[:])
+	prefix := strings.Trim(path.Join(provider.ExpandPlaceholders(s.cfg.Path, inv), inv.Folder), "/")
+
+	for n := 1; n <= maxVersions; n++ {
+		name := versionedName(inv.Filename, n)
+		key := strings.TrimPrefix(prefix+"/"+name, "/")
+
+		head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(s.cfg.Bucket), Key: aws.String(key)})
+		var apiErr smithy.APIError
+		switch {
+		case err == nil && s3SameContent(head, hash, inv.PDFData):
+			// Stored by an earlier run
+			return name, nil
+		case err == nil:
+			continue
+		case !errors.As(err, &apiErr) || (apiErr.ErrorCode() != "NotFound" && apiErr.ErrorCode() != "NoSuchKey"):
+			return "", fmt.Errorf("checking %s failed: %v", key, err)
+		}
+
+		input := &s3.PutObjectInput{
+			Bucket:         aws.String(s.cfg.Bucket),
+			Key:            aws.String(key),
+			Body:           bytes.NewReader(inv.PDFData),
+			ContentType:    aws.String("application/pdf"),
+			ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+			Metadata:       map[string]string{"sha256": hash},
+		}
+		if sse := s3Encryptions[strings.ToLower(s.cfg.Encryption)]; sse != "" {
+			input.ServerSideEncryption = sse
+			if s.cfg.KMSKeyID != "" {
+				input.SSEKMSKeyId = aws.String(s.cfg.KMSKeyID)
+			}
+		}
+		if _, err := s.client.PutObject(ctx, input); err != nil {
+			return "", fmt.Errorf("upload failed: %v", err)
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("%s: no free version below v%d", inv.Filename, maxVersions)
+}
+
+// s3SameContent reports whether an existing object holds data. Objects uploaded by
+// this tool carry the SHA-256 in their metadata; for others the ETag is compared,
+// which is the MD5 of single-part uploads without KMS.
+func s3SameContent(head *s3.HeadObjectOutput, hash string, data []byte) bool {
+	if stored, ok := head.Metadata["sha256"]; ok {
+		return stored == hash
+	}
+	sum := md5.Sum(data)
+	return strings.Trim(aws.ToString(head.ETag), `"`) == hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// fakeS3Object is an object of fakeS3.
+type fakeS3Object struct {
+	data       []byte
+	sha256     string
+	encryption string
+}
+
+// fakeS3 is a path-style S3 endpoint keeping objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeS3Object // "bucket/key" -> object
+	puts    int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	s := &fakeS3{objects: map[string]fakeS3Object{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		switch r.Method {
+		case http.MethodHead:
+			obj, ok := s.objects[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sum := md5.Sum(obj.data)
+			w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+			if obj.sha256 != "" {
+				w.Header().Set("x-amz-meta-sha256", obj.sha256)
+			}
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			s.objects[name] = fakeS3Object{data: data, sha256: r.Header.Get("x-amz-meta-sha256"), encryption: r.Header.Get("x-amz-server-side-encryption")}
+			s.puts++
+			w.Header().Set("ETag", `"etag"`)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+// setAWSTestEnv isolates the test from the host's AWS config and sets static credentials.
+func setAWSTestEnv(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-central-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestS3Storage(t *testing.T) {
+	setAWSTestEnv(t)
+	fake, endpoint := newFakeS3(t)
+	// An object uploaded by another tool, without the hash in its metadata
+	fake.objects["rechnungen/archiv/2026/01_2026_Rechnung_Vodafone_Kabel.pdf"] = fakeS3Object{data: []byte("%PDF-jan")}
+
+	s, err := newStorage(StorageConfig{Type: "s3", URL: endpoint, Bucket: "rechnungen", Path: "/archiv/{year}/", User: "minio", Pass: "minio123", Encryption: "AES256"})
+	if err != nil {
+		t.Fatalf("newStorage() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		inv      provider.Invoice
+		want     string
+		wantPuts int
+	}{
+		{name: "new", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf", wantPuts: 1},
+		{name: "rerun", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf", wantPuts: 1},
+		{name: "changed", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb-2")}, want: "02_2026_Rechnung_Vodafone_Kabel_v2.pdf", wantPuts: 2},
+		{name: "foreign upload by ETag", inv: provider.Invoice{Filename: "01_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-jan")}, want: "01_2026_Rechnung_Vodafone_Kabel.pdf", wantPuts: 2},
+		{name: "folder", inv: provider.Invoice{Filename: "Zahlung.pdf", Year: "2026", Folder: "Zahlungen", PDFData: []byte("%PDF-z")}, want: "Zahlung.pdf", wantPuts: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Put(tt.inv)
+			if err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Put() = %q, want %q", got, tt.want)
+			}
+			if fake.puts != tt.wantPuts {
+				t.Errorf("%d uploads, want %d", fake.puts, tt.wantPuts)
+			}
+		})
+	}
+
+	obj, ok := fake.objects["rechnungen/archiv/2026/02_2026_Rechnung_Vodafone_Kabel.pdf"]
+	if !ok || string(obj.data) != "%PDF-feb" || obj.encryption != "AES256" || len(obj.sha256) != 64 {
+		t.Errorf("object = %+v, %v", obj, ok)
+	}
+	if _, ok := fake.objects["rechnungen/archiv/2026/Zahlungen/Zahlung.pdf"]; !ok {
+		t.Errorf("folder object missing: %v", keys(fake.objects))
+	}
+}
+
+func keys(m map[string]fakeS3Object) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestNewS3Storage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{name: "minimal", config: StorageConfig{Type: "s3", Bucket: "b"}},
+		{name: "kms", config: StorageConfig{Type: "s3", Bucket: "b", Encryption: "aws:kms", KMSKeyID: "alias/rechnungen"}},
+		{name: "no bucket", config: StorageConfig{Type: "s3"}, wantErr: true},
+		{name: "unknown encryption", config: StorageConfig{Type: "s3", Bucket: "b", Encryption: "rot13"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newStorage(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.Name() != "S3" {
+				t.Errorf("Name() = %q", s.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseJitter parses schedule.jitter, the maximum distance of a run from its
+// scheduled time. An empty or non-positive window disables the jitter.
+func parseJitter(window string) (time.Duration, error) {
+	if window == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule.jitter: %v", err)
+	}
+	return max(d, 0), nil
+}
+
+// jitterOffset returns a random offset from the scheduled time in [-window, window].
+func jitterOffset(window time.Duration, randN func(time.Duration) time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	return randN(2*window+1) - window
+}
+
+// waitForJitter delays a cron-started run (--jitter) by a random amount, so installs
+// started at the same minute don't all log into Vodafone at once. Cron can't start a
+// run early, so the job is scheduled one window before the desired time and waits
+// between 0 and twice the window, which centers the runs on it.
+func waitForJitter(c ScheduleConfig) error {
+	window, err := parseJitter(c.Jitter)
+	if err != nil {
+		return err
+	}
+	delay := jitterOffset(window, rand.N[time.Duration]) + window
+	if delay == 0 {
+		return nil
+	}
+	slog.Info("Waiting before starting (schedule jitter)", "wait", delay.Round(time.Second))
+	time.Sleep(delay)
+	return nil
+}
+
+// nowLayouts are the accepted formats of the --now flag.
+var nowLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+// parseNow returns the time given with --now in the local time zone, or the current
+// time if s is empty.
+func parseNow(s string) (time.Time, error) {
+	if s == "" {
+		return time.Now(), nil
+	}
+	for _, layout := range nowLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date like 2026-01-02 or 2026-01-02T08:00", s)
+}
+
+// parsePeriod validates the --month and --year flags and returns them as "01" and
+// "2026". The year defaults to that of now. An empty month selects no period.
+func parsePeriod(month, year string, now time.Time) (string, string, error) {
+	if month == "" {
+		if year != "" {
+			return "", "", fmt.Errorf("--year needs --month")
+		}
+		return "", "", nil
+	}
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return "", "", fmt.Errorf("%q is not a month between 1 and 12", month)
+	}
+	y := now.Year()
+	if year != "" {
+		if y, err = strconv.Atoi(year); err != nil || y < 2000 || y > now.Year() {
+			return "", "", fmt.Errorf("%q is not a year between 2000 and %d", year, now.Year())
+		}
+	}
+	return fmt.Sprintf("%02d", m), strconv.Itoa(y), nil
+}
+
+// cronSchedule is a parsed schedule.run: the minutes, hours, days, months and
+// weekdays at which the daemon runs, as bit sets.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+var dailyPattern = regexp.MustCompile(`^daily at (\d{1,2}):(\d{2})$`)
+
+// parseSchedule parses a cron expression with the five fields minute, hour, day of
+// month, month and weekday ("0 8 25-31 * *"), or "daily at 08:00". Fields accept
+// *, lists, ranges and steps; weekdays are 0-7 with Sunday as 0 or 7. As in cron, a
+// day matches if either the day of month or the weekday matches when both are set.
+func parseSchedule(s string) (*cronSchedule, error) {
+	expr := strings.TrimSpace(s)
+	if m := dailyPattern.FindStringSubmatch(expr); m != nil {
+		expr = m[2] + " " + m[1] + " * * *"
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule.run %q: want a cron expression like \"0 8 25-31 * *\" or \"daily at 08:00\"", s)
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule.run %q: %v", s, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	c := &cronSchedule{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: fields[2] == "*", dowAny: fields[4] == "*",
+	}
+	if c.next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule.run %q: never runs", s)
+	}
+	return c, nil
+}
+
+// parseCronField parses one field of a cron expression into a bit set.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loText); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiText); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t.
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first time after t at which the schedule runs, or the zero time if
+// it doesn't run within the next five years.
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "disabled", window: ""},
+		{name: "minutes", window: "45m", want: 45 * time.Minute},
+		{name: "zero", window: "0s"},
+		{name: "negative", window: "-5m"},
+		{name: "invalid", window: "45", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseJitter(tc.window)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJitter() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("parseJitter() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Deterministic stand-ins for rand.N: the lowest, middle and highest value
+	window := 45 * time.Minute
+	for _, tc := range []struct {
+		randN func(time.Duration) time.Duration
+		want  time.Duration
+	}{
+		{randN: func(time.Duration) time.Duration { return 0 }, want: -window},
+		{randN: func(n time.Duration) time.Duration { return n / 2 }, want: 0},
+		{randN: func(n time.Duration) time.Duration { return n - 1 }, want: window},
+	} {
+		if got := jitterOffset(window, tc.randN); got != tc.want {
+			t.Errorf("jitterOffset() = %v, want %v", got, tc.want)
+		}
+	}
+	if got := jitterOffset(0, func(time.Duration) time.Duration { panic("rand.N(0)") }); got != 0 {
+		t.Errorf("jitterOffset(0) = %v, want 0", got)
+	}
+}
+
+func TestParseNow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2026-01-02", want: time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)},
+		{in: "2025-12-31T23:30", want: time.Date(2025, 12, 31, 23, 30, 0, 0, time.Local)},
+		{in: "2026-01-01T00:15:00+01:00", want: time.Date(2026, 1, 1, 0, 15, 0, 0, time.FixedZone("", 3600))},
+		{in: "02.01.2026", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseNow(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseNow(%q) expected error, got %v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseNow(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+
+	if got, err := parseNow(""); err != nil || time.Since(got) > time.Minute {
+		t.Errorf("parseNow(\"\") = %v, %v; want the current time", got, err)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 3, 4, 6, 0, 0, 0, time.Local)
+	tests := []struct {
+		month, year string
+		wantMonth   string
+		wantYear    string
+		wantErr     bool
+	}{
+		{month: "", year: ""},
+		{month: "01", year: "2026", wantMonth: "01", wantYear: "2026"},
+		{month: "1", wantMonth: "01", wantYear: "2026"},
+		{month: "12", year: "2025", wantMonth: "12", wantYear: "2025"},
+		{month: "13", wantErr: true},
+		{month: "Januar", wantErr: true},
+		{month: "01", year: "2027", wantErr: true},
+		{year: "2025", wantErr: true},
+	}
+	for _, tc := range tests {
+		month, year, err := parsePeriod(tc.month, tc.year, now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parsePeriod(%q, %q) error = %v, wantErr %v", tc.month, tc.year, err, tc.wantErr)
+			continue
+		}
+		if month != tc.wantMonth || year != tc.wantYear {
+			t.Errorf("parsePeriod(%q, %q) = %q, %q; want %q, %q", tc.month, tc.year, month, year, tc.wantMonth, tc.wantYear)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2026, 2, 24, 9, 30, 0, 0, time.UTC) // a Tuesday
+	tests := []struct {
+		schedule string
+		want     []string
+		wantErr  bool
+	}{
+		{schedule: "daily at 08:00", want: []string{"2026-02-25 08:00", "2026-02-26 08:00"}},
+		{schedule: "daily at 9:45", want: []string{"2026-02-24 09:45", "2026-02-25 09:45"}},
+		{schedule: "0 8 25-31 * *", want: []string{"2026-02-25 08:00", "2026-02-26 08:00", "2026-02-27 08:00", "2026-02-28 08:00", "2026-03-25 08:00"}},
+		{schedule: "*/20 10 * * *", want: []string{"2026-02-24 10:00", "2026-02-24 10:20", "2026-02-24 10:40", "2026-02-25 10:00"}},
+		{schedule: "0 7 * * 0", want: []string{"2026-03-01 07:00", "2026-03-08 07:00"}},
+		{schedule: "0 7 * * 7", want: []string{"2026-03-01 07:00"}},
+		{schedule: "30 6 1 * 1-5", want: []string{"2026-02-25 06:30", "2026-02-26 06:30", "2026-02-27 06:30", "2026-03-01 06:30", "2026-03-02 06:30"}},
+		{schedule: "0 12 29 2 *", want: []string{"2028-02-29 12:00"}},
+		{schedule: "0 8 31 2 *", wantErr: true},
+		{schedule: "0 25 * * *", wantErr: true},
+		{schedule: "0 8 * *", wantErr: true},
+		{schedule: "daily at noon", wantErr: true},
+		{schedule: "*/0 8 * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			s, err := parseSchedule(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			next := from
+			for _, want := range tt.want {
+				next = s.next(next)
+				if got := next.Format("2006-01-02 15:04"); got != want {
+					t.Fatalf("next() = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretCmdPrefix marks a credential value as a shell command whose output is the secret.
+const secretCmdPrefix = "cmd:"
+
+// resolveSecret returns the value of a credential field. Values starting with "cmd:"
+// are run through the shell (e.g. "cmd:pass show vodafone/web") and the first line
+// of stdout is used as the secret, which matches the pass/gopass convention of
+// storing the password on the first line. All other values are returned unchanged.
+func resolveSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretCmdPrefix) {
+		return value, nil
+	}
+
+	command := strings.TrimSpace(strings.TrimPrefix(value, secretCmdPrefix))
+	if command == "" {
+		return "", fmt.Errorf("empty secret command")
+	}
+
+	out, err := exec.Command("sh", "-c", command).Output()
+	if err != nil {
+		return "", fmt.Errorf("secret command %q failed: %v", command, err)
+	}
+
+	secret, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimRight(secret, "\r"), nil
+}
+
+// resolveSecrets replaces all credential fields in the config with their resolved values.
+// "vault:" references are read from the Vault server of the vault section, whose own
+// token and secret ID may be given with "cmd:".
+func resolveSecrets(c *Config) error {
+	for _, field := range []*string{&c.Vault.Token, &c.Vault.SecretID} {
+		secret, err := resolveSecret(*field)
+		if err != nil {
+			return err
+		}
+		*field = secret
+	}
+	fields := []*string{
+		&c.Vodafone.User,
+		&c.Vodafone.Pass,
+		&c.Vodafone.TOTPSecret,
+		&c.SMTP.User,
+		&c.SMTP.Pass,
+		&c.SMTP.OAuth2.ClientSecret,
+		&c.SMTP.OAuth2.RefreshToken,
+		&c.Email.Graph.ClientSecret,
+		&c.Email.SendGrid.APIKey,
+		&c.Email.IMAP.User,
+		&c.Email.IMAP.Pass,
+		&c.Docspell.HeaderValue,
+		&c.Docspell.Pass,
+		&c.Paperless.Token,
+		&c.Paperless.Pass,
+		&c.Telegram.BotToken,
+		&c.Slack.Token,
+		&c.Ntfy.Token,
+		&c.Ntfy.Pass,
+		&c.Pushover.Token,
+		&c.Pushover.User,
+		&c.Matrix.AccessToken,
+	}
+	for i := range c.Storage {
+		fields = append(fields, &c.Storage[i].User, &c.Storage[i].Pass, &c.Storage[i].ClientSecret, &c.Storage[i].RefreshToken, &c.Storage[i].Secret)
+	}
+	for i := range c.Accounts {
+		fields = append(fields, &c.Accounts[i].User, &c.Accounts[i].Pass, &c.Accounts[i].TOTPSecret)
+	}
+	var vault *vaultClient
+	for _, field := range fields {
+		if ref, ok := strings.CutPrefix(*field, secretVaultPrefix); ok {
+			if vault == nil {
+				var err error
+				if vault, err = newVaultClient(c.Vault); err != nil {
+					return err
+				}
+			}
+			secret, err := vault.secret(ref)
+			if err != nil {
+				return err
+			}
+			*field = secret
+			continue
+		}
+		secret, err := resolveSecret(*field)
+		if err != nil {
+			return err
+		}
+		*field = secret
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain value", value: "hunter2", want: "hunter2"},
+		{name: "empty value", value: "", want: ""},
+		{name: "command output", value: "cmd:echo hunter2", want: "hunter2"},
+		{name: "first line only", value: "cmd:printf 'hunter2\\nlogin: me\\n'", want: "hunter2"},
+		{name: "CRLF trimmed", value: "cmd:printf 'hunter2\\r\\n'", want: "hunter2"},
+		{name: "keeps surrounding spaces", value: "cmd:printf ' pw '", want: " pw "},
+		{name: "failing command", value: "cmd:exit 3", wantErr: true},
+		{name: "empty command", value: "cmd:  ", wantErr: true},
+		{name: "prefix must be at start", value: "xcmd:echo hi", want: "xcmd:echo hi"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveSecret(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveSecret() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("resolveSecret(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigResolvesSecretCommands(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+vodafone:
+  user: "plainuser"
+  pass: "cmd:echo vodafone-secret"
+smtp:
+  user: "cmd:echo smtp-user"
+  pass: "cmd:echo smtp-secret"
+`), 0644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if cfg.Vodafone.User != "plainuser" {
+		t.Errorf("Vodafone.User = %q, want %q", cfg.Vodafone.User, "plainuser")
+	}
+	if cfg.Vodafone.Pass != "vodafone-secret" {
+		t.Errorf("Vodafone.Pass = %q, want %q", cfg.Vodafone.Pass, "vodafone-secret")
+	}
+	if cfg.SMTP.User != "smtp-user" {
+		t.Errorf("SMTP.User = %q, want %q", cfg.SMTP.User, "smtp-user")
+	}
+	if cfg.SMTP.Pass != "smtp-secret" {
+		t.Errorf("SMTP.Pass = %q, want %q", cfg.SMTP.Pass, "smtp-secret")
+	}
+}
+
+func TestLoadConfigSecretCommandFailure(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+smtp:
+  pass: "cmd:false"
+`), 0644)
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("expected error for failing secret command, got nil")
+	}
+	if !strings.Contains(err.Error(), "secret command") {
+		t.Errorf("error = %q, want it to mention 'secret command'", err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// sentState records which invoices were already emailed, so that a tool run daily
+// sends each month's invoice once. It is stored as JSON in email.sent_file. A nil
+// state treats every invoice as unsent and records nothing.
+type sentState struct {
+	path string
+	Sent map[string]time.Time `json:"sent"` // "Kabel 2026-02" -> time of sending
+}
+
+// sentKey identifies an invoice by contract and period.
+func sentKey(inv provider.Invoice) string {
+	return inv.Type + " " + inv.Year + "-" + inv.Month
+}
+
+// loadSentState reads the sent state at path. A missing file yields an empty state;
+// an empty path disables it.
+func loadSentState(path string) (*sentState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	s := &sentState{path: path, Sent: map[string]time.Time{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Sent == nil {
+		s.Sent = map[string]time.Time{}
+	}
+	return s, nil
+}
+
+// unsent returns the invoices that weren't emailed before.
+func (s *sentState) unsent(invoices []provider.Invoice) []provider.Invoice {
+	if s == nil {
+		return invoices
+	}
+	var result []provider.Invoice
+	for _, inv := range invoices {
+		if _, ok := s.Sent[sentKey(inv)]; !ok {
+			result = append(result, inv)
+		}
+	}
+	return result
+}
+
+// record marks the invoices as sent at now and saves the state.
+func (s *sentState) record(invoices []provider.Invoice, now time.Time) error {
+	if s == nil {
+		return nil
+	}
+	for _, inv := range invoices {
+		s.Sent[sentKey(inv)] = now
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(s.path, data, 0600)
+}
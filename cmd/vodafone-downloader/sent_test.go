@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestSentState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sent.json")
+	kabel := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026"}
+	mobilfunk := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026"}
+	nextKabel := provider.Invoice{Type: "Kabel", Month: "03", Year: "2026"}
+
+	s, err := loadSentState(path)
+	if err != nil {
+		t.Fatalf("loadSentState() error: %v", err)
+	}
+	if got := s.unsent([]provider.Invoice{kabel, mobilfunk}); len(got) != 2 {
+		t.Fatalf("unsent() on empty state = %d invoices, want 2", len(got))
+	}
+	if err := s.record([]provider.Invoice{kabel}, time.Now()); err != nil {
+		t.Fatalf("record() error: %v", err)
+	}
+
+	reloaded, err := loadSentState(path)
+	if err != nil {
+		t.Fatalf("loadSentState() error: %v", err)
+	}
+	got := reloaded.unsent([]provider.Invoice{kabel, mobilfunk, nextKabel})
+	if len(got) != 2 || got[0].Type != "Mobilfunk" || got[1].Month != "03" {
+		t.Errorf("unsent() = %+v, want Mobilfunk 02 and Kabel 03", got)
+	}
+
+	os.WriteFile(path, []byte("{"), 0600)
+	if _, err := loadSentState(path); err == nil {
+		t.Error("loadSentState() accepted a broken file")
+	}
+}
+
+func TestNilSentState(t *testing.T) {
+	s, err := loadSentState("")
+	if err != nil || s != nil {
+		t.Fatalf("loadSentState(\"\") = %v, %v; want nil", s, err)
+	}
+	invoices := []provider.Invoice{{Type: "Kabel", Month: "02", Year: "2026"}}
+	if got := s.unsent(invoices); len(got) != 1 {
+		t.Errorf("unsent() = %+v, want all invoices", got)
+	}
+	if err := s.record(invoices, time.Now()); err != nil {
+		t.Errorf("record() error: %v", err)
+	}
+}
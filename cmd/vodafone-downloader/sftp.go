@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpTimeout bounds connecting and authenticating to the SFTP server.
+const sftpTimeout = 30 * time.Second
+
+// sftpStorage uploads the PDFs to a directory on an SFTP server, e.g. a NAS. The
+// directory may contain the {type}, {month} and {year} placeholders. The server's
+// host key must match host_key or an entry of the known_hosts file.
+type sftpStorage struct {
+	name string
+	addr string // host:port
+	cfg  StorageConfig
+}
+
+// newSFTPStorage validates the settings of an sftp storage target.
+func newSFTPStorage(c StorageConfig) (*sftpStorage, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Scheme != "sftp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("sftp storage needs a url like sftp://nas.local:22")
+	}
+	if c.User == "" {
+		return nil, fmt.Errorf("sftp storage needs a user")
+	}
+	if c.Pass == "" && c.KeyFile == "" {
+		return nil, fmt.Errorf("sftp storage needs a key_file or a pass")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	return &sftpStorage{name: orDefault(c.Name, "SFTP"), addr: net.JoinHostPort(u.Hostname(), port), cfg: c}, nil
+}
+
+func (s *sftpStorage) Name() string { return s.name }
+
+// hostKeyCallback verifies the server against host_key or the known_hosts file,
+// by default ~/.ssh/known_hosts.
+func (s *sftpStorage) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if s.cfg.HostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s.cfg.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("host_key: %v", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+	file := s.cfg.KnownHosts
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return knownhosts.New(file)
+}
+
+// connect opens an SFTP session; the returned function closes it.
+func (s *sftpStorage) connect() (*sftp.Client, func(), error) {
+	hostKeys, err := s.hostKeyCallback()
+	if err != nil {
+		return nil, nil, err
+	}
+	var auth []ssh.AuthMethod
+	if s.cfg.KeyFile != "" {
+		pem, err := os.ReadFile(s.cfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		var signer ssh.Signer
+		if s.cfg.Pass != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pem, []byte(s.cfg.Pass))
+		} else {
+			signer, err = ssh.ParsePrivateKey(pem)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("key_file: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	} else {
+		auth = append(auth, ssh.Password(s.cfg.Pass))
+	}
+
+	conn, err := ssh.Dial("tcp", s.addr, &ssh.ClientConfig{User: s.cfg.User, Auth: auth, HostKeyCallback: hostKeys, Timeout: sftpTimeout})
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return client, func() { client.Close(); conn.Close() }, nil
+}
+
+func (s *sftpStorage) Put(inv provider.Invoice) (string, error) {
+	client, closeClient, err := s.connect()
+	if err != nil {
+		return "", err
+	}
+	defer closeClient()
+
+	dir := path.Join(provider.ExpandPlaceholders(s.cfg.Path, inv), inv.Folder)
+	if dir != "" && dir != "." {
+		if err := client.MkdirAll(dir); err != nil {
+			return "", fmt.Errorf("creating %s failed: %v", dir, err)
+		}
+	}
+	for n := 1; n <= maxVersions; n++ {
+		name := versionedName(inv.Filename, n)
+		target := path.Join(dir, name)
+		existing, err := sftpReadFile(client, target)
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, sftpWriteFileAtomic(client, target, inv.PDFData)
+		}
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(existing, inv.PDFData) {
+			// Stored by an earlier run
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no free version below v%d", inv.Filename, maxVersions)
+}
+
+func sftpReadFile(client *sftp.Client, name string) ([]byte, error) {
+	f, err := client.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// sftpWriteFileAtomic writes data to a temporary file next to name and renames it,
+// so an interrupted upload never leaves a truncated PDF behind.
+func sftpWriteFileAtomic(client *sftp.Client, name string, data []byte) error {
+	tmp := path.Join(path.Dir(name), "."+path.Base(name)+".tmp")
+	f, err := client.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		client.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+	if err := client.PosixRename(tmp, name); err != nil {
+		if err := client.Rename(tmp, name); err != nil {
+			client.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
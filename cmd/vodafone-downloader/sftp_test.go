@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newFakeSFTP starts an SSH server serving dir over SFTP. It accepts the password
+// "secret" and the public key of clientKey. Returns the sftp:// URL and the host key.
+func newFakeSFTP(t *testing.T, dir string, clientKey ssh.PublicKey) (string, ssh.PublicKey) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != "secret" {
+				return nil, os.ErrPermission
+			}
+			return nil, nil
+		},
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if clientKey == nil || string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, os.ErrPermission
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSFTP(conn, config, dir)
+		}
+	}()
+	return "sftp://" + ln.Addr().String(), hostKey.PublicKey()
+}
+
+func serveFakeSFTP(conn net.Conn, config *ssh.ServerConfig, dir string) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					server, err := sftp.NewServer(ch, sftp.WithServerWorkingDirectory(dir))
+					if err == nil {
+						server.Serve()
+					}
+					ch.Close()
+				}
+			}
+		}()
+	}
+}
+
+func TestSFTPStorage(t *testing.T) {
+	dir := t.TempDir()
+	srvURL, hostKey := newFakeSFTP(t, dir, nil)
+	s, err := newStorage(StorageConfig{Type: "sftp", URL: srvURL, User: "nas", Pass: "secret", Path: "rechnungen/{year}",
+		HostKey: string(ssh.MarshalAuthorizedKey(hostKey))})
+	if err != nil {
+		t.Fatalf("newStorage() error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		inv  provider.Invoice
+		want string
+	}{
+		{name: "new", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf"},
+		{name: "rerun", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb")}, want: "02_2026_Rechnung_Vodafone_Kabel.pdf"},
+		{name: "changed", inv: provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Year: "2026", PDFData: []byte("%PDF-feb-2")}, want: "02_2026_Rechnung_Vodafone_Kabel_v2.pdf"},
+		{name: "folder", inv: provider.Invoice{Filename: "Gutschrift.pdf", Year: "2026", Folder: "Dokumente", PDFData: []byte("%PDF-doc")}, want: "Gutschrift.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Put(tt.inv)
+			if err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Put() = %q, want %q", got, tt.want)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "rechnungen", "2026", tt.inv.Folder, got))
+			if err != nil {
+				t.Fatalf("reading upload: %v", err)
+			}
+			if string(data) != string(tt.inv.PDFData) {
+				t.Errorf("uploaded %q, want %q", data, tt.inv.PDFData)
+			}
+		})
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "rechnungen", "2026"))
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestSFTPStorageAuth(t *testing.T) {
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := ssh.NewPublicKey(clientPriv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srvURL, hostKey := newFakeSFTP(t, t.TempDir(), clientKey)
+	host := strings.TrimPrefix(srvURL, "sftp://")
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{host}, hostKey)+"\n"), 0o600)
+	otherHosts := filepath.Join(t.TempDir(), "known_hosts")
+	os.WriteFile(otherHosts, []byte(knownhosts.Line([]string{"nas.local"}, hostKey)+"\n"), 0o600)
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ssh.NewPublicKey(otherPriv.Public())
+
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr string
+	}{
+		{name: "key file and known_hosts", config: StorageConfig{KeyFile: keyFile, KnownHosts: knownHosts}},
+		{name: "password and known_hosts", config: StorageConfig{Pass: "secret", KnownHosts: knownHosts}},
+		{name: "wrong password", config: StorageConfig{Pass: "wrong", KnownHosts: knownHosts}, wantErr: "unable to authenticate"},
+		{name: "host not in known_hosts", config: StorageConfig{Pass: "secret", KnownHosts: otherHosts}, wantErr: "key is unknown"},
+		{name: "host key mismatch", config: StorageConfig{Pass: "secret", HostKey: string(ssh.MarshalAuthorizedKey(otherKey))}, wantErr: "host key mismatch"},
+		{name: "invalid host key", config: StorageConfig{Pass: "secret", HostKey: "ssh-ed25519 garbage"}, wantErr: "host_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Type, tt.config.URL, tt.config.User = "sftp", srvURL, "nas"
+			s, err := newStorage(tt.config)
+			if err != nil {
+				t.Fatalf("newStorage() error: %v", err)
+			}
+			_, err = s.Put(provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF")})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Put() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Put() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSFTPStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{name: "password", config: StorageConfig{Type: "sftp", URL: "sftp://nas.local", User: "u", Pass: "p"}},
+		{name: "key file", config: StorageConfig{Type: "sftp", URL: "sftp://nas.local:2222", User: "u", KeyFile: "id_ed25519"}},
+		{name: "no scheme", config: StorageConfig{Type: "sftp", URL: "nas.local", User: "u", Pass: "p"}, wantErr: true},
+		{name: "no user", config: StorageConfig{Type: "sftp", URL: "sftp://nas.local", Pass: "p"}, wantErr: true},
+		{name: "no credentials", config: StorageConfig{Type: "sftp", URL: "sftp://nas.local", User: "u"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newStorage(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("newStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// sheetsAPIBase is the Google Sheets API endpoint (overridden in tests).
+var sheetsAPIBase = "https://sheets.googleapis.com/v4/spreadsheets"
+
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// sheetRow returns the spreadsheet row for an invoice: download date, contract,
+// period, amount, invoice number and the name the PDF was stored under. Unknown
+// amounts are left empty.
+func sheetRow(inv provider.Invoice, downloaded time.Time) []interface{} {
+	var amount interface{} = ""
+	if inv.Amount > 0 {
+		amount = inv.Amount
+	}
+	return []interface{}{
+		downloaded.Format("2006-01-02"),
+		inv.Type,
+		inv.Month + "/" + inv.Year,
+		amount,
+		inv.Number,
+		inv.StoredName(),
+	}
+}
+
+// appendToSheet appends one row per invoice to the configured Google Sheet. Invoices
+// whose file is already listed in the sheet, e.g. from an earlier run in the same
+// month, are skipped.
+func appendToSheet(google GoogleConfig, sheets SheetsConfig, invoices []provider.Invoice) error {
+	token, err := googleAccessToken(google.CredentialsFile, sheetsScope)
+	if err != nil {
+		return fmt.Errorf("google auth: %v", err)
+	}
+
+	sheetRange := sheets.Range
+	if sheetRange == "" {
+		sheetRange = "A1"
+	}
+
+	listed, err := sheetFiles(token, sheets.SpreadsheetID, sheetRange)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var values [][]interface{}
+	for _, inv := range invoices {
+		if listed[inv.StoredName()] {
+			continue
+		}
+		values = append(values, sheetRow(inv, now))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	body, _ := json.Marshal(map[string]interface{}{"values": values})
+
+	endpoint := fmt.Sprintf("%s/%s/values/%s:append?valueInputOption=USER_ENTERED&insertDataOption=INSERT_ROWS",
+		sheetsAPIBase, url.PathEscape(sheets.SpreadsheetID), url.PathEscape(sheetRange))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sheets append failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// sheetFiles returns the file names already listed in the sheet that sheetRange
+// appends to, i.e. the last cell of every row.
+func sheetFiles(token, spreadsheetID, sheetRange string) (map[string]bool, error) {
+	// Read the whole sheet rather than the append range, which may be a single cell
+	readRange := "A:Z"
+	if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		readRange = sheetRange[:i]
+	}
+	endpoint := fmt.Sprintf("%s/%s/values/%s", sheetsAPIBase, url.PathEscape(spreadsheetID), url.PathEscape(readRange))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sheets read failed: %s", resp.Status)
+	}
+	var result struct {
+		Values [][]interface{} `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("sheets read: %v", err)
+	}
+	listed := map[string]bool{}
+	for _, row := range result.Values {
+		if len(row) > 0 {
+			listed[fmt.Sprint(row[len(row)-1])] = true
+		}
+	}
+	return listed, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// writeServiceAccountKey creates a throwaway service account key file whose
+// token_uri points at tokenURL.
+func writeServiceAccountKey(t *testing.T, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	data, _ := json.Marshal(googleServiceAccount{
+		ClientEmail: "downloader@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    tokenURL,
+	})
+	path := filepath.Join(t.TempDir(), "sa.json")
+	os.WriteFile(path, data, 0600)
+	return path
+}
+
+func TestGoogleAccessToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if got := r.Form.Get("grant_type"); got != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("grant_type = %q", got)
+		}
+		if parts := strings.Split(r.Form.Get("assertion"), "."); len(parts) != 3 {
+			t.Errorf("assertion is not a JWT: %q", r.Form.Get("assertion"))
+		}
+		w.Write([]byte(`{"access_token":"tok-123","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	token, err := googleAccessToken(writeServiceAccountKey(t, srv.URL), sheetsScope)
+	if err != nil {
+		t.Fatalf("googleAccessToken() error: %v", err)
+	}
+	if token != "tok-123" {
+		t.Errorf("token = %q, want tok-123", token)
+	}
+}
+
+func TestGoogleAccessTokenErrors(t *testing.T) {
+	t.Run("missing key file", func(t *testing.T) {
+		if _, err := googleAccessToken(filepath.Join(t.TempDir(), "missing.json"), sheetsScope); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("no private key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sa.json")
+		os.WriteFile(path, []byte(`{"client_email":"x"}`), 0600)
+		if _, err := googleAccessToken(path, sheetsScope); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("token endpoint rejects", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+		}))
+		defer srv.Close()
+		if _, err := googleAccessToken(writeServiceAccountKey(t, srv.URL), sheetsScope); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestSheetRow(t *testing.T) {
+	day := time.Date(2026, 2, 20, 8, 0, 0, 0, time.UTC)
+
+	row := sheetRow(provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 44.98, Number: "42", Filename: "f.pdf"}, day)
+	want := []interface{}{"2026-02-20", "Kabel", "02/2026", 44.98, "42", "f.pdf"}
+	for i := range want {
+		if row[i] != want[i] {
+			t.Errorf("row[%d] = %v, want %v", i, row[i], want[i])
+		}
+	}
+
+	if row := sheetRow(provider.Invoice{Type: "Kabel"}, day); row[3] != "" {
+		t.Errorf("unknown amount = %v, want empty", row[3])
+	}
+}
+
+func TestAppendToSheet(t *testing.T) {
+	origBase := sheetsAPIBase
+	defer func() { sheetsAPIBase = origBase }()
+
+	var gotPath, gotReadPath, gotAuth string
+	var appends int
+	var gotBody struct {
+		Values [][]interface{} `json:"values"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token":"tok-123"}`))
+			return
+		}
+		if r.Method == http.MethodGet {
+			gotReadPath = r.URL.EscapedPath()
+			w.Write([]byte(`{"values":[["Datum","Vertrag","Zeitraum","Betrag","Nummer","Datei"],["2026-02-20","Mobilfunk","02/2026","24,98","","02_2026_Rechnung_Vodafone_Mobilfunk.pdf"]]}`))
+			return
+		}
+		appends++
+		gotPath, gotAuth = r.URL.EscapedPath(), r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &gotBody)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	sheetsAPIBase = srv.URL + "/v4/spreadsheets"
+	google := GoogleConfig{CredentialsFile: writeServiceAccountKey(t, srv.URL+"/token")}
+	sheets := SheetsConfig{SpreadsheetID: "sheet-id", Range: "Vodafone!A:F"}
+
+	mobil := provider.Invoice{Type: "Mobilfunk", Month: "02", Year: "2026", Amount: 24.98, Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf"}
+	kabel := provider.Invoice{Type: "Kabel", Month: "02", Year: "2026", Amount: 44.98, Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf"}
+	if err := appendToSheet(google, sheets, []provider.Invoice{mobil, kabel}); err != nil {
+		t.Fatalf("appendToSheet() error: %v", err)
+	}
+	if want := "/v4/spreadsheets/sheet-id/values/Vodafone"; gotReadPath != want {
+		t.Errorf("read path = %q, want %q", gotReadPath, want)
+	}
+	if want := "/v4/spreadsheets/sheet-id/values/Vodafone%21A:F:append"; gotPath != want {
+		t.Errorf("path = %q, want %q", gotPath, want)
+	}
+	if gotAuth != "Bearer tok-123" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if len(gotBody.Values) != 1 || gotBody.Values[0][1] != "Kabel" {
+		t.Errorf("values = %v, want only the Kabel row", gotBody.Values)
+	}
+
+	// Nothing new: the sheet isn't touched
+	if err := appendToSheet(google, sheets, []provider.Invoice{mobil}); err != nil {
+		t.Fatalf("appendToSheet() error: %v", err)
+	}
+	if appends != 1 {
+		t.Errorf("appends = %d, want 1", appends)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// slackAPIBase is the Slack Web API endpoint (overridden in tests).
+var slackAPIBase = "https://slack.com/api"
+
+// checkSlack validates the slack section.
+func checkSlack(c SlackConfig) error {
+	if c.Token == "" {
+		return nil
+	}
+	if c.Channel == "" {
+		return fmt.Errorf("slack needs a channel")
+	}
+	return nil
+}
+
+// slackSummary lists the invoices with their amounts and, for several, the total.
+func slackSummary(invoices []provider.Invoice) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d Vodafone-Rechnung(en):\n", len(invoices))
+	var total float64
+	for _, inv := range invoices {
+		fmt.Fprintf(&b, "• %s\n", invoiceCaption(inv))
+		total += inv.Amount
+	}
+	if len(invoices) > 1 && total > 0 {
+		fmt.Fprintf(&b, "Summe: %s\n", provider.FormatAmount(total))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sendSlack posts one message to the channel with the summary and the invoice PDFs
+// attached, using Slack's external upload flow: an upload URL per file, then a
+// single call sharing all files with the summary as the message text.
+func sendSlack(c SlackConfig, invoices []provider.Invoice, retry *provider.RetryPolicy) error {
+	return retry.Do(provider.StageSend, func() error {
+		type file struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+		var files []file
+		for _, inv := range invoices {
+			id, err := slackUpload(c, inv.Filename, inv.PDFData)
+			if err != nil {
+				return fmt.Errorf("%s: %v", inv.Filename, err)
+			}
+			files = append(files, file{ID: id, Title: invoiceCaption(inv)})
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"files":           files,
+			"channel_id":      c.Channel,
+			"initial_comment": slackSummary(invoices),
+		})
+		return slackCall(c, "files.completeUploadExternal", "application/json; charset=utf-8", body, nil)
+	})
+}
+
+// slackUpload uploads a file without sharing it and returns its ID.
+func slackUpload(c SlackConfig, name string, data []byte) (string, error) {
+	form := url.Values{"filename": {name}, "length": {strconv.Itoa(len(data))}}
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	if err := slackCall(c, "files.getUploadURLExternal", "application/x-www-form-urlencoded", []byte(form.Encode()), &upload); err != nil {
+		return "", err
+	}
+	resp, err := httpclient.Client.Post(upload.UploadURL, "application/pdf", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("upload failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return upload.FileID, nil
+}
+
+// slackCall calls a Web API method and decodes the response into out if given. Slack
+// reports errors as {"ok": false, "error": "..."}, mostly with status 200.
+func slackCall(c SlackConfig, method, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, slackAPIBase+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil || !result.OK {
+		return fmt.Errorf("%s failed: %s: %s", method, resp.Status, result.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
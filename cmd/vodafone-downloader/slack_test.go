@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestSlackSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		invoices []provider.Invoice
+		want     string
+	}{
+		{
+			name:     "single",
+			invoices: []provider.Invoice{{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98}},
+			want:     "1 Vodafone-Rechnung(en):\n• Kabel: Februar 2026 — 24,98 €",
+		},
+		{
+			name: "total",
+			invoices: []provider.Invoice{
+				{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98},
+				{Type: "Mobilfunk", MonthName: "Februar", Year: "2026", Amount: 15.01},
+			},
+			want: "2 Vodafone-Rechnung(en):\n• Kabel: Februar 2026 — 24,98 €\n• Mobilfunk: Februar 2026 — 15,01 €\nSumme: 39,99 €",
+		},
+		{
+			name: "no amounts",
+			invoices: []provider.Invoice{
+				{Type: "Kabel", MonthName: "Februar", Year: "2026"},
+				{Type: "DSL", MonthName: "Februar", Year: "2026"},
+			},
+			want: "2 Vodafone-Rechnung(en):\n• Kabel: Februar 2026\n• DSL: Februar 2026",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slackSummary(tt.invoices); got != tt.want {
+				t.Errorf("slackSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendSlack(t *testing.T) {
+	uploads := map[string]string{} // file ID -> content
+	var complete struct {
+		Files []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"files"`
+		ChannelID      string `json:"channel_id"`
+		InitialComment string `json:"initial_comment"`
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/upload/") {
+			data, _ := io.ReadAll(r.Body)
+			uploads[strings.TrimPrefix(r.URL.Path, "/upload/")] = string(data)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			fmt.Fprint(w, `{"ok":false,"error":"invalid_auth"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			r.ParseForm()
+			id := "F" + r.Form.Get("filename")
+			if r.Form.Get("length") == "" {
+				t.Errorf("no length for %s", r.Form.Get("filename"))
+			}
+			fmt.Fprintf(w, `{"ok":true,"upload_url":%q,"file_id":%q}`, srv.URL+"/upload/"+id, id)
+		case "/files.completeUploadExternal":
+			json.NewDecoder(r.Body).Decode(&complete)
+			fmt.Fprint(w, `{"ok":true,"files":[]}`)
+		default:
+			fmt.Fprint(w, `{"ok":false,"error":"unknown_method"}`)
+		}
+	}))
+	defer srv.Close()
+	base := slackAPIBase
+	slackAPIBase = srv.URL
+	defer func() { slackAPIBase = base }()
+
+	invoices := []provider.Invoice{
+		{Filename: "kabel.pdf", Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98, PDFData: []byte("%PDF-kabel")},
+		{Filename: "mobil.pdf", Type: "Mobilfunk", MonthName: "Februar", Year: "2026", Amount: 15.01, PDFData: []byte("%PDF-mobil")},
+	}
+	if err := sendSlack(SlackConfig{Token: "xoxb-test", Channel: "C0123456789"}, invoices, nil); err != nil {
+		t.Fatalf("sendSlack() error: %v", err)
+	}
+
+	if want := map[string]string{"Fkabel.pdf": "%PDF-kabel", "Fmobil.pdf": "%PDF-mobil"}; !reflect.DeepEqual(uploads, want) {
+		t.Errorf("uploads = %v, want %v", uploads, want)
+	}
+	if complete.ChannelID != "C0123456789" || complete.InitialComment != slackSummary(invoices) {
+		t.Errorf("completed in %q with %q", complete.ChannelID, complete.InitialComment)
+	}
+	if len(complete.Files) != 2 || complete.Files[1].ID != "Fmobil.pdf" || complete.Files[1].Title != "Mobilfunk: Februar 2026 — 15,01 €" {
+		t.Errorf("files = %+v", complete.Files)
+	}
+
+	err := sendSlack(SlackConfig{Token: "xoxb-wrong", Channel: "C0123456789"}, invoices, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("sendSlack() error = %v, want invalid_auth", err)
+	}
+}
+
+func TestCheckSlack(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SlackConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: SlackConfig{}},
+		{name: "valid", config: SlackConfig{Token: "xoxb-test", Channel: "C0123456789"}},
+		{name: "no channel", config: SlackConfig{Token: "xoxb-test"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSlack(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkSlack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
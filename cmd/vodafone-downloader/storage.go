@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// Storage is a target the invoice PDFs are archived in besides the email.
+type Storage interface {
+	// Name identifies the target in logs and in the run summary.
+	Name() string
+	// Put stores a single invoice PDF and returns the name it was stored under. If a
+	// different file already has the invoice's name, a versioned name is used.
+	Put(inv provider.Invoice) (string, error)
+}
+
+// maxVersions bounds the search for a free versioned name.
+const maxVersions = 100
+
+// versionedName returns the n-th version of a filename, e.g. "a.pdf" → "a_v2.pdf".
+// The first version is the name itself.
+func versionedName(name string, n int) string {
+	if n <= 1 {
+		return name
+	}
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s_v%d%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// versionOf returns n if name is the n-th version of filename, 0 otherwise.
+func versionOf(name, filename string) int {
+	for n := 2; n <= maxVersions; n++ {
+		if versionedName(filename, n) == name {
+			return n
+		}
+	}
+	return 0
+}
+
+// localStorageRoot returns the directory of a local storage path up to its first
+// placeholder, since {type}, {month} and {year} are only known per invoice.
+func localStorageRoot(path string) string {
+	static, _, _ := strings.Cut(path, "{")
+	if static == path {
+		return filepath.Clean(path)
+	}
+	return filepath.Dir(static)
+}
+
+// newStorage creates the backend described by c.
+func newStorage(c StorageConfig) (Storage, error) {
+	switch strings.ToLower(c.Type) {
+	case "local":
+		if c.Path == "" {
+			return nil, fmt.Errorf("local storage needs a path")
+		}
+		return &localStorage{name: orDefault(c.Name, "Lokal"), dir: c.Path}, nil
+	case "webdav":
+		if c.URL == "" {
+			return nil, fmt.Errorf("webdav storage needs a url")
+		}
+		return &webdavStorage{name: orDefault(c.Name, "WebDAV"), url: strings.TrimRight(c.URL, "/"), dir: c.Path, user: c.User, pass: c.Pass}, nil
+	case "s3":
+		return newS3Storage(c)
+	case "gdrive":
+		return newDriveStorage(c)
+	case "sftp":
+		return newSFTPStorage(c)
+	case "webhook":
+		return newWebhookStorage(c)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", c.Type)
+	}
+}
+
+// newStorageTargets creates all storage targets configured in c. Docspell and
+// Paperless-ngx are included when configured, so they take part in the same status
+// reporting.
+func newStorageTargets(c *Config) ([]Storage, error) {
+	var targets []Storage
+	for _, sc := range c.Storage {
+		s, err := newStorage(sc)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, s)
+	}
+	if c.Docspell.URL != "" {
+		targets = append(targets, docspellStorage{cfg: c.Docspell})
+	}
+	if c.Paperless.URL != "" {
+		s, err := newPaperlessStorage(c.Paperless)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, s)
+	}
+	return targets, nil
+}
+
+// storeInvoices puts every invoice into every target. A failing target never stops
+// the others; its errors are collected in the returned status instead. Failed uploads
+// are retried per the retry policy.
+func storeInvoices(targets []Storage, invoices []provider.Invoice, retry *provider.RetryPolicy) []mailer.StorageStatus {
+	var statuses []mailer.StorageStatus
+	for _, target := range targets {
+		status := mailer.StorageStatus{Target: target.Name()}
+		for _, inv := range invoices {
+			if len(inv.PDFData) == 0 {
+				continue
+			}
+			slog.Info("Storing invoice", "file", inv.Filename, "target", target.Name(), "contract", inv.Type, "month", inv.Month, "year", inv.Year, "step", provider.StageUpload)
+			var name string
+			err := retry.Do(provider.StageUpload, func() error {
+				var err error
+				name, err = target.Put(inv)
+				return err
+			})
+			if err != nil {
+				slog.Warn("Storing failed", "file", inv.Filename, "target", target.Name(), "step", provider.StageUpload, "err", err)
+				status.Errors = append(status.Errors, fmt.Sprintf("%s: %v", inv.Filename, err))
+				continue
+			}
+			if name != inv.Filename {
+				slog.Info("File exists with different content, stored as new version", "file", inv.Filename, "target", target.Name(), "stored_as", name)
+				status.Versions = append(status.Versions, mailer.VersionedFile{Original: inv.Filename, Stored: name, Version: versionOf(name, inv.Filename)})
+			}
+			status.Stored++
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// applyVersions records in the invoices the versioned name they were stored under, so
+// the history, the database and the CSV file show how the copy relates to the
+// original. If the targets differ, the first one with a new version wins.
+func applyVersions(invoices []provider.Invoice, statuses []mailer.StorageStatus) {
+	for i := range invoices {
+		for _, s := range statuses {
+			for _, v := range s.Versions {
+				if v.Original == invoices[i].Filename && invoices[i].StoredAs == "" {
+					invoices[i].StoredAs, invoices[i].Version = v.Stored, v.Version
+				}
+			}
+		}
+	}
+}
+
+// storedAll reports whether every target stored every PDF.
+func storedAll(statuses []mailer.StorageStatus) bool {
+	for _, s := range statuses {
+		if len(s.Errors) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// localStorage writes the PDFs to a local directory. The directory may contain the
+// {type}, {month} and {year} placeholders, e.g. "/srv/rechnungen/{year}". Files are
+// written atomically, so an interrupted run never leaves a truncated PDF behind.
+type localStorage struct {
+	name string
+	dir  string
+}
+
+func (s *localStorage) Name() string { return s.name }
+
+func (s *localStorage) Put(inv provider.Invoice) (string, error) {
+	dir := filepath.Join(provider.ExpandPlaceholders(s.dir, inv), inv.Folder)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	for n := 1; n <= maxVersions; n++ {
+		name := versionedName(inv.Filename, n)
+		existing, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, fsutil.WriteFileAtomic(filepath.Join(dir, name), inv.PDFData, 0600)
+		}
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(existing, inv.PDFData) {
+			// Stored by an earlier run
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no free version below v%d", inv.Filename, maxVersions)
+}
+
+// webdavStorage uploads the PDFs to a WebDAV server (e.g. Nextcloud) with PUT,
+// creating missing directories below the base URL with MKCOL first.
+type webdavStorage struct {
+	name string
+	url  string
+	dir  string
+	user string
+	pass string
+}
+
+func (s *webdavStorage) Name() string { return s.name }
+
+func (s *webdavStorage) Put(inv provider.Invoice) (string, error) {
+	dir := strings.Trim(provider.ExpandPlaceholders(s.dir, inv)+"/"+inv.Folder, "/")
+
+	// Create each directory level; 405 means it already exists
+	current := s.url
+	if dir != "" {
+		for _, segment := range strings.Split(dir, "/") {
+			current += "/" + url.PathEscape(segment)
+			resp, err := s.do("MKCOL", current, nil)
+			if err != nil {
+				return "", err
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+				return "", fmt.Errorf("creating %s failed: %s", path.Join("/", dir), resp.Status)
+			}
+		}
+	}
+
+	for n := 1; n <= maxVersions; n++ {
+		name := versionedName(inv.Filename, n)
+		target := current + "/" + url.PathEscape(name)
+
+		resp, err := s.do(http.MethodGet, target, nil)
+		if err != nil {
+			return "", err
+		}
+		existing, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusOK && bytes.Equal(existing, inv.PDFData):
+			// Stored by an earlier run
+			return name, nil
+		case resp.StatusCode == http.StatusOK:
+			continue
+		case resp.StatusCode != http.StatusNotFound:
+			return "", fmt.Errorf("checking %s failed: %s", name, resp.Status)
+		}
+
+		resp, err = s.do(http.MethodPut, target, inv.PDFData)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", fmt.Errorf("upload failed: %s", resp.Status)
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("%s: no free version below v%d", inv.Filename, maxVersions)
+}
+
+func (s *webdavStorage) do(method, target string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/pdf")
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.pass)
+	}
+	return httpclient.Client.Do(req)
+}
+
+// docspellStorage hands the PDFs to the configured Docspell collective.
+type docspellStorage struct {
+	cfg DocspellConfig
+}
+
+func (docspellStorage) Name() string { return "Docspell" }
+
+// Put uploads the PDF; Docspell skips files it already holds, so the name is kept.
+func (s docspellStorage) Put(inv provider.Invoice) (string, error) {
+	return inv.Filename, uploadToDocspell(s.cfg, inv)
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestNewStorage(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   StorageConfig
+		wantName string
+		wantErr  bool
+	}{
+		{name: "local", config: StorageConfig{Type: "local", Path: "/tmp"}, wantName: "Lokal"},
+		{name: "webdav with name", config: StorageConfig{Type: "WebDAV", URL: "https://cloud.example.com/dav", Name: "Nextcloud"}, wantName: "Nextcloud"},
+		{name: "local without path", config: StorageConfig{Type: "local"}, wantErr: true},
+		{name: "webdav without url", config: StorageConfig{Type: "webdav"}, wantErr: true},
+		{name: "unknown type", config: StorageConfig{Type: "ftp"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := newStorage(tc.config)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newStorage() error: %v", err)
+			}
+			if s.Name() != tc.wantName {
+				t.Errorf("Name() = %q, want %q", s.Name(), tc.wantName)
+			}
+		})
+	}
+}
+
+func TestNewStorageTargets(t *testing.T) {
+	c := &Config{
+		Storage:  []StorageConfig{{Type: "local", Path: "/tmp"}, {Type: "webdav", URL: "https://cloud.example.com", Name: "Nextcloud"}},
+		Docspell: DocspellConfig{URL: "https://docspell.example.com", Collective: "family"},
+	}
+	targets, err := newStorageTargets(c)
+	if err != nil {
+		t.Fatalf("newStorageTargets() error: %v", err)
+	}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name())
+	}
+	if want := []string{"Lokal", "Nextcloud", "Docspell"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("targets = %v, want %v", names, want)
+	}
+
+	c.Storage = append(c.Storage, StorageConfig{Type: "ftp"})
+	if _, err := newStorageTargets(c); err == nil {
+		t.Error("expected error for unknown storage type, got nil")
+	}
+}
+
+func TestLocalStoragePut(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := newStorage(StorageConfig{Type: "local", Path: filepath.Join(dir, "{year}")})
+
+	inv := provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", PDFData: []byte("%PDF-kabel")}
+	if _, err := s.Put(inv); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "2026", inv.Filename))
+	if err != nil {
+		t.Fatalf("stored file not found: %v", err)
+	}
+	if string(data) != "%PDF-kabel" {
+		t.Errorf("stored content = %q", data)
+	}
+}
+
+func TestLocalStoragePutFolder(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := newStorage(StorageConfig{Type: "local", Path: dir})
+
+	doc := provider.Invoice{Filename: "2026-02-12_SEPA-Mandat_Vodafone.pdf", Folder: "Zahlungsbelege", PDFData: []byte("%PDF")}
+	if _, err := s.Put(doc); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Zahlungsbelege", doc.Filename)); err != nil {
+		t.Errorf("document not stored in its folder: %v", err)
+	}
+}
+
+func TestWebDAVStoragePut(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var gotPDF []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, pass, ok := r.BasicAuth(); !ok || user != "u" || pass != "p" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case "MKCOL":
+			// The first level already exists
+			if r.URL.Path == "/dav/Rechnungen" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			gotPDF, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	s, _ := newStorage(StorageConfig{Type: "webdav", URL: srv.URL + "/dav/", Path: "/Rechnungen/{type} {year}", User: "u", Pass: "p"})
+	name, err := s.Put(provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", PDFData: []byte("%PDF-kabel")})
+	if err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	want := []string{
+		"MKCOL /dav/Rechnungen",
+		"MKCOL /dav/Rechnungen/Kabel%202026",
+		"GET /dav/Rechnungen/Kabel%202026/02_2026_Rechnung_Vodafone_Kabel.pdf",
+		"PUT /dav/Rechnungen/Kabel%202026/02_2026_Rechnung_Vodafone_Kabel.pdf",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	if string(gotPDF) != "%PDF-kabel" {
+		t.Errorf("uploaded content = %q", gotPDF)
+	}
+	if name != "02_2026_Rechnung_Vodafone_Kabel.pdf" {
+		t.Errorf("stored name = %q", name)
+	}
+}
+
+func TestVersionedName(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"02_2026_Rechnung_Vodafone_Kabel.pdf", 1, "02_2026_Rechnung_Vodafone_Kabel.pdf"},
+		{"02_2026_Rechnung_Vodafone_Kabel.pdf", 2, "02_2026_Rechnung_Vodafone_Kabel_v2.pdf"},
+		{"notes", 3, "notes_v3"},
+	}
+	for _, tc := range tests {
+		if got := versionedName(tc.name, tc.n); got != tc.want {
+			t.Errorf("versionedName(%q, %d) = %q, want %q", tc.name, tc.n, got, tc.want)
+		}
+		if want := tc.n; tc.n > 1 && versionOf(tc.want, tc.name) != want {
+			t.Errorf("versionOf(%q, %q) = %d, want %d", tc.want, tc.name, versionOf(tc.want, tc.name), want)
+		}
+	}
+	if got := versionOf("a.pdf", "a.pdf"); got != 0 {
+		t.Errorf("versionOf() of the original name = %d, want 0", got)
+	}
+}
+
+func TestApplyVersions(t *testing.T) {
+	invoices := []provider.Invoice{{Filename: "m.pdf"}, {Filename: "k.pdf"}}
+	applyVersions(invoices, []mailer.StorageStatus{
+		{Target: "Lokal", Stored: 2},
+		{Target: "WebDAV", Stored: 2, Versions: []mailer.VersionedFile{{Original: "k.pdf", Stored: "k_v2.pdf", Version: 2}}},
+		{Target: "S3", Stored: 2, Versions: []mailer.VersionedFile{{Original: "k.pdf", Stored: "k_v3.pdf", Version: 3}}},
+	})
+	if invoices[0].StoredAs != "" || invoices[0].Version != 0 || invoices[0].StoredName() != "m.pdf" {
+		t.Errorf("unversioned invoice = %+v", invoices[0])
+	}
+	if invoices[1].StoredAs != "k_v2.pdf" || invoices[1].Version != 2 || invoices[1].StoredName() != "k_v2.pdf" {
+		t.Errorf("versioned invoice = %+v, want the first target's k_v2.pdf", invoices[1])
+	}
+}
+
+func TestLocalStoragePutVersions(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := newStorage(StorageConfig{Type: "local", Path: dir})
+	inv := provider.Invoice{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf"}
+
+	put := func(data string) string {
+		t.Helper()
+		inv.PDFData = []byte(data)
+		name, err := s.Put(inv)
+		if err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		return name
+	}
+
+	if got := put("%PDF-original"); got != inv.Filename {
+		t.Errorf("first Put() = %q, want original name", got)
+	}
+	if got := put("%PDF-original"); got != inv.Filename {
+		t.Errorf("Put() with same content = %q, want original name", got)
+	}
+	if got := put("%PDF-corrected"); got != "02_2026_Rechnung_Vodafone_Kabel_v2.pdf" {
+		t.Errorf("Put() with different content = %q, want _v2", got)
+	}
+	if got := put("%PDF-corrected"); got != "02_2026_Rechnung_Vodafone_Kabel_v2.pdf" {
+		t.Errorf("repeated Put() = %q, want existing _v2", got)
+	}
+
+	original, _ := os.ReadFile(filepath.Join(dir, inv.Filename))
+	if string(original) != "%PDF-original" {
+		t.Errorf("original overwritten: %q", original)
+	}
+}
+
+func TestWebDAVStoragePutVersion(t *testing.T) {
+	files := map[string]string{"/a.pdf": "%PDF-original"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(data))
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			files[r.URL.Path] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	s, _ := newStorage(StorageConfig{Type: "webdav", URL: srv.URL})
+	name, err := s.Put(provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF-corrected")})
+	if err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if name != "a_v2.pdf" || files["/a_v2.pdf"] != "%PDF-corrected" || files["/a.pdf"] != "%PDF-original" {
+		t.Errorf("name = %q, files = %v", name, files)
+	}
+}
+
+func TestWebDAVStoragePutRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	s, _ := newStorage(StorageConfig{Type: "webdav", URL: srv.URL})
+	_, err := s.Put(provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF")})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put() error = %v, want 403", err)
+	}
+}
+
+// fakeStorage records stored filenames and fails for the ones listed in fail.
+type fakeStorage struct {
+	name   string
+	fail   map[string]bool
+	stored []string
+}
+
+func (s *fakeStorage) Name() string { return s.name }
+
+func (s *fakeStorage) Put(inv provider.Invoice) (string, error) {
+	if s.fail[inv.Filename] {
+		return "", errors.New("disk full")
+	}
+	s.stored = append(s.stored, inv.Filename)
+	return inv.Filename, nil
+}
+
+func TestStoreInvoices(t *testing.T) {
+	ok := &fakeStorage{name: "Lokal"}
+	broken := &fakeStorage{name: "WebDAV", fail: map[string]bool{"k.pdf": true}}
+	invoices := []provider.Invoice{
+		{Filename: "m.pdf", PDFData: []byte("%PDF")},
+		{Filename: "k.pdf", PDFData: []byte("%PDF")},
+		{Filename: "empty.pdf"},
+	}
+
+	got := storeInvoices([]Storage{ok, broken}, invoices, nil)
+
+	want := []mailer.StorageStatus{
+		{Target: "Lokal", Stored: 2},
+		{Target: "WebDAV", Stored: 1, Errors: []string{"k.pdf: disk full"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("storeInvoices() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(ok.stored, []string{"m.pdf", "k.pdf"}) {
+		t.Errorf("stored = %v, PDFs without data must be skipped", ok.stored)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// tariffMismatches returns the invoices flagged by checkTariffs.
+func tariffMismatches(invoices []provider.Invoice) []provider.Invoice {
+	var flagged []provider.Invoice
+	for _, inv := range invoices {
+		if inv.Tariff != "" {
+			flagged = append(flagged, inv)
+		}
+	}
+	return flagged
+}
+
+// buildTariffMessage constructs the alert about invoices not matching the tariff.
+// It goes to notify if set, otherwise to the regular invoice recipient.
+func buildTariffMessage(m *mailer.Mailer, flagged []provider.Invoice, notify string) *gomail.Message {
+	var body strings.Builder
+	body.WriteString("Bei folgenden Rechnungen passt die Grundgebühr nicht zum Tarif im Vertrag:\n\n")
+	for _, inv := range flagged {
+		fmt.Fprintf(&body, "%s %s %s: %s\n", inv.Type, inv.MonthName, inv.Year, inv.Tariff)
+	}
+	body.WriteString("\nMögliche Ursachen sind ein ausgelaufener Aktionspreis oder eine Preiserhöhung.\n")
+	return m.AlertMessage(notify, "Vodafone-Rechnung weicht vom Tarif ab", body.String())
+}
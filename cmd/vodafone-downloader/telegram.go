@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// telegramAPIBase is the Telegram Bot API endpoint (overridden in tests).
+var telegramAPIBase = "https://api.telegram.org"
+
+// checkTelegram validates the telegram section.
+func checkTelegram(c TelegramConfig) error {
+	if c.BotToken == "" {
+		if c.Commands {
+			return fmt.Errorf("telegram.commands needs a bot_token")
+		}
+		return nil
+	}
+	if c.ChatID == "" {
+		return fmt.Errorf("telegram needs a chat_id")
+	}
+	return nil
+}
+
+// invoiceCaption describes an invoice in one line for chat messages, e.g.
+// "Kabel: Februar 2026 — 24,98 €".
+func invoiceCaption(inv provider.Invoice) string {
+	caption := fmt.Sprintf("%s: %s %s", inv.Type, inv.MonthName, inv.Year)
+	if inv.Amount > 0 {
+		caption += " — " + provider.FormatAmount(inv.Amount)
+	}
+	if inv.Fallback {
+		caption += " (nur Ausdruck der Rechnungsseite)"
+	}
+	return caption
+}
+
+// sendTelegram sends each invoice PDF to the chat as a document with a caption. A
+// failed document doesn't stop the others; the errors are returned together.
+func sendTelegram(c TelegramConfig, invoices []provider.Invoice, retry *provider.RetryPolicy) error {
+	var errs []error
+	for _, inv := range invoices {
+		err := retry.Do(provider.StageSend, func() error { return sendTelegramDocument(c, inv) })
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", inv.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sendTelegramDocument(c TelegramConfig, inv provider.Invoice) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("chat_id", c.ChatID)
+	mw.WriteField("caption", invoiceCaption(inv))
+	fw, err := mw.CreateFormFile("document", inv.Filename)
+	if err != nil {
+		return err
+	}
+	fw.Write(inv.PDFData)
+	mw.Close()
+
+	resp, err := httpclient.Client.Post(telegramAPIBase+"/bot"+c.BotToken+"/sendDocument", mw.FormDataContentType(), &body)
+	if err != nil {
+		// The URL contains the bot token, so only the cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("sendDocument: %v", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.OK {
+		return fmt.Errorf("sendDocument failed: %s: %s", resp.Status, strings.TrimSpace(result.Description))
+	}
+	return nil
+}
+
+// telegramPollTimeout is how long a getUpdates request waits for new messages.
+const telegramPollTimeout = 50 * time.Second
+
+// telegramCommand is a command posted in the configured chat, e.g. "/fetch kabel".
+type telegramCommand struct {
+	Name string // without the slash and a "@bot" suffix, e.g. "fetch"
+	Args []string
+}
+
+// parseTelegramCommand splits a chat message into a command and its arguments. ok is
+// false for messages that aren't commands.
+func parseTelegramCommand(text string) (cmd telegramCommand, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return telegramCommand{}, false
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	return telegramCommand{Name: strings.ToLower(name), Args: fields[1:]}, name != ""
+}
+
+// telegramMessage is the part of a Bot API message the commands need.
+type telegramMessage struct {
+	Date int64  `json:"date"`
+	Text string `json:"text"`
+	Chat struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"chat"`
+}
+
+// fromChat reports whether the message was posted in chatID, given as ID or as
+// "@username".
+func (m telegramMessage) fromChat(chatID string) bool {
+	return chatID == strconv.FormatInt(m.Chat.ID, 10) || (m.Chat.Username != "" && chatID == "@"+m.Chat.Username)
+}
+
+// telegramUpdates waits for the updates after offset and returns the commands posted
+// in the configured chat since since, with the offset of the next request. Messages
+// from other chats are ignored, so only the chat's members control the daemon.
+func telegramUpdates(ctx context.Context, c TelegramConfig, offset int64, since time.Time) ([]telegramCommand, int64, error) {
+	params := url.Values{"offset": {strconv.FormatInt(offset, 10)}, "timeout": {strconv.Itoa(int(telegramPollTimeout.Seconds()))}}
+	var updates []struct {
+		UpdateID    int64            `json:"update_id"`
+		Message     *telegramMessage `json:"message"`
+		ChannelPost *telegramMessage `json:"channel_post"`
+	}
+	if err := callTelegram(ctx, c, "getUpdates", params, &updates); err != nil {
+		return nil, offset, err
+	}
+	var commands []telegramCommand
+	for _, u := range updates {
+		offset = max(offset, u.UpdateID+1)
+		m := u.Message
+		if m == nil {
+			m = u.ChannelPost
+		}
+		if m == nil || !m.fromChat(c.ChatID) || time.Unix(m.Date, 0).Before(since) {
+			continue
+		}
+		if cmd, ok := parseTelegramCommand(m.Text); ok {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands, offset, nil
+}
+
+// pollTelegramCommands passes the commands posted in the chat to out until ctx is
+// done. Commands sent before the start are skipped. Failed requests are logged and
+// tried again a minute later.
+func pollTelegramCommands(ctx context.Context, c TelegramConfig, out chan<- telegramCommand) {
+	since := time.Now()
+	var offset int64
+	for ctx.Err() == nil {
+		commands, next, err := telegramUpdates(ctx, c, offset, since)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("Telegram commands failed", "err", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Minute):
+				}
+			}
+			continue
+		}
+		offset = next
+		for _, cmd := range commands {
+			select {
+			case out <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// sendTelegramMessage posts text to the chat.
+func sendTelegramMessage(c TelegramConfig, text string) error {
+	return callTelegram(context.Background(), c, "sendMessage", url.Values{"chat_id": {c.ChatID}, "text": {text}}, nil)
+}
+
+// callTelegram calls a Bot API method with form parameters and decodes its result
+// into out if given.
+func callTelegram(ctx context.Context, c TelegramConfig, method string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPIBase+"/bot"+c.BotToken+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		// The URL contains the bot token, so only the cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.OK {
+		return fmt.Errorf("%s failed: %s: %s", method, resp.Status, strings.TrimSpace(result.Description))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Result, out)
+}
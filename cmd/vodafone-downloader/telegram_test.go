@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestInvoiceCaption(t *testing.T) {
+	tests := []struct {
+		name string
+		inv  provider.Invoice
+		want string
+	}{
+		{name: "amount", inv: provider.Invoice{Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98}, want: "Kabel: Februar 2026 — 24,98 €"},
+		{name: "no amount", inv: provider.Invoice{Type: "Mobilfunk", MonthName: "März", Year: "2026"}, want: "Mobilfunk: März 2026"},
+		{name: "fallback", inv: provider.Invoice{Type: "DSL", MonthName: "Januar", Year: "2026", Amount: 39.99, Fallback: true}, want: "DSL: Januar 2026 — 39,99 € (nur Ausdruck der Rechnungsseite)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := invoiceCaption(tt.inv); got != tt.want {
+				t.Errorf("invoiceCaption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendTelegram(t *testing.T) {
+	type document struct{ path, chatID, caption, filename, pdf string }
+	var got []document
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm failed: %v", err)
+		}
+		file, header, err := r.FormFile("document")
+		if err != nil {
+			t.Errorf("no document: %v", err)
+			return
+		}
+		pdf, _ := io.ReadAll(file)
+		got = append(got, document{r.URL.Path, r.FormValue("chat_id"), r.FormValue("caption"), header.Filename, string(pdf)})
+		if header.Filename == "bad.pdf" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: file is too big"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1}}`)
+	}))
+	defer srv.Close()
+	base := telegramAPIBase
+	telegramAPIBase = srv.URL
+	defer func() { telegramAPIBase = base }()
+
+	c := TelegramConfig{BotToken: "123:abc", ChatID: "4711"}
+	invoices := []provider.Invoice{
+		{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", MonthName: "Februar", Year: "2026", Amount: 24.98, PDFData: []byte("%PDF-kabel")},
+		{Filename: "bad.pdf", Type: "DSL", MonthName: "Februar", Year: "2026", PDFData: []byte("%PDF-dsl")},
+		{Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", Type: "Mobilfunk", MonthName: "Februar", Year: "2026", PDFData: []byte("%PDF-mobil")},
+	}
+	err := sendTelegram(c, invoices, nil)
+	if err == nil || !strings.Contains(err.Error(), "bad.pdf") || !strings.Contains(err.Error(), "file is too big") {
+		t.Errorf("sendTelegram() error = %v, want the failed document", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("%d documents sent, want all 3 despite the failure", len(got))
+	}
+	want := document{"/bot123:abc/sendDocument", "4711", "Kabel: Februar 2026 — 24,98 €", "02_2026_Rechnung_Vodafone_Kabel.pdf", "%PDF-kabel"}
+	if got[0] != want {
+		t.Errorf("first document = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestSendTelegramHidesToken(t *testing.T) {
+	base := telegramAPIBase
+	telegramAPIBase = "http://127.0.0.1:1"
+	defer func() { telegramAPIBase = base }()
+
+	err := sendTelegram(TelegramConfig{BotToken: "123:secret", ChatID: "4711"}, []provider.Invoice{{Filename: "a.pdf"}}, nil)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("sendTelegram() error = %v, want an error without the token", err)
+	}
+}
+
+func TestCheckTelegram(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TelegramConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: TelegramConfig{}},
+		{name: "valid", config: TelegramConfig{BotToken: "123:abc", ChatID: "@rechnungen"}},
+		{name: "no chat", config: TelegramConfig{BotToken: "123:abc"}, wantErr: true},
+		{name: "commands without bot", config: TelegramConfig{ChatID: "4711", Commands: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkTelegram(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkTelegram() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTelegramCommand(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   telegramCommand
+		wantOK bool
+	}{
+		{text: "/status", want: telegramCommand{Name: "status", Args: []string{}}, wantOK: true},
+		{text: "/fetch@VodafoneBot kabel dsl", want: telegramCommand{Name: "fetch", Args: []string{"kabel", "dsl"}}, wantOK: true},
+		{text: " /Resend 2026-02 ", want: telegramCommand{Name: "resend", Args: []string{"2026-02"}}, wantOK: true},
+		{text: "hello"},
+		{text: "/"},
+		{text: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := parseTelegramCommand(tt.text)
+			if ok != tt.wantOK || (ok && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("parseTelegramCommand(%q) = %+v, %v, want %+v, %v", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTelegramUpdates(t *testing.T) {
+	since := time.Date(2026, 2, 25, 6, 0, 0, 0, time.UTC)
+	var gotPath, gotOffset string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotOffset = r.URL.Path, r.FormValue("offset")
+		fmt.Fprintf(w, `{"ok":true,"result":[
+			{"update_id":10,"message":{"date":%[1]d,"text":"/status","chat":{"id":4711}}},
+			{"update_id":11,"message":{"date":%[1]d,"text":"/fetch kabel","chat":{"id":666}}},
+			{"update_id":12,"message":{"date":%[2]d,"text":"/fetch","chat":{"id":4711}}},
+			{"update_id":13,"message":{"date":%[1]d,"text":"thanks","chat":{"id":4711}}},
+			{"update_id":14,"channel_post":{"date":%[1]d,"text":"/resend 2026-01","chat":{"id":-100,"username":"rechnungen"}}}
+		]}`, since.Add(time.Minute).Unix(), since.Add(-time.Minute).Unix())
+	}))
+	defer srv.Close()
+	base := telegramAPIBase
+	telegramAPIBase = srv.URL
+	defer func() { telegramAPIBase = base }()
+
+	tests := []struct {
+		chatID string
+		want   []telegramCommand
+	}{
+		{chatID: "4711", want: []telegramCommand{{Name: "status", Args: []string{}}}},
+		{chatID: "@rechnungen", want: []telegramCommand{{Name: "resend", Args: []string{"2026-01"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chatID, func(t *testing.T) {
+			got, offset, err := telegramUpdates(context.Background(), TelegramConfig{BotToken: "123:abc", ChatID: tt.chatID}, 10, since)
+			if err != nil {
+				t.Fatalf("telegramUpdates() error: %v", err)
+			}
+			if gotPath != "/bot123:abc/getUpdates" || gotOffset != "10" {
+				t.Errorf("request = %s offset %s", gotPath, gotOffset)
+			}
+			if offset != 15 {
+				t.Errorf("offset = %d, want 15", offset)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
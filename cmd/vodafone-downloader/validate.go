@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+)
+
+// checkFields checks the settings a run needs but only notices once it uses them:
+// the credentials, the email addresses and the SMTP server. Every problem is
+// returned, each naming the setting and how to fix it.
+func checkFields(c *Config) []error {
+	var errs []error
+	missing := func(key, hint string) {
+		errs = append(errs, fmt.Errorf("%s is missing: %s", key, hint))
+	}
+	address := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if emailAddress(value) != nil {
+			errs = append(errs, fmt.Errorf("%s %q is not an email address: use e.g. \"name@example.com\" or \"Name <name@example.com>\"", key, value))
+		}
+	}
+
+	if len(c.Accounts) == 0 {
+		if c.Vodafone.User == "" {
+			missing("vodafone.user", "set the MeinVodafone username or email")
+		}
+		if c.Vodafone.Pass == "" {
+			missing("vodafone.pass", "set the MeinVodafone password")
+		}
+	}
+	for i, a := range c.Accounts {
+		key := fmt.Sprintf("accounts[%d] (%s)", i+1, a.Name)
+		if a.User == "" {
+			missing(key+".user", "set the account's MeinVodafone username")
+		}
+		if a.Pass == "" {
+			missing(key+".pass", "set the account's MeinVodafone password")
+		}
+		address(key+".to", a.To)
+	}
+
+	if c.Email.From == "" {
+		missing("email.from", "set the sender address of the invoice email")
+	}
+	if c.Email.To == "" {
+		missing("email.to", "set the recipient of the invoice email")
+	}
+	address("email.from", c.Email.From)
+	address("email.to", c.Email.To)
+	for i, r := range c.Rules {
+		address(fmt.Sprintf("rules[%d].notify", i+1), r.Notify)
+	}
+	address("inbox.notify", c.Inbox.Notify)
+
+	if smtpDelivery(c.Email) {
+		if c.SMTP.Host == "" {
+			missing("smtp.host", "set the SMTP server, e.g. smtp.example.com")
+		}
+		if c.SMTP.Port == "" {
+			missing("smtp.port", "set the SMTP port, usually 587 (STARTTLS) or 465 (TLS)")
+		} else if portNumber(c.SMTP.Port) != nil {
+			errs = append(errs, fmt.Errorf("smtp.port %q is not a port number: use e.g. 587 (STARTTLS) or 465 (TLS)", c.SMTP.Port))
+		}
+	}
+	return errs
+}
+
+// smtpDelivery reports whether emails are sent via SMTP, the default delivery.
+func smtpDelivery(c mailer.Config) bool {
+	return c.Delivery == "" || strings.EqualFold(c.Delivery, "smtp")
+}
+
+// runValidateConfig implements the "validate-config" subcommand, which loads the
+// config, resolves its secrets and checks it without logging in. Unless --offline is
+// given, it also logs into the SMTP server. Every problem found is logged before the
+// command fails, so they can all be fixed at once.
+func runValidateConfig(args []string) error {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	offline := fs.Bool("offline", false, "don't connect to the SMTP server")
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	problems := checkFields(cfg)
+	if err := checkConfig(cfg); err != nil {
+		problems = append(problems, unjoin(err)...)
+	}
+	if !*offline && smtpDelivery(cfg.Email) && cfg.SMTP.Host != "" && portNumber(cfg.SMTP.Port) == nil {
+		if err := mailer.DialSMTP(cfg.SMTP); err != nil {
+			problems = append(problems, fmt.Errorf("smtp: %v; check smtp.host, smtp.port, smtp.user and smtp.pass", err))
+		}
+	}
+	for _, problem := range problems {
+		slog.Error("Config problem", "file", configPath, "err", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %d problem(s) found", configPath, len(problems))
+	}
+	slog.Info("Config is valid", "file", configPath)
+	return nil
+}
+
+// unjoin returns the errors joined in err, or err itself.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
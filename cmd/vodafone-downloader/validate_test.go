@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/mailer"
+	"github.com/rummeyer/vodafone-downloader/pkg/vodafone"
+)
+
+func TestCheckFields(t *testing.T) {
+	valid := Config{
+		Vodafone: vodafone.Config{User: "anna@example.com", Pass: "secret"},
+		Email:    mailer.Config{From: "anna@example.com", To: "Rechnungen <rechnungen@example.com>"},
+		SMTP:     mailer.SMTPConfig{Host: "smtp.example.com", Port: "587"},
+	}
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{name: "valid", change: func(c *Config) {}},
+		{name: "empty", change: func(c *Config) { *c = Config{} },
+			want: []string{"vodafone.user is missing", "vodafone.pass is missing", "email.from is missing", "email.to is missing", "smtp.host is missing", "smtp.port is missing"}},
+		{name: "malformed addresses", change: func(c *Config) {
+			c.Email.To = "rechnungen(at)example.com"
+			c.Rules = []RuleConfig{{If: "amount > 50", Notify: "anna"}}
+		}, want: []string{`email.to "rechnungen(at)example.com" is not an email address`, `rules[1].notify "anna"`}},
+		{name: "bad port", change: func(c *Config) { c.SMTP.Port = "smtp" }, want: []string{`smtp.port "smtp" is not a port number`}},
+		{name: "port out of range", change: func(c *Config) { c.SMTP.Port = "70000" }, want: []string{`smtp.port "70000"`}},
+		{name: "no SMTP for maildir", change: func(c *Config) {
+			c.Email.Delivery, c.Email.Mailbox = "maildir", "/var/mail/rechnungen"
+			c.SMTP = mailer.SMTPConfig{}
+		}},
+		{name: "accounts", change: func(c *Config) {
+			c.Vodafone = vodafone.Config{}
+			c.Accounts = []AccountConfig{{Name: "anna", User: "anna", Pass: "x"}, {Name: "eltern", To: "eltern"}}
+		}, want: []string{"accounts[2] (eltern).user is missing", "accounts[2] (eltern).pass is missing", `accounts[2] (eltern).to "eltern"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.change(&c)
+			var got []string
+			for _, err := range checkFields(&c) {
+				got = append(got, err.Error())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkFields() = %q, want %d problems", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("problem %d = %q, want %q...", i+1, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunValidateConfig(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := fmt.Sprint(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+
+	path := configPath
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { configPath = path })
+
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		wantErr string
+	}{
+		{name: "SMTP unreachable", config: "vodafone: {user: anna, pass: x}\nemail: {from: a@example.com, to: b@example.com}\nsmtp: {host: 127.0.0.1, port: \"" + closed + "\"}\n",
+			wantErr: "1 problem(s)"},
+		{name: "offline", config: "vodafone: {user: anna, pass: x}\nemail: {from: a@example.com, to: b@example.com}\nsmtp: {host: 127.0.0.1, port: \"" + closed + "\"}\n",
+			args: []string{"--offline"}},
+		{name: "all problems at once", config: "vodafone: {contracts: [festnetz]}\nemail: {from: a@example.com, to: b@example.com}\nsmtp: {host: 127.0.0.1, port: \"" + closed + "\"}\nschedule: {jitter: soon}\n",
+			wantErr: "5 problem(s)"}, // user, pass, contract, jitter, SMTP
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			err := runValidateConfig(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("runValidateConfig() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runValidateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
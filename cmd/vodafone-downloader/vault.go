@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+)
+
+// secretVaultPrefix marks a credential value as a HashiCorp Vault reference,
+// "vault:<path>#<key>".
+const secretVaultPrefix = "vault:"
+
+// VaultConfig is the Vault server credential fields starting with "vault:" are read
+// from. The keys match the Vault CLI's environment variables, so VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE apply as usual.
+type VaultConfig struct {
+	Addr      string `yaml:"addr"`      // e.g. "https://vault.example.com:8200"
+	Token     string `yaml:"token"`     // token auth
+	RoleID    string `yaml:"role_id"`   // AppRole auth, used without a token
+	SecretID  string `yaml:"secret_id"` // AppRole secret ID
+	AuthPath  string `yaml:"auth_path"` // mount of the AppRole auth method, default "approle"
+	Namespace string `yaml:"namespace"` // Vault Enterprise namespace
+}
+
+// vaultClient reads secrets from Vault. It logs in on the first read and reads each
+// path once, so several keys of one secret cost a single request.
+type vaultClient struct {
+	cfg     VaultConfig
+	token   string
+	secrets map[string]map[string]interface{}
+}
+
+func newVaultClient(c VaultConfig) (*vaultClient, error) {
+	if c.Addr == "" {
+		return nil, fmt.Errorf("vault: secret references need vault.addr or VAULT_ADDR")
+	}
+	if c.Token == "" && c.RoleID == "" {
+		return nil, fmt.Errorf("vault: secret references need vault.token or vault.role_id and vault.secret_id")
+	}
+	return &vaultClient{cfg: c, token: c.Token, secrets: map[string]map[string]interface{}{}}, nil
+}
+
+// secret resolves a reference like "secret/data/vodafone#password". KV version 2
+// paths contain "data/" after the mount; the key is looked up in the secret's data
+// either way.
+func (v *vaultClient) secret(ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault: reference %q must look like vault:secret/data/vodafone#password", ref)
+	}
+	data, ok := v.secrets[path]
+	if !ok {
+		var err error
+		if data, err = v.read(path); err != nil {
+			return "", fmt.Errorf("vault: reading %s failed: %v", path, err)
+		}
+		v.secrets[path] = data
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no key %q", path, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// read returns the data of the secret at path, unwrapping the KV version 2 envelope.
+func (v *vaultClient) read(path string) (map[string]interface{}, error) {
+	if v.token == "" {
+		if err := v.login(); err != nil {
+			return nil, err
+		}
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := v.call(http.MethodGet, path, nil, &secret); err != nil {
+		return nil, err
+	}
+	if inner, ok := secret.Data["data"].(map[string]interface{}); ok && secret.Data["metadata"] != nil {
+		return inner, nil
+	}
+	return secret.Data, nil
+}
+
+// login gets a token with the AppRole credentials.
+func (v *vaultClient) login() error {
+	mount := v.cfg.AuthPath
+	if mount == "" {
+		mount = "approle"
+	}
+	body, _ := json.Marshal(map[string]string{"role_id": v.cfg.RoleID, "secret_id": v.cfg.SecretID})
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := v.call(http.MethodPost, "auth/"+strings.Trim(mount, "/")+"/login", body, &resp); err != nil {
+		return fmt.Errorf("AppRole login failed: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return fmt.Errorf("AppRole login returned no token")
+	}
+	v.token = resp.Auth.ClientToken
+	return nil
+}
+
+// call sends a Vault API request and decodes the JSON response into out.
+func (v *vaultClient) call(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimRight(v.cfg.Addr, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakeVault serves a KV version 2 secret at secret/data/vodafone, a KV version 1
+// secret at kv/smtp and AppRole logins, and counts the secret reads.
+func newFakeVault(t *testing.T) (string, *int) {
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var login map[string]string
+			json.NewDecoder(r.Body).Decode(&login)
+			if login["role_id"] != "downloader" || login["secret_id"] != "s3cret" {
+				http.Error(w, `{"errors":["invalid role or secret ID"]}`, http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"auth":{"client_token":"approle-token"}}`)
+			return
+		}
+		if token := r.Header.Get("X-Vault-Token"); token != "root-token" && token != "approle-token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		reads++
+		switch r.URL.Path {
+		case "/v1/secret/data/vodafone":
+			fmt.Fprint(w, `{"data":{"data":{"user":"anna@example.com","password":"vf-secret","pin":1234},"metadata":{"version":3}}}`)
+		case "/v1/kv/smtp":
+			fmt.Fprint(w, `{"data":{"password":"smtp-secret"}}`)
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &reads
+}
+
+func TestVaultSecret(t *testing.T) {
+	addr, _ := newFakeVault(t)
+	tests := []struct {
+		name    string
+		cfg     VaultConfig
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "KV v2 with token", cfg: VaultConfig{Token: "root-token"}, ref: "secret/data/vodafone#password", want: "vf-secret"},
+		{name: "KV v1", cfg: VaultConfig{Token: "root-token"}, ref: "kv/smtp#password", want: "smtp-secret"},
+		{name: "non-string value", cfg: VaultConfig{Token: "root-token"}, ref: "secret/data/vodafone#pin", want: "1234"},
+		{name: "AppRole", cfg: VaultConfig{RoleID: "downloader", SecretID: "s3cret"}, ref: "secret/data/vodafone#user", want: "anna@example.com"},
+		{name: "AppRole rejected", cfg: VaultConfig{RoleID: "downloader", SecretID: "wrong"}, ref: "secret/data/vodafone#user", wantErr: "AppRole login failed"},
+		{name: "bad token", cfg: VaultConfig{Token: "expired"}, ref: "secret/data/vodafone#user", wantErr: "403"},
+		{name: "missing key", cfg: VaultConfig{Token: "root-token"}, ref: "secret/data/vodafone#pass", wantErr: `no key "pass"`},
+		{name: "missing secret", cfg: VaultConfig{Token: "root-token"}, ref: "secret/data/other#pass", wantErr: "404"},
+		{name: "no key", cfg: VaultConfig{Token: "root-token"}, ref: "secret/data/vodafone", wantErr: "must look like"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Addr = addr
+			v, err := newVaultClient(tt.cfg)
+			if err != nil {
+				t.Fatalf("newVaultClient() error: %v", err)
+			}
+			got, err := v.secret(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("secret(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("secret(%q) error: %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Errorf("secret(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newVaultClient(VaultConfig{Token: "root-token"}); err == nil {
+		t.Error("newVaultClient() without an address succeeded")
+	}
+	if _, err := newVaultClient(VaultConfig{Addr: addr}); err == nil {
+		t.Error("newVaultClient() without credentials succeeded")
+	}
+}
+
+func TestLoadConfigResolvesVaultSecrets(t *testing.T) {
+	addr, reads := newFakeVault(t)
+	path := configPath
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { configPath = path })
+	t.Setenv("VAULT_ADDR", addr)
+	t.Setenv("VAULT_TOKEN", "")
+
+	os.WriteFile(configPath, []byte(`
+vodafone:
+  user: "vault:secret/data/vodafone#user"
+  pass: "vault:secret/data/vodafone#password"
+smtp:
+  user: "plain@example.com"
+  pass: "vault:kv/smtp#password"
+storage:
+  - type: s3
+    bucket: rechnungen
+    user: "vault:secret/data/vodafone#user"
+    pass: "vault:kv/smtp#password"
+vault:
+  role_id: "downloader"
+  secret_id: "cmd:echo s3cret"
+`), 0600)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if cfg.Vodafone.User != "anna@example.com" || cfg.Vodafone.Pass != "vf-secret" || cfg.SMTP.Pass != "smtp-secret" || cfg.SMTP.User != "plain@example.com" {
+		t.Errorf("resolved = %q %q %q %q", cfg.Vodafone.User, cfg.Vodafone.Pass, cfg.SMTP.User, cfg.SMTP.Pass)
+	}
+	if s := cfg.Storage[0]; s.User != "anna@example.com" || s.Pass != "smtp-secret" {
+		t.Errorf("storage credentials = %q %q", s.User, s.Pass)
+	}
+	if *reads != 2 {
+		t.Errorf("secret reads = %d, want 2 (one per path)", *reads)
+	}
+}
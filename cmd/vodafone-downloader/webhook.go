@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// webhookSignatureHeader carries the HMAC-SHA256 of the request body, hex-encoded
+// with a "sha256=" prefix as GitHub does, when the webhook has a secret.
+const webhookSignatureHeader = "X-Signature-256"
+
+// webhookPayload is the invoice metadata posted to a webhook. In the json format it
+// includes the PDF as base64; the multipart format sends the PDF as a file instead.
+type webhookPayload struct {
+	Contract string  `json:"contract"`
+	Period   string  `json:"period"` // "2026-02"
+	Amount   float64 `json:"amount"`
+	VAT      float64 `json:"vat"`
+	Number   string  `json:"number"`
+	Filename string  `json:"filename"`
+	Folder   string  `json:"folder,omitempty"`
+	Fallback bool    `json:"fallback"` // the PDF is a print of the invoice page
+	PDF      []byte  `json:"pdf,omitempty"`
+}
+
+// webhookStorage posts each invoice to a URL, e.g. an n8n or Zapier webhook.
+type webhookStorage struct {
+	name string
+	cfg  StorageConfig
+}
+
+// newWebhookStorage validates the settings of a webhook target.
+func newWebhookStorage(c StorageConfig) (*webhookStorage, error) {
+	if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		return nil, fmt.Errorf("webhook needs an http(s) url")
+	}
+	if c.Format != "" && c.Format != "json" && c.Format != "multipart" {
+		return nil, fmt.Errorf("unknown webhook format %q (json or multipart)", c.Format)
+	}
+	return &webhookStorage{name: orDefault(c.Name, "Webhook"), cfg: c}, nil
+}
+
+func (s *webhookStorage) Name() string { return s.name }
+
+// Put posts the invoice; any 2xx response counts as stored. The receiver is
+// responsible for duplicates, so the name is kept.
+func (s *webhookStorage) Put(inv provider.Invoice) (string, error) {
+	payload := webhookPayload{
+		Contract: inv.Type,
+		Period:   inv.Year + "-" + inv.Month,
+		Amount:   inv.Amount,
+		VAT:      inv.VAT,
+		Number:   inv.Number,
+		Filename: inv.Filename,
+		Folder:   inv.Folder,
+		Fallback: inv.Fallback,
+	}
+
+	var body bytes.Buffer
+	var contentType string
+	if s.cfg.Format == "multipart" {
+		meta, _ := json.Marshal(payload)
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("metadata", string(meta))
+		fw, err := mw.CreateFormFile("file", inv.Filename)
+		if err != nil {
+			return "", err
+		}
+		fw.Write(inv.PDFData)
+		mw.Close()
+		contentType = mw.FormDataContentType()
+	} else {
+		payload.PDF = inv.PDFData
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return "", err
+		}
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.cfg.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(s.cfg.Secret, body.Bytes()))
+	}
+	if s.cfg.User != "" {
+		req.SetBasicAuth(s.cfg.User, s.cfg.Pass)
+	}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("webhook failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return inv.Filename, nil
+}
+
+// webhookSignature returns the value of the signature header for body.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
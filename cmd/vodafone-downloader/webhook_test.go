@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestWebhookStorage(t *testing.T) {
+	inv := provider.Invoice{
+		Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026",
+		Amount: 24.98, VAT: 3.99, Number: "R-1234", PDFData: []byte("%PDF-kabel"),
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		secret string
+	}{
+		{name: "json", format: ""},
+		{name: "json signed", format: "json", secret: "hook-secret"},
+		{name: "multipart signed", format: "multipart", secret: "hook-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got webhookPayload
+			var gotPDF []byte
+			var gotSignature, wantSignature string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotSignature = r.Header.Get(webhookSignatureHeader)
+				if tt.secret != "" {
+					wantSignature = webhookSignature(tt.secret, body)
+				}
+				r.Body = io.NopCloser(strings.NewReader(string(body)))
+				if tt.format == "multipart" {
+					if err := r.ParseMultipartForm(1 << 20); err != nil {
+						t.Errorf("ParseMultipartForm failed: %v", err)
+					}
+					json.Unmarshal([]byte(r.FormValue("metadata")), &got)
+					if file, _, err := r.FormFile("file"); err == nil {
+						gotPDF, _ = io.ReadAll(file)
+					}
+				} else {
+					if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+						t.Errorf("Content-Type = %q", ct)
+					}
+					json.Unmarshal(body, &got)
+					gotPDF = got.PDF
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			s, err := newStorage(StorageConfig{Type: "webhook", URL: srv.URL, Format: tt.format, Secret: tt.secret})
+			if err != nil {
+				t.Fatalf("newStorage() error: %v", err)
+			}
+			name, err := s.Put(inv)
+			if err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if name != inv.Filename {
+				t.Errorf("Put() = %q, want %q", name, inv.Filename)
+			}
+			if got.Contract != "Kabel" || got.Period != "2026-02" || got.Amount != 24.98 || got.VAT != 3.99 || got.Number != "R-1234" || got.Filename != inv.Filename {
+				t.Errorf("payload = %+v", got)
+			}
+			if string(gotPDF) != "%PDF-kabel" {
+				t.Errorf("PDF = %q", gotPDF)
+			}
+			if gotSignature != wantSignature {
+				t.Errorf("signature = %q, want %q", gotSignature, wantSignature)
+			}
+		})
+	}
+}
+
+func TestWebhookStorageRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	s, _ := newStorage(StorageConfig{Type: "webhook", URL: srv.URL, Secret: "wrong"})
+	_, err := s.Put(provider.Invoice{Filename: "a.pdf", PDFData: []byte("%PDF")})
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("Put() error = %v, want the response", err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	// HMAC-SHA256 test vector from RFC 4231, test case 2
+	got := webhookSignature("Jefe", []byte("what do ya want for nothing?"))
+	if want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; got != want {
+		t.Errorf("webhookSignature() = %q, want %q", got, want)
+	}
+}
+
+func TestNewWebhookStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{name: "json", config: StorageConfig{Type: "webhook", URL: "https://n8n.example.com/webhook/vodafone"}},
+		{name: "multipart", config: StorageConfig{Type: "webhook", URL: "http://localhost:5678/webhook", Format: "multipart"}},
+		{name: "no url", config: StorageConfig{Type: "webhook"}, wantErr: true},
+		{name: "unknown format", config: StorageConfig{Type: "webhook", URL: "https://example.com", Format: "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newStorage(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("newStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package fsutil has file helpers shared by the downloader and its CLI.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// WriteFileAtomic writes data under a temporary name and renames it to path, so
+// readers such as the node_exporter or a dashboard never see a partial file. The
+// data and the rename are synced to disk, so after a crash path holds either the
+// old or the new content.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".vodafone-*")
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir syncs a directory so a rename in it survives a crash. Windows can't sync
+// directories and doesn't need to.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,16 @@
+// Package httpclient has the HTTP client the downloader and its CLI use to talk to
+// other services.
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Timeout bounds a request including reading the response, so a hung endpoint
+// can't block a run, or in daemon mode every later run.
+const Timeout = 2 * time.Minute
+
+// Client is used for every request to a notification, storage, secret or email
+// service.
+var Client = &http.Client{Timeout: Timeout}
@@ -0,0 +1,76 @@
+package mailer
+
+type Config struct {
+	From           string         `yaml:"from"`
+	To             string         `yaml:"to"`
+	Subject        string         `yaml:"subject"` // may contain {{.MonthName}}, {{.Month}}, {{.Year}} and {{.Count}}
+	Chart          bool           `yaml:"chart"`
+	PerInvoice     bool           `yaml:"per_invoice"`     // send each invoice as its own email
+	InvoiceSubject string         `yaml:"invoice_subject"` // subject template of per-invoice emails, like Subject with {{.Type}}
+	Delivery       string         `yaml:"delivery"`        // "smtp" (default), "maildir", "mbox", "graph", "sendgrid" or "ses"
+	Mailbox        string         `yaml:"mailbox"`         // Maildir directory or mbox file for local delivery
+	SentFile       string         `yaml:"sent_file"`       // JSON file of the invoices already sent, which aren't sent again
+	HTMLTemplate   string         `yaml:"html_template"`   // html/template file of the HTML body, default built in
+	Graph          GraphConfig    `yaml:"graph"`           // app registration for the "graph" delivery
+	SendGrid       SendGridConfig `yaml:"sendgrid"`        // API key for the "sendgrid" delivery
+	SES            SESConfig      `yaml:"ses"`             // AWS settings for the "ses" delivery
+	IMAP           IMAPConfig     `yaml:"imap"`            // mailbox each sent email is appended to
+}
+
+// IMAPConfig is the IMAP account sent emails are stored in after delivery.
+type IMAPConfig struct {
+	Host   string        `yaml:"host"`
+	Port   string        `yaml:"port"` // default 993, implicit TLS
+	User   string        `yaml:"user"`
+	Pass   string        `yaml:"pass"`
+	Folder string        `yaml:"folder"` // default "Sent"
+	TLS    SMTPTLSConfig `yaml:"tls"`
+}
+
+type SendGridConfig struct {
+	APIKey string `yaml:"api_key"`
+}
+
+// SESConfig overrides the AWS settings found in the environment and shared config
+// files for the "ses" delivery.
+type SESConfig struct {
+	Region           string `yaml:"region"`            // default AWS_REGION or the profile's region
+	Profile          string `yaml:"profile"`           // shared config profile, default AWS_PROFILE
+	Endpoint         string `yaml:"endpoint"`          // e.g. a VPC endpoint or LocalStack
+	ConfigurationSet string `yaml:"configuration_set"` // SES configuration set for event publishing
+}
+
+// GraphConfig holds the application credentials for sending via Microsoft Graph.
+type GraphConfig struct {
+	TenantID     string `yaml:"tenant_id"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	Sender       string `yaml:"sender"` // mailbox sent from, default the address of email.from
+}
+
+type SMTPConfig struct {
+	Host   string           `yaml:"host"`
+	Port   string           `yaml:"port"`
+	User   string           `yaml:"user"`
+	Pass   string           `yaml:"pass"`
+	TLS    SMTPTLSConfig    `yaml:"tls"`
+	OAuth2 SMTPOAuth2Config `yaml:"oauth2"`
+}
+
+// SMTPOAuth2Config enables XOAUTH2 authentication with an access token obtained from a
+// refresh token instead of the password.
+type SMTPOAuth2Config struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RefreshToken string `yaml:"refresh_token"`
+	TokenURL     string `yaml:"token_url"` // default Google's token endpoint
+	Scope        string `yaml:"scope"`     // requested with the refresh, e.g. for Office 365
+}
+
+type SMTPTLSConfig struct {
+	MinVersion         string `yaml:"min_version"`          // "1.2" or "1.3", default Go's minimum
+	CAFile             string `yaml:"ca_file"`              // PEM bundle trusted in addition to the system CAs
+	CertFile           string `yaml:"cert_file"`            // client certificate (PEM)
+	KeyFile            string `yaml:"key_file"`             // client key (PEM)
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // don't verify the server certificate
+}
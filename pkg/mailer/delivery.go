@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// Deliverer sends a built email. SMTP is the default; the others write the message
+// into a mailbox on this host or send it with an HTTP API instead.
+type Deliverer interface {
+	// Name identifies the backend in errors.
+	Name() string
+	// Deliver sends msg to the recipients in its headers.
+	Deliver(msg *gomail.Message) error
+}
+
+// newDeliverer creates the backend chosen by email.delivery. Network backends retry
+// failed sends per the retry policy.
+func newDeliverer(c Config, smtp SMTPConfig, retry *provider.RetryPolicy) (Deliverer, error) {
+	switch delivery := strings.ToLower(c.Delivery); delivery {
+	case "", "smtp":
+		return &smtpDeliverer{smtp: smtp, retry: retry}, nil
+	case "maildir", "mbox":
+		if c.Mailbox == "" {
+			return nil, fmt.Errorf("email.delivery %s needs email.mailbox", delivery)
+		}
+		if delivery == "maildir" {
+			return maildirDeliverer{dir: c.Mailbox}, nil
+		}
+		return mboxDeliverer{path: c.Mailbox}, nil
+	case "graph":
+		if err := checkGraph(c); err != nil {
+			return nil, err
+		}
+		return &graphDeliverer{graph: c.Graph, from: c.From, retry: retry}, nil
+	case "sendgrid":
+		if c.SendGrid.APIKey == "" {
+			return nil, fmt.Errorf("email.delivery sendgrid needs email.sendgrid.api_key")
+		}
+		return &sendgridDeliverer{apiKey: c.SendGrid.APIKey, retry: retry}, nil
+	case "ses":
+		return &sesDeliverer{ses: c.SES, retry: retry}, nil
+	default:
+		return nil, fmt.Errorf("unknown email.delivery %q", c.Delivery)
+	}
+}
+
+// CheckDelivery validates the delivery settings of the email section.
+func CheckDelivery(c Config) error {
+	_, err := newDeliverer(c, SMTPConfig{}, nil)
+	return err
+}
+
+type maildirDeliverer struct{ dir string }
+
+func (d maildirDeliverer) Name() string                      { return "maildir" }
+func (d maildirDeliverer) Deliver(msg *gomail.Message) error { return deliverMaildir(d.dir, msg) }
+
+type mboxDeliverer struct{ path string }
+
+func (d mboxDeliverer) Name() string                      { return "mbox" }
+func (d mboxDeliverer) Deliver(msg *gomail.Message) error { return deliverMbox(d.path, msg) }
+
+// mailboxMessage renders msg with the LF line endings used in local mailboxes.
+func mailboxMessage(msg *gomail.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return bytes.ReplaceAll(buf.Bytes(), []byte("\r\n"), []byte("\n")), nil
+}
+
+var maildirCounter atomic.Int64
+
+// deliverMaildir writes msg into the new/ folder of the Maildir at dir, creating the
+// Maildir if needed. The message is written to tmp/ first and then moved, so mail
+// readers never see a partial message.
+func deliverMaildir(dir string, msg *gomail.Message) error {
+	data, err := mailboxMessage(msg)
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return err
+		}
+	}
+	host, _ := os.Hostname()
+	host = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(host)
+	now := time.Now()
+	name := fmt.Sprintf("%d.M%dP%dQ%d.%s", now.Unix(), now.Nanosecond()/1000, os.Getpid(), maildirCounter.Add(1), host)
+
+	tmp := filepath.Join(dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+var mboxFromLine = regexp.MustCompile(`(?m)^(>*From )`)
+
+// deliverMbox appends msg to the mbox file at path. Body lines starting with "From "
+// are quoted with ">" (mboxrd), so they are not taken for the start of a message.
+func deliverMbox(path string, msg *gomail.Message) error {
+	data, err := mailboxMessage(msg)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From MAILER-DAEMON %s\n", time.Now().UTC().Format(time.ANSIC))
+	buf.Write(mboxFromLine.ReplaceAll(data, []byte(">$1")))
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
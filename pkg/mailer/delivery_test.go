@@ -0,0 +1,89 @@
+package mailer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestCheckDelivery(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "default", config: Config{}},
+		{name: "smtp", config: Config{Delivery: "SMTP"}},
+		{name: "maildir", config: Config{Delivery: "maildir", Mailbox: "/var/mail/rechnungen"}},
+		{name: "mbox without path", config: Config{Delivery: "mbox"}, wantErr: true},
+		{name: "graph", config: Config{Delivery: "graph", Graph: GraphConfig{TenantID: "t", ClientID: "c", ClientSecret: "s"}}},
+		{name: "graph without secret", config: Config{Delivery: "graph", Graph: GraphConfig{TenantID: "t", ClientID: "c"}}, wantErr: true},
+		{name: "sendgrid", config: Config{Delivery: "sendgrid", SendGrid: SendGridConfig{APIKey: "SG.key"}}},
+		{name: "sendgrid without key", config: Config{Delivery: "sendgrid"}, wantErr: true},
+		{name: "ses", config: Config{Delivery: "ses"}},
+		{name: "unknown", config: Config{Delivery: "lmtp", Mailbox: "/run/lmtp"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckDelivery(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkDelivery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliverMaildir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Maildir")
+	m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "maildir", Mailbox: dir}, SMTPConfig{})
+	for i := 0; i < 2; i++ {
+		if err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n")); err != nil {
+			t.Fatalf("sendMessage() error: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("new/ = %v, %v; want 2 messages", entries, err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("tmp/ not empty: %v", tmp)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	if !strings.Contains(string(data), "Subject: Test\n") || strings.Contains(string(data), "\r\n") {
+		t.Errorf("message = %q, want LF line endings", data)
+	}
+}
+
+func TestDeliverMbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rechnungen.mbox")
+	m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "mbox", Mailbox: path}, SMTPConfig{})
+	for _, body := range []string{"Erste\n", "From here on\n>From quoted\n"} {
+		if err := m.SendMessage(m.AlertMessage("", "Test", body)); err != nil {
+			t.Fatalf("sendMessage() error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	if n := strings.Count("\n"+text, "\nFrom MAILER-DAEMON "); n != 2 {
+		t.Errorf("found %d message separators, want 2:\n%s", n, text)
+	}
+	if !strings.Contains(text, "\n>From here on\n>>From quoted\n") {
+		t.Errorf("From lines not quoted:\n%s", text)
+	}
+	if !strings.HasSuffix(text, "\n\n") {
+		t.Errorf("mbox does not end with a blank line")
+	}
+
+	m = New(Config{From: "a@b.com", To: "c@d.com", Delivery: "mbox", Mailbox: filepath.Join(path, "missing", "dir")}, SMTPConfig{})
+	if err := m.SendMessage(m.AlertMessage("", "Test", "x")); !errors.Is(err, provider.ErrDelivery) {
+		t.Errorf("sendMessage() error = %v, want ErrDelivery", err)
+	}
+}
@@ -0,0 +1,17 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestSendMessageWrapsSMTPErrors(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{Host: "smtp.example.com", Port: "invalid"})
+
+	err := sender.SendMessage(sender.AlertMessage("", "Test", "body"))
+	if !errors.Is(err, provider.ErrDelivery) {
+		t.Errorf("sendMessage() error = %v, want it to wrap ErrDelivery", err)
+	}
+}
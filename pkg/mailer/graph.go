@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// graphAPIBase and microsoftLoginBase are the Microsoft Graph and Entra ID
+// endpoints (overridden in tests).
+var (
+	graphAPIBase       = "https://graph.microsoft.com/v1.0"
+	microsoftLoginBase = "https://login.microsoftonline.com"
+)
+
+const graphScope = "https://graph.microsoft.com/.default"
+
+// graphDeliverer sends messages via Microsoft Graph.
+type graphDeliverer struct {
+	graph GraphConfig
+	from  string // email.from, whose address is the default sender
+	retry *provider.RetryPolicy
+}
+
+func (d *graphDeliverer) Name() string { return "graph" }
+
+func (d *graphDeliverer) Deliver(msg *gomail.Message) error {
+	sender := d.graph.Sender
+	if sender == "" {
+		from, err := mail.ParseAddress(d.from)
+		if err != nil {
+			return fmt.Errorf("email.from: %v", err)
+		}
+		sender = from.Address
+	}
+	return d.retry.Do(provider.StageSend, func() error { return deliverGraph(d.graph, sender, msg) })
+}
+
+// checkGraph validates the email.graph section used by the "graph" delivery.
+func checkGraph(c Config) error {
+	switch {
+	case c.Graph.TenantID == "":
+		return fmt.Errorf("email.delivery graph needs email.graph.tenant_id")
+	case c.Graph.ClientID == "":
+		return fmt.Errorf("email.delivery graph needs email.graph.client_id")
+	case c.Graph.ClientSecret == "":
+		return fmt.Errorf("email.delivery graph needs email.graph.client_secret")
+	}
+	return nil
+}
+
+// graphAccessToken obtains an application token with the client credentials of the
+// app registration, which needs the Mail.Send application permission.
+func graphAccessToken(c GraphConfig) (string, error) {
+	resp, err := httpclient.Client.PostForm(microsoftLoginBase+"/"+url.PathEscape(c.TenantID)+"/oauth2/v2.0/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"scope":         {graphScope},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// deliverGraph sends msg with Graph's sendMail from the mailbox sender. The message
+// goes as base64-encoded MIME, so attachments and the HTML part are sent exactly as
+// via SMTP.
+func deliverGraph(c GraphConfig, sender string, msg *gomail.Message) error {
+	var mime bytes.Buffer
+	if _, err := msg.WriteTo(&mime); err != nil {
+		return err
+	}
+	token, err := graphAccessToken(c)
+	if err != nil {
+		return err
+	}
+
+	endpoint := graphAPIBase + "/users/" + url.PathEscape(sender) + "/sendMail"
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(base64.StdEncoding.EncodeToString(mime.Bytes())))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sendMail failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
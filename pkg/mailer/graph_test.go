@@ -0,0 +1,105 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// newGraphServer serves the token and sendMail endpoints and records the decoded
+// messages sent. status is the reply of sendMail.
+func newGraphServer(t *testing.T, status int) (*[]string, *[]string) {
+	var paths, messages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tenant-1/oauth2/v2.0/token":
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "secret" || r.Form.Get("scope") != graphScope {
+				http.Error(w, "invalid_client", http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"access_token":"graph-token"}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMail"):
+			if r.Header.Get("Authorization") != "Bearer graph-token" || r.Header.Get("Content-Type") != "text/plain" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			mime, err := base64.StdEncoding.DecodeString(string(body))
+			if err != nil {
+				http.Error(w, "not base64", http.StatusBadRequest)
+				return
+			}
+			paths = append(paths, r.URL.EscapedPath())
+			messages = append(messages, string(mime))
+			w.WriteHeader(status)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	api, login := graphAPIBase, microsoftLoginBase
+	graphAPIBase, microsoftLoginBase = srv.URL+"/v1.0", srv.URL
+	t.Cleanup(func() { graphAPIBase, microsoftLoginBase = api, login })
+	return &paths, &messages
+}
+
+func TestDeliverGraph(t *testing.T) {
+	graph := GraphConfig{TenantID: "tenant-1", ClientID: "app", ClientSecret: "secret"}
+
+	t.Run("sends MIME with attachments", func(t *testing.T) {
+		paths, messages := newGraphServer(t, http.StatusAccepted)
+		m := New(Config{From: "Rechnungen <rechnungen@example.com>", To: "c@d.com", Delivery: "graph", Graph: graph}, SMTPConfig{})
+		invoices := []provider.Invoice{{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", PDFData: []byte("%PDF-k")}}
+		if err := m.SendMessage(m.Compose(invoices, nil, nil, nil)); err != nil {
+			t.Fatalf("sendMessage() error: %v", err)
+		}
+		if len(*paths) != 1 || (*paths)[0] != "/v1.0/users/rechnungen@example.com/sendMail" {
+			t.Fatalf("paths = %v", *paths)
+		}
+		msg := (*messages)[0]
+		for _, want := range []string{"To: c@d.com", "02_2026_Rechnung_Vodafone_Kabel.pdf", "Content-Transfer-Encoding: base64"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("message missing %q", want)
+			}
+		}
+	})
+
+	t.Run("configured sender", func(t *testing.T) {
+		paths, _ := newGraphServer(t, http.StatusAccepted)
+		g := graph
+		g.Sender = "shared@example.com"
+		m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "graph", Graph: g}, SMTPConfig{})
+		if err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n")); err != nil {
+			t.Fatalf("sendMessage() error: %v", err)
+		}
+		if len(*paths) != 1 || (*paths)[0] != "/v1.0/users/shared@example.com/sendMail" {
+			t.Errorf("paths = %v", *paths)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		newGraphServer(t, http.StatusForbidden)
+		m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "graph", Graph: graph}, SMTPConfig{})
+		if err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n")); !errors.Is(err, provider.ErrDelivery) {
+			t.Errorf("sendMessage() error = %v, want ErrDelivery", err)
+		}
+	})
+
+	t.Run("bad client secret", func(t *testing.T) {
+		newGraphServer(t, http.StatusAccepted)
+		g := graph
+		g.ClientSecret = "wrong"
+		m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "graph", Graph: g}, SMTPConfig{})
+		if err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n")); !errors.Is(err, provider.ErrDelivery) {
+			t.Errorf("sendMessage() error = %v, want ErrDelivery", err)
+		}
+	})
+}
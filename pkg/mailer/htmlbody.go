@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"os"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// defaultHTMLTemplate renders the HTML alternative of the invoice email unless
+// email.html_template names another file.
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<p>Dokumente anbei.</p>
+{{- if .Invoices}}
+<table cellpadding="4" style="border-collapse: collapse">
+<tr><th align="left">Vertrag</th><th align="left">Monat</th><th align="right">Betrag</th><th align="left">Anhang</th></tr>
+{{- range .Invoices}}
+<tr><td>{{.Type}}</td><td>{{.Month}} {{.Year}}</td><td align="right">{{.Amount}}</td><td>{{.Filename}}{{if .Fallback}} (nur Ausdruck der Rechnungsseite){{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Failures}}
+<p>Nicht abgerufen (nächster Versuch beim nächsten Lauf):</p>
+<ul>
+{{- range .Failures}}
+<li>{{.Type}}: {{.Reason}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Stored}}
+<p>Ablage:</p>
+<ul>
+{{- range .Stored}}
+<li>{{.Target}}: {{.Stored}} gespeichert{{if .Errors}}, {{len .Errors}} fehlgeschlagen{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Chart}}
+<p><img src="{{.Chart}}" alt="Rechnungsbeträge der letzten 12 Monate"></p>
+{{- end}}
+</body>
+</html>
+`
+
+// htmlBody is the data of the HTML template.
+type htmlBody struct {
+	Invoices []htmlInvoice
+	Failures []provider.Failure
+	Stored   []StorageStatus
+	Text     string       // the plain text body
+	Chart    template.URL // cid: URL of the inline chart, empty without one
+}
+
+// htmlInvoice is an invoice row of the HTML template.
+type htmlInvoice struct {
+	Type     string
+	Month    string // month name, e.g. "Januar"
+	Year     string
+	Amount   string // formatted, e.g. "24,98 €", empty if not found on the page
+	Filename string // name of the attachment
+	Fallback bool
+}
+
+// LoadHTMLTemplate parses the template at path, or the default template if path is empty.
+func LoadHTMLTemplate(path string) (*template.Template, error) {
+	text := defaultHTMLTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("email").Parse(text)
+}
+
+// renderHTMLBody executes tmpl for the given run. chart is set if the chart image is
+// embedded in the message.
+func renderHTMLBody(tmpl *template.Template, invoices []provider.Invoice, failures []provider.Failure, stored []StorageStatus, chart bool) (string, error) {
+	data := htmlBody{Failures: failures, Stored: stored, Text: messageBody(invoices, failures, stored)}
+	for _, inv := range invoices {
+		row := htmlInvoice{Type: inv.Type, Month: inv.MonthName, Year: inv.Year, Filename: inv.Filename, Fallback: inv.Fallback}
+		if inv.Amount > 0 {
+			row.Amount = provider.FormatAmount(inv.Amount)
+		}
+		data.Invoices = append(data.Invoices, row)
+	}
+	if chart {
+		data.Chart = template.URL("cid:" + chartFilename)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// chartFilename is the name (and content ID) of the inline chart image in the email.
+const chartFilename = "ausgaben.png"
+
+// embedChart embeds the chart image in the message, referenced from the HTML body by
+// its content ID.
+func embedChart(m *gomail.Message, png []byte) {
+	m.Embed(chartFilename, gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(png)
+		return err
+	}))
+}
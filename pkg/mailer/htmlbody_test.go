@@ -0,0 +1,167 @@
+package mailer
+
+import (
+	"bytes"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestRenderHTMLBody(t *testing.T) {
+	invoices := []provider.Invoice{
+		{Type: "Mobilfunk", MonthName: "Januar", Year: "2026", Amount: 24.98, Filename: "2026-01-mobilfunk.pdf"},
+		{Type: "Kabel", MonthName: "Januar", Year: "2026", Filename: "2026-01-kabel.pdf", Fallback: true},
+	}
+	failures := []provider.Failure{{Type: "DSL", Reason: "<timeout>"}}
+
+	tests := []struct {
+		name     string
+		invoices []provider.Invoice
+		failures []provider.Failure
+		chart    bool
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "table",
+			invoices: invoices,
+			want: []string{
+				"<td>Mobilfunk</td><td>Januar 2026</td><td align=\"right\">24,98 €</td><td>2026-01-mobilfunk.pdf</td>",
+				"<td>Kabel</td><td>Januar 2026</td><td align=\"right\"></td><td>2026-01-kabel.pdf (nur Ausdruck der Rechnungsseite)</td>",
+			},
+			notWant: []string{"<img", "Nicht abgerufen"},
+		},
+		{
+			name:     "failures escaped",
+			failures: failures,
+			want:     []string{"<li>DSL: &lt;timeout&gt;</li>"},
+			notWant:  []string{"<table"},
+		},
+		{
+			name:     "chart",
+			invoices: invoices,
+			chart:    true,
+			want:     []string{`<img src="cid:` + chartFilename + `"`},
+		},
+	}
+
+	tmpl, err := LoadHTMLTemplate("")
+	if err != nil {
+		t.Fatalf("loadHTMLTemplate() error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := renderHTMLBody(tmpl, tt.invoices, tt.failures, nil, tt.chart)
+			if err != nil {
+				t.Fatalf("renderHTMLBody() error: %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(body, s) {
+					t.Errorf("body missing %q:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %q:\n%s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadHTMLTemplate(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "custom.html")
+	os.WriteFile(custom, []byte(`{{range .Invoices}}{{.Filename}}={{.Amount}};{{end}}`), 0600)
+	broken := filepath.Join(dir, "broken.html")
+	os.WriteFile(broken, []byte(`{{range .Invoices}}`), 0600)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "custom", path: custom, want: "a.pdf=1,50 €;"},
+		{name: "missing", path: filepath.Join(dir, "missing.html"), wantErr: true},
+		{name: "broken", path: broken, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := LoadHTMLTemplate(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadHTMLTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			body, err := renderHTMLBody(tmpl, []provider.Invoice{{Filename: "a.pdf", Amount: 1.5}}, nil, nil, false)
+			if err != nil {
+				t.Fatalf("renderHTMLBody() error: %v", err)
+			}
+			if body != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeEmailHTML(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+	msg := sender.Compose([]provider.Invoice{{Type: "Mobilfunk", MonthName: "Januar", Year: "2026", Filename: "x.pdf"}}, nil, nil, nil)
+
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	raw := buf.String()
+	for _, s := range []string{"multipart/alternative", "text/plain", "text/html"} {
+		if !strings.Contains(raw, s) {
+			t.Errorf("message missing %q", s)
+		}
+	}
+}
+
+func TestEmbedChart(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+
+	m := sender.BuildMessage(nil, nil, nil)
+	embedChart(m, []byte("fake-png"))
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType failed: %v", err)
+	}
+	if mediaType != "multipart/related" {
+		t.Fatalf("Content-Type = %s, want multipart/related", mediaType)
+	}
+
+	var foundImage bool
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		if strings.Contains(part.Header.Get("Content-ID"), chartFilename) {
+			foundImage = true
+		}
+	}
+	if !foundImage {
+		t.Error("inline chart image not found")
+	}
+}
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	gomail "gopkg.in/gomail.v2"
+)
+
+// defaultIMAPFolder is the folder sent emails are appended to unless email.imap.folder
+// is set.
+const defaultIMAPFolder = "Sent"
+
+// imapTimeout bounds the whole IMAP session.
+const imapTimeout = time.Minute
+
+// CheckIMAP validates the email.imap section.
+func CheckIMAP(c IMAPConfig) error {
+	if c.Host == "" {
+		return nil
+	}
+	if c.User == "" {
+		return fmt.Errorf("email.imap needs a user")
+	}
+	if _, err := smtpTLSConfig(c.Host, c.TLS); err != nil {
+		return fmt.Errorf("email.imap: %v", err)
+	}
+	return nil
+}
+
+// appendIMAP stores msg as read in the configured folder, so emails sent via SMTP show
+// up in the sender's Sent mailbox. The connection uses implicit TLS (port 993).
+func appendIMAP(c IMAPConfig, msg *gomail.Message) error {
+	var raw bytes.Buffer
+	if _, err := msg.WriteTo(&raw); err != nil {
+		return err
+	}
+	tlsConfig, err := smtpTLSConfig(c.Host, c.TLS)
+	if err != nil {
+		return err
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: imapTimeout}, "tcp", net.JoinHostPort(c.Host, orDefault(c.Port, "993")), tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(imapTimeout))
+
+	s := &imapSession{conn: conn, r: bufio.NewReader(conn)}
+	if greeting, err := s.readLine(); err != nil {
+		return err
+	} else if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return fmt.Errorf("unexpected greeting %q", greeting)
+	}
+	if err := s.command("LOGIN "+imapQuote(c.User)+" "+imapQuote(c.Pass), nil); err != nil {
+		return fmt.Errorf("login: %v", err)
+	}
+	folder := orDefault(c.Folder, defaultIMAPFolder)
+	literal := raw.Bytes()
+	if err := s.command(fmt.Sprintf(`APPEND %s (\Seen) {%d}`, imapQuote(folder), len(literal)), literal); err != nil {
+		return fmt.Errorf("append to %s: %v", folder, err)
+	}
+	s.command("LOGOUT", nil)
+	return nil
+}
+
+// imapSession is a connection to an IMAP server, just enough for LOGIN and APPEND.
+type imapSession struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+func (s *imapSession) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// command sends a tagged command and waits for its completion. A literal is sent
+// once the server asks for it with a continuation request.
+func (s *imapSession) command(cmd string, literal []byte) error {
+	s.tag++
+	tag := fmt.Sprintf("a%d", s.tag)
+	if _, err := fmt.Fprintf(s.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return err
+	}
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "+") && literal != nil:
+			if _, err := s.conn.Write(append(literal, "\r\n"...)); err != nil {
+				return err
+			}
+			literal = nil
+		case strings.HasPrefix(line, tag+" "):
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return fmt.Errorf("%s", status)
+			}
+			return nil
+		}
+	}
+}
+
+// imapQuote returns s as an IMAP quoted string.
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,158 @@
+package mailer
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeIMAPServer accepts LOGIN for one account and records appended messages.
+type fakeIMAPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	folders  []string
+	messages []string
+}
+
+func newFakeIMAPServer(t *testing.T) *fakeIMAPServer {
+	t.Helper()
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeIMAPServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("* OK IMAP4rev1 ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		switch {
+		case strings.HasPrefix(cmd, "LOGIN "):
+			if cmd != `LOGIN "anna@example.com" "p\"w"` {
+				reply(tag + " NO [AUTHENTICATIONFAILED] Invalid credentials")
+				continue
+			}
+			reply(tag + " OK LOGIN completed")
+		case strings.HasPrefix(cmd, "APPEND "):
+			open := strings.LastIndex(cmd, "{")
+			size, _ := strconv.Atoi(strings.TrimSuffix(cmd[open+1:], "}"))
+			reply("+ Ready for literal data")
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.folders = append(s.folders, strings.TrimSpace(cmd[len("APPEND "):open]))
+			s.messages = append(s.messages, string(data[:size]))
+			s.mu.Unlock()
+			reply(tag + " OK APPEND completed")
+		case cmd == "LOGOUT":
+			reply("* BYE")
+			reply(tag + " OK LOGOUT completed")
+			return
+		default:
+			reply(tag + " BAD unknown command")
+		}
+	}
+}
+
+func (s *fakeIMAPServer) port() string {
+	return fmt.Sprint(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestAppendIMAP(t *testing.T) {
+	srv := newFakeIMAPServer(t)
+	imap := IMAPConfig{Host: "127.0.0.1", Port: srv.port(), User: "anna@example.com", Pass: `p"w`, TLS: SMTPTLSConfig{InsecureSkipVerify: true}}
+
+	t.Run("default folder", func(t *testing.T) {
+		m := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+		if err := appendIMAP(imap, m.AlertMessage("", "Test", "Hallo\n")); err != nil {
+			t.Fatalf("appendIMAP() error: %v", err)
+		}
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		if len(srv.messages) != 1 || srv.folders[0] != `"Sent" (\Seen)` || !strings.Contains(srv.messages[0], "Subject: Test\r\n") {
+			t.Errorf("folders = %v, messages = %q", srv.folders, srv.messages)
+		}
+	})
+
+	t.Run("after delivery", func(t *testing.T) {
+		c := imap
+		c.Folder = "Gesendete Elemente"
+		m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "mbox", Mailbox: t.TempDir() + "/mbox", IMAP: c}, SMTPConfig{})
+		if err := m.SendMessage(m.AlertMessage("", "Zweite", "Hallo\n")); err != nil {
+			t.Fatalf("sendMessage() error: %v", err)
+		}
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		if len(srv.folders) != 2 || srv.folders[1] != `"Gesendete Elemente" (\Seen)` {
+			t.Errorf("folders = %v", srv.folders)
+		}
+	})
+
+	t.Run("rejected login", func(t *testing.T) {
+		c := imap
+		c.Pass = "wrong"
+		m := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+		err := appendIMAP(c, m.AlertMessage("", "Test", "Hallo\n"))
+		if err == nil || !strings.Contains(err.Error(), "AUTHENTICATIONFAILED") {
+			t.Errorf("appendIMAP() error = %v, want login failure", err)
+		}
+	})
+}
+
+func TestCheckIMAP(t *testing.T) {
+	tests := []struct {
+		name    string
+		imap    IMAPConfig
+		wantErr bool
+	}{
+		{name: "not configured"},
+		{name: "complete", imap: IMAPConfig{Host: "imap.example.com", User: "anna"}},
+		{name: "no user", imap: IMAPConfig{Host: "imap.example.com"}, wantErr: true},
+		{name: "bad tls", imap: IMAPConfig{Host: "imap.example.com", User: "anna", TLS: SMTPTLSConfig{MinVersion: "ssl3"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckIMAP(tt.imap); (err != nil) != tt.wantErr {
+				t.Errorf("checkIMAP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIMAPQuote(t *testing.T) {
+	if got := imapQuote(`a"b\c`); got != `"a\"b\\c"` {
+		t.Errorf("imapQuote() = %s", got)
+	}
+}
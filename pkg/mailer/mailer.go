@@ -0,0 +1,393 @@
+// Package mailer sends the downloaded invoices by email, via SMTP, a local mailbox or
+// an HTTP API, together with the notifications about failed and unusual runs.
+package mailer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// defaultSubject is the subject of the invoice email.
+const defaultSubject = "Deine PDF-Rechnungen von Vodafone"
+
+// defaultInvoiceSubject is the subject of per-invoice emails.
+const defaultInvoiceSubject = "Vodafone-Rechnung {{.Type}} {{.Month}}/{{.Year}}"
+
+// anomalySubjectPrefix is prepended to the invoice email subject when any invoice was flagged.
+const anomalySubjectPrefix = "[Prüfen] "
+
+// Mailer builds and sends the invoice and notification emails.
+type Mailer struct {
+	email Config
+	smtp  SMTPConfig
+	Retry *provider.RetryPolicy // nil sends once
+	Extra []string              // additional recipients of this run's invoice email
+
+	deliverer Deliverer // created from email.delivery on the first send
+}
+
+// New creates a Mailer sending from and to the given addresses via the given
+// SMTP server.
+func New(email Config, smtp SMTPConfig) *Mailer {
+	return &Mailer{email: email, smtp: smtp}
+}
+
+// StorageStatus is the outcome of storing this run's invoices in one target.
+type StorageStatus struct {
+	Target   string
+	Stored   int
+	Errors   []string
+	Versions []VersionedFile
+}
+
+// VersionedFile records that a PDF was stored under a versioned name because a file
+// with different content already had its name.
+type VersionedFile struct {
+	Original string
+	Stored   string
+	Version  int // e.g. 2 for "..._v2.pdf"
+}
+
+// BuildMessage constructs the email message with invoice details and PDF attachments.
+// Contracts that failed in this run and the storage status are listed in separate
+// sections of the body.
+func (m *Mailer) BuildMessage(invoices []provider.Invoice, failures []provider.Failure, stored []StorageStatus) *gomail.Message {
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", m.email.From)
+	msg.SetHeader("To", mergeRecipients(m.email.To, m.Extra)...)
+	if cc := ruleRecipients(invoices); len(cc) > 0 {
+		msg.SetHeader("Cc", cc...)
+	}
+	switch messagePriority(invoices) {
+	case "high":
+		msg.SetHeader("X-Priority", "1 (Highest)")
+		msg.SetHeader("Importance", "high")
+	case "low":
+		msg.SetHeader("X-Priority", "5 (Lowest)")
+		msg.SetHeader("Importance", "low")
+	}
+	setting, subject := "email.subject", orDefault(m.email.Subject, defaultSubject)
+	if m.email.PerInvoice && len(invoices) == 1 {
+		setting, subject = "email.invoice_subject", orDefault(m.email.InvoiceSubject, defaultInvoiceSubject)
+	}
+	if expanded, err := ExpandSubject(subject, invoices); err == nil {
+		subject = expanded
+	} else {
+		slog.Warn("Expanding "+setting+" failed", "err", err)
+	}
+	if flagged(invoices) {
+		subject = anomalySubjectPrefix + subject
+	}
+	msg.SetHeader("Subject", subject)
+
+	msg.SetBody("text/plain", messageBody(invoices, failures, stored))
+
+	// Attach each invoice PDF from its in-memory byte slice
+	for _, inv := range invoices {
+		if len(inv.PDFData) == 0 {
+			continue
+		}
+		pdfData := inv.PDFData
+		msg.Attach(inv.Filename, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(pdfData)
+			return err
+		}))
+	}
+	AttachScreenshot(msg, failures)
+
+	return msg
+}
+
+// flagged reports whether any invoice was flagged as unusual.
+func flagged(invoices []provider.Invoice) bool {
+	for _, inv := range invoices {
+		if inv.Anomaly != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectData is the data of the email.subject and email.invoice_subject templates.
+type subjectData struct {
+	Type      string // contract type of a single invoice, e.g. "Kabel"
+	MonthName string // e.g. "Februar"
+	Month     string // e.g. "02"
+	Year      string
+	Count     int // number of invoices in the email
+}
+
+// ExpandSubject renders subject as a text/template, e.g. "Vodafone Rechnungen
+// {{.MonthName}} {{.Year}}". The month is the latest one among the invoices. The
+// {type}, {month} and {year} placeholders of earlier versions still work for a
+// single invoice.
+func ExpandSubject(subject string, invoices []provider.Invoice) (string, error) {
+	data := subjectData{Count: len(invoices)}
+	if len(invoices) == 1 {
+		data.Type = invoices[0].Type
+		subject = provider.ExpandPlaceholders(subject, invoices[0])
+	}
+	tmpl, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return "", err
+	}
+	for _, inv := range invoices {
+		if inv.Year+inv.Month > data.Year+data.Month {
+			data.MonthName, data.Month, data.Year = inv.MonthName, inv.Month, inv.Year
+		}
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// messageBody lists the attached invoices with any extra charges and tariff mismatches
+// and, if any, the contracts that failed and the outcome per storage target.
+func messageBody(invoices []provider.Invoice, failures []provider.Failure, stored []StorageStatus) string {
+	var body strings.Builder
+	body.WriteString("Dokumente anbei.\n")
+	if len(invoices) > 0 {
+		body.WriteString("\n")
+		for _, inv := range invoices {
+			fmt.Fprintf(&body, "%s: %s %s", inv.Type, inv.MonthName, inv.Year)
+			if inv.Amount > 0 {
+				fmt.Fprintf(&body, " — %s", provider.FormatAmount(inv.Amount))
+			}
+			if inv.Fallback {
+				body.WriteString(" (nur Ausdruck der Rechnungsseite, Rechnungs-PDF nicht abrufbar)")
+			}
+			body.WriteString("\n")
+			for _, extra := range inv.Extras {
+				fmt.Fprintf(&body, "  + %s: %s\n", extra.Description, provider.FormatAmount(extra.Amount))
+			}
+			if inv.Tariff != "" {
+				fmt.Fprintf(&body, "  ! %s\n", inv.Tariff)
+			}
+		}
+	}
+	if len(failures) > 0 {
+		body.WriteString("\nNicht abgerufen (nächster Versuch beim nächsten Lauf):\n")
+		for _, f := range failures {
+			fmt.Fprintf(&body, "%s: %s\n", f.Type, f.Reason)
+		}
+	}
+	if len(stored) > 0 {
+		body.WriteString("\nAblage:\n")
+		for _, s := range stored {
+			if len(s.Errors) == 0 {
+				fmt.Fprintf(&body, "%s: %d gespeichert\n", s.Target, s.Stored)
+				continue
+			}
+			fmt.Fprintf(&body, "%s: %d gespeichert, %d fehlgeschlagen (%s)\n", s.Target, s.Stored, len(s.Errors), strings.Join(s.Errors, "; "))
+		}
+		for _, s := range stored {
+			for _, v := range s.Versions {
+				fmt.Fprintf(&body, "%s: %s als %s gespeichert (abweichender Inhalt)\n", s.Target, v.Original, v.Stored)
+			}
+		}
+	}
+	return body.String()
+}
+
+// Compose builds the invoice email with all invoice PDFs as attachments and an
+// HTML version of the body rendered from the template. If chartPNG is non-nil, it is
+// shown inline in the HTML version. If the template fails, the email is sent as plain
+// text only.
+func (m *Mailer) Compose(invoices []provider.Invoice, failures []provider.Failure, stored []StorageStatus, chartPNG []byte) *gomail.Message {
+	msg := m.BuildMessage(invoices, failures, stored)
+	tmpl, err := LoadHTMLTemplate(m.email.HTMLTemplate)
+	var body string
+	if err == nil {
+		body, err = renderHTMLBody(tmpl, invoices, failures, stored, chartPNG != nil)
+	}
+	if err != nil {
+		slog.Warn("HTML email body failed, sending plain text only", "err", err)
+		return msg
+	}
+	msg.AddAlternative("text/html", body)
+	if chartPNG != nil {
+		embedChart(msg, chartPNG)
+	}
+	return msg
+}
+
+// Send builds the invoice email and sends it via SMTP/TLS, and returns the invoices
+// that were delivered. With email.per_invoice, every invoice is sent as its own email
+// with its own subject; the remaining emails are still sent if one fails, and the
+// first error is returned along with the invoices that went out.
+func (m *Mailer) Send(invoices []provider.Invoice, failures []provider.Failure, stored []StorageStatus, chartPNG []byte) ([]provider.Invoice, error) {
+	if !m.email.PerInvoice {
+		if err := m.SendMessage(m.Compose(invoices, failures, stored, chartPNG)); err != nil {
+			return nil, err
+		}
+		return invoices, nil
+	}
+	var sent []provider.Invoice
+	var firstErr error
+	for _, inv := range invoices {
+		err := m.SendMessage(m.Compose([]provider.Invoice{inv}, failures, stored, chartPNG))
+		switch {
+		case err == nil:
+			sent = append(sent, inv)
+		case firstErr == nil:
+			firstErr = fmt.Errorf("%s: %w", inv.Filename, err)
+		}
+	}
+	return sent, firstErr
+}
+
+// smtpDeliverer sends messages via SMTP/TLS, the default delivery.
+type smtpDeliverer struct {
+	smtp  SMTPConfig
+	retry *provider.RetryPolicy
+	token *OAuth2Token // XOAUTH2 access token, refreshed when expired
+}
+
+func (s *smtpDeliverer) Name() string { return "smtp" }
+
+func (s *smtpDeliverer) Deliver(msg *gomail.Message) error {
+	d, err := s.newDialer()
+	if err != nil {
+		return err
+	}
+	return s.retry.Do(provider.StageSend, func() error { return d.DialAndSend(msg) })
+}
+
+// newDialer creates an SMTP dialer from the configured credentials and TLS settings.
+// With smtp.oauth2, it authenticates with XOAUTH2 and an access token that is
+// refreshed when expired.
+func (s *smtpDeliverer) newDialer() (*gomail.Dialer, error) {
+	port, err := strconv.Atoi(s.smtp.Port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP port: %v", err)
+	}
+	tlsConfig, err := smtpTLSConfig(s.smtp.Host, s.smtp.TLS)
+	if err != nil {
+		return nil, err
+	}
+	d := gomail.NewDialer(s.smtp.Host, port, s.smtp.User, s.smtp.Pass)
+	d.TLSConfig = tlsConfig
+	if s.smtp.OAuth2.RefreshToken != "" {
+		if now := time.Now(); !s.token.Valid(now) {
+			if s.token, err = RefreshOAuth2Token(s.smtp.OAuth2, now); err != nil {
+				return nil, fmt.Errorf("smtp.oauth2: %v", err)
+			}
+		}
+		d.Auth = &xoauth2Auth{user: s.smtp.User, token: s.token.Value}
+	}
+	return d, nil
+}
+
+// DialSMTP connects to the SMTP server and logs in without sending anything, to test
+// the settings.
+func DialSMTP(c SMTPConfig) error {
+	d, err := (&smtpDeliverer{smtp: c}).newDialer()
+	if err != nil {
+		return err
+	}
+	conn, err := d.Dial()
+	if err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrDelivery, err)
+	}
+	return conn.Close()
+}
+
+// smtpTLSVersions are the accepted values of smtp.tls.min_version.
+var smtpTLSVersions = map[string]uint16{"1.0": tls.VersionTLS10, "1.1": tls.VersionTLS11, "1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+
+// smtpTLSConfig builds the TLS configuration of the SMTP connection. The CA bundle is
+// trusted in addition to the system roots, so relays with a private CA work without
+// disabling verification.
+func smtpTLSConfig(host string, c SMTPTLSConfig) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host, InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.MinVersion != "" {
+		version, ok := smtpTLSVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid smtp.tls.min_version %q", c.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("smtp.tls.ca_file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("smtp.tls.ca_file: no certificates found in %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("smtp.tls client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// AlertMessage constructs a plain text notification email. It is sent to the
+// given recipient, or to the regular invoice recipient if to is empty.
+func (m *Mailer) AlertMessage(to, subject, body string) *gomail.Message {
+	if to == "" {
+		to = m.email.To
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", m.email.From)
+	msg.SetHeader("To", to)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/plain", body)
+	return msg
+}
+
+// SendMessage delivers a prepared message with the backend chosen by email.delivery
+// and, with email.imap, appends it to the Sent folder. Delivery errors wrap
+// provider.ErrDelivery whichever backend is used; a failed append is only logged.
+func (m *Mailer) SendMessage(msg *gomail.Message) error {
+	if m.deliverer == nil {
+		d, err := newDeliverer(m.email, m.smtp, m.Retry)
+		if err != nil {
+			return fmt.Errorf("%w: %v", provider.ErrDelivery, err)
+		}
+		m.deliverer = d
+	}
+	if err := m.deliverer.Deliver(msg); err != nil {
+		return fmt.Errorf("%w: %s: %v", provider.ErrDelivery, m.deliverer.Name(), err)
+	}
+	if m.email.IMAP.Host != "" {
+		if err := appendIMAP(m.email.IMAP, msg); err != nil {
+			slog.Warn("Saving the email via IMAP failed", "folder", orDefault(m.email.IMAP.Folder, defaultIMAPFolder), "err", err)
+		}
+	}
+	return nil
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,701 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// writeTestCert writes a self-signed certificate and its key as PEM files to dir.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Internal Relay CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestSMTPTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	garbage := filepath.Join(dir, "garbage.pem")
+	os.WriteFile(garbage, []byte("not a certificate"), 0600)
+
+	t.Run("defaults", func(t *testing.T) {
+		c, err := smtpTLSConfig("smtp.example.com", SMTPTLSConfig{})
+		if err != nil {
+			t.Fatalf("smtpTLSConfig() error: %v", err)
+		}
+		if c.ServerName != "smtp.example.com" || c.InsecureSkipVerify || c.RootCAs != nil || c.MinVersion != 0 {
+			t.Errorf("smtpTLSConfig() = %+v, want verification against system roots", c)
+		}
+	})
+
+	t.Run("all options", func(t *testing.T) {
+		c, err := smtpTLSConfig("relay.internal", SMTPTLSConfig{MinVersion: "1.3", CAFile: certFile, CertFile: certFile, KeyFile: keyFile, InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("smtpTLSConfig() error: %v", err)
+		}
+		if c.MinVersion != tls.VersionTLS13 {
+			t.Errorf("MinVersion = %x, want TLS 1.3", c.MinVersion)
+		}
+		if c.RootCAs == nil || len(c.Certificates) != 1 || !c.InsecureSkipVerify {
+			t.Errorf("smtpTLSConfig() = %+v, want CA pool, client certificate and skip verify", c)
+		}
+	})
+
+	for name, tc := range map[string]SMTPTLSConfig{
+		"unknown version":  {MinVersion: "1.4"},
+		"missing CA file":  {CAFile: filepath.Join(dir, "missing.pem")},
+		"CA without certs": {CAFile: garbage},
+		"cert without key": {CertFile: certFile},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := smtpTLSConfig("smtp.example.com", tc); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewDialerTLS(t *testing.T) {
+	s := &smtpDeliverer{smtp: SMTPConfig{Host: "relay.internal", Port: "587", TLS: SMTPTLSConfig{MinVersion: "1.2"}}}
+	d, err := s.newDialer()
+	if err != nil {
+		t.Fatalf("newDialer() error: %v", err)
+	}
+	if d.TLSConfig == nil || d.TLSConfig.ServerName != "relay.internal" || d.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("TLSConfig = %+v", d.TLSConfig)
+	}
+
+	s = &smtpDeliverer{smtp: SMTPConfig{Host: "relay.internal", Port: "587", TLS: SMTPTLSConfig{MinVersion: "ssl3"}}}
+	if _, err := s.newDialer(); err == nil {
+		t.Error("expected error for invalid min_version, got nil")
+	}
+}
+
+// fakeSMTPServer accepts plain SMTP connections and records the subject of every
+// message it receives. Messages whose subject contains reject are refused.
+type fakeSMTPServer struct {
+	listener net.Listener
+	reject   string
+	mu       sync.Mutex
+	subjects []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			rejected := false
+			for {
+				data, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" {
+					break
+				}
+				if subject, ok := strings.CutPrefix(data, "Subject: "); ok {
+					decoded, _ := new(mime.WordDecoder).DecodeHeader(strings.TrimSpace(subject))
+					s.mu.Lock()
+					s.subjects = append(s.subjects, decoded)
+					s.mu.Unlock()
+					rejected = s.reject != "" && strings.Contains(decoded, s.reject)
+				}
+			}
+			if rejected {
+				reply("554 rejected")
+				continue
+			}
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) port() string {
+	return fmt.Sprint(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestSendPerInvoice(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	invoices := []provider.Invoice{
+		{Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", Type: "Mobilfunk", Month: "02", Year: "2026", MonthName: "Februar", PDFData: []byte("%PDF-m")},
+		{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", MonthName: "Februar", PDFData: []byte("%PDF-k")},
+	}
+
+	sender := New(Config{From: "a@b.com", To: "c@d.com", PerInvoice: true, InvoiceSubject: "Rechnung {{.Type}} {{.Year}}-{{.Month}}"},
+		SMTPConfig{Host: "127.0.0.1", Port: srv.port()})
+	sent, err := sender.Send(invoices, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("Send() = %d sent invoices, want 2", len(sent))
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if want := []string{"Rechnung Mobilfunk 2026-02", "Rechnung Kabel 2026-02"}; !reflect.DeepEqual(srv.subjects, want) {
+		t.Errorf("subjects = %v, want %v", srv.subjects, want)
+	}
+}
+
+func TestSendPerInvoicePartialFailure(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	srv.reject = "Mobilfunk"
+	invoices := []provider.Invoice{
+		{Filename: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf", Type: "Mobilfunk", Month: "02", Year: "2026", MonthName: "Februar", PDFData: []byte("%PDF-m")},
+		{Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", MonthName: "Februar", PDFData: []byte("%PDF-k")},
+	}
+
+	sender := New(Config{From: "a@b.com", To: "c@d.com", PerInvoice: true}, SMTPConfig{Host: "127.0.0.1", Port: srv.port()})
+	sent, err := sender.Send(invoices, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "Mobilfunk.pdf") {
+		t.Errorf("Send() error = %v, want the Mobilfunk invoice's error", err)
+	}
+	if len(sent) != 1 || sent[0].Type != "Kabel" {
+		t.Errorf("Send() sent = %v, want only the Kabel invoice", sent)
+	}
+}
+
+func TestDialSMTP(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	if err := DialSMTP(SMTPConfig{Host: "127.0.0.1", Port: srv.port()}); err != nil {
+		t.Errorf("DialSMTP() error: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := fmt.Sprint(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+	if err := DialSMTP(SMTPConfig{Host: "127.0.0.1", Port: closed}); !errors.Is(err, provider.ErrDelivery) {
+		t.Errorf("DialSMTP() to a closed port error = %v, want ErrDelivery", err)
+	}
+	if err := DialSMTP(SMTPConfig{Host: "127.0.0.1", Port: "smtp"}); err == nil {
+		t.Error("DialSMTP() with an invalid port succeeded")
+	}
+}
+
+func TestBuildMessagePerInvoiceDefaultSubject(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com", PerInvoice: true}, SMTPConfig{})
+	m := sender.BuildMessage([]provider.Invoice{{Type: "Kabel", Month: "02", Year: "2026"}}, nil, nil)
+	if got := m.GetHeader("Subject"); len(got) != 1 || got[0] != "Vodafone-Rechnung Kabel 02/2026" {
+		t.Errorf("Subject = %v", got)
+	}
+}
+
+func TestExpandSubject(t *testing.T) {
+	invoices := []provider.Invoice{
+		{Type: "Kabel", Month: "01", Year: "2026", MonthName: "Januar"},
+		{Type: "Mobilfunk", Month: "02", Year: "2026", MonthName: "Februar"},
+	}
+	tests := []struct {
+		name     string
+		subject  string
+		invoices []provider.Invoice
+		want     string
+		wantErr  bool
+	}{
+		{name: "static", subject: "Deine PDF-Rechnungen von Vodafone", invoices: invoices, want: "Deine PDF-Rechnungen von Vodafone"},
+		{name: "latest month", subject: "Vodafone Rechnungen {{.MonthName}} {{.Year}}", invoices: invoices, want: "Vodafone Rechnungen Februar 2026"},
+		{name: "count", subject: "{{.Count}} Rechnung(en) {{.Month}}/{{.Year}}", invoices: invoices[:1], want: "1 Rechnung(en) 01/2026"},
+		{name: "no invoices", subject: "Rechnungen {{.MonthName}}", want: "Rechnungen "},
+		{name: "type of a single invoice", subject: "Vodafone-Rechnung {{.Type}} {{.MonthName}}", invoices: invoices[1:], want: "Vodafone-Rechnung Mobilfunk Februar"},
+		{name: "no type for several invoices", subject: "Rechnungen {{.Type}}", invoices: invoices, want: "Rechnungen "},
+		{name: "old placeholders", subject: "Rechnung {type} {month}/{year} ({{.MonthName}})", invoices: invoices[:1], want: "Rechnung Kabel 01/2026 (Januar)"},
+		{name: "unknown field", subject: "{{.Monat}}", wantErr: true},
+		{name: "syntax", subject: "{{.Year", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandSubject(tt.subject, tt.invoices)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandSubject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandSubject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	tests := []struct {
+		name              string
+		invoices          []provider.Invoice
+		wantSubject       string
+		wantBodyContains  []string
+		wantAttachments   []string // expected filenames
+		wantNoAttachments bool
+	}{
+		{
+			name: "single invoice",
+			invoices: []provider.Invoice{
+				{
+					Filename:  "02_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+					Month:     "02",
+					Year:      "2026",
+					MonthName: "Februar",
+					Type:      "Mobilfunk",
+					PDFData:   []byte("%PDF-fake-content"),
+				},
+			},
+			wantSubject:      "Deine PDF-Rechnungen von Vodafone",
+			wantBodyContains: []string{"Mobilfunk: Februar 2026"},
+			wantAttachments:  []string{"02_2026_Rechnung_Vodafone_Mobilfunk.pdf"},
+		},
+		{
+			name: "multiple invoices",
+			invoices: []provider.Invoice{
+				{
+					Filename:  "02_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+					Month:     "02",
+					Year:      "2026",
+					MonthName: "Februar",
+					Type:      "Mobilfunk",
+					PDFData:   []byte("%PDF-mobilfunk"),
+				},
+				{
+					Filename:  "02_2026_Rechnung_Vodafone_Kabel.pdf",
+					Month:     "02",
+					Year:      "2026",
+					MonthName: "Februar",
+					Type:      "Kabel",
+					PDFData:   []byte("%PDF-kabel"),
+				},
+			},
+			wantSubject: "Deine PDF-Rechnungen von Vodafone",
+			wantBodyContains: []string{
+				"Mobilfunk: Februar 2026",
+				"Kabel: Februar 2026",
+			},
+			wantAttachments: []string{
+				"02_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+				"02_2026_Rechnung_Vodafone_Kabel.pdf",
+			},
+		},
+		{
+			name: "invoice with empty PDFData is skipped",
+			invoices: []provider.Invoice{
+				{
+					Filename:  "02_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+					Month:     "02",
+					Year:      "2026",
+					MonthName: "Februar",
+					Type:      "Mobilfunk",
+					PDFData:   nil,
+				},
+			},
+			wantSubject:       "Deine PDF-Rechnungen von Vodafone",
+			wantBodyContains:  []string{"Mobilfunk: Februar 2026"},
+			wantNoAttachments: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := New(
+				Config{From: "sender@example.com", To: "recipient@example.com"},
+				SMTPConfig{Host: "smtp.example.com", Port: "587", User: "sender@example.com", Pass: "pass"},
+			)
+
+			m := sender.BuildMessage(tc.invoices, nil, nil)
+
+			// Verify headers
+			if got := m.GetHeader("From"); len(got) != 1 || got[0] != "sender@example.com" {
+				t.Errorf("From = %v, want [sender@example.com]", got)
+			}
+			if got := m.GetHeader("To"); len(got) != 1 || got[0] != "recipient@example.com" {
+				t.Errorf("To = %v, want [recipient@example.com]", got)
+			}
+			if got := m.GetHeader("Subject"); len(got) != 1 || got[0] != tc.wantSubject {
+				t.Errorf("Subject = %v, want [%s]", got, tc.wantSubject)
+			}
+
+			// Write message to buffer and parse as MIME
+			var buf bytes.Buffer
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo failed: %v", err)
+			}
+
+			msg, err := mail.ReadMessage(&buf)
+			if err != nil {
+				t.Fatalf("ReadMessage failed: %v", err)
+			}
+
+			contentType := msg.Header.Get("Content-Type")
+			mediaType, params, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				t.Fatalf("ParseMediaType failed: %v", err)
+			}
+
+			if tc.wantNoAttachments {
+				// Without attachments, gomail produces a simple message (no multipart/mixed)
+				body, err := io.ReadAll(msg.Body)
+				if err != nil {
+					t.Fatalf("ReadAll body failed: %v", err)
+				}
+				bodyStr := string(body)
+				for _, want := range tc.wantBodyContains {
+					if !strings.Contains(bodyStr, want) {
+						t.Errorf("body missing %q", want)
+					}
+				}
+				return
+			}
+
+			if !strings.HasPrefix(mediaType, "multipart/") {
+				t.Fatalf("expected multipart, got %s", mediaType)
+			}
+
+			reader := multipart.NewReader(msg.Body, params["boundary"])
+			var bodyText string
+			var attachmentNames []string
+
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("NextPart failed: %v", err)
+				}
+
+				partData, err := io.ReadAll(part)
+				if err != nil {
+					t.Fatalf("ReadAll part failed: %v", err)
+				}
+
+				disposition := part.Header.Get("Content-Disposition")
+				if strings.HasPrefix(disposition, "attachment") {
+					_, dParams, _ := mime.ParseMediaType(disposition)
+					attachmentNames = append(attachmentNames, dParams["filename"])
+				} else {
+					bodyText += string(partData)
+				}
+			}
+
+			for _, want := range tc.wantBodyContains {
+				if !strings.Contains(bodyText, want) {
+					t.Errorf("body missing %q, got: %s", want, bodyText)
+				}
+			}
+
+			if len(attachmentNames) != len(tc.wantAttachments) {
+				t.Fatalf("got %d attachments %v, want %d %v",
+					len(attachmentNames), attachmentNames,
+					len(tc.wantAttachments), tc.wantAttachments)
+			}
+			for i, want := range tc.wantAttachments {
+				if attachmentNames[i] != want {
+					t.Errorf("attachment[%d] = %q, want %q", i, attachmentNames[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildMessageCustomSubject(t *testing.T) {
+	sender := New(Config{From: "sender@example.com", To: "recipient@example.com", Subject: "Custom Subject"}, SMTPConfig{})
+
+	m := sender.BuildMessage([]provider.Invoice{{
+		Filename: "test.pdf", Month: "02", Year: "2026",
+		MonthName: "Februar", Type: "Mobilfunk", PDFData: nil,
+	}}, nil, nil)
+
+	if got := m.GetHeader("Subject"); len(got) != 1 || got[0] != "Custom Subject" {
+		t.Errorf("Subject = %v, want [Custom Subject]", got)
+	}
+}
+
+func TestSendInvalidPort(t *testing.T) {
+	sender := New(
+		Config{From: "sender@example.com", To: "recipient@example.com"},
+		SMTPConfig{Host: "smtp.example.com", Port: "not-a-number", User: "sender@example.com", Pass: "pass"},
+	)
+
+	_, err := sender.Send([]provider.Invoice{
+		{
+			Filename:  "test.pdf",
+			Month:     "02",
+			Year:      "2026",
+			MonthName: "Februar",
+			Type:      "Mobilfunk",
+			PDFData:   []byte("%PDF-test"),
+		},
+	}, nil, nil, nil)
+
+	if err == nil {
+		t.Fatal("expected error for invalid port, got nil")
+	}
+	if !strings.Contains(err.Error(), "invalid SMTP port") {
+		t.Errorf("error = %q, want it to contain 'invalid SMTP port'", err.Error())
+	}
+}
+
+func TestSendEmptyPort(t *testing.T) {
+	sender := New(
+		Config{From: "a@b.com", To: "c@d.com"},
+		SMTPConfig{Host: "smtp.example.com", Port: "", User: "u", Pass: "p"},
+	)
+
+	_, err := sender.Send([]provider.Invoice{{
+		Filename: "test.pdf", Month: "01", Year: "2026",
+		MonthName: "Januar", Type: "Mobilfunk", PDFData: []byte("%PDF"),
+	}}, nil, nil, nil)
+
+	if err == nil {
+		t.Fatal("expected error for empty port, got nil")
+	}
+	if !strings.Contains(err.Error(), "invalid SMTP port") {
+		t.Errorf("error = %q, want it to contain 'invalid SMTP port'", err.Error())
+	}
+}
+
+func TestBuildMessageEmptyInvoices(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+
+	m := sender.BuildMessage([]provider.Invoice{}, nil, nil)
+
+	if got := m.GetHeader("Subject"); len(got) != 1 || got[0] != "Deine PDF-Rechnungen von Vodafone" {
+		t.Errorf("Subject = %v, want default subject", got)
+	}
+
+	// With no attachments, the message should still be valid
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	// Verify it's a parseable email message
+	if _, err := mail.ReadMessage(&buf); err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+}
+
+func TestBuildMessageAttachmentContent(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+
+	pdfContent := []byte("%PDF-1.4 test content here")
+	m := sender.BuildMessage([]provider.Invoice{{
+		Filename:  "01_2026_Rechnung_Vodafone_Mobilfunk.pdf",
+		Month:     "01",
+		Year:      "2026",
+		MonthName: "Januar",
+		Type:      "Mobilfunk",
+		PDFData:   pdfContent,
+	}}, nil, nil)
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+
+	contentType := msg.Header.Get("Content-Type")
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType failed: %v", err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var foundAttachment bool
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+
+		disposition := part.Header.Get("Content-Disposition")
+		if strings.HasPrefix(disposition, "attachment") {
+			foundAttachment = true
+			data, _ := io.ReadAll(part)
+			// Attachment is base64-encoded by gomail, just verify it's non-empty
+			if len(data) == 0 {
+				t.Error("attachment data should not be empty")
+			}
+		}
+	}
+	if !foundAttachment {
+		t.Error("expected at least one attachment")
+	}
+}
+
+func TestMessageBody(t *testing.T) {
+	invoices := []provider.Invoice{{Type: "Mobilfunk", MonthName: "Februar", Year: "2026"}}
+
+	t.Run("all succeeded", func(t *testing.T) {
+		body := messageBody(invoices, nil, nil)
+		if want := "Dokumente anbei.\n\nMobilfunk: Februar 2026\n"; body != want {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+	})
+
+	t.Run("amount", func(t *testing.T) {
+		withAmount := []provider.Invoice{{Type: "Mobilfunk", MonthName: "Februar", Year: "2026", Amount: 24.98}}
+		body := messageBody(withAmount, nil, nil)
+		if want := "Mobilfunk: Februar 2026 — 24,98 €\n"; !strings.Contains(body, want) {
+			t.Errorf("body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("extra charges", func(t *testing.T) {
+		withExtras := []provider.Invoice{{Type: "Mobilfunk", MonthName: "Februar", Year: "2026", Extras: []provider.ExtraCharge{{Description: "Roaming EU Zone 1", Amount: 3.5}}}}
+		body := messageBody(withExtras, nil, nil)
+		if want := "Mobilfunk: Februar 2026\n  + Roaming EU Zone 1: 3,50 €\n"; !strings.Contains(body, want) {
+			t.Errorf("body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("tariff mismatch", func(t *testing.T) {
+		mismatch := []provider.Invoice{{Type: "Kabel", MonthName: "Februar", Year: "2026", Tariff: "Grundgebühr 44,99 € statt Tarifpreis 39,99 €"}}
+		body := messageBody(mismatch, nil, nil)
+		if want := "Kabel: Februar 2026\n  ! Grundgebühr 44,99 € statt Tarifpreis 39,99 €\n"; !strings.Contains(body, want) {
+			t.Errorf("body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("page print fallback", func(t *testing.T) {
+		fallback := []provider.Invoice{{Type: "Kabel", MonthName: "Februar", Year: "2026", Fallback: true}}
+		body := messageBody(fallback, nil, nil)
+		if want := "Kabel: Februar 2026 (nur Ausdruck der Rechnungsseite, Rechnungs-PDF nicht abrufbar)\n"; !strings.Contains(body, want) {
+			t.Errorf("body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		body := messageBody(invoices, []provider.Failure{{Type: "Kabel", Reason: "PDF download failed: no PDF captured"}}, nil)
+		if !strings.Contains(body, "Mobilfunk: Februar 2026") {
+			t.Errorf("body missing successful invoice: %q", body)
+		}
+		if !strings.Contains(body, "Nicht abgerufen") {
+			t.Errorf("body missing failure section: %q", body)
+		}
+		if !strings.Contains(body, "Kabel: PDF download failed: no PDF captured") {
+			t.Errorf("body missing failure reason: %q", body)
+		}
+		if !strings.Contains(body, "nächster Versuch") {
+			t.Errorf("body missing next retry hint: %q", body)
+		}
+	})
+}
+
+func TestBuildMessageWithFailures(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+
+	m := sender.BuildMessage(
+		[]provider.Invoice{{Type: "Mobilfunk", MonthName: "Februar", Year: "2026", Filename: "m.pdf", PDFData: []byte("%PDF")}},
+		[]provider.Failure{{Type: "Kabel", Reason: "invoice page not reachable: timeout"}},
+		nil,
+	)
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Kabel: invoice page not reachable: timeout") {
+		t.Errorf("message missing failure section:\n%s", buf.String())
+	}
+}
+
+func TestMessageBodyStorageStatus(t *testing.T) {
+	body := messageBody(nil, nil, []StorageStatus{
+		{Target: "Lokal", Stored: 2, Versions: []VersionedFile{{Original: "k.pdf", Stored: "k_v2.pdf"}}},
+		{Target: "WebDAV", Stored: 1, Errors: []string{"k.pdf: disk full"}},
+	})
+
+	for _, want := range []string{"Ablage:\n", "Lokal: 2 gespeichert\n", "WebDAV: 1 gespeichert, 1 fehlgeschlagen (k.pdf: disk full)\n", "Lokal: k.pdf als k_v2.pdf gespeichert (abweichender Inhalt)\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
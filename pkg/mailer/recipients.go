@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRecipients reads the additional recipients of a single run, given with
+// --recipients-file. A .yaml/.yml file holds a list of addresses, either on its own or
+// under "recipients"; any other file is read as CSV with the address in the first
+// column. Empty lines, lines starting with # and a header row are skipped.
+func LoadRecipients(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		entries, err = parseRecipientsYAML(data)
+	default:
+		entries, err = parseRecipientsCSV(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var recipients []string
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		addr, err := mail.ParseAddress(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid address %q", path, entry)
+		}
+		if key := strings.ToLower(addr.Address); !seen[key] {
+			seen[key] = true
+			if addr.Name == "" {
+				recipients = append(recipients, addr.Address)
+			} else {
+				recipients = append(recipients, addr.String())
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("%s: no recipients", path)
+	}
+	return recipients, nil
+}
+
+// parseRecipientsYAML accepts either a plain list or a "recipients" list.
+func parseRecipientsYAML(data []byte) ([]string, error) {
+	var list []string
+	if err := yaml.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var doc struct {
+		Recipients []string `yaml:"recipients"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Recipients, nil
+}
+
+// parseRecipientsCSV returns the first column of each row. Further columns, e.g. a
+// note on why the address was added, are ignored.
+func parseRecipientsCSV(text string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var entries []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := strings.TrimSpace(record[0])
+		if field == "" || (len(entries) == 0 && !strings.Contains(field, "@")) {
+			continue
+		}
+		entries = append(entries, field)
+	}
+}
+
+// mergeRecipients returns the configured recipient followed by the extra ones that
+// are not the same address.
+func mergeRecipients(to string, extra []string) []string {
+	merged := []string{to}
+	for _, r := range extra {
+		if a, err := mail.ParseAddress(r); err == nil && strings.EqualFold(a.Address, to) {
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// ruleRecipients returns the additional recipients of the invoices, each listed once.
+func ruleRecipients(invoices []provider.Invoice) []string {
+	var recipients []string
+	seen := map[string]bool{}
+	for _, inv := range invoices {
+		for _, r := range inv.Notify {
+			if !seen[r] {
+				seen[r] = true
+				recipients = append(recipients, r)
+			}
+		}
+	}
+	return recipients
+}
+
+// priorityRank orders the priorities; unset counts as normal.
+var priorityRank = map[string]int{"low": 0, "": 1, "normal": 1, "high": 2}
+
+// messagePriority returns the priority of an email carrying the invoices: high if any
+// invoice is high, low only if all of them are low.
+func messagePriority(invoices []provider.Invoice) string {
+	priority := ""
+	for i, inv := range invoices {
+		if i == 0 || priorityRank[inv.Priority] > priorityRank[priority] {
+			priority = inv.Priority
+		}
+	}
+	return priority
+}
@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestLoadRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "yaml list",
+			file:    "extra.yaml",
+			content: "- steuer@example.com\n- Steuerbüro <kanzlei@example.com>\n",
+			want:    []string{"steuer@example.com", "=?utf-8?q?Steuerb=C3=BCro?= <kanzlei@example.com>"},
+		},
+		{
+			name:    "yaml recipients key",
+			file:    "extra.yml",
+			content: "recipients:\n  - steuer@example.com\n",
+			want:    []string{"steuer@example.com"},
+		},
+		{
+			name:    "csv with header, comment and note column",
+			file:    "extra.csv",
+			content: "email,note\n# December only\nsteuer@example.com, Jahresabschluss\n\nSTEUER@example.com\n",
+			want:    []string{"steuer@example.com"},
+		},
+		{
+			name:    "invalid address",
+			file:    "extra.csv",
+			content: "steuer@example.com\nnot an address\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			file:    "extra.yaml",
+			content: "recipients: []\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadRecipients(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadRecipients() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadRecipients() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMessageExtraRecipients(t *testing.T) {
+	m := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+	m.Extra = []string{"C@d.com", "steuer@example.com"}
+	msg := m.BuildMessage([]provider.Invoice{{Type: "Kabel", Month: "12", Year: "2025", Filename: "x.pdf", PDFData: []byte("%PDF")}}, nil, nil)
+	if got := msg.GetHeader("To"); !reflect.DeepEqual(got, []string{"c@d.com", "steuer@example.com"}) {
+		t.Errorf("To = %q", got)
+	}
+}
+
+func TestMessagePriority(t *testing.T) {
+	tests := []struct {
+		priorities []string
+		want       string
+	}{
+		{priorities: nil, want: ""},
+		{priorities: []string{"", "high"}, want: "high"},
+		{priorities: []string{"low", "low"}, want: "low"},
+		{priorities: []string{"low", ""}, want: ""},
+		{priorities: []string{"low", "high", ""}, want: "high"},
+	}
+
+	for _, tc := range tests {
+		var invoices []provider.Invoice
+		for _, p := range tc.priorities {
+			invoices = append(invoices, provider.Invoice{Priority: p})
+		}
+		if got := messagePriority(invoices); got != tc.want {
+			t.Errorf("messagePriority(%q) = %q, want %q", tc.priorities, got, tc.want)
+		}
+	}
+}
+
+func TestBuildMessageRuleHeaders(t *testing.T) {
+	sender := New(Config{From: "a@b.com", To: "c@d.com"}, SMTPConfig{})
+	msg := sender.BuildMessage([]provider.Invoice{
+		{Type: "Kabel", Notify: []string{"partner@example.com"}, Priority: "high"},
+	}, nil, nil)
+
+	if got := msg.GetHeader("Cc"); !reflect.DeepEqual(got, []string{"partner@example.com"}) {
+		t.Errorf("Cc = %v", got)
+	}
+	if got := msg.GetHeader("Importance"); !reflect.DeepEqual(got, []string{"high"}) {
+		t.Errorf("Importance = %v, want high", got)
+	}
+
+	msg = sender.BuildMessage([]provider.Invoice{{Type: "Kabel"}}, nil, nil)
+	if got := msg.GetHeader("Cc"); got != nil {
+		t.Errorf("Cc = %v, want none without matching rules", got)
+	}
+}
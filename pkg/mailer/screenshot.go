@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// AttachScreenshot attaches the latest screenshot of the failures to a notification.
+// A screenshot that can no longer be read is left out rather than failing the email.
+func AttachScreenshot(msg *gomail.Message, failures []provider.Failure) {
+	path := provider.LatestScreenshot(failures)
+	if path == "" {
+		return
+	}
+	png, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("Attaching screenshot failed", "err", err)
+		return
+	}
+	msg.Attach(filepath.Base(path), gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(png)
+		return err
+	}))
+}
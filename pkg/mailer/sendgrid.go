@@ -0,0 +1,208 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// sendgridAPIBase is the SendGrid v3 API endpoint (overridden in tests).
+var sendgridAPIBase = "https://api.sendgrid.com/v3"
+
+// sendgridDeliverer sends messages with SendGrid's mail send API.
+type sendgridDeliverer struct {
+	apiKey string
+	retry  *provider.RetryPolicy
+}
+
+func (d *sendgridDeliverer) Name() string { return "sendgrid" }
+
+func (d *sendgridDeliverer) Deliver(msg *gomail.Message) error {
+	payload, err := sendgridPayload(msg)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return d.retry.Do(provider.StageSend, func() error {
+		req, err := http.NewRequest(http.MethodPost, sendgridAPIBase+"/mail/send", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := httpclient.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("mail send failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+		return nil
+	})
+}
+
+type sendgridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendgridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendgridAttachment struct {
+	Content     string `json:"content"` // base64
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sendgridPersonalization struct {
+	To []sendgridAddress `json:"to"`
+	Cc []sendgridAddress `json:"cc,omitempty"`
+}
+
+// sendgridMail is the request body of POST /v3/mail/send.
+type sendgridMail struct {
+	Personalizations []sendgridPersonalization `json:"personalizations"`
+	From             sendgridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendgridContent         `json:"content"`
+	Attachments      []sendgridAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+// sendgridPayload converts a built message into SendGrid's JSON, since the API
+// doesn't accept MIME. The message is rendered and parsed again, so the body,
+// HTML part, inline chart and attachments are exactly those sent via SMTP.
+func sendgridPayload(msg *gomail.Message) (*sendgridMail, error) {
+	var raw bytes.Buffer
+	if _, err := msg.WriteTo(&raw); err != nil {
+		return nil, err
+	}
+	parsed, err := mail.ReadMessage(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload sendgridMail
+	from, err := mail.ParseAddress(parsed.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("From: %v", err)
+	}
+	payload.From = sendgridAddress{Email: from.Address, Name: from.Name}
+	var p sendgridPersonalization
+	for _, field := range []struct {
+		name string
+		list *[]sendgridAddress
+	}{{"To", &p.To}, {"Cc", &p.Cc}} {
+		if parsed.Header.Get(field.name) == "" {
+			continue
+		}
+		addrs, err := parsed.Header.AddressList(field.name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", field.name, err)
+		}
+		for _, a := range addrs {
+			*field.list = append(*field.list, sendgridAddress{Email: a.Address, Name: a.Name})
+		}
+	}
+	payload.Personalizations = []sendgridPersonalization{p}
+	if payload.Subject, err = new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); err != nil {
+		return nil, fmt.Errorf("Subject: %v", err)
+	}
+	for _, name := range []string{"X-Priority", "Importance"} {
+		if value := parsed.Header.Get(name); value != "" {
+			if payload.Headers == nil {
+				payload.Headers = map[string]string{}
+			}
+			payload.Headers[name] = value
+		}
+	}
+
+	if err := addSendgridPart(&payload, parsed.Header, parsed.Body); err != nil {
+		return nil, err
+	}
+	// SendGrid requires text/plain before text/html.
+	if len(payload.Content) == 2 && payload.Content[0].Type == "text/html" {
+		payload.Content[0], payload.Content[1] = payload.Content[1], payload.Content[0]
+	}
+	return &payload, nil
+}
+
+// mimeHeader is implemented by both the message header and the headers of its parts.
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// addSendgridPart adds a MIME part to the payload: text bodies as content, other
+// parts as attachments, descending into multipart containers.
+func addSendgridPart(payload *sendgridMail, header mimeHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(orDefault(header.Get("Content-Type"), "text/plain"))
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := r.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := addSendgridPart(payload, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	if (mediaType == "text/plain" || mediaType == "text/html") && disposition == "" {
+		text := strings.ReplaceAll(string(data), "\r\n", "\n")
+		payload.Content = append(payload.Content, sendgridContent{Type: mediaType, Value: text})
+		return nil
+	}
+	attachment := sendgridAttachment{
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Type:        mediaType,
+		Filename:    dparams["filename"],
+		Disposition: orDefault(disposition, "attachment"),
+	}
+	if id := header.Get("Content-ID"); id != "" {
+		attachment.ContentID = strings.Trim(id, "<>")
+	}
+	payload.Attachments = append(payload.Attachments, attachment)
+	return nil
+}
@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestSendgridPayload(t *testing.T) {
+	sender := New(Config{From: "Rechnungen <a@b.com>", To: "c@d.com", Subject: "Rechnungen {{.MonthName}} {{.Year}}"}, SMTPConfig{})
+	invoices := []provider.Invoice{{
+		Filename: "02_2026_Rechnung_Vodafone_Kabel.pdf", Type: "Kabel", Month: "02", Year: "2026", MonthName: "Februar",
+		Notify: []string{"steuer@example.com"}, Priority: "high", PDFData: []byte("%PDF-k"),
+	}}
+	payload, err := sendgridPayload(sender.Compose(invoices, nil, nil, []byte("fake-png")))
+	if err != nil {
+		t.Fatalf("sendgridPayload() error: %v", err)
+	}
+
+	if payload.From != (sendgridAddress{Email: "a@b.com", Name: "Rechnungen"}) {
+		t.Errorf("From = %+v", payload.From)
+	}
+	p := payload.Personalizations[0]
+	if len(p.To) != 1 || p.To[0].Email != "c@d.com" || len(p.Cc) != 1 || p.Cc[0].Email != "steuer@example.com" {
+		t.Errorf("Personalizations = %+v", payload.Personalizations)
+	}
+	if payload.Subject != "Rechnungen Februar 2026" {
+		t.Errorf("Subject = %q", payload.Subject)
+	}
+	if payload.Headers["Importance"] != "high" {
+		t.Errorf("Headers = %v", payload.Headers)
+	}
+	if len(payload.Content) != 2 || payload.Content[0].Type != "text/plain" || payload.Content[1].Type != "text/html" {
+		t.Fatalf("Content = %+v", payload.Content)
+	}
+	if payload.Content[0].Value != messageBody(invoices, nil, nil) {
+		t.Errorf("text = %q", payload.Content[0].Value)
+	}
+
+	byName := map[string]sendgridAttachment{}
+	for _, a := range payload.Attachments {
+		byName[a.Filename] = a
+	}
+	pdf := byName["02_2026_Rechnung_Vodafone_Kabel.pdf"]
+	if data, _ := base64.StdEncoding.DecodeString(pdf.Content); string(data) != "%PDF-k" || pdf.Disposition != "attachment" {
+		t.Errorf("PDF attachment = %+v", pdf)
+	}
+	chart := byName[chartFilename]
+	if chart.Disposition != "inline" || chart.ContentID != chartFilename {
+		t.Errorf("chart attachment = %+v", chart)
+	}
+}
+
+func TestSendgridDeliverer(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "rejected", status: http.StatusUnauthorized, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got sendgridMail
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer SG.key" {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+			base := sendgridAPIBase
+			sendgridAPIBase = srv.URL + "/v3"
+			defer func() { sendgridAPIBase = base }()
+
+			m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "sendgrid", SendGrid: SendGridConfig{APIKey: "SG.key"}}, SMTPConfig{})
+			err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n"))
+			if tt.wantErr {
+				if !errors.Is(err, provider.ErrDelivery) {
+					t.Errorf("sendMessage() error = %v, want ErrDelivery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sendMessage() error: %v", err)
+			}
+			if got.Subject != "Test" || len(got.Content) != 1 || got.Content[0].Value != "Hallo\n" {
+				t.Errorf("payload = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// sesDeliverer sends messages with Amazon SES's SendRawEmail. Credentials and region
+// come from the standard AWS sources: environment, shared config files or the
+// instance role.
+type sesDeliverer struct {
+	ses    SESConfig
+	retry  *provider.RetryPolicy
+	client *ses.Client // created on the first send
+}
+
+func (d *sesDeliverer) Name() string { return "ses" }
+
+func (d *sesDeliverer) Deliver(msg *gomail.Message) error {
+	ctx := context.Background()
+	if d.client == nil {
+		var opts []func(*awsconfig.LoadOptions) error
+		if d.ses.Region != "" {
+			opts = append(opts, awsconfig.WithRegion(d.ses.Region))
+		}
+		if d.ses.Profile != "" {
+			opts = append(opts, awsconfig.WithSharedConfigProfile(d.ses.Profile))
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return err
+		}
+		d.client = ses.NewFromConfig(cfg, func(o *ses.Options) {
+			if d.ses.Endpoint != "" {
+				o.BaseEndpoint = aws.String(d.ses.Endpoint)
+			}
+		})
+	}
+
+	var raw bytes.Buffer
+	if _, err := msg.WriteTo(&raw); err != nil {
+		return err
+	}
+	input := &ses.SendRawEmailInput{RawMessage: &sestypes.RawMessage{Data: raw.Bytes()}}
+	if d.ses.ConfigurationSet != "" {
+		input.ConfigurationSetName = aws.String(d.ses.ConfigurationSet)
+	}
+	return d.retry.Do(provider.StageSend, func() error {
+		_, err := d.client.SendRawEmail(ctx, input)
+		return err
+	})
+}
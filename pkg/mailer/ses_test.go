@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// setAWSTestEnv isolates the test from the host's AWS config and sets static credentials.
+func setAWSTestEnv(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-central-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestSESDeliverer(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "sent", status: http.StatusOK},
+		{name: "rejected", status: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setAWSTestEnv(t)
+			var raw, auth, configSet string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				auth = r.Header.Get("Authorization")
+				if r.Form.Get("Action") != "SendRawEmail" {
+					http.Error(w, "unknown action", http.StatusBadRequest)
+					return
+				}
+				data, _ := base64.StdEncoding.DecodeString(r.Form.Get("RawMessage.Data"))
+				raw, configSet = string(data), r.Form.Get("ConfigurationSetName")
+				w.Header().Set("Content-Type", "text/xml")
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>MessageRejected</Code><Message>Email address is not verified.</Message></Error><RequestId>r</RequestId></ErrorResponse>`)
+					return
+				}
+				io.WriteString(w, `<SendRawEmailResponse xmlns="http://ses.amazonaws.com/doc/2010-12-01/"><SendRawEmailResult><MessageId>m-1</MessageId></SendRawEmailResult><ResponseMetadata><RequestId>r</RequestId></ResponseMetadata></SendRawEmailResponse>`)
+			}))
+			defer srv.Close()
+
+			m := New(Config{From: "a@b.com", To: "c@d.com", Delivery: "ses",
+				SES: SESConfig{Endpoint: srv.URL, ConfigurationSet: "rechnungen"}}, SMTPConfig{})
+			err := m.SendMessage(m.AlertMessage("", "Test", "Hallo\n"))
+			if tt.wantErr {
+				if !errors.Is(err, provider.ErrDelivery) || !strings.Contains(err.Error(), "not verified") {
+					t.Errorf("sendMessage() error = %v, want ErrDelivery with the SES message", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sendMessage() error: %v", err)
+			}
+			if !strings.Contains(raw, "Subject: Test\r\n") || !strings.Contains(raw, "To: c@d.com") {
+				t.Errorf("raw message = %q", raw)
+			}
+			if !strings.Contains(auth, "Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-central-1/ses/") {
+				t.Errorf("Authorization = %q, want SigV4 for eu-central-1", auth)
+			}
+			if configSet != "rechnungen" {
+				t.Errorf("ConfigurationSetName = %q", configSet)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/internal/httpclient"
+)
+
+// defaultOAuth2TokenURL is the token endpoint used unless smtp.oauth2.token_url is set.
+const defaultOAuth2TokenURL = "https://oauth2.googleapis.com/token"
+
+// CheckSMTP validates the TLS and OAuth2 settings of the smtp section.
+func CheckSMTP(c SMTPConfig) error {
+	if _, err := smtpTLSConfig(c.Host, c.TLS); err != nil {
+		return err
+	}
+	return checkSMTPOAuth2(c)
+}
+
+// checkSMTPOAuth2 validates the smtp.oauth2 section. It is used once a refresh
+// token is set; otherwise the password is sent with PLAIN or LOGIN as before.
+func checkSMTPOAuth2(c SMTPConfig) error {
+	if c.OAuth2.RefreshToken == "" {
+		if c.OAuth2.ClientID != "" {
+			return fmt.Errorf("smtp.oauth2 needs a refresh_token")
+		}
+		return nil
+	}
+	if c.OAuth2.ClientID == "" {
+		return fmt.Errorf("smtp.oauth2 needs a client_id")
+	}
+	if c.User == "" {
+		return fmt.Errorf("smtp.oauth2 needs smtp.user, the mailbox to send from")
+	}
+	return nil
+}
+
+// OAuth2Token is an access token obtained with the refresh token.
+type OAuth2Token struct {
+	Value   string
+	Expires time.Time
+}
+
+// Valid reports whether the token can still be used, leaving a minute for the send.
+func (t *OAuth2Token) Valid(now time.Time) bool {
+	return t != nil && now.Add(time.Minute).Before(t.Expires)
+}
+
+// RefreshOAuth2Token exchanges the configured refresh token for an access token.
+func RefreshOAuth2Token(c SMTPOAuth2Config, now time.Time) (*OAuth2Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"refresh_token": {c.RefreshToken},
+	}
+	if c.Scope != "" {
+		form.Set("scope", c.Scope)
+	}
+	resp, err := httpclient.Client.PostForm(orDefault(c.TokenURL, defaultOAuth2TokenURL), form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response without access_token")
+	}
+	return &OAuth2Token{Value: token.AccessToken, Expires: now.Add(time.Duration(token.ExpiresIn) * time.Second)}, nil
+}
+
+// xoauth2Auth implements the XOAUTH2 SASL mechanism of Gmail and Office 365.
+type xoauth2Auth struct {
+	user, token string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" && server.Name != "::1" {
+		return "", nil, errors.New("XOAUTH2 needs an encrypted connection")
+	}
+	return "XOAUTH2", []byte("user=" + a.user + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+// Next answers the server's error challenge with an empty response, after which
+// the server reports the error (e.g. an expired token) with its final reply.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+)
+
+func TestCheckSMTPOAuth2(t *testing.T) {
+	tests := []struct {
+		name    string
+		smtp    SMTPConfig
+		wantErr bool
+	}{
+		{name: "not configured", smtp: SMTPConfig{User: "a@b.com", Pass: "p"}},
+		{name: "complete", smtp: SMTPConfig{User: "a@b.com", OAuth2: SMTPOAuth2Config{ClientID: "id", RefreshToken: "rt"}}},
+		{name: "no refresh token", smtp: SMTPConfig{User: "a@b.com", OAuth2: SMTPOAuth2Config{ClientID: "id"}}, wantErr: true},
+		{name: "no client id", smtp: SMTPConfig{User: "a@b.com", OAuth2: SMTPOAuth2Config{RefreshToken: "rt"}}, wantErr: true},
+		{name: "no user", smtp: SMTPConfig{OAuth2: SMTPOAuth2Config{ClientID: "id", RefreshToken: "rt"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSMTPOAuth2(tt.smtp); (err != nil) != tt.wantErr {
+				t.Errorf("checkSMTPOAuth2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefreshOAuth2Token(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "rt" ||
+			r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"at-%d","expires_in":3600}`, requests)
+	}))
+	defer srv.Close()
+	c := SMTPOAuth2Config{ClientID: "id", ClientSecret: "secret", RefreshToken: "rt", TokenURL: srv.URL}
+
+	token, err := RefreshOAuth2Token(c, now)
+	if err != nil {
+		t.Fatalf("refreshOAuth2Token() error: %v", err)
+	}
+	if token.Value != "at-1" || !token.Expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("token = %+v", token)
+	}
+	if !token.Valid(now.Add(58*time.Minute)) || token.Valid(now.Add(59*time.Minute+30*time.Second)) {
+		t.Error("token should be valid until a minute before it expires")
+	}
+	if (*OAuth2Token)(nil).Valid(now) {
+		t.Error("nil token should not be valid")
+	}
+
+	c.RefreshToken = "revoked"
+	if _, err := RefreshOAuth2Token(c, now); err == nil {
+		t.Error("expected error for a rejected refresh token")
+	}
+}
+
+func TestNewDialerOAuth2(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"access_token":"at","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	s := &smtpDeliverer{smtp: SMTPConfig{Host: "smtp.gmail.com", Port: "587", User: "a@gmail.com",
+		OAuth2: SMTPOAuth2Config{ClientID: "id", RefreshToken: "rt", TokenURL: srv.URL}}}
+	for i := 0; i < 2; i++ {
+		d, err := s.newDialer()
+		if err != nil {
+			t.Fatalf("newDialer() error: %v", err)
+		}
+		auth, ok := d.Auth.(*xoauth2Auth)
+		if !ok || auth.user != "a@gmail.com" || auth.token != "at" {
+			t.Errorf("Auth = %#v", d.Auth)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token requested %d times, want once while valid", requests)
+	}
+
+	plain := &smtpDeliverer{smtp: SMTPConfig{Host: "smtp.example.com", Port: "587", User: "u", Pass: "p"}}
+	d, err := plain.newDialer()
+	if err != nil {
+		t.Fatalf("newDialer() error: %v", err)
+	}
+	if d.Auth != nil {
+		t.Errorf("Auth = %#v, want nil for PLAIN/LOGIN", d.Auth)
+	}
+}
+
+func TestXOAUTH2Auth(t *testing.T) {
+	auth := &xoauth2Auth{user: "a@gmail.com", token: "at"}
+	proto, resp, err := auth.Start(&smtp.ServerInfo{Name: "smtp.gmail.com", TLS: true})
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if want := "user=a@gmail.com\x01auth=Bearer at\x01\x01"; proto != "XOAUTH2" || string(resp) != want {
+		t.Errorf("Start() = %q, %q", proto, resp)
+	}
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.gmail.com"}); err == nil {
+		t.Error("expected error without TLS")
+	}
+	if resp, err := auth.Next([]byte(`{"status":"401"}`), true); err != nil || len(resp) != 0 {
+		t.Errorf("Next() = %q, %v, want empty response", resp, err)
+	}
+}
@@ -0,0 +1,21 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure classes returned through the pipeline. Errors wrap one of these, so callers
+// can branch with errors.Is regardless of the detail message or the provider.
+var (
+	ErrLoginFailed     = errors.New("login failed")
+	Err2FARequired     = errors.New("two-factor authentication required")
+	ErrInvoiceNotReady = errors.New("invoice not ready")
+	ErrCaptureFailed   = errors.New("PDF download failed")
+	ErrDelivery        = errors.New("email delivery failed")
+)
+
+// ErrCredentialsRejected is the ErrLoginFailed of a login form that rejected the
+// credentials. Unlike an unreachable login page, trying again can't fix it and could
+// get the account locked.
+var ErrCredentialsRejected = fmt.Errorf("%w: credentials rejected", ErrLoginFailed)
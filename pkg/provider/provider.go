@@ -0,0 +1,100 @@
+// Package provider defines what the pipeline needs from an ISP's customer portal:
+// a Provider logs in, finds the contracts and downloads their invoices, and the
+// email, storage and history steps work on the Invoice and Failure values it
+// returns. pkg/vodafone implements it for MeinVodafone; other German ISPs such as
+// Telekom, O2 or 1&1 can be added the same way without touching the pipeline.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Provider downloads the invoices of one customer account from an ISP's portal.
+// The methods are called in order with the same browser context: Login, optionally
+// Discover, then Download.
+type Provider interface {
+	// Name is the ISP's name as shown in logs, e.g. "Vodafone".
+	Name() string
+	// Login signs into the portal. A failed login wraps ErrLoginFailed, rejected
+	// credentials ErrCredentialsRejected, a request for a one-time code Err2FARequired.
+	Login(ctx context.Context) error
+	// Discover finds the contracts of the given types on the account, so that
+	// Download includes additional contracts of the same type. Without it, one
+	// contract per configured type is downloaded.
+	Discover(ctx context.Context, types []string) error
+	// Download downloads the current invoice of every contract. Contracts without
+	// one are returned as failures, whose Err wraps one of the Err* classes.
+	Download(ctx context.Context) ([]Invoice, []Failure)
+}
+
+// Failure describes a contract whose invoice could not be downloaded in this run.
+type Failure struct {
+	Type   string
+	Reason string
+	Err    error // wraps one of the Err* failure classes where known
+
+	Screenshot string // screenshot of the page when the step failed, empty if none
+}
+
+// Invoice is an invoice found on the portal, with its PDF once downloaded. Anomaly,
+// Notify, Tags and Priority are left to the caller, e.g. the CLI's rules.
+type Invoice struct {
+	Filename  string
+	Month     string
+	Year      string
+	MonthName string
+	Type      string
+	Amount    float64 // in euros, 0 if not found on the page
+	Number    string  // Rechnungsnummer, empty if not found on the page
+	VAT       float64 // USt. amount read from the PDF, 0 if not found
+	BaseFee   float64 // Grundgebühr read from the PDF, 0 if not found
+	Anomaly   string  // reason the amount was flagged, empty if unremarkable
+	Tariff    string  // how the base fee differs from the tariff price, empty if consistent or unknown
+	Folder    string  // archive subfolder, empty for invoices
+	StoredAs  string  // versioned name in the storage targets if another file had Filename, empty otherwise
+	Version   int     // version of StoredAs, e.g. 2 for "..._v2.pdf"; 0 if stored under Filename
+	Extras    []ExtraCharge
+	Fallback  bool     // PDFData is a print of the invoice page, not the invoice PDF
+	Notify    []string // additional recipients from matching rules
+	Tags      []string // additional storage tags from matching rules
+	Priority  string   // email priority from matching rules
+	PDFData   []byte
+}
+
+// StoredName returns the name the invoice's PDF was stored under.
+func (inv Invoice) StoredName() string {
+	if inv.StoredAs != "" {
+		return inv.StoredAs
+	}
+	return inv.Filename
+}
+
+// ExtraCharge is an invoice position beyond the base fee, e.g. roaming or a
+// third-party service.
+type ExtraCharge struct {
+	Description string
+	Amount      float64
+}
+
+// ExpandPlaceholders replaces {type}, {month} and {year} in s with the invoice's values.
+func ExpandPlaceholders(s string, inv Invoice) string {
+	return strings.NewReplacer("{type}", inv.Type, "{month}", inv.Month, "{year}", inv.Year).Replace(s)
+}
+
+// FormatAmount renders an amount the way Vodafone shows it (e.g. "24,98 €").
+func FormatAmount(amount float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", amount), ".", ",", 1) + " €"
+}
+
+// LatestScreenshot returns the most recent screenshot among the failures, "" if
+// there is none.
+func LatestScreenshot(failures []Failure) string {
+	for i := len(failures) - 1; i >= 0; i-- {
+		if failures[i].Screenshot != "" {
+			return failures[i].Screenshot
+		}
+	}
+	return ""
+}
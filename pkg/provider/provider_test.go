@@ -0,0 +1,22 @@
+package provider
+
+import "testing"
+
+func TestLatestScreenshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures []Failure
+		want     string
+	}{
+		{name: "none", failures: nil, want: ""},
+		{name: "without screenshot", failures: []Failure{{Type: "Kabel"}}, want: ""},
+		{name: "latest wins", failures: []Failure{{Type: "Mobilfunk", Screenshot: "debug/a.png"}, {Type: "Kabel", Screenshot: "debug/b.png"}, {Type: "DSL"}}, want: "debug/b.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LatestScreenshot(tt.failures); got != tt.want {
+				t.Errorf("LatestScreenshot() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
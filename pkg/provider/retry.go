@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// Pipeline stages that can be retried, as named in retry.stages.
+const (
+	StageLogin      = "login"
+	StageNavigation = "navigation"
+	StageCapture    = "capture"
+	StageUpload     = "upload"
+	StageSend       = "send"
+)
+
+var retryStages = []string{StageLogin, StageNavigation, StageCapture, StageUpload, StageSend}
+
+// RetryPolicy retries failed steps with exponential backoff. A nil policy runs every
+// step once.
+type RetryPolicy struct {
+	attempts   int
+	delay      time.Duration
+	multiplier float64
+	maxDelay   time.Duration
+	jitter     float64 // share of each delay that is randomized
+	stages     map[string]bool
+	sleep      func(time.Duration)
+	random     func() float64 // in [0, 1)
+}
+
+// NewRetryPolicy validates the retry section. Without max_attempts > 1 it returns nil,
+// which keeps the single-shot behavior.
+func NewRetryPolicy(c RetryConfig) (*RetryPolicy, error) {
+	if c.MaxAttempts <= 1 {
+		return nil, nil
+	}
+	p := &RetryPolicy{
+		attempts:   c.MaxAttempts,
+		delay:      5 * time.Second,
+		multiplier: 2,
+		maxDelay:   time.Minute,
+		stages:     map[string]bool{},
+		sleep:      time.Sleep,
+		random:     rand.Float64,
+	}
+	var err error
+	if c.InitialDelay != "" {
+		if p.delay, err = time.ParseDuration(c.InitialDelay); err != nil {
+			return nil, fmt.Errorf("invalid retry.initial_delay: %v", err)
+		}
+	}
+	if c.MaxDelay != "" {
+		if p.maxDelay, err = time.ParseDuration(c.MaxDelay); err != nil {
+			return nil, fmt.Errorf("invalid retry.max_delay: %v", err)
+		}
+		if p.maxDelay < p.delay {
+			return nil, fmt.Errorf("invalid retry.max_delay: shorter than retry.initial_delay")
+		}
+	}
+	// The default upper bound never cuts a longer initial delay short
+	p.maxDelay = max(p.maxDelay, p.delay)
+	if c.Multiplier != 0 {
+		if c.Multiplier < 1 {
+			return nil, fmt.Errorf("invalid retry.multiplier: must be at least 1")
+		}
+		p.multiplier = c.Multiplier
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return nil, fmt.Errorf("invalid retry.jitter: must be between 0 and 1")
+	}
+	p.jitter = c.Jitter
+
+	stages := c.Stages
+	if len(stages) == 0 {
+		stages = retryStages
+	}
+	for _, stage := range stages {
+		known := false
+		for _, s := range retryStages {
+			known = known || s == stage
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown retry stage %q", stage)
+		}
+		p.stages[stage] = true
+	}
+	return p, nil
+}
+
+// permanent reports whether an error can't be fixed by trying again. Retrying
+// rejected credentials could lock the account.
+func permanent(err error) bool {
+	return errors.Is(err, ErrCredentialsRejected) || errors.Is(err, Err2FARequired) || errors.Is(err, ErrInvoiceNotReady)
+}
+
+// jittered returns delay varied randomly by up to the jitter share in either
+// direction, so retries of parallel installs don't hit the portal in lockstep.
+func (p *RetryPolicy) jittered(delay time.Duration) time.Duration {
+	if p.jitter == 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 - p.jitter + 2*p.jitter*p.random())).Round(time.Millisecond)
+}
+
+// Do runs fn and, if the stage is retryable, retries it after a growing delay until
+// it succeeds, fails permanently or the attempts are used up. The last error is returned.
+func (p *RetryPolicy) Do(stage string, fn func() error) error {
+	err := fn()
+	if p == nil || !p.stages[stage] {
+		return err
+	}
+	delay := p.delay
+	attempt := 2
+	for ; attempt <= p.attempts && err != nil && !permanent(err); attempt++ {
+		wait := p.jittered(delay)
+		slog.Warn("Step failed, retrying", "step", stage, "wait", wait, "attempt", attempt, "attempts", p.attempts, "err", err)
+		p.sleep(wait)
+		err = fn()
+		delay = min(time.Duration(float64(delay)*p.multiplier), p.maxDelay)
+	}
+	if err == nil && attempt > 2 {
+		slog.Info("Step succeeded after retrying", "step", stage, "attempt", attempt-1, "attempts", p.attempts)
+	}
+	return err
+}
+
+// RetryConfig is the retry section of the config, applied to every stage listed in
+// stages.
+type RetryConfig struct {
+	MaxAttempts  int      `yaml:"max_attempts"`  // attempts per step including the first, default 1
+	InitialDelay string   `yaml:"initial_delay"` // wait before the first retry, default 5s
+	Multiplier   float64  `yaml:"multiplier"`    // delay growth per retry, default 2
+	MaxDelay     string   `yaml:"max_delay"`     // upper bound of the delay, default 1m
+	Jitter       float64  `yaml:"jitter"`        // randomize each delay by up to this share, 0-1, default 0
+	Stages       []string `yaml:"stages"`        // login, navigation, capture, upload, send; default all
+}
@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RetryConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "not configured", config: RetryConfig{}, wantNil: true},
+		{name: "single attempt", config: RetryConfig{MaxAttempts: 1}, wantNil: true},
+		{name: "defaults", config: RetryConfig{MaxAttempts: 3}},
+		{name: "all options", config: RetryConfig{MaxAttempts: 4, InitialDelay: "10s", Multiplier: 1.5, MaxDelay: "2m", Stages: []string{"capture", "send"}}},
+		{name: "invalid delay", config: RetryConfig{MaxAttempts: 3, InitialDelay: "soon"}, wantErr: true},
+		{name: "invalid max delay", config: RetryConfig{MaxAttempts: 3, MaxDelay: "1 minute"}, wantErr: true},
+		{name: "max delay below initial delay", config: RetryConfig{MaxAttempts: 3, InitialDelay: "30s", MaxDelay: "10s"}, wantErr: true},
+		{name: "initial delay above default max delay", config: RetryConfig{MaxAttempts: 3, InitialDelay: "2m"}},
+		{name: "shrinking delay", config: RetryConfig{MaxAttempts: 3, Multiplier: 0.5}, wantErr: true},
+		{name: "jitter", config: RetryConfig{MaxAttempts: 3, Jitter: 0.2}},
+		{name: "jitter above 1", config: RetryConfig{MaxAttempts: 3, Jitter: 1.5}, wantErr: true},
+		{name: "unknown stage", config: RetryConfig{MaxAttempts: 3, Stages: []string{"download"}}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewRetryPolicy(tc.config)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newRetryPolicy() error: %v", err)
+			}
+			if (p == nil) != tc.wantNil {
+				t.Errorf("newRetryPolicy() = %+v, want nil: %v", p, tc.wantNil)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := errors.New("net::ERR_CONNECTION_RESET")
+	tests := []struct {
+		name       string
+		stage      string
+		errs       []error // returned by successive attempts, nil afterwards
+		wantCalls  int
+		wantErr    error
+		wantDelays []time.Duration
+	}{
+		{name: "succeeds first time", stage: StageCapture, wantCalls: 1},
+		{name: "succeeds on retry", stage: StageCapture, errs: []error{transient, transient}, wantCalls: 3, wantDelays: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up", stage: StageCapture, errs: []error{transient, transient, transient, transient, transient}, wantCalls: 4, wantErr: transient, wantDelays: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}},
+		{name: "stage not retried", stage: StageSend, errs: []error{transient}, wantCalls: 1, wantErr: transient},
+		{name: "credentials rejected", stage: StageLogin, errs: []error{ErrCredentialsRejected}, wantCalls: 1, wantErr: ErrCredentialsRejected},
+		{name: "wrapped rejection", stage: StageLogin, errs: []error{fmt.Errorf("kabel: %w", ErrCredentialsRejected)}, wantCalls: 1, wantErr: ErrCredentialsRejected},
+		{name: "code requested", stage: StageLogin, errs: []error{Err2FARequired}, wantCalls: 1, wantErr: Err2FARequired},
+		{name: "login page timeout", stage: StageLogin, errs: []error{fmt.Errorf("%w: login page not reachable", ErrLoginFailed)}, wantCalls: 2, wantDelays: []time.Duration{time.Second}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewRetryPolicy(RetryConfig{MaxAttempts: 4, InitialDelay: "1s", MaxDelay: "3s", Stages: []string{"login", "capture"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var delays []time.Duration
+			p.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+			calls := 0
+			err = p.Do(tc.stage, func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tc.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tc.wantCalls)
+			}
+			if !errors.Is(err, tc.wantErr) || (err == nil) != (tc.wantErr == nil) {
+				t.Errorf("do() error = %v, want %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(delays, tc.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, tc.wantDelays)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	tests := []struct {
+		random float64
+		want   []time.Duration
+	}{
+		{random: 0, want: []time.Duration{800 * time.Millisecond, 1600 * time.Millisecond}},
+		{random: 0.5, want: []time.Duration{time.Second, 2 * time.Second}},
+		{random: 0.999, want: []time.Duration{1200 * time.Millisecond, 2400 * time.Millisecond}},
+	}
+	for _, tc := range tests {
+		p, err := NewRetryPolicy(RetryConfig{MaxAttempts: 3, InitialDelay: "1s", Jitter: 0.2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var delays []time.Duration
+		p.sleep = func(d time.Duration) { delays = append(delays, d) }
+		p.random = func() float64 { return tc.random }
+		p.Do(StageNavigation, func() error { return errors.New("timeout") })
+		for i := range delays {
+			delays[i] = delays[i].Round(100 * time.Millisecond)
+		}
+		if !reflect.DeepEqual(delays, tc.want) {
+			t.Errorf("random %v: delays = %v, want %v", tc.random, delays, tc.want)
+		}
+	}
+}
+
+func TestNilRetryPolicyRunsOnce(t *testing.T) {
+	var p *RetryPolicy
+	calls := 0
+	err := p.Do(StageUpload, func() error { calls++; return errors.New("disk full") })
+	if calls != 1 || err == nil {
+		t.Errorf("calls = %d, err = %v; want one failing call", calls, err)
+	}
+}
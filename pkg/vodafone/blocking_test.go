@@ -0,0 +1,54 @@
+package vodafone
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestBlockPatterns(t *testing.T) {
+	tests := []struct {
+		name      string
+		kinds     []string
+		wantTypes []network.ResourceType
+		wantURLs  int
+		wantErr   bool
+	}{
+		{name: "none"},
+		{name: "resource types", kinds: []string{"images", "fonts", "media"}, wantTypes: []network.ResourceType{network.ResourceTypeImage, network.ResourceTypeFont, network.ResourceTypeMedia}},
+		{name: "analytics", kinds: []string{"analytics"}, wantURLs: 2 * len(analyticsHosts)},
+		{name: "unknown", kinds: []string{"images", "scripts"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			patterns, err := BlockPatterns(tc.kinds)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("blockPatterns() error: %v", err)
+			}
+			var types []network.ResourceType
+			urls := 0
+			for _, p := range patterns {
+				if p.URLPattern != "" {
+					urls++
+				} else {
+					types = append(types, p.ResourceType)
+				}
+			}
+			if len(types) != len(tc.wantTypes) || urls != tc.wantURLs {
+				t.Fatalf("patterns: types %v, %d URLs; want %v, %d URLs", types, urls, tc.wantTypes, tc.wantURLs)
+			}
+			for i := range types {
+				if types[i] != tc.wantTypes[i] {
+					t.Errorf("type %d = %s, want %s", i, types[i], tc.wantTypes[i])
+				}
+			}
+		})
+	}
+}
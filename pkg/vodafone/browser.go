@@ -0,0 +1,188 @@
+package vodafone
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// browser is what the client does in the MeinVodafone pages on the way to the
+// invoices. chromeBrowser runs it in Chrome; the tests use a browser that serves
+// recorded HTML pages instead, so navigation and parsing run without an account.
+type browser interface {
+	// navigate opens url and waits for the page's scripts to render it.
+	navigate(ctx context.Context, url string) error
+	// clickCard opens the n-th contract card whose heading contains one of names.
+	clickCard(ctx context.Context, names []string, n int) error
+	// clickLink clicks the first link or button whose text contains text.
+	clickLink(ctx context.Context, text string) error
+	// cards lists the contract cards of the current page.
+	cards(ctx context.Context) ([]contractCard, error)
+	// hasInvoices reports whether the current page shows the invoice view.
+	hasInvoices(ctx context.Context) bool
+	// text returns the text of the current page and, if withHTML is set, its DOM.
+	text(ctx context.Context, withHTML bool) (text, html string)
+	// capturePDF clicks the download of the current invoice, or with entry >= 0 of
+	// that archive entry, and returns the PDF the page generates.
+	capturePDF(ctx context.Context, entry int) ([]byte, error)
+	// printPage renders the current page as a PDF.
+	printPage(ctx context.Context) ([]byte, error)
+}
+
+// currentInvoice is the entry passed to capturePDF for the current invoice.
+const currentInvoice = -1
+
+// chromeBrowser drives the Chrome of the context through chromedp.
+type chromeBrowser struct{}
+
+func (chromeBrowser) navigate(ctx context.Context, url string) error {
+	return chromedp.Run(ctx, chromedp.Navigate(url), chromedp.Sleep(stepTimeouts(ctx).settle()))
+}
+
+func (chromeBrowser) clickCard(ctx context.Context, names []string, n int) error {
+	list, _ := json.Marshal(names)
+	return chromedp.Run(ctx,
+		chromedp.Evaluate(fmt.Sprintf(`(() => {
+			const names = %s;
+			const h = [...document.querySelectorAll('h2')].filter(h => names.some(n => h.innerText.includes(n)))[%d];
+			if (h) (h.closest('a') || h.parentElement).click();
+		})()`, list, n), nil),
+		chromedp.Sleep(stepTimeouts(ctx).settle()),
+	)
+}
+
+func (chromeBrowser) clickLink(ctx context.Context, text string) error {
+	label, _ := json.Marshal(text)
+	return chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(`
+		[...document.querySelectorAll('a, button')].find(el =>
+			el.innerText.includes(%s))?.click();
+	`, label), nil))
+}
+
+// listContractCardsJS returns the heading and text of every card on the services page.
+const listContractCardsJS = `[...document.querySelectorAll('h2')].map(h => {
+	const card = h.closest('a') || h.parentElement;
+	return {heading: h.innerText.trim(), text: card.innerText.trim()};
+})`
+
+func (chromeBrowser) cards(ctx context.Context) ([]contractCard, error) {
+	var cards []contractCard
+	err := chromedp.Run(ctx, chromedp.Evaluate(listContractCardsJS, &cards))
+	return cards, err
+}
+
+// invoiceContentJS reports whether the invoice view has loaded.
+const invoiceContentJS = `
+	document.body.innerText.includes('Aktuelle Rechnung') ||
+	document.body.innerText.includes('Deine Rechnungen') ||
+	document.body.innerText.includes('Rechnungsübersicht')
+`
+
+func (chromeBrowser) hasInvoices(ctx context.Context) bool {
+	var hasContent bool
+	chromedp.Run(ctx, chromedp.Evaluate(invoiceContentJS, &hasContent))
+	return hasContent
+}
+
+func (chromeBrowser) text(ctx context.Context, withHTML bool) (string, string) {
+	var text, html string
+	actions := []chromedp.Action{chromedp.Text(`body`, &text, chromedp.ByQuery)}
+	if withHTML {
+		actions = append(actions, chromedp.OuterHTML(`html`, &html, chromedp.ByQuery))
+	}
+	chromedp.Run(ctx, actions...)
+	return text, html
+}
+
+// JS to click the current invoice download button (force-enable if disabled)
+const clickCurrentInvoice = `(() => {
+	const btn = [...document.querySelectorAll('button')].find(btn =>
+		btn.innerText.includes('Rechnung herunterladen') ||
+		(btn.innerText.includes('Rechnung') && btn.classList.contains('ws10-button--primary')));
+	if (btn) {
+		btn.disabled = false;
+		btn.classList.remove('ws10-button--disabled', 'disabled');
+		btn.removeAttribute('aria-disabled');
+		btn.click();
+	}
+})()`
+
+// clickArchiveEntryJS returns JS that clicks the "Rechnung (PDF)" link of the n-th
+// archive entry, counted like parseArchiveEntries.
+func clickArchiveEntryJS(n int) string {
+	return fmt.Sprintf(`(() => {
+	const links = [...document.querySelectorAll('button, a')].filter(b =>
+		b.innerText.trim() === 'Rechnung (PDF)' &&
+		b.classList.contains('ws10-button-link'));
+	if (links.length > %[1]d) links[%[1]d].click();
+})()`, n)
+}
+
+func (chromeBrowser) capturePDF(ctx context.Context, entry int) ([]byte, error) {
+	if entry == currentInvoice {
+		return capturePDF(ctx, clickCurrentInvoice)
+	}
+	return capturePDF(ctx, clickArchiveEntryJS(entry))
+}
+
+// capturePDF intercepts the browser's PDF blob creation to capture the invoice data.
+// It hooks URL.createObjectURL to grab any PDF blob, executes the provided clickJS
+// to trigger the PDF generation, and finally extracts the base64-encoded PDF data.
+func capturePDF(ctx context.Context, clickJS string) ([]byte, error) {
+	// Hook URL.createObjectURL to intercept PDF blobs before they become download URLs
+	chromedp.Run(ctx, chromedp.Evaluate(`
+		window._capturedPDFs = [];
+		if (!window._origCreateObjectURL) window._origCreateObjectURL = URL.createObjectURL;
+		URL.createObjectURL = function(blob) {
+			if (blob?.type === 'application/pdf') {
+				const reader = new FileReader();
+				reader.onload = () => window._capturedPDFs.push(reader.result);
+				reader.readAsDataURL(blob);
+			}
+			return window._origCreateObjectURL.call(URL, blob);
+		};
+	`, nil))
+
+	// Click the download button/link to trigger PDF generation
+	chromedp.Run(ctx, chromedp.Evaluate(clickJS, nil))
+
+	// Wait up to timeouts.pdf_capture for the PDF blob to be generated and captured
+	// by our hook
+	deadline := time.Now().Add(stepTimeouts(ctx).PDFCapture)
+	for time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+		var n int
+		chromedp.Run(ctx, chromedp.Evaluate(`(window._capturedPDFs || []).length`, &n))
+		if n > 0 {
+			break
+		}
+	}
+
+	// Retrieve captured PDF data from our hook
+	var captured []string
+	chromedp.Run(ctx, chromedp.Evaluate(`window._capturedPDFs || []`, &captured))
+
+	if len(captured) == 0 {
+		return nil, fmt.Errorf("no PDF captured")
+	}
+
+	// Decode from base64 data URL to raw PDF bytes
+	pdfBase64 := strings.TrimPrefix(captured[0], "data:application/pdf;base64,")
+	return base64.StdEncoding.DecodeString(pdfBase64)
+}
+
+func (chromeBrowser) printPage(ctx context.Context) ([]byte, error) {
+	var pdfData []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		pdfData, _, err = page.PrintToPDF().WithPrintBackground(true).Do(ctx)
+		return err
+	}))
+	return pdfData, err
+}
@@ -0,0 +1,348 @@
+package vodafone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+	"golang.org/x/net/html"
+)
+
+// fixtureBrowser serves the recorded MeinVodafone pages in testdata/meinvodafone.
+// Links and contract cards are followed by their href, which names the page file;
+// the services page is served for servicesURL.
+type fixtureBrowser struct {
+	dir      string
+	page     string     // file name of the current page
+	raw      string     // HTML of the current page
+	doc      *html.Node // parsed current page
+	pdf      []byte     // returned for every captured or printed PDF
+	captured []string   // "<page> <entry>" of every captured PDF
+}
+
+func newFixtureBrowser(pdf []byte) *fixtureBrowser {
+	return &fixtureBrowser{dir: filepath.Join("testdata", "meinvodafone"), pdf: pdf}
+}
+
+func (f *fixtureBrowser) navigate(ctx context.Context, url string) error {
+	page := url
+	if url == servicesURL {
+		page = "services.html"
+	}
+	data, err := os.ReadFile(filepath.Join(f.dir, page))
+	if err != nil {
+		return fmt.Errorf("page not found: %s", url)
+	}
+	doc, err := html.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	f.page, f.raw, f.doc = page, string(data), doc
+	return nil
+}
+
+func (f *fixtureBrowser) clickCard(ctx context.Context, names []string, n int) error {
+	var matching []*html.Node
+	for _, h := range findAll(f.doc, "h2") {
+		for _, name := range names {
+			if strings.Contains(innerText(h), name) {
+				matching = append(matching, h)
+				break
+			}
+		}
+	}
+	if n >= len(matching) {
+		return nil
+	}
+	return f.follow(ctx, cardOf(matching[n]))
+}
+
+func (f *fixtureBrowser) clickLink(ctx context.Context, text string) error {
+	for _, el := range findAll(f.doc, "a", "button") {
+		if strings.Contains(innerText(el), text) {
+			return f.follow(ctx, el)
+		}
+	}
+	return nil
+}
+
+// follow opens the href of a link; other elements do nothing when clicked.
+func (f *fixtureBrowser) follow(ctx context.Context, el *html.Node) error {
+	if href := attr(el, "href"); el.Data == "a" && href != "" && href != "#" {
+		return f.navigate(ctx, href)
+	}
+	return nil
+}
+
+func (f *fixtureBrowser) cards(ctx context.Context) ([]contractCard, error) {
+	var cards []contractCard
+	for _, h := range findAll(f.doc, "h2") {
+		cards = append(cards, contractCard{Heading: innerText(h), Text: innerText(cardOf(h))})
+	}
+	return cards, nil
+}
+
+func (f *fixtureBrowser) hasInvoices(ctx context.Context) bool {
+	text := innerText(f.doc)
+	return strings.Contains(text, "Aktuelle Rechnung") ||
+		strings.Contains(text, "Deine Rechnungen") ||
+		strings.Contains(text, "Rechnungsübersicht")
+}
+
+func (f *fixtureBrowser) text(ctx context.Context, withHTML bool) (string, string) {
+	body := innerText(f.doc)
+	if !withHTML {
+		return body, ""
+	}
+	return body, f.raw
+}
+
+// capturePDF finds the download button like clickCurrentInvoice and the archive links
+// like clickArchiveEntryJS.
+func (f *fixtureBrowser) capturePDF(ctx context.Context, entry int) ([]byte, error) {
+	var links []*html.Node
+	for _, el := range findAll(f.doc, "a", "button") {
+		text := innerText(el)
+		switch {
+		case entry == currentInvoice && el.Data == "button" && strings.Contains(text, "Rechnung herunterladen"):
+			links = append(links, el)
+		case entry != currentInvoice && text == "Rechnung (PDF)" && strings.Contains(attr(el, "class"), "ws10-button-link"):
+			links = append(links, el)
+		}
+	}
+	if entry == currentInvoice {
+		entry = 0
+	}
+	if entry >= len(links) {
+		return nil, fmt.Errorf("no PDF captured")
+	}
+	f.captured = append(f.captured, fmt.Sprintf("%s %d", f.page, entry))
+	return f.pdf, nil
+}
+
+func (f *fixtureBrowser) printPage(ctx context.Context) ([]byte, error) {
+	return f.pdf, nil
+}
+
+// findAll returns the elements with one of the given tags below n, in document order.
+func findAll(n *html.Node, tags ...string) []*html.Node {
+	var found []*html.Node
+	for c := range n.Descendants() {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		for _, tag := range tags {
+			if c.Data == tag {
+				found = append(found, c)
+			}
+		}
+	}
+	return found
+}
+
+// cardOf returns the card of a heading like listContractCardsJS: the enclosing link,
+// otherwise the heading's parent.
+func cardOf(h *html.Node) *html.Node {
+	for p := h.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "a" {
+			return p
+		}
+	}
+	return h.Parent
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// blockElements start a new line in innerText.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "br": true, "div": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "header": true, "li": true, "main": true,
+	"nav": true, "p": true, "section": true, "tr": true, "ul": true, "a": true, "button": true,
+}
+
+// innerText approximates the innerText of an element as Chrome renders it: one line
+// per block, whitespace collapsed, without scripts and styles.
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			if words := strings.Fields(n.Data); len(words) > 0 {
+				b.WriteString(strings.Join(words, " ") + " ")
+			}
+			return
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "head"):
+			return
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			b.WriteString("\n")
+		}
+	}
+	walk(n)
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fixtureClient returns a client that runs against the recorded pages in March 2026.
+func fixtureClient(c Config) (*Client, *fixtureBrowser) {
+	f := newFixtureBrowser(minimalPDF("Rechnung", "Grundpreis 44,99 EUR"))
+	d := NewClient(c)
+	d.browser = f
+	d.Now = func() time.Time { return time.Date(2026, 3, 10, 6, 0, 0, 0, time.UTC) }
+	return d, f
+}
+
+// fixtureContext doesn't wait for pages that never show invoices.
+func fixtureContext() context.Context {
+	return withTimeouts(context.Background(), Timeouts{Total: time.Minute})
+}
+
+func TestDiscoverFixtures(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  string
+	}{
+		{name: "defaults", types: DefaultContracts, want: "kabel/Kabel/0 mobilfunk/Mobilfunk/0 mobilfunk_2/Mobilfunk 2/1"},
+		{name: "mobilfunk only", types: []string{"mobilfunk"}, want: "mobilfunk/Mobilfunk/0 mobilfunk_2/Mobilfunk 2/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := fixtureClient(Config{})
+			if err := d.Discover(fixtureContext(), tt.types); err != nil {
+				t.Fatalf("Discover() error: %v", err)
+			}
+			var got []string
+			for _, c := range d.contracts {
+				got = append(got, fmt.Sprintf("%s/%s/%d", c.key, c.name, c.card))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("contracts = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	d, _ := fixtureClient(Config{})
+	if err := d.Discover(fixtureContext(), []string{"dsl"}); err == nil {
+		t.Error("Discover() of a type without cards succeeded")
+	}
+}
+
+func TestDownloadFixtures(t *testing.T) {
+	d, f := fixtureClient(Config{})
+	ctx := fixtureContext()
+	if err := d.Discover(ctx, DefaultContracts); err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	invoices, failures := d.Download(ctx)
+
+	var got []string
+	for _, inv := range invoices {
+		got = append(got, fmt.Sprintf("%s %.2f %s %.2f", inv.Filename, inv.Amount, inv.Number, inv.BaseFee))
+	}
+	want := []string{
+		"03_2026_Rechnung_Vodafone_Kabel.pdf 39.99 000012345678 44.99",
+		"02_2026_Rechnung_Vodafone_Mobilfunk.pdf 24.99  44.99",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("invoices =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(failures) != 1 || failures[0].Type != "Mobilfunk 2" || !errors.Is(failures[0].Err, provider.ErrInvoiceNotReady) {
+		t.Errorf("failures = %+v, want Mobilfunk 2 not ready", failures)
+	}
+	if want := "kabel_rechnungen.html 0 mobilfunk_rechnungen.html 0"; strings.Join(f.captured, " ") != want {
+		t.Errorf("captured = %v, want %s", f.captured, want)
+	}
+}
+
+func TestDownloadInvoiceFixtures(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		month, year string
+		contract    string
+		want        string
+		wantErr     error
+	}{
+		{name: "current", contract: "kabel", want: "03_2026_Rechnung_Vodafone_Kabel.pdf 39.99 kabel_rechnungen.html 0"},
+		{name: "archive", contract: "kabel", month: "01", year: "2026", want: "01_2026_Rechnung_Vodafone_Kabel.pdf 41.50 kabel_rechnungen.html 1"},
+		{name: "not in archive", contract: "kabel", month: "11", year: "2025", wantErr: provider.ErrInvoiceNotReady},
+		{name: "invoice url", contract: "mobilfunk", config: Config{InvoiceURLs: map[string]string{"mobilfunk": "mobilfunk_rechnungen.html"}},
+			want: "02_2026_Rechnung_Vodafone_Mobilfunk.pdf 24.99 mobilfunk_rechnungen.html 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, f := fixtureClient(tt.config)
+			d.Month, d.Year = tt.month, tt.year
+			inv, err := d.DownloadInvoice(fixtureContext(), tt.contract)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("DownloadInvoice() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DownloadInvoice() error: %v", err)
+			}
+			if got := fmt.Sprintf("%s %.2f %s", inv.Filename, inv.Amount, strings.Join(f.captured, " ")); got != tt.want {
+				t.Errorf("invoice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckInvoicePageFixtures(t *testing.T) {
+	d, _ := fixtureClient(Config{})
+	if failure := d.CheckInvoicePage(fixtureContext(), "kabel", "Kabel"); failure != nil {
+		t.Errorf("CheckInvoicePage() = %+v, want nil", failure)
+	}
+
+	d, _ = fixtureClient(Config{InvoiceURLs: map[string]string{"kabel": "wartung.html"}})
+	if failure := d.CheckInvoicePage(fixtureContext(), "kabel", "Kabel"); failure == nil || failure.Reason != "invoice page did not load" {
+		t.Errorf("CheckInvoicePage() on the maintenance page = %+v", failure)
+	}
+
+	d, _ = fixtureClient(Config{InvoiceURLs: map[string]string{"kabel": "missing.html"}})
+	if failure := d.CheckInvoicePage(fixtureContext(), "kabel", "Kabel"); failure == nil || !strings.Contains(failure.Reason, "not reachable") {
+		t.Errorf("CheckInvoicePage() on a missing page = %+v", failure)
+	}
+}
+
+func TestCheckTariffsFixtures(t *testing.T) {
+	d, _ := fixtureClient(Config{})
+	invoices := []provider.Invoice{{Type: "Kabel", BaseFee: 44.99}, {Type: "Mobilfunk", BaseFee: 24.99}}
+	d.CheckTariffs(fixtureContext(), invoices, nil)
+	if want := "Grundgebühr 44,99 € statt Tarifpreis 39,99 €"; invoices[0].Tariff != want {
+		t.Errorf("Kabel: Tariff = %q, want %q", invoices[0].Tariff, want)
+	}
+	if invoices[1].Tariff != "" {
+		t.Errorf("Mobilfunk: Tariff = %q, want none", invoices[1].Tariff)
+	}
+}
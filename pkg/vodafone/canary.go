@@ -0,0 +1,33 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// CheckInvoicePage opens the invoice page of a contract and verifies that the invoice
+// view has loaded, without downloading anything. It returns nil if the page is fine
+// and otherwise the failure, with the screenshot of the failed step.
+func (d *Client) CheckInvoicePage(ctx context.Context, contractType, typeName string) *provider.Failure {
+	d.lastScreenshot = ""
+	if err := d.checkInvoicePage(ctx, contractType, typeName); err != nil {
+		return &provider.Failure{Type: typeName, Reason: err.Error(), Err: err, Screenshot: d.lastScreenshot}
+	}
+	return nil
+}
+
+func (d *Client) checkInvoicePage(ctx context.Context, contractType, typeName string) error {
+	if err := d.Retry.Do(provider.StageNavigation, func() error {
+		return d.navigateToInvoicePage(ctx, contractType, typeName)
+	}); err != nil {
+		d.debugFailedStep(ctx, contractType+"_"+provider.StageNavigation)
+		return fmt.Errorf("invoice page not reachable: %v", err)
+	}
+	if !d.browser.hasInvoices(ctx) {
+		d.debugFailedStep(ctx, contractType+"_"+provider.StageNavigation)
+		return fmt.Errorf("invoice page did not load")
+	}
+	return nil
+}
@@ -0,0 +1,619 @@
+package vodafone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestParseInvoiceInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantMonth string
+		wantYear  string
+		wantNil   bool
+	}{
+		{
+			name:      "Aktuelle Rechnung format",
+			text:      "Aktuelle Rechnung Februar 2026\nRechnung vom 10.02.2026",
+			wantMonth: "02",
+			wantYear:  "2026",
+		},
+		{
+			name:      "Rechnungsdatum format",
+			text:      "Rechnungsdatum: 01. Januar 2026\nKosten: 24,98€",
+			wantMonth: "01",
+			wantYear:  "2026",
+		},
+		{
+			name:      "Rechnung März with special char",
+			text:      "Aktuelle Rechnung März 2026",
+			wantMonth: "03",
+			wantYear:  "2026",
+		},
+		{
+			name:      "Dezember end of year",
+			text:      "Aktuelle Rechnung Dezember 2025\nRechnung vom 10.12.2025",
+			wantMonth: "12",
+			wantYear:  "2025",
+		},
+		{
+			name:    "no match in text",
+			text:    "Willkommen bei Vodafone. Keine Rechnung vorhanden.",
+			wantNil: true,
+		},
+		{
+			name:    "empty text",
+			text:    "",
+			wantNil: true,
+		},
+		{
+			name:    "unknown month name",
+			text:    "Aktuelle Rechnung January 2026",
+			wantNil: true,
+		},
+		{
+			name:      "picks first match",
+			text:      "Aktuelle Rechnung Februar 2026\nRechnungsdatum: 15. Januar 2025",
+			wantMonth: "02",
+			wantYear:  "2026",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := parseInvoiceInfo(tc.text)
+			if tc.wantNil {
+				if info != nil {
+					t.Errorf("expected nil, got month=%s year=%s", info.Month, info.Year)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("expected Invoice, got nil")
+			}
+			if info.Month != tc.wantMonth {
+				t.Errorf("Month = %q, want %q", info.Month, tc.wantMonth)
+			}
+			if info.Year != tc.wantYear {
+				t.Errorf("Year = %q, want %q", info.Year, tc.wantYear)
+			}
+		})
+	}
+}
+
+func TestParseArchiveFirstEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantMonth string
+		wantYear  string
+		wantName  string
+		wantNil   bool
+	}{
+		{
+			name: "typical archive page",
+			text: `Aktuelle Rechnung Februar 2026
+Rechnungsarchiv
+Datum	Betrag	Rechnung
+Januar
+04.01.2026
+24,98 €
+Rechnung (PDF)
+Dezember
+04.12.2025
+24,98 €`,
+			wantMonth: "01",
+			wantYear:  "2026",
+			wantName:  "Januar",
+		},
+		{
+			name: "März entry with umlaut",
+			text: `Rechnungsarchiv
+März
+15.03.2026
+44,98 €`,
+			wantMonth: "03",
+			wantYear:  "2026",
+			wantName:  "März",
+		},
+		{
+			name: "picks first entry not second",
+			text: `Rechnungsarchiv
+November
+10.11.2025
+44,98 €
+Oktober
+09.10.2025
+44,98 €`,
+			wantMonth: "11",
+			wantYear:  "2025",
+			wantName:  "November",
+		},
+		{
+			name:    "no Rechnungsarchiv section",
+			text:    "Aktuelle Rechnung Februar 2026\nKeine weiteren Rechnungen.",
+			wantNil: true,
+		},
+		{
+			name:    "Rechnungsarchiv but no entries",
+			text:    "Rechnungsarchiv\nKeine Rechnungen vorhanden.",
+			wantNil: true,
+		},
+		{
+			name:    "empty text",
+			text:    "",
+			wantNil: true,
+		},
+		{
+			name: "ignores current invoice before archive section",
+			text: `Aktuelle Rechnung Februar 2026
+Rechnung vom 10.02.2026
+Rechnungsarchiv
+Januar
+04.01.2026
+24,98 €`,
+			wantMonth: "01",
+			wantYear:  "2026",
+			wantName:  "Januar",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := parseArchiveFirstEntry(tc.text)
+			if tc.wantNil {
+				if info != nil {
+					t.Errorf("expected nil, got month=%s year=%s", info.Month, info.Year)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("expected Invoice, got nil")
+			}
+			if info.Month != tc.wantMonth {
+				t.Errorf("Month = %q, want %q", info.Month, tc.wantMonth)
+			}
+			if info.Year != tc.wantYear {
+				t.Errorf("Year = %q, want %q", info.Year, tc.wantYear)
+			}
+			if info.MonthName != tc.wantName {
+				t.Errorf("MonthName = %q, want %q", info.MonthName, tc.wantName)
+			}
+		})
+	}
+}
+
+func TestParseInvoiceInfoAllMonths(t *testing.T) {
+	for monthName, monthNum := range months {
+		t.Run(monthName, func(t *testing.T) {
+			text := "Aktuelle Rechnung " + monthName + " 2026"
+			info := parseInvoiceInfo(text)
+			if info == nil {
+				t.Fatalf("expected Invoice for %s, got nil", monthName)
+			}
+			if info.Month != monthNum {
+				t.Errorf("Month = %q, want %q", info.Month, monthNum)
+			}
+			if info.Year != "2026" {
+				t.Errorf("Year = %q, want %q", info.Year, "2026")
+			}
+			if info.MonthName != monthName {
+				t.Errorf("MonthName = %q, want %q", info.MonthName, monthName)
+			}
+		})
+	}
+}
+
+func TestParseInvoiceInfoRechnungsdatumAllMonths(t *testing.T) {
+	for monthName, monthNum := range months {
+		t.Run(monthName, func(t *testing.T) {
+			text := "Rechnungsdatum: 15. " + monthName + " 2025"
+			info := parseInvoiceInfo(text)
+			if info == nil {
+				t.Fatalf("expected Invoice for %s, got nil", monthName)
+			}
+			if info.Month != monthNum {
+				t.Errorf("Month = %q, want %q", info.Month, monthNum)
+			}
+			if info.Year != "2025" {
+				t.Errorf("Year = %q, want %q", info.Year, "2025")
+			}
+		})
+	}
+}
+
+func TestParseInvoiceInfoEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantMonth string
+		wantYear  string
+		wantNil   bool
+	}{
+		{
+			name:    "month name with lowercase",
+			text:    "Aktuelle Rechnung februar 2026",
+			wantNil: true,
+		},
+		{
+			name:    "year too short",
+			text:    "Aktuelle Rechnung Februar 26",
+			wantNil: true,
+		},
+		{
+			name:      "extra whitespace in Rechnungsdatum",
+			text:      "Rechnungsdatum:  01.  März  2026",
+			wantMonth: "03",
+			wantYear:  "2026",
+		},
+		{
+			name:      "Rechnungsdatum without colon",
+			text:      "Rechnungsdatum 01. April 2026",
+			wantMonth: "04",
+			wantYear:  "2026",
+		},
+		{
+			name:      "text with lots of surrounding content",
+			text:      "Hallo Nutzer\nDein Vertrag\nDetails\nAktuelle Rechnung Oktober 2025\nRechnung vom 01.10.2025\nBetrag: 39,99€\nMehr anzeigen",
+			wantMonth: "10",
+			wantYear:  "2025",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := parseInvoiceInfo(tc.text)
+			if tc.wantNil {
+				if info != nil {
+					t.Errorf("expected nil, got month=%s year=%s", info.Month, info.Year)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("expected Invoice, got nil")
+			}
+			if info.Month != tc.wantMonth {
+				t.Errorf("Month = %q, want %q", info.Month, tc.wantMonth)
+			}
+			if info.Year != tc.wantYear {
+				t.Errorf("Year = %q, want %q", info.Year, tc.wantYear)
+			}
+		})
+	}
+}
+
+func TestParseArchiveFirstEntryEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantMonth string
+		wantYear  string
+		wantName  string
+		wantNil   bool
+	}{
+		{
+			name:    "unknown month in archive",
+			text:    "Rechnungsarchiv\nJanuary\n04.01.2026\n24,98 €",
+			wantNil: true,
+		},
+		{
+			name: "all months parseable in archive",
+			text: `Rechnungsarchiv
+Dezember
+15.12.2025
+44,98 €`,
+			wantMonth: "12",
+			wantYear:  "2025",
+			wantName:  "Dezember",
+		},
+		{
+			name:    "Rechnungsarchiv with only header text",
+			text:    "Rechnungsarchiv\nDatum\tBetrag\tRechnung",
+			wantNil: true,
+		},
+		{
+			name: "multiple archive sections picks from first",
+			text: `Rechnungsarchiv
+April
+01.04.2026
+30,00 €
+Rechnungsarchiv
+Mai
+01.05.2026
+35,00 €`,
+			wantMonth: "04",
+			wantYear:  "2026",
+			wantName:  "April",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := parseArchiveFirstEntry(tc.text)
+			if tc.wantNil {
+				if info != nil {
+					t.Errorf("expected nil, got month=%s year=%s", info.Month, info.Year)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("expected Invoice, got nil")
+			}
+			if info.Month != tc.wantMonth {
+				t.Errorf("Month = %q, want %q", info.Month, tc.wantMonth)
+			}
+			if info.Year != tc.wantYear {
+				t.Errorf("Year = %q, want %q", info.Year, tc.wantYear)
+			}
+			if info.MonthName != tc.wantName {
+				t.Errorf("MonthName = %q, want %q", info.MonthName, tc.wantName)
+			}
+		})
+	}
+}
+
+func TestParseInvoiceAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want float64
+	}{
+		{
+			name: "amount after current invoice",
+			text: "Aktuelle Rechnung Februar 2026\nRechnung vom 10.02.2026\n24,98 €\nRechnungsarchiv\nJanuar\n04.01.2026\n19,99 €",
+			want: 24.98,
+		},
+		{
+			name: "thousands separator",
+			text: "Rechnungsdatum: 01. März 2026\nBetrag: 1.024,50€",
+			want: 1024.50,
+		},
+		{
+			name: "archive amount is not used for current invoice",
+			text: "Aktuelle Rechnung Februar 2026\nRechnungsarchiv\nJanuar\n04.01.2026\n19,99 €",
+			want: 0,
+		},
+		{
+			name: "amount before match is ignored",
+			text: "Guthaben 5,00 €\nAktuelle Rechnung Februar 2026",
+			want: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := parseInvoiceInfo(tc.text)
+			if info == nil {
+				t.Fatal("expected Invoice, got nil")
+			}
+			if info.Amount != tc.want {
+				t.Errorf("Amount = %v, want %v", info.Amount, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseArchiveFirstEntryAmount(t *testing.T) {
+	info := parseArchiveFirstEntry("Rechnungsarchiv\nJanuar\n04.01.2026\n24,98 €\nRechnung (PDF)")
+	if info == nil {
+		t.Fatal("expected Invoice, got nil")
+	}
+	if info.Amount != 24.98 {
+		t.Errorf("Amount = %v, want 24.98", info.Amount)
+	}
+
+	info = parseArchiveFirstEntry("Rechnungsarchiv\nJanuar\n04.01.2026\nRechnung (PDF)")
+	if info == nil {
+		t.Fatal("expected Invoice, got nil")
+	}
+	if info.Amount != 0 {
+		t.Errorf("Amount = %v, want 0 when missing", info.Amount)
+	}
+}
+
+func TestParseInvoiceNumber(t *testing.T) {
+	info := parseInvoiceInfo("Aktuelle Rechnung Februar 2026\nRechnungsnummer: 123456789012\n24,98 €\nRechnungsarchiv\nRechnungsnummer: 999")
+	if info == nil {
+		t.Fatal("expected Invoice, got nil")
+	}
+	if info.Number != "123456789012" {
+		t.Errorf("Number = %q, want %q", info.Number, "123456789012")
+	}
+
+	info = parseInvoiceInfo("Aktuelle Rechnung Februar 2026\nRechnungsarchiv\nRechnungsnummer: 999")
+	if info == nil {
+		t.Fatal("expected Invoice, got nil")
+	}
+	if info.Number != "" {
+		t.Errorf("Number = %q, want empty (archive number must not be used)", info.Number)
+	}
+}
+
+func TestBrowserCrashed(t *testing.T) {
+	live := context.Background()
+	if browserCrashed(live) {
+		t.Error("live context reported as crashed")
+	}
+
+	crashed, cancel := context.WithCancelCause(live)
+	cancel(ErrBrowserCrashed)
+	if !browserCrashed(crashed) {
+		t.Error("crashed page not detected")
+	}
+
+	timedOut, cancelTimeout := context.WithTimeout(crashed, time.Hour)
+	defer cancelTimeout()
+	if !browserCrashed(timedOut) {
+		t.Error("crash not detected through a derived context")
+	}
+
+	expired, cancelExpired := context.WithDeadline(live, time.Now().Add(-time.Second))
+	defer cancelExpired()
+	if browserCrashed(expired) {
+		t.Error("timeout reported as crash")
+	}
+}
+
+func TestDownloadAllRecoversFromCrash(t *testing.T) {
+	s, err := NewSessionRecorder(t.TempDir(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Save()
+	replay, err := OpenSession(s.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crashed, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrBrowserCrashed)
+
+	t.Run("relaunch", func(t *testing.T) {
+		d := NewClient(Config{})
+		d.Session = replay
+		relaunches := 0
+		d.Relaunch = func() (context.Context, error) {
+			relaunches++
+			return context.Background(), nil
+		}
+		_, failures := d.Download(crashed)
+		if relaunches != 1 {
+			t.Errorf("relaunches = %d, want 1", relaunches)
+		}
+		for _, f := range failures {
+			if errors.Is(f.Err, ErrBrowserCrashed) {
+				t.Errorf("%s failed with crash after relaunch: %v", f.Type, f.Err)
+			}
+		}
+	})
+
+	t.Run("no recovery", func(t *testing.T) {
+		d := NewClient(Config{})
+		d.Session = replay
+		_, failures := d.Download(crashed)
+		if len(failures) != len(DefaultContracts) {
+			t.Fatalf("failures = %+v", failures)
+		}
+		for _, f := range failures {
+			if !errors.Is(f.Err, ErrBrowserCrashed) {
+				t.Errorf("%s error = %v, want ErrBrowserCrashed", f.Type, f.Err)
+			}
+		}
+	})
+	t.Run("memory limit", func(t *testing.T) {
+		overMemory, cancel := context.WithCancelCause(context.Background())
+		cancel(fmt.Errorf("%w: Chrome used 900 MB, limit 800 MB", ErrBrowserMemory))
+		d := NewClient(Config{})
+		d.Session = replay
+		d.Relaunch = func() (context.Context, error) {
+			t.Error("browser relaunched after exceeding its memory limit")
+			return context.Background(), nil
+		}
+		_, failures := d.Download(overMemory)
+		for _, f := range failures {
+			if !errors.Is(f.Err, ErrBrowserMemory) || errors.Is(f.Err, ErrBrowserCrashed) {
+				t.Errorf("%s error = %v, want ErrBrowserMemory", f.Type, f.Err)
+			}
+		}
+	})
+}
+
+func TestParseArchiveEntries(t *testing.T) {
+	text := "Aktuelle Rechnung März 2026\nRechnungsarchiv\nFebruar\n04.02.2026\n24,98 €\nRechnung (PDF)\nJanuar\n04.01.2026\n19,99 €\nRechnung (PDF)\nDezember\n04.12.2025\n24,98 €"
+	entries := parseArchiveEntries(text)
+	want := []string{"02/2026 24.98", "01/2026 19.99", "12/2025 24.98"}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v", entries)
+	}
+	for i, e := range entries {
+		if got := fmt.Sprintf("%s/%s %.2f", e.Month, e.Year, e.Amount); got != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, got, want[i])
+		}
+	}
+	if parseArchiveEntries("Aktuelle Rechnung März 2026") != nil {
+		t.Error("expected no entries without Rechnungsarchiv")
+	}
+}
+
+func TestDownloadInvoiceSelectedMonth(t *testing.T) {
+	rec, err := NewSessionRecorder(t.TempDir(), time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.text("kabel", func() (string, string) {
+		return "Aktuelle Rechnung März 2026\nRechnungsarchiv\nFebruar\n04.02.2026\n39,99 €\nJanuar\n04.01.2026\n41,50 €", ""
+	})
+	rec.pdf("kabel_archive", func() ([]byte, error) { return []byte("%PDF-1.4"), nil })
+	rec.Save()
+	s, err := OpenSession(rec.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewClient(Config{})
+	d.Session = s
+	d.Now = s.Now
+	d.Month, d.Year = "01", "2026"
+	inv, err := d.DownloadInvoice(context.Background(), "kabel")
+	if err != nil {
+		t.Fatalf("DownloadInvoice() error: %v", err)
+	}
+	if inv.Filename != "01_2026_Rechnung_Vodafone_Kabel.pdf" || inv.Amount != 41.50 {
+		t.Errorf("invoice = %s, %.2f", inv.Filename, inv.Amount)
+	}
+
+	d.Month, d.Year = "11", "2025"
+	if _, err := d.DownloadInvoice(context.Background(), "kabel"); !errors.Is(err, provider.ErrInvoiceNotReady) {
+		t.Errorf("DownloadInvoice() for a month not in the archive error = %v, want ErrInvoiceNotReady", err)
+	}
+	if _, err := d.DownloadInvoice(context.Background(), "festnetz"); err == nil {
+		t.Error("DownloadInvoice() of an unknown contract succeeded")
+	}
+}
+
+func TestDownloadArchiveEntryWrongPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		pdf     []byte
+		wantErr bool
+	}{
+		{name: "matching period", pdf: minimalPDF("Rechnung Januar 2026")},
+		{name: "no period in the PDF", pdf: minimalPDF("Rechnung")},
+		{name: "other period", pdf: minimalPDF("Rechnung Februar 2026"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := NewSessionRecorder(t.TempDir(), time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.text("kabel", func() (string, string) {
+				return "Aktuelle Rechnung März 2026\nRechnungsarchiv\nFebruar\n04.02.2026\n39,99 €\nJanuar\n04.01.2026\n41,50 €", ""
+			})
+			rec.pdf("kabel_archive", func() ([]byte, error) { return tt.pdf, nil })
+			rec.Save()
+			s, err := OpenSession(rec.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d := NewClient(Config{})
+			d.Session = s
+			d.Now = s.Now
+			d.Month, d.Year = "01", "2026"
+			_, err = d.DownloadInvoice(context.Background(), "kabel")
+			if tt.wantErr && !errors.Is(err, provider.ErrCaptureFailed) {
+				t.Errorf("DownloadInvoice() error = %v, want ErrCaptureFailed", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("DownloadInvoice() error: %v", err)
+			}
+		})
+	}
+}
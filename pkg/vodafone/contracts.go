@@ -0,0 +1,125 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// contract is one contract card on the services page. Several cards may have the same
+// type, e.g. two Mobilfunk contracts.
+type contract struct {
+	key  string // "mobilfunk", "mobilfunk_2": identifies the contract in invoice_urls, checkpoints and recordings
+	typ  string // contract type, e.g. "mobilfunk"
+	name string // "Mobilfunk", "Mobilfunk 2": shown in emails and used in file names
+	card int    // index among the cards of its type on the services page
+}
+
+// configuredContracts returns the contracts of the configured contract types, one per type.
+func configuredContracts(types []string) []contract {
+	contracts := make([]contract, 0, len(types))
+	for _, t := range types {
+		contracts = append(contracts, contract{key: t, typ: t, name: ContractTypes[t]})
+	}
+	return contracts
+}
+
+// contractCard is a card found on the services page: its heading and its full text.
+type contractCard struct {
+	Heading string `json:"heading"`
+	Text    string `json:"text"`
+}
+
+var contractNumberPattern = regexp.MustCompile(`(?:Vertragsnummer|Kundennummer|Rufnummer)[:\s]+([0-9][0-9 /-]*[0-9])`)
+
+// contractCardType returns the contract type whose card headings match heading, or "".
+// DSL is tried last, since its headings are the most general.
+func contractCardType(heading string) string {
+	for _, contractType := range []string{"mobilfunk", "kabel", "dsl"} {
+		for _, name := range contractCardNames(ContractTypes[contractType]) {
+			if strings.Contains(heading, name) {
+				return contractType
+			}
+		}
+	}
+	return ""
+}
+
+// parseContractCards turns the cards of the services page into contracts, keeping
+// only the given types. The second card of a type becomes e.g. "mobilfunk_2" /
+// "Mobilfunk 2". Cards of unknown types are skipped.
+func parseContractCards(cards []contractCard, types []string) []contract {
+	wanted := map[string]bool{}
+	for _, t := range types {
+		wanted[t] = true
+	}
+	var contracts []contract
+	seen := map[string]int{}
+	for _, card := range cards {
+		t := contractCardType(card.Heading)
+		if t == "" {
+			continue
+		}
+		n := seen[t]
+		seen[t]++
+		if !wanted[t] {
+			continue
+		}
+		c := contract{key: t, typ: t, name: ContractTypes[t], card: n}
+		if n > 0 {
+			c.key = fmt.Sprintf("%s_%d", t, n+1)
+			c.name = fmt.Sprintf("%s %d", ContractTypes[t], n+1)
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts
+}
+
+// discoverContracts lists the contract cards on the services page and returns the
+// contracts of the given types, so that every contract of the account is downloaded,
+// including additional Mobilfunk contracts.
+func (d *Client) discoverContracts(ctx context.Context, types []string) ([]contract, error) {
+	if err := d.browser.navigate(ctx, servicesURL); err != nil {
+		return nil, err
+	}
+	cards, err := d.browser.cards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contracts := parseContractCards(cards, types)
+	for _, card := range cards {
+		t := contractCardType(card.Heading)
+		number := ""
+		if m := contractNumberPattern.FindStringSubmatch(card.Text); m != nil {
+			number = m[1]
+		}
+		switch {
+		case t == "":
+			slog.Info("Found card of unknown contract type", "heading", card.Heading)
+		default:
+			slog.Info("Found contract", "contract", ContractTypes[t], "number", number)
+		}
+	}
+	if len(contracts) == 0 {
+		return nil, fmt.Errorf("no contract cards found")
+	}
+	return contracts, nil
+}
+
+// contractFor returns the contract with the given key, or the first card of the type
+// if the key is not among the contracts.
+func (d *Client) contractFor(key string) contract {
+	for _, c := range d.contracts {
+		if c.key == key {
+			return c
+		}
+	}
+	return contract{key: key, typ: key, name: ContractTypes[key]}
+}
+
+// fileTypeName returns the contract name as used in file names, e.g. "Mobilfunk_2".
+func fileTypeName(typeName string) string {
+	return strings.ReplaceAll(typeName, " ", "_")
+}
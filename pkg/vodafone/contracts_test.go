@@ -0,0 +1,107 @@
+package vodafone
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseContractCards(t *testing.T) {
+	cards := []contractCard{
+		{Heading: "Mobilfunk-Vertrag", Text: "Mobilfunk-Vertrag\nRufnummer 0172 1234567"},
+		{Heading: "Kabel-Vertrag", Text: "Kabel-Vertrag\nKundennummer 123456789"},
+		{Heading: "Mobilfunk-Vertrag", Text: "Mobilfunk-Vertrag\nRufnummer 0152 7654321"},
+		{Heading: "Internet & Festnetz", Text: "Internet & Festnetz\nDSL 100"},
+		{Heading: "Meine Daten", Text: "Meine Daten"},
+	}
+
+	tests := []struct {
+		name  string
+		types []string
+		want  []contract
+	}{
+		{
+			name:  "all types",
+			types: []string{"mobilfunk", "kabel", "dsl"},
+			want: []contract{
+				{key: "mobilfunk", typ: "mobilfunk", name: "Mobilfunk", card: 0},
+				{key: "kabel", typ: "kabel", name: "Kabel", card: 0},
+				{key: "mobilfunk_2", typ: "mobilfunk", name: "Mobilfunk 2", card: 1},
+				{key: "dsl", typ: "dsl", name: "DSL", card: 0},
+			},
+		},
+		{
+			name:  "filtered",
+			types: []string{"mobilfunk"},
+			want: []contract{
+				{key: "mobilfunk", typ: "mobilfunk", name: "Mobilfunk", card: 0},
+				{key: "mobilfunk_2", typ: "mobilfunk", name: "Mobilfunk 2", card: 1},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseContractCards(cards, tc.types); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseContractCards() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContractCardType(t *testing.T) {
+	tests := map[string]string{
+		"Mobilfunk-Vertrag":                 "mobilfunk",
+		"Kabel-Vertrag":                     "kabel",
+		"DSL-Vertrag":                       "dsl",
+		"Kabel-Vertrag Internet & Festnetz": "kabel",
+		"Meine Daten":                       "",
+	}
+	for heading, want := range tests {
+		if got := contractCardType(heading); got != want {
+			t.Errorf("contractCardType(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestDownloadAllDiscoveredContracts(t *testing.T) {
+	rec, err := NewSessionRecorder(t.TempDir(), time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"mobilfunk", "mobilfunk_2"} {
+		rec.text(key, func() (string, string) { return "Aktuelle Rechnung Februar 2026\nBetrag 24,98 €", "" })
+		rec.pdf(key+"_current", func() ([]byte, error) { return []byte("%PDF-1.4"), nil })
+	}
+	rec.Save()
+	s, err := OpenSession(rec.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewClient(Config{})
+	d.Session = s
+	d.Now = func() time.Time { return s.manifest.Now }
+	d.contracts = parseContractCards([]contractCard{{Heading: "Mobilfunk-Vertrag"}, {Heading: "Mobilfunk-Vertrag"}}, []string{"mobilfunk"})
+	results, failures := d.Download(context.Background())
+	if len(failures) != 0 {
+		t.Fatalf("failures = %+v", failures)
+	}
+	var names []string
+	for _, inv := range results {
+		names = append(names, inv.Type+": "+inv.Filename)
+	}
+	want := []string{"Mobilfunk: 02_2026_Rechnung_Vodafone_Mobilfunk.pdf", "Mobilfunk 2: 02_2026_Rechnung_Vodafone_Mobilfunk_2.pdf"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("results = %v, want %v", names, want)
+	}
+}
+
+func TestContractCardNames(t *testing.T) {
+	if got := contractCardNames("Kabel"); !reflect.DeepEqual(got, []string{"Kabel-Vertrag"}) {
+		t.Errorf("contractCardNames(Kabel) = %v", got)
+	}
+	if got := contractCardNames("DSL"); len(got) < 2 || got[0] != "DSL-Vertrag" {
+		t.Errorf("contractCardNames(DSL) = %v", got)
+	}
+}
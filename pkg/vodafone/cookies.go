@@ -0,0 +1,105 @@
+package vodafone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/internal/fsutil"
+)
+
+// savedCookie is a browser cookie as stored in the cookie file.
+type savedCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitzero"` // zero for session cookies
+	HTTPOnly bool      `json:"http_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	SameSite string    `json:"same_site,omitempty"`
+}
+
+// newSavedCookies converts the cookies of the browser for storing.
+func newSavedCookies(cookies []*network.Cookie) []savedCookie {
+	saved := make([]savedCookie, 0, len(cookies))
+	for _, c := range cookies {
+		s := savedCookie{
+			Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path,
+			HTTPOnly: c.HTTPOnly, Secure: c.Secure, SameSite: string(c.SameSite),
+		}
+		if !c.Session && c.Expires > 0 {
+			s.Expires = time.Unix(int64(c.Expires), 0).UTC()
+		}
+		saved = append(saved, s)
+	}
+	return saved
+}
+
+// cookieParams converts stored cookies back for the browser, leaving out those that
+// have expired by now.
+func cookieParams(saved []savedCookie, now time.Time) []*network.CookieParam {
+	var params []*network.CookieParam
+	for _, s := range saved {
+		if !s.Expires.IsZero() && !s.Expires.After(now) {
+			continue
+		}
+		p := &network.CookieParam{
+			Name: s.Name, Value: s.Value, Domain: s.Domain, Path: s.Path,
+			HTTPOnly: s.HTTPOnly, Secure: s.Secure, SameSite: network.CookieSameSite(s.SameSite),
+		}
+		if !s.Expires.IsZero() {
+			expires := cdp.TimeSinceEpoch(s.Expires)
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// restoreCookies loads the cookies saved by an earlier run into the browser, so the
+// login page can skip the form while the portal session is still valid. A missing
+// file restores nothing. Returns the number of cookies restored.
+func restoreCookies(ctx context.Context, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var saved []savedCookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, err
+	}
+	params := cookieParams(saved, time.Now())
+	if len(params) == 0 {
+		return 0, nil
+	}
+	return len(params), chromedp.Run(ctx, network.SetCookies(params))
+}
+
+// saveCookies writes all cookies of the browser to path after a successful login.
+// The file grants access to the account like the password, so it is only readable
+// by the owner.
+func saveCookies(ctx context.Context, path string) error {
+	var cookies []*network.Cookie
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().Do(ctx)
+		return err
+	})); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(newSavedCookies(cookies), "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0600)
+}
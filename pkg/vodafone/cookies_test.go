@@ -0,0 +1,31 @@
+package vodafone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestCookieRoundTrip(t *testing.T) {
+	now := time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC)
+	saved := newSavedCookies([]*network.Cookie{
+		{Name: "session", Value: "abc", Domain: ".vodafone.de", Path: "/", Session: true, Expires: -1, HTTPOnly: true, Secure: true},
+		{Name: "remember", Value: "def", Domain: ".vodafone.de", Path: "/", Expires: float64(now.Add(24 * time.Hour).Unix()), SameSite: network.CookieSameSiteLax},
+		{Name: "expired", Value: "ghi", Domain: ".vodafone.de", Path: "/", Expires: float64(now.Add(-time.Hour).Unix())},
+	})
+	if len(saved) != 3 || !saved[0].Expires.IsZero() || saved[1].Expires.IsZero() {
+		t.Fatalf("saved = %+v", saved)
+	}
+
+	params := cookieParams(saved, now)
+	if len(params) != 2 {
+		t.Fatalf("params = %d, want 2 (expired cookie left out)", len(params))
+	}
+	if p := params[0]; p.Name != "session" || p.Expires != nil || !p.HTTPOnly || !p.Secure {
+		t.Errorf("session cookie = %+v", p)
+	}
+	if p := params[1]; p.Name != "remember" || p.Expires == nil || !p.Expires.Time().Equal(now.Add(24*time.Hour)) || p.SameSite != network.CookieSameSiteLax {
+		t.Errorf("persistent cookie = %+v", p)
+	}
+}
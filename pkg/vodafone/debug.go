@@ -0,0 +1,84 @@
+package vodafone
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultDebugDir is where screenshots of failed steps are written.
+const DefaultDebugDir = "debug"
+
+// debugFailedStep keeps the state of the browser after a failed step: a screenshot
+// and, with debug.dump_html, the page's HTML.
+func (d *Client) debugFailedStep(ctx context.Context, name string) {
+	d.screenshot(ctx, name)
+	d.dumpPage(ctx, name)
+}
+
+// screenshot saves a full-page screenshot of the browser as
+// <debug dir>/<timestamp>_<name>.png, so a failed navigation or PDF capture can be
+// diagnosed after the run, e.g. after a Vodafone layout change. Returns the path, or
+// "" if no screenshot was taken; errors are only logged.
+func (d *Client) screenshot(ctx context.Context, name string) string {
+	if d.DebugDir == "" || d.Session.replaying() || ctx.Err() != nil {
+		return ""
+	}
+	var png []byte
+	if err := chromedp.Run(ctx, chromedp.FullScreenshot(&png, 100)); err != nil {
+		slog.Warn("Screenshot failed", "err", err)
+		return ""
+	}
+	path, err := writeDebugFile(d.DebugDir, name+".png", png)
+	if err != nil {
+		slog.Warn("Screenshot failed", "err", err)
+		return ""
+	}
+	slog.Info("Screenshot saved", "file", path)
+	d.lastScreenshot = path
+	return path
+}
+
+// dumpPage saves the HTML of the current page as <debug dir>/<timestamp>_<name>.html
+// with its URL in a leading comment, so a selector regression can be reproduced
+// offline. Only with debug.dump_html; errors are only logged.
+func (d *Client) dumpPage(ctx context.Context, name string) {
+	if !d.DumpHTML || d.DebugDir == "" || d.Session.replaying() || ctx.Err() != nil {
+		return
+	}
+	var location, html string
+	if err := chromedp.Run(ctx,
+		chromedp.Location(&location),
+		chromedp.Evaluate(`document.documentElement.outerHTML`, &html),
+	); err != nil {
+		slog.Warn("Saving page HTML failed", "err", err)
+		return
+	}
+	path, err := writeDebugFile(d.DebugDir, name+".html", []byte(pageDump(location, html)))
+	if err != nil {
+		slog.Warn("Saving page HTML failed", "err", err)
+		return
+	}
+	slog.Info("Page HTML saved", "file", path, "url", location)
+}
+
+// pageDump prepends the page URL to its HTML as a comment. Double hyphens would end
+// the comment early and are escaped.
+func pageDump(location, html string) string {
+	return "<!-- " + strings.ReplaceAll(location, "--", "%2D%2D") + " -->\n" + html
+}
+
+// writeDebugFile writes data to dir with the current time prepended to name, so the
+// files of several runs sort chronologically and don't overwrite each other.
+func writeDebugFile(dir, name string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, time.Now().Format("20060102-150405")+"_"+name)
+	return path, os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,40 @@
+package vodafone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWriteDebugFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "debug")
+	path, err := writeDebugFile(dir, "kabel_capture.png", []byte("png"))
+	if err != nil {
+		t.Fatalf("writeDebugFile() error: %v", err)
+	}
+	if !regexp.MustCompile(`^\d{8}-\d{6}_kabel_capture\.png$`).MatchString(filepath.Base(path)) || filepath.Dir(path) != dir {
+		t.Errorf("path = %s", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "png" {
+		t.Errorf("content = %q", data)
+	}
+}
+
+func TestPageDump(t *testing.T) {
+	got := pageDump("https://www.vodafone.de/meinvodafone/services/?a--b", "<html><body>Rechnungsarchiv</body></html>")
+	want := "<!-- https://www.vodafone.de/meinvodafone/services/?a%2D%2Db -->\n<html><body>Rechnungsarchiv</body></html>"
+	if got != want {
+		t.Errorf("pageDump() = %q, want %q", got, want)
+	}
+
+	// Without debug.dump_html nothing is written, and no browser is needed
+	dir := filepath.Join(t.TempDir(), "debug")
+	d := NewClient(Config{})
+	d.DebugDir = dir
+	d.dumpPage(context.Background(), "kabel_invoice_info")
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("debug directory created without dump_html: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const (
+	defaultDocumentsURL         = "https://www.vodafone.de/meinvodafone/services/dokumente"
+	defaultDocumentsFolder      = "Zahlungsbelege"
+	defaultPriceDocumentsFolder = "Preisinformationen"
+)
+
+// documentKinds maps a pattern matching the title of a document in the documents area
+// to the short kind used in filenames. price marks the yearly price information
+// letters, which are needed to exercise special cancellation rights; the others are
+// payment documents.
+var documentKinds = []struct {
+	pattern *regexp.Regexp
+	kind    string
+	price   bool
+}{
+	{regexp.MustCompile(`(?i)SEPA|Lastschriftmandat`), "SEPA-Mandat", false},
+	{regexp.MustCompile(`(?i)Erstattung|Gutschrift|Rückzahlung`), "Erstattung", false},
+	{regexp.MustCompile(`(?i)Zahlungsbestätigung|Zahlungseingang|Zahlungsbeleg`), "Zahlungsbestaetigung", false},
+	{regexp.MustCompile(`(?i)Entgeltübersicht|Entgeltuebersicht`), "Entgeltuebersicht", true},
+	{regexp.MustCompile(`(?i)Preisinformation|Preisänderung|Preisanpassung`), "Preisinformation", true},
+}
+
+// documentKind returns the kind of a document from its title and whether it is a price
+// information letter, or "" if the document is of no interest.
+func documentKind(title string) (kind string, price bool) {
+	for _, k := range documentKinds {
+		if k.pattern.MatchString(title) {
+			return k.kind, k.price
+		}
+	}
+	return "", false
+}
+
+var documentDatePattern = regexp.MustCompile(`(\d{2})\.(\d{2})\.(\d{4})`)
+
+// parseDocumentEntry turns the text of a documents list entry (e.g.
+// "SEPA-Lastschriftmandat\n12.02.2026\nPDF herunterladen") into a document without
+// data, filed in the folder configured for its kind. Returns nil for entries that are
+// not dated documents of an enabled kind.
+func parseDocumentEntry(text string, c DocumentsConfig) *provider.Invoice {
+	kind, price := documentKind(text)
+	date := documentDatePattern.FindStringSubmatch(text)
+	if kind == "" || date == nil {
+		return nil
+	}
+	folder := orDefault(c.Folder, defaultDocumentsFolder)
+	if price {
+		folder = orDefault(c.PricesFolder, defaultPriceDocumentsFolder)
+	}
+	if (price && !c.Prices) || (!price && !c.Payments) {
+		return nil
+	}
+	month, _ := time.Parse("01", date[2])
+	return &provider.Invoice{
+		Type:      kind,
+		Month:     date[2],
+		Year:      date[3],
+		MonthName: MonthNames[month.Month()],
+		Filename:  fmt.Sprintf("%s-%s-%s_%s_Vodafone.pdf", date[3], date[2], date[1], kind),
+		Folder:    folder,
+	}
+}
+
+// markDocumentsJS tags the innermost entries of the documents list that offer a PDF
+// with data-vd-doc and returns their text.
+const markDocumentsJS = `(() => {
+	const items = [...document.querySelectorAll('li, tr, article, [role="row"]')].filter(el =>
+		/\d{2}\.\d{2}\.\d{4}/.test(el.innerText) &&
+		[...el.querySelectorAll('a, button')].some(b => /PDF|herunterladen/i.test(b.innerText)));
+	const inner = items.filter(el => !items.some(o => o !== el && el.contains(o)));
+	inner.forEach((el, i) => el.setAttribute('data-vd-doc', i));
+	return inner.map(el => el.innerText.trim());
+})()`
+
+// clickDocumentJS returns JS that clicks the download link of the n-th tagged entry.
+func clickDocumentJS(n int) string {
+	return fmt.Sprintf(`[...document.querySelector('[data-vd-doc="%d"]').querySelectorAll('a, button')]
+		.find(b => /PDF|herunterladen/i.test(b.innerText)).click()`, n)
+}
+
+// DownloadDocuments downloads this month's SEPA mandate confirmations, payment or
+// refund receipts and price information letters from the documents area, as far as
+// enabled. They are returned like invoices, with Folder set so storage targets keep
+// them apart from the invoices.
+func (d *Client) DownloadDocuments(ctx context.Context, c DocumentsConfig, now time.Time) ([]provider.Invoice, error) {
+	if err := chromedp.Run(ctx,
+		chromedp.Navigate(orDefault(c.URL, defaultDocumentsURL)),
+		chromedp.Sleep(stepTimeouts(ctx).settle()),
+	); err != nil {
+		return nil, fmt.Errorf("documents area not reachable: %v", err)
+	}
+
+	var entries []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(markDocumentsJS, &entries)); err != nil {
+		return nil, err
+	}
+
+	month, year := fmt.Sprintf("%02d", now.Month()), fmt.Sprintf("%d", now.Year())
+	var documents []provider.Invoice
+	for i, entry := range entries {
+		doc := parseDocumentEntry(entry, c)
+		if doc == nil || doc.Month != month || doc.Year != year {
+			continue
+		}
+		slog.Info("Downloading document", "file", doc.Filename, "step", provider.StageCapture)
+		pdfData, err := d.captureClick(ctx, fmt.Sprintf("document_%d", i), clickDocumentJS(i))
+		if err != nil {
+			slog.Warn("Document download failed", "file", doc.Filename, "step", provider.StageCapture, "err", err)
+			continue
+		}
+		doc.PDFData = pdfData
+		documents = append(documents, *doc)
+	}
+	return documents, nil
+}
+
+type DocumentsConfig struct {
+	Payments     bool   `yaml:"payments"`      // download SEPA mandate confirmations and payment/refund receipts
+	Prices       bool   `yaml:"prices"`        // download the yearly price information letters ("Entgeltübersicht")
+	URL          string `yaml:"url"`           // documents area page, defaults to defaultDocumentsURL
+	Folder       string `yaml:"folder"`        // archive subfolder for payment documents, defaults to "Zahlungsbelege"
+	PricesFolder string `yaml:"prices_folder"` // archive subfolder for price information, defaults to "Preisinformationen"
+}
@@ -0,0 +1,80 @@
+package vodafone
+
+import (
+	"testing"
+)
+
+func TestParseDocumentEntry(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		config       DocumentsConfig
+		wantNil      bool
+		wantType     string
+		wantFilename string
+		wantFolder   string
+	}{
+		{
+			name:         "SEPA mandate",
+			text:         "SEPA-Lastschriftmandat\n12.02.2026\nPDF herunterladen",
+			config:       DocumentsConfig{Payments: true},
+			wantType:     "SEPA-Mandat",
+			wantFilename: "2026-02-12_SEPA-Mandat_Vodafone.pdf",
+			wantFolder:   "Zahlungsbelege",
+		},
+		{
+			name:         "refund",
+			text:         "Gutschrift zu deiner Rechnung\n03.03.2026\nPDF",
+			config:       DocumentsConfig{Payments: true},
+			wantType:     "Erstattung",
+			wantFilename: "2026-03-03_Erstattung_Vodafone.pdf",
+			wantFolder:   "Zahlungsbelege",
+		},
+		{
+			name:         "payment receipt",
+			text:         "Zahlungsbestätigung\n28.02.2026\nherunterladen",
+			config:       DocumentsConfig{Payments: true},
+			wantType:     "Zahlungsbestaetigung",
+			wantFilename: "2026-02-28_Zahlungsbestaetigung_Vodafone.pdf",
+			wantFolder:   "Zahlungsbelege",
+		},
+		{
+			name:         "price overview",
+			text:         "Deine Entgeltübersicht 2026\n15.01.2026\nPDF herunterladen",
+			config:       DocumentsConfig{Prices: true, PricesFolder: "Preise"},
+			wantType:     "Entgeltuebersicht",
+			wantFilename: "2026-01-15_Entgeltuebersicht_Vodafone.pdf",
+			wantFolder:   "Preise",
+		},
+		{
+			name:         "price change letter",
+			text:         "Preisinformation zu deinem Vertrag\n02.03.2026\nPDF",
+			config:       DocumentsConfig{Prices: true},
+			wantType:     "Preisinformation",
+			wantFilename: "2026-03-02_Preisinformation_Vodafone.pdf",
+			wantFolder:   "Preisinformationen",
+		},
+		{name: "price documents disabled", text: "Entgeltübersicht\n15.01.2026\nPDF", config: DocumentsConfig{Payments: true}, wantNil: true},
+		{name: "payment documents disabled", text: "SEPA-Lastschriftmandat\n12.02.2026\nPDF", config: DocumentsConfig{Prices: true}, wantNil: true},
+		{name: "other document", text: "Vertragszusammenfassung\n12.02.2026\nPDF", config: DocumentsConfig{Payments: true, Prices: true}, wantNil: true},
+		{name: "no date", text: "SEPA-Lastschriftmandat\nPDF", config: DocumentsConfig{Payments: true}, wantNil: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parseDocumentEntry(tc.text, tc.config)
+			if tc.wantNil {
+				if doc != nil {
+					t.Errorf("expected nil, got %+v", doc)
+				}
+				return
+			}
+			if doc == nil {
+				t.Fatal("expected document, got nil")
+			}
+			if doc.Type != tc.wantType || doc.Filename != tc.wantFilename || doc.Folder != tc.wantFolder {
+				t.Errorf("document = %+v, want type %q, filename %q, folder %q", doc, tc.wantType, tc.wantFilename, tc.wantFolder)
+			}
+		})
+	}
+}
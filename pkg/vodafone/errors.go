@@ -0,0 +1,32 @@
+package vodafone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// Failure classes of the browser, besides the provider.Err* classes of the portal.
+var (
+	ErrBrowserCrashed = errors.New("browser crashed")
+	ErrBrowserMemory  = errors.New("browser memory limit exceeded")
+)
+
+// twoFactorMarkers are texts shown by MeinVodafone when it asks for a one-time code.
+var twoFactorMarkers = []string{"Sicherheitscode", "Bestätigungscode", "SMS-Code"}
+
+// loginError inspects the page after submitting the credentials. It returns
+// Err2FARequired if a one-time code is requested, ErrCredentialsRejected if the login
+// form is still shown, and nil otherwise.
+func loginError(pageText string, onLoginPage bool) error {
+	for _, marker := range twoFactorMarkers {
+		if strings.Contains(pageText, marker) {
+			return provider.Err2FARequired
+		}
+	}
+	if onLoginPage {
+		return provider.ErrCredentialsRejected
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package vodafone
+
+import (
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestLoginError(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		onLoginPage bool
+		want        error
+	}{
+		{name: "logged in", text: "Hallo Max, willkommen in MeinVodafone"},
+		{name: "one-time code requested", text: "Bitte gib den Sicherheitscode ein, den wir dir per SMS geschickt haben", want: provider.Err2FARequired},
+		{name: "code prompt on login page", text: "SMS-Code eingeben", onLoginPage: true, want: provider.Err2FARequired},
+		{name: "credentials rejected", text: "Benutzername oder Kennwort falsch", onLoginPage: true, want: provider.ErrCredentialsRejected},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := loginError(tc.text, tc.onLoginPage); got != tc.want {
+				t.Errorf("loginError() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	defaultInboxURL = "https://www.vodafone.de/meinvodafone/services/postfach"
+	// maxInboxMessages bounds a run in case opening a message doesn't mark it as read.
+	maxInboxMessages = 10
+)
+
+// InboxMessage is an unread message from the MeinVodafone message center, such as a
+// price change or contract notice.
+type InboxMessage struct {
+	Subject     string
+	Date        string
+	Text        string
+	Attachments []InboxAttachment
+}
+
+// InboxAttachment is a PDF attached to an inbox message.
+type InboxAttachment struct {
+	Filename string
+	PDFData  []byte
+}
+
+// markUnreadJS tags the innermost message list entries that look unread with
+// data-vd-unread and returns their text.
+const markUnreadJS = `(() => {
+	const unread = el => /ungelesen|unread/i.test(el.className + ' ' + (el.getAttribute('aria-label') || '')) ||
+		el.querySelector('[class*="unread"], [aria-label*="ungelesen"]') !== null;
+	const items = [...document.querySelectorAll('li, tr, article, [role="row"]')].filter(unread);
+	const inner = items.filter(el => !items.some(o => o !== el && el.contains(o)));
+	inner.forEach((el, i) => el.setAttribute('data-vd-unread', i));
+	return inner.map(el => el.innerText.trim());
+})()`
+
+// clickFirstUnreadJS opens the first entry tagged by markUnreadJS.
+const clickFirstUnreadJS = `(() => {
+	const el = document.querySelector('[data-vd-unread="0"]');
+	(el.querySelector('a, button') || el).click();
+})()`
+
+// countAttachmentsJS returns the number of PDF attachment links of the opened message.
+const countAttachmentsJS = `[...document.querySelectorAll('a, button')].filter(b =>
+	/\.pdf|PDF/.test(b.innerText + ' ' + (b.getAttribute('href') || ''))).length`
+
+// clickAttachmentJS returns JS that clicks the n-th PDF attachment link.
+func clickAttachmentJS(n int) string {
+	return fmt.Sprintf(`[...document.querySelectorAll('a, button')].filter(b =>
+		/\.pdf|PDF/.test(b.innerText + ' ' + (b.getAttribute('href') || '')))[%d].click()`, n)
+}
+
+var (
+	inboxDatePattern    = regexp.MustCompile(`\d{2}\.\d{2}\.\d{4}`)
+	filenameUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// parseInboxEntry extracts subject and date from the text of a message list entry,
+// e.g. "Preisänderung zu deinem Vertrag\n12.02.2026" → subject, "12.02.2026".
+func parseInboxEntry(text string) (subject, date string) {
+	date = inboxDatePattern.FindString(text)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == date || strings.EqualFold(line, "ungelesen") || strings.EqualFold(line, "neu") {
+			continue
+		}
+		return line, date
+	}
+	return "", date
+}
+
+// FetchInboxMessages opens every unread message in the message center and returns its
+// text and PDF attachments. Opening a message marks it as read in the portal, so each
+// message is forwarded once.
+func (d *Client) FetchInboxMessages(ctx context.Context, inboxURL string) ([]InboxMessage, error) {
+	var messages []InboxMessage
+	for len(messages) < maxInboxMessages {
+		if err := chromedp.Run(ctx,
+			chromedp.Navigate(orDefault(inboxURL, defaultInboxURL)),
+			chromedp.Sleep(stepTimeouts(ctx).settle()),
+		); err != nil {
+			return messages, fmt.Errorf("message center not reachable: %v", err)
+		}
+
+		var unread []string
+		if err := chromedp.Run(ctx, chromedp.Evaluate(markUnreadJS, &unread)); err != nil {
+			return messages, err
+		}
+		if len(unread) == 0 {
+			break
+		}
+
+		msg := InboxMessage{}
+		msg.Subject, msg.Date = parseInboxEntry(unread[0])
+		slog.Info("Reading inbox message", "subject", msg.Subject)
+		chromedp.Run(ctx,
+			chromedp.Evaluate(clickFirstUnreadJS, nil),
+			chromedp.Sleep(stepTimeouts(ctx).settle()),
+			chromedp.Evaluate(`(document.querySelector('main') || document.body).innerText`, &msg.Text),
+		)
+
+		var attachments int
+		chromedp.Run(ctx, chromedp.Evaluate(countAttachmentsJS, &attachments))
+		for i := 0; i < attachments; i++ {
+			pdfData, err := d.captureClick(ctx, fmt.Sprintf("inbox_%d_%d", len(messages), i), clickAttachmentJS(i))
+			if err != nil {
+				slog.Warn("Inbox attachment failed", "attachment", i+1, "subject", msg.Subject, "err", err)
+				continue
+			}
+			msg.Attachments = append(msg.Attachments, InboxAttachment{
+				Filename: inboxAttachmentFilename(msg, i),
+				PDFData:  pdfData,
+			})
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// inboxAttachmentFilename names the n-th attachment of a message after its date and
+// subject, e.g. "2026-02-12_Preisaenderung_zu_deinem_Vertrag_1.pdf".
+func inboxAttachmentFilename(msg InboxMessage, n int) string {
+	date := "Nachricht"
+	if parts := strings.Split(msg.Date, "."); len(parts) == 3 {
+		date = parts[2] + "-" + parts[1] + "-" + parts[0]
+	}
+	subject := strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss").Replace(msg.Subject)
+	subject = strings.Trim(filenameUnsafeChars.ReplaceAllString(subject, "_"), "_")
+	if subject == "" {
+		return fmt.Sprintf("%s_%d.pdf", date, n+1)
+	}
+	return fmt.Sprintf("%s_%s_%d.pdf", date, subject, n+1)
+}
@@ -0,0 +1,44 @@
+package vodafone
+
+import (
+	"testing"
+)
+
+func TestParseInboxEntry(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantSubject string
+		wantDate    string
+	}{
+		{name: "subject and date", text: "Preisänderung zu deinem Vertrag\n12.02.2026", wantSubject: "Preisänderung zu deinem Vertrag", wantDate: "12.02.2026"},
+		{name: "badge and date first", text: "Neu\n03.01.2026\n\nDeine Vertragsverlängerung", wantSubject: "Deine Vertragsverlängerung", wantDate: "03.01.2026"},
+		{name: "no date", text: "Wichtige Information", wantSubject: "Wichtige Information"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subject, date := parseInboxEntry(tc.text)
+			if subject != tc.wantSubject || date != tc.wantDate {
+				t.Errorf("parseInboxEntry() = %q, %q, want %q, %q", subject, date, tc.wantSubject, tc.wantDate)
+			}
+		})
+	}
+}
+
+func TestInboxAttachmentFilename(t *testing.T) {
+	tests := []struct {
+		msg  InboxMessage
+		want string
+	}{
+		{msg: InboxMessage{Subject: "Preisänderung zu deinem Vertrag", Date: "12.02.2026"}, want: "2026-02-12_Preisaenderung_zu_deinem_Vertrag_1.pdf"},
+		{msg: InboxMessage{Subject: "AGB / Änderungen!"}, want: "Nachricht_AGB_Aenderungen_1.pdf"},
+		{msg: InboxMessage{Date: "01.03.2026"}, want: "2026-03-01_1.pdf"},
+	}
+
+	for _, tc := range tests {
+		if got := inboxAttachmentFilename(tc.msg, 0); got != tc.want {
+			t.Errorf("inboxAttachmentFilename(%+v) = %q, want %q", tc.msg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+const LoginURL = "https://www.vodafone.de/meinvodafone/account/login"
+
+// waitForLoginForm polls for up to timeouts.page_load until the login form is
+// visible. Returns false if it doesn't appear, e.g. because a saved session skips the
+// login.
+func waitForLoginForm(ctx context.Context) bool {
+	deadline := time.Now().Add(stepTimeouts(ctx).PageLoad)
+	for {
+		var hasForm bool
+		chromedp.Run(ctx, chromedp.Evaluate(`document.querySelector('#username-text') !== null`, &hasForm))
+		if hasForm {
+			return chromedp.Run(ctx, chromedp.WaitVisible(`#username-text`, chromedp.ByID)) == nil
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// Login navigates to the Vodafone login page, dismisses the cookie banner,
+// and submits the account's credentials. If the browser profile still holds a
+// valid session, the login page shows no form and the credentials are skipped.
+func (d *Client) Login(ctx context.Context) error {
+	if d.cfg.CookieFile != "" {
+		if n, err := restoreCookies(ctx, d.cfg.CookieFile); err != nil {
+			slog.Warn("Restoring cookies failed", "err", err)
+		} else if n > 0 {
+			slog.Info("Cookies restored", "count", n, "file", d.cfg.CookieFile)
+		}
+	}
+
+	if err := chromedp.Run(ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			// Remove webdriver flag before any page scripts run
+			_, err := page.AddScriptToEvaluateOnNewDocument(hideWebdriverJS).Do(ctx)
+			return err
+		}),
+		chromedp.Navigate(LoginURL),
+	); err != nil {
+		return fmt.Errorf("%w: login page not reachable: %v", provider.ErrLoginFailed, err)
+	}
+
+	if !waitForLoginForm(ctx) {
+		if d.cfg.ProfileDir == "" && d.cfg.CookieFile == "" {
+			return fmt.Errorf("%w: login form not found", provider.ErrLoginFailed)
+		}
+		return d.reuseSession(ctx)
+	}
+
+	// Dismiss cookie consent banner (ignore error if not present)
+	chromedp.Run(ctx, chromedp.Click(`#dip-consent-summary-reject-all`, chromedp.ByID))
+	time.Sleep(time.Second)
+
+	if err := chromedp.Run(ctx,
+		chromedp.SendKeys(`#username-text`, d.cfg.User, chromedp.ByID),
+		chromedp.SendKeys(`#passwordField-input`, d.cfg.Pass, chromedp.ByID),
+		chromedp.Click(`#submit`, chromedp.ByID),
+		chromedp.Sleep(stepTimeouts(ctx).submit()),
+	); err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrLoginFailed, err)
+	}
+
+	// Tell a rejected login or a one-time code prompt apart from a successful login
+	var pageText string
+	var onLoginPage bool
+	chromedp.Run(ctx,
+		chromedp.Text(`body`, &pageText, chromedp.ByQuery),
+		chromedp.Evaluate(`document.querySelector('#username-text') !== null`, &onLoginPage),
+	)
+	if err := d.answerTwoFactor(ctx, loginError(pageText, onLoginPage)); err != nil {
+		return err
+	}
+	d.saveCookies(ctx)
+	return nil
+}
+
+// CheckSession reports whether the browser is logged in, e.g. after a manual login
+// in a visible browser: the login page must show no form, like for a saved session
+// in Login. The session cookies are then saved to vodafone.cookie_file if set.
+func (d *Client) CheckSession(ctx context.Context) error {
+	if err := chromedp.Run(ctx, chromedp.Navigate(LoginURL)); err != nil {
+		return fmt.Errorf("%w: login page not reachable: %v", provider.ErrLoginFailed, err)
+	}
+	if waitForLoginForm(ctx) {
+		return fmt.Errorf("%w: not logged in, the login form is still shown", provider.ErrLoginFailed)
+	}
+	return d.reuseSession(ctx)
+}
+
+// reuseSession accepts the session of a login page without a form: still logged in
+// from the saved session, unless a code is requested.
+func (d *Client) reuseSession(ctx context.Context) error {
+	var pageText string
+	chromedp.Run(ctx, chromedp.Text(`body`, &pageText, chromedp.ByQuery))
+	if err := d.answerTwoFactor(ctx, loginError(pageText, false)); err != nil {
+		return err
+	}
+	slog.Info("Reusing saved session", "step", provider.StageLogin)
+	d.saveCookies(ctx)
+	return nil
+}
+
+// saveCookies stores the session cookies after a login if vodafone.cookie_file is set.
+// A failure only costs a full login on the next run, so it is logged.
+func (d *Client) saveCookies(ctx context.Context) {
+	if d.cfg.CookieFile == "" {
+		return
+	}
+	if err := saveCookies(ctx, d.cfg.CookieFile); err != nil {
+		slog.Warn("Saving cookies failed", "err", err)
+	}
+}
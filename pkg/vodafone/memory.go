@@ -0,0 +1,107 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// memoryPollInterval is how often the memory of Chrome is measured.
+const memoryPollInterval = 2 * time.Second
+
+// BrowserLimits returns the Chrome flags for the configured memory settings and the
+// memory budget in bytes (0 if unlimited). With a budget, Chrome runs a single
+// renderer process; js_heap caps the JavaScript heap of each renderer.
+func BrowserLimits(c Config) ([]chromedp.ExecAllocatorOption, uint64, error) {
+	var opts []chromedp.ExecAllocatorOption
+	var limit uint64
+	if c.MemoryLimit != "" {
+		var err error
+		if limit, err = ParseSize(c.MemoryLimit); err != nil {
+			return nil, 0, fmt.Errorf("vodafone.memory_limit: %v", err)
+		}
+		opts = append(opts, chromedp.Flag("renderer-process-limit", "1"))
+	}
+	if c.JSHeap != "" {
+		heap, err := ParseSize(c.JSHeap)
+		if err != nil {
+			return nil, 0, fmt.Errorf("vodafone.js_heap: %v", err)
+		}
+		opts = append(opts, chromedp.Flag("js-flags", fmt.Sprintf("--max-old-space-size=%d", heap>>20)))
+	}
+	return opts, limit, nil
+}
+
+// WatchMemory measures the memory used by Chrome and its child processes until ctx
+// ends and returns a context that is canceled with ErrBrowserMemory once the total
+// exceeds limit. Chrome is then closed, so the run can still send what it has before
+// the kernel's OOM killer ends it without a trace. The peak is logged at the end.
+// A zero limit or a platform without process memory information disables the watch.
+func WatchMemory(ctx context.Context, limit uint64) context.Context {
+	if limit == 0 {
+		return ctx
+	}
+	if _, ok := processTreeRSS(0); !ok {
+		slog.Warn("Memory limit not supported on this platform")
+		return ctx
+	}
+	watched, cancel := context.WithCancelCause(ctx)
+	go func() {
+		var peak uint64
+		warned := false
+		ticker := time.NewTicker(memoryPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-watched.Done():
+				if peak > 0 {
+					slog.Debug("Chrome peak memory", "mb", peak>>20)
+				}
+				return
+			case <-ticker.C:
+			}
+			c := chromedp.FromContext(ctx)
+			if c == nil || c.Browser == nil || c.Browser.Process() == nil {
+				continue
+			}
+			rss, ok := processTreeRSS(c.Browser.Process().Pid)
+			if !ok {
+				continue
+			}
+			peak = max(peak, rss)
+			if !warned && rss > limit/10*8 {
+				warned = true
+				slog.Warn("Chrome exceeds its memory limit", "mb", rss>>20, "limit_mb", limit>>20)
+			}
+			if rss > limit {
+				cancel(fmt.Errorf("%w: Chrome used %d MB, limit %d MB", ErrBrowserMemory, rss>>20, limit>>20))
+				chromedp.Cancel(ctx)
+			}
+		}
+	}()
+	return watched
+}
+
+var sizePattern = regexp.MustCompile(`^(\d+)\s*([KMGT]?)I?B?$`)
+
+// sizeShifts maps a size unit to its power of 1024.
+var sizeShifts = map[string]uint{"": 0, "K": 10, "M": 20, "G": 30, "T": 40}
+
+// ParseSize parses a size such as "500MB" or "2G" into bytes. Units are binary.
+func ParseSize(s string) (uint64, error) {
+	m := sizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	n, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n << sizeShifts[m[2]], nil
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package vodafone
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processTreeRSS returns the resident memory of the process pid and all its
+// descendants, as read from /proc. A pid of 0 only checks that /proc is available.
+func processTreeRSS(pid int) (uint64, bool) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, false
+	}
+	if pid == 0 {
+		return 0, true
+	}
+
+	children := map[int][]int{}
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if parent, ok := procParent(child); ok {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var total uint64
+	found := false
+	for queue := []int{pid}; len(queue) > 0; queue = queue[1:] {
+		if rss, ok := procRSS(queue[0]); ok {
+			total += rss
+			found = true
+		}
+		queue = append(queue, children[queue[0]]...)
+	}
+	return total, found
+}
+
+// procParent returns the parent pid from /proc/<pid>/stat. The command name in
+// parentheses may contain spaces, so the fields are read after the last ")".
+func procParent(pid int) (int, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, false
+	}
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	parent, err := strconv.Atoi(fields[1])
+	return parent, err == nil
+}
+
+// procRSS returns the resident memory of a process from /proc/<pid>/statm.
+func procRSS(pid int) (uint64, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package vodafone
+
+// processTreeRSS is not implemented on this platform; the memory limit is not enforced.
+func processTreeRSS(pid int) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package vodafone
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestBrowserLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		wantFlags int
+		wantLimit uint64
+		wantErr   bool
+	}{
+		{name: "unlimited", config: Config{}},
+		{name: "memory limit", config: Config{MemoryLimit: "600MB"}, wantFlags: 1, wantLimit: 600 << 20},
+		{name: "memory and heap", config: Config{MemoryLimit: "1G", JSHeap: "256M"}, wantFlags: 2, wantLimit: 1 << 30},
+		{name: "invalid memory limit", config: Config{MemoryLimit: "viel"}, wantErr: true},
+		{name: "invalid heap", config: Config{JSHeap: "-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, limit, err := BrowserLimits(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("browserLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(flags) != tt.wantFlags || limit != tt.wantLimit {
+				t.Errorf("browserLimits() = %d flags, limit %d; want %d flags, limit %d", len(flags), limit, tt.wantFlags, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestProcessTreeRSS(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process memory is only measured on Linux")
+	}
+	rss, ok := processTreeRSS(os.Getpid())
+	if !ok || rss == 0 {
+		t.Errorf("processTreeRSS(self) = %d, %v", rss, ok)
+	}
+	if _, ok := processTreeRSS(1 << 30); ok {
+		t.Error("processTreeRSS() of a missing process: expected false")
+	}
+}
+
+func TestWatchMemoryUnlimited(t *testing.T) {
+	ctx := context.Background()
+	if got := WatchMemory(ctx, 0); got != ctx {
+		t.Error("watchMemory() without limit should return the context unchanged")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "1024", want: 1024},
+		{in: "500MB", want: 500 << 20},
+		{in: "2G", want: 2 << 30},
+		{in: "1 GiB", want: 1 << 30},
+		{in: "64kb", want: 64 << 10},
+		{in: "viel", wantErr: true},
+		{in: "1.5GB", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseSize(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d, error %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package vodafone
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+var otpCodePattern = regexp.MustCompile(`^\d{4,8}$`)
+
+// otpCode runs the configured OTP command (e.g. "oathtool --totp -b $SECRET") and
+// returns the one-time code from the first line of its output.
+func otpCode(command string) (string, error) {
+	out, err := exec.Command("sh", "-c", command).Output()
+	if err != nil {
+		return "", fmt.Errorf("OTP command %q failed: %v", command, err)
+	}
+	code, _, _ := strings.Cut(string(out), "\n")
+	code = strings.TrimSpace(code)
+	if !otpCodePattern.MatchString(code) {
+		return "", fmt.Errorf("OTP command %q printed no 4-8 digit code", command)
+	}
+	return code, nil
+}
+
+// totpCode returns the 6-digit TOTP code (RFC 6238: HMAC-SHA1, 30 second steps) of a
+// base32 secret as shown when setting up an authenticator app. Spaces and lower case
+// in the secret are accepted.
+func totpCode(secret string, now time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return "", fmt.Errorf("invalid totp_secret: not base32")
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(now.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// oneTimeCode returns the code for the prompt from vodafone.totp_secret or, if that
+// is not set, from vodafone.otp_command.
+func (d *Client) oneTimeCode() (code, source string, err error) {
+	if d.cfg.TOTPSecret != "" {
+		code, err = totpCode(d.cfg.TOTPSecret, time.Now())
+		return code, "totp_secret", err
+	}
+	code, err = otpCode(d.cfg.OTPCommand)
+	return code, "otp_command", err
+}
+
+// markOTPInputJS tags the input field of the one-time code prompt with data-vd-otp
+// and reports whether one was found.
+const markOTPInputJS = `(() => {
+	const el = document.querySelector('input[autocomplete="one-time-code"]') ||
+		[...document.querySelectorAll('input:not([type="hidden"])')].find(i =>
+			/otp|code|token|tan/i.test((i.name || '') + ' ' + (i.id || '') + ' ' + (i.getAttribute('aria-label') || '')));
+	if (!el) return false;
+	el.setAttribute('data-vd-otp', '');
+	return true;
+})()`
+
+// submitOTPJS clicks the submit button of the form containing the tagged code field.
+const submitOTPJS = `(() => {
+	const input = document.querySelector('[data-vd-otp]');
+	const scope = input.form || document;
+	const button = scope.querySelector('button[type="submit"], input[type="submit"]') ||
+		[...scope.querySelectorAll('button')].find(b => /bestätigen|weiter|absenden|senden/i.test(b.innerText));
+	if (button) button.click(); else input.form && input.form.submit();
+})()`
+
+// answerTwoFactor handles the result of a login attempt. If a one-time code is
+// requested and vodafone.totp_secret or vodafone.otp_command is set, the code is
+// entered and the page is checked again; otherwise err is returned unchanged.
+func (d *Client) answerTwoFactor(ctx context.Context, err error) error {
+	if err != provider.Err2FARequired || (d.cfg.TOTPSecret == "" && d.cfg.OTPCommand == "") {
+		return err
+	}
+
+	code, source, err := d.oneTimeCode()
+	if err != nil {
+		return fmt.Errorf("%w: %v", provider.Err2FARequired, err)
+	}
+
+	var found bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(markOTPInputJS, &found)); err != nil || !found {
+		return fmt.Errorf("%w: code field not found", provider.Err2FARequired)
+	}
+	slog.Info("Entering one-time code", "source", source, "step", provider.StageLogin)
+	if err := chromedp.Run(ctx,
+		chromedp.SendKeys(`[data-vd-otp]`, code, chromedp.ByQuery),
+		chromedp.Evaluate(submitOTPJS, nil),
+		chromedp.Sleep(stepTimeouts(ctx).submit()),
+	); err != nil {
+		return fmt.Errorf("%w: %v", provider.Err2FARequired, err)
+	}
+
+	var pageText string
+	var onLoginPage bool
+	chromedp.Run(ctx,
+		chromedp.Text(`body`, &pageText, chromedp.ByQuery),
+		chromedp.Evaluate(`document.querySelector('#username-text') !== null`, &onLoginPage),
+	)
+	if err := loginError(pageText, onLoginPage); err != nil {
+		return fmt.Errorf("%w: one-time code not accepted", err)
+	}
+	return nil
+}
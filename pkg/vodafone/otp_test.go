@@ -0,0 +1,90 @@
+package vodafone
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestOTPCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{name: "code", command: "echo 123456", want: "123456"},
+		{name: "first line only", command: "printf '042317\\nexpires in 12s\\n'", want: "042317"},
+		{name: "surrounding whitespace", command: "echo '  98765432 '", want: "98765432"},
+		{name: "not a code", command: "echo token-expired", wantErr: true},
+		{name: "command fails", command: "exit 1", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := otpCode(tc.command)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got code %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("otpCode() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("otpCode() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnswerTwoFactorPassesThrough(t *testing.T) {
+	// Without otp_command, or when no code is requested, the login result is unchanged
+	d := NewClient(Config{})
+	for _, err := range []error{nil, provider.ErrLoginFailed, provider.Err2FARequired} {
+		if got := d.answerTwoFactor(context.Background(), err); got != err {
+			t.Errorf("answerTwoFactor(%v) = %v", err, got)
+		}
+	}
+
+	d = NewClient(Config{OTPCommand: "exit 1"})
+	if got := d.answerTwoFactor(context.Background(), provider.ErrLoginFailed); got != provider.ErrLoginFailed {
+		t.Errorf("answerTwoFactor(ErrLoginFailed) = %v", got)
+	}
+	if got := d.answerTwoFactor(context.Background(), provider.Err2FARequired); !errors.Is(got, provider.Err2FARequired) {
+		t.Errorf("answerTwoFactor() with failing command = %v, want Err2FARequired", got)
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test secret "12345678901234567890", truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		name    string
+		secret  string
+		unix    int64
+		want    string
+		wantErr bool
+	}{
+		{name: "t=59", secret: secret, unix: 59, want: "287082"},
+		{name: "t=1111111109", secret: secret, unix: 1111111109, want: "081804"},
+		{name: "t=2000000000", secret: secret, unix: 2000000000, want: "279037"},
+		{name: "lower case with spaces", secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", unix: 59, want: "287082"},
+		{name: "not base32", secret: "not-a-secret!", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := totpCode(tc.secret, time.Unix(tc.unix, 0))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("totpCode() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("totpCode() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
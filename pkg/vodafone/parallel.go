@@ -0,0 +1,64 @@
+package vodafone
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// contractResult is the outcome of downloading one contract.
+type contractResult struct {
+	invoices   []provider.Invoice
+	failed     []provider.Failure
+	screenshot string // the latest screenshot of a failed step
+}
+
+// downloadInTabs downloads the contracts at the same time, each in a browser tab of
+// its own. The tabs belong to the logged-in browser and share its session cookies.
+// Results are keyed by contract.
+func (d *Client) downloadInTabs(ctx context.Context, contracts []contract) map[string]contractResult {
+	return runParallel(contracts, func(c contract) contractResult {
+		tabCtx, cancel := chromedp.NewContext(ctx)
+		defer cancel()
+		if err := chromedp.Run(tabCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			if _, err := page.AddScriptToEvaluateOnNewDocument(hideWebdriverJS).Do(ctx); err != nil {
+				return err
+			}
+			return emulation.SetUserAgentOverride(userAgent).Do(ctx)
+		})); err != nil {
+			slog.Warn("Opening tab failed", "contract", c.name, "err", err)
+		}
+
+		// Each tab keeps its own screenshot of a failed step
+		tab := *d
+		tab.lastScreenshot = ""
+		slog.Info("Searching invoices", "contract", c.name, "step", provider.StageNavigation)
+		invoices, failed := tab.downloadContract(tabCtx, c.key, c.name)
+		return contractResult{invoices: invoices, failed: failed, screenshot: tab.lastScreenshot}
+	})
+}
+
+// runParallel runs download for every contract in a goroutine of its own and
+// collects the results from a channel once all have finished.
+func runParallel(contracts []contract, download func(contract) contractResult) map[string]contractResult {
+	type keyed struct {
+		key string
+		contractResult
+	}
+	done := make(chan keyed, len(contracts))
+	for _, c := range contracts {
+		go func() {
+			done <- keyed{c.key, download(c)}
+		}()
+	}
+	results := make(map[string]contractResult, len(contracts))
+	for range contracts {
+		r := <-done
+		results[r.key] = r.contractResult
+	}
+	return results
+}
@@ -0,0 +1,45 @@
+package vodafone
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestRunParallel(t *testing.T) {
+	contracts := []contract{{key: "mobilfunk", name: "Mobilfunk"}, {key: "kabel", name: "Kabel"}}
+
+	// Each download waits until all have started, which only finishes if they run at
+	// the same time
+	var started sync.WaitGroup
+	started.Add(len(contracts))
+	done := make(chan map[string]contractResult)
+	go func() {
+		done <- runParallel(contracts, func(c contract) contractResult {
+			started.Done()
+			started.Wait()
+			if c.key == "kabel" {
+				return contractResult{failed: []provider.Failure{{Type: c.name, Reason: "invoice page not reachable"}}, screenshot: "debug/kabel_navigation.png"}
+			}
+			return contractResult{invoices: []provider.Invoice{{Type: c.name, Amount: 29.99}}}
+		})
+	}()
+
+	var results map[string]contractResult
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("downloads didn't run concurrently")
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := results["mobilfunk"]; len(r.invoices) != 1 || r.invoices[0].Amount != 29.99 || len(r.failed) != 0 {
+		t.Errorf("mobilfunk = %+v", r)
+	}
+	if r := results["kabel"]; len(r.invoices) != 0 || len(r.failed) != 1 || r.screenshot != "debug/kabel_navigation.png" {
+		t.Errorf("kabel = %+v", r)
+	}
+}
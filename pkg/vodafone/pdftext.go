@@ -0,0 +1,158 @@
+package vodafone
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// ExtractPDFText returns the plain text content of all pages of a PDF.
+func ExtractPDFText(data []byte) (text string, err error) {
+	// The PDF library panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	content, err := io.ReadAll(plain)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// pdfTotalPattern matches the invoice total as printed on Vodafone invoices.
+var pdfTotalPattern = regexp.MustCompile(`(?:Rechnungsbetrag|Gesamtbetrag|Zu zahlender Betrag|Summe)[^0-9]{0,40}(` + amountPattern + `)`)
+
+// parsePDFAmount extracts the invoice total from the PDF text, or 0 if not found.
+func parsePDFAmount(text string) float64 {
+	matches := pdfTotalPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0
+	}
+	amount, _ := ParseAmount(matches[1])
+	return amount
+}
+
+// pdfVATPattern matches the VAT line of an invoice, e.g. "Umsatzsteuer 19 % 3,99" or
+// "USt. 19 % auf 20,99 € 3,99 €", skipping the rate and the net base. The whitespace
+// after the keyword keeps the VAT ID ("USt-IdNr.") from matching.
+var pdfVATPattern = regexp.MustCompile(`(?:Umsatzsteuer|USt\.?|MwSt\.?)\s+(?:\d{1,2}(?:,\d{1,2})?\s*%\s*)?(?:(?:auf|von)\s+` + amountPattern + `\s*(?:€|EUR)?\s*)?[^0-9]{0,30}?(` + amountPattern + `)`)
+
+// parsePDFVAT extracts the VAT (USt.) amount from the PDF text, or 0 if not found.
+func parsePDFVAT(text string) float64 {
+	matches := pdfVATPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0
+	}
+	vat, _ := ParseAmount(matches[1])
+	return vat
+}
+
+// pdfBaseFeePattern matches the monthly base fee position of an invoice, skipping a
+// billing period such as "01.02.–28.02." between the description and the amount.
+var pdfBaseFeePattern = regexp.MustCompile(`(?:Grundgebühr|Grundpreis|Paketpreis)[^\n€]{0,60}?(` + amountPattern + `)`)
+
+// parsePDFBaseFee extracts the base fee (Grundgebühr) from the PDF text, or 0 if not found.
+func parsePDFBaseFee(text string) float64 {
+	matches := pdfBaseFeePattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0
+	}
+	fee, _ := ParseAmount(matches[1])
+	return fee
+}
+
+// pdfDatePattern matches the numeric invoice date, e.g. "Rechnungsdatum: 12.02.2026".
+var pdfDatePattern = regexp.MustCompile(`Rechnungsdatum[:\s]+(\d{1,2})\.(\d{1,2})\.(\d{4})`)
+
+// ParsePDFPeriod extracts the invoice month and year from the PDF text, preferring a
+// spelled-out period such as "Rechnung Februar 2026" over the invoice date.
+func ParsePDFPeriod(text string) (month, year string, ok bool) {
+	if info := parseInvoiceInfo(text); info != nil {
+		return info.Month, info.Year, true
+	}
+	m := pdfDatePattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", "", false
+	}
+	n, _ := strconv.Atoi(m[2])
+	if n < 1 || n > 12 {
+		return "", "", false
+	}
+	return fmt.Sprintf("%02d", n), m[3], true
+}
+
+// ParsePDFContract returns the contract type named in the PDF text, or "" if none or
+// more than one is mentioned.
+func ParsePDFContract(text string) string {
+	found := ""
+	for _, typeName := range ContractTypes {
+		if strings.Contains(text, typeName) {
+			if found != "" {
+				return ""
+			}
+			found = typeName
+		}
+	}
+	return found
+}
+
+// pdfExtraPattern matches invoice positions beyond the base fee, such as roaming,
+// third-party services (Drittanbieter) and premium services (Mehrwertdienste), with
+// the amount printed after the description.
+var pdfExtraPattern = regexp.MustCompile(`((?i:Roaming|Drittanbieter|Mehrwertdienst|Premium-?SMS|Sonderrufnummer|Auslandsverbindung|Verbindungen ins Ausland)[^€]{0,60}?)\s(` + amountPattern + `)`)
+
+// parsePDFExtras returns the positions beyond the base fee found in the PDF text.
+// Positions printed more than once (e.g. in the summary and the details) are
+// listed once.
+func parsePDFExtras(text string) []provider.ExtraCharge {
+	var extras []provider.ExtraCharge
+	seen := map[provider.ExtraCharge]bool{}
+	for _, m := range pdfExtraPattern.FindAllStringSubmatch(text, -1) {
+		amount, ok := ParseAmount(m[2])
+		if !ok || amount <= 0 {
+			continue
+		}
+		extra := provider.ExtraCharge{Description: strings.TrimRight(strings.Join(strings.Fields(m[1]), " "), " :-"), Amount: amount}
+		if !seen[extra] {
+			seen[extra] = true
+			extras = append(extras, extra)
+		}
+	}
+	return extras
+}
+
+// ApplyPDFDetails fills in the amount, VAT, base fee, invoice number and extra charges from the captured PDF,
+// which is authoritative over what the invoice page shows. Errors reading the PDF
+// are ignored; the page values are kept in that case.
+func ApplyPDFDetails(inv *provider.Invoice) {
+	text, err := ExtractPDFText(inv.PDFData)
+	if err != nil {
+		return
+	}
+	if amount := parsePDFAmount(text); amount > 0 {
+		inv.Amount = amount
+	}
+	inv.VAT = parsePDFVAT(text)
+	inv.BaseFee = parsePDFBaseFee(text)
+	if inv.Number == "" {
+		inv.Number = findInvoiceNumber(text)
+	}
+	inv.Extras = parsePDFExtras(text)
+}
@@ -0,0 +1,198 @@
+package vodafone
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// minimalPDF builds a single-page PDF showing each line of text, with a valid xref table.
+func minimalPDF(lines ...string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 72 720 Td 14 TL\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", line)
+	}
+	content.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	text, err := ExtractPDFText(minimalPDF("Rechnungsnummer: 123456789", "Rechnungsbetrag 24,98 EUR"))
+	if err != nil {
+		t.Fatalf("extractPDFText() error: %v", err)
+	}
+	if !strings.Contains(text, "123456789") || !strings.Contains(text, "24,98") {
+		t.Errorf("text = %q, missing invoice details", text)
+	}
+}
+
+func TestExtractPDFTextInvalid(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("%PDF-fake-content"), []byte("not a pdf")} {
+		if _, err := ExtractPDFText(data); err == nil {
+			t.Errorf("extractPDFText(%q) expected error, got nil", data)
+		}
+	}
+}
+
+func TestParsePDFAmount(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"Rechnungsbetrag 24,98 €", 24.98},
+		{"Gesamtbetrag (brutto): 1.044,98 EUR", 1044.98},
+		{"Zu zahlender Betrag\n44,98", 44.98},
+		{"Monatlicher Grundpreis 19,99 €", 0},
+		{"", 0},
+	}
+
+	for _, tc := range tests {
+		if got := parsePDFAmount(tc.text); got != tc.want {
+			t.Errorf("parsePDFAmount(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestParsePDFVAT(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"Umsatzsteuer 19 % 3,99 €", 3.99},
+		{"zzgl. USt. 19,00 % auf 20,99 € 3,99 €", 3.99},
+		{"MwSt. 7,18 EUR", 7.18},
+		{"USt-IdNr.: DE811917213 Rechnungsbetrag 24,98", 0},
+		{"Rechnungsbetrag 24,98 €", 0},
+	}
+
+	for _, tc := range tests {
+		if got := parsePDFVAT(tc.text); got != tc.want {
+			t.Errorf("parsePDFVAT(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestApplyPDFDetails(t *testing.T) {
+	inv := &provider.Invoice{Amount: 20, PDFData: minimalPDF("Rechnungsnummer: 987654321", "Umsatzsteuer 19 % 3,99 EUR", "Rechnungsbetrag 24,98 EUR")}
+	ApplyPDFDetails(inv)
+	if inv.Amount != 24.98 {
+		t.Errorf("Amount = %v, want 24.98 from PDF", inv.Amount)
+	}
+	if inv.VAT != 3.99 {
+		t.Errorf("VAT = %v, want 3.99 from PDF", inv.VAT)
+	}
+	if inv.Number != "987654321" {
+		t.Errorf("Number = %q, want 987654321 from PDF", inv.Number)
+	}
+
+	// Unreadable PDFs keep the values from the page
+	inv = &provider.Invoice{Amount: 20, Number: "1", PDFData: []byte("%PDF-fake")}
+	ApplyPDFDetails(inv)
+	if inv.Amount != 20 || inv.Number != "1" {
+		t.Errorf("got amount=%v number=%q, want page values kept", inv.Amount, inv.Number)
+	}
+}
+
+func TestParsePDFExtras(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []provider.ExtraCharge
+	}{
+		{
+			name: "roaming and third party",
+			text: "Grundpreis Red M 39,99 Roaming EU Zone 1 3,50 Drittanbieter: Spotify 9,99 Rechnungsbetrag 53,48",
+			want: []provider.ExtraCharge{{Description: "Roaming EU Zone 1", Amount: 3.50}, {Description: "Drittanbieter: Spotify", Amount: 9.99}},
+		},
+		{
+			name: "listed in summary and details",
+			text: "Mehrwertdienste 1,99 EUR ... Mehrwertdienste 1,99 EUR",
+			want: []provider.ExtraCharge{{Description: "Mehrwertdienste", Amount: 1.99}},
+		},
+		{
+			name: "base fee only",
+			text: "Grundpreis Red M 39,99 Rechnungsbetrag 39,99",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parsePDFExtras(tc.text)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parsePDFExtras() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyPDFDetailsExtras(t *testing.T) {
+	inv := &provider.Invoice{PDFData: minimalPDF("Grundpreis Red M 39,99 EUR", "Roaming Schweiz 4,20 EUR", "Rechnungsbetrag 44,19 EUR")}
+	ApplyPDFDetails(inv)
+
+	want := []provider.ExtraCharge{{Description: "Roaming Schweiz", Amount: 4.20}}
+	if !reflect.DeepEqual(inv.Extras, want) {
+		t.Errorf("Extras = %+v, want %+v", inv.Extras, want)
+	}
+}
+
+func TestParsePDFPeriod(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantMonth string
+		wantYear  string
+		wantOK    bool
+	}{
+		{text: "Ihre Rechnung Februar 2026", wantMonth: "02", wantYear: "2026", wantOK: true},
+		{text: "Rechnungsdatum: 12.03.2026", wantMonth: "03", wantYear: "2026", wantOK: true},
+		{text: "Rechnungsdatum 1.11.2025", wantMonth: "11", wantYear: "2025", wantOK: true},
+		{text: "Rechnungsdatum: 12.13.2026"},
+		{text: "Kundennummer 123"},
+	}
+	for _, tc := range tests {
+		month, year, ok := ParsePDFPeriod(tc.text)
+		if month != tc.wantMonth || year != tc.wantYear || ok != tc.wantOK {
+			t.Errorf("parsePDFPeriod(%q) = %q, %q, %v; want %q, %q, %v", tc.text, month, year, ok, tc.wantMonth, tc.wantYear, tc.wantOK)
+		}
+	}
+}
+
+func TestParsePDFContract(t *testing.T) {
+	tests := map[string]string{
+		"Ihr Kabel-Anschluss":            "Kabel",
+		"Mobilfunk-Vertrag":              "Mobilfunk",
+		"Kabel und Mobilfunk kombiniert": "",
+		"GigaCube":                       "",
+	}
+	for text, want := range tests {
+		if got := ParsePDFContract(text); got != want {
+			t.Errorf("parsePDFContract(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
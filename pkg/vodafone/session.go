@@ -0,0 +1,161 @@
+package vodafone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// sessionManifest is the index of a session bundle, stored as manifest.json.
+type sessionManifest struct {
+	Version    string                 `json:"version"`
+	RecordedAt time.Time              `json:"recorded_at"`
+	Now        time.Time              `json:"now"` // the run's current time, which decides the month matching
+	Steps      map[string]sessionStep `json:"steps"`
+}
+
+// sessionStep is one page or PDF seen during the recorded run. File names are
+// relative to the bundle directory.
+type sessionStep struct {
+	Text  string `json:"text,omitempty"`  // page text as read by the parsers
+	HTML  string `json:"html,omitempty"`  // DOM snapshot, for debugging only
+	PDF   string `json:"pdf,omitempty"`   // captured PDF
+	Error string `json:"error,omitempty"` // capture error
+}
+
+// Session records the pages and PDFs of a run into a bundle directory, or replays
+// them from one instead of using the browser. A nil session does neither, so the
+// Client can call it unconditionally.
+type Session struct {
+	dir      string
+	replay   bool
+	manifest sessionManifest
+}
+
+// NewSessionRecorder starts recording a run whose current time is now into dir.
+func NewSessionRecorder(dir string, now time.Time) (*Session, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Session{dir: dir, manifest: sessionManifest{
+		Version:    Version,
+		RecordedAt: time.Now(),
+		Now:        now,
+		Steps:      map[string]sessionStep{},
+	}}, nil
+}
+
+// OpenSession loads a recorded bundle for replay.
+func OpenSession(dir string) (*Session, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{dir: dir, replay: true}
+	if err := json.Unmarshal(data, &s.manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return s, nil
+}
+
+// Now returns the current time of the recorded run, so a replay picks the same
+// month as the recording.
+func (s *Session) Now() time.Time { return s.manifest.Now }
+
+// replaying reports whether the browser is replaced by a recorded bundle.
+func (s *Session) replaying() bool {
+	return s != nil && s.replay
+}
+
+var sessionKeyPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// writeFile stores data in the bundle under a name derived from key and returns it.
+func (s *Session) writeFile(key, ext string, data []byte) string {
+	name := sessionKeyPattern.ReplaceAllString(key, "_") + ext
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0600); err != nil {
+		slog.Warn("Recording failed", "file", name, "err", err)
+		return ""
+	}
+	return name
+}
+
+// readFile loads a file of the bundle; a missing name yields nil.
+func (s *Session) readFile(name string) []byte {
+	if name == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		slog.Warn("Replaying failed", "file", name, "err", err)
+	}
+	return data
+}
+
+// text returns the page text named key. read is called unless replaying; it returns
+// the page text and, when recording, a DOM snapshot.
+func (s *Session) text(key string, read func() (text, html string)) string {
+	if s.replaying() {
+		return string(s.readFile(s.manifest.Steps[key].Text))
+	}
+	text, html := read()
+	if s != nil {
+		step := s.manifest.Steps[key]
+		step.Text = s.writeFile(key, ".txt", []byte(text))
+		if html != "" {
+			step.HTML = s.writeFile(key, ".html", []byte(html))
+		}
+		s.manifest.Steps[key] = step
+	}
+	return text
+}
+
+// pdf returns the PDF named key. capture is called unless replaying, in which case
+// the recorded PDF or capture error is returned.
+func (s *Session) pdf(key string, capture func() ([]byte, error)) ([]byte, error) {
+	if s.replaying() {
+		step, ok := s.manifest.Steps[key]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s not in session bundle", key)
+		case step.Error != "":
+			return nil, errors.New(step.Error)
+		}
+		return s.readFile(step.PDF), nil
+	}
+	data, err := capture()
+	if s != nil {
+		step := s.manifest.Steps[key]
+		if err != nil {
+			step.Error = err.Error()
+		} else {
+			step.PDF = s.writeFile(key, ".pdf", data)
+		}
+		s.manifest.Steps[key] = step
+	}
+	return data, err
+}
+
+// Save writes the manifest of a recording.
+func (s *Session) Save() error {
+	if s == nil || s.replay {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, "manifest.json"), data, 0600)
+}
+
+// pageText reads the text of the current page, recording or replaying it under key.
+func (d *Client) pageText(ctx context.Context, key string) string {
+	return d.Session.text(key, func() (string, string) {
+		return d.browser.text(ctx, d.Session != nil)
+	})
+}
@@ -0,0 +1,55 @@
+package vodafone
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 2, 12, 6, 0, 0, 0, time.UTC)
+
+	rec, err := NewSessionRecorder(dir, now)
+	if err != nil {
+		t.Fatalf("newSessionRecorder() error: %v", err)
+	}
+	rec.text("kabel", func() (string, string) { return "Aktuelle Rechnung Februar 2026", "<html></html>" })
+	rec.pdf("kabel_current", func() ([]byte, error) { return []byte("%PDF-1.4"), nil })
+	rec.pdf("mobilfunk_current", func() ([]byte, error) { return nil, errors.New("no PDF captured") })
+	if err := rec.Save(); err != nil {
+		t.Fatalf("save() error: %v", err)
+	}
+
+	s, err := OpenSession(dir)
+	if err != nil {
+		t.Fatalf("openSession() error: %v", err)
+	}
+	if !s.manifest.Now.Equal(now) {
+		t.Errorf("now = %v, want %v", s.manifest.Now, now)
+	}
+	browser := func() (string, string) {
+		t.Error("browser used during replay")
+		return "", ""
+	}
+	if got := s.text("kabel", browser); got != "Aktuelle Rechnung Februar 2026" {
+		t.Errorf("text = %q", got)
+	}
+	if got := s.text("unknown", browser); got != "" {
+		t.Errorf("text of unrecorded page = %q, want empty", got)
+	}
+
+	capture := func() ([]byte, error) {
+		t.Error("browser used during replay")
+		return nil, nil
+	}
+	if data, err := s.pdf("kabel_current", capture); err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("pdf = %q, %v", data, err)
+	}
+	if _, err := s.pdf("mobilfunk_current", capture); err == nil || err.Error() != "no PDF captured" {
+		t.Errorf("pdf error = %v, want recorded error", err)
+	}
+	if _, err := s.pdf("mobilfunk_archive", capture); err == nil {
+		t.Error("pdf of unrecorded capture: expected error")
+	}
+}
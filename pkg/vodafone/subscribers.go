@@ -0,0 +1,138 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// nationalNumber reduces a phone number to its national significant digits, so that
+// "+49 172 1234567", "0049172/1234567" and "0172 1234567" all become "1721234567".
+func nationalNumber(msisdn string) string {
+	var digits strings.Builder
+	for _, r := range msisdn {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	n := digits.String()
+	switch {
+	case strings.HasPrefix(msisdn, "+49"):
+		n = strings.TrimPrefix(n, "49")
+	case strings.HasPrefix(n, "0049"):
+		n = strings.TrimPrefix(n, "0049")
+	}
+	return strings.TrimLeft(n, "0")
+}
+
+// subscriberClickJS returns JS that clicks the button or link labelled label inside the
+// smallest page section mentioning the given subscriber's phone number.
+func subscriberClickJS(msisdn, label string) string {
+	return fmt.Sprintf(`(() => {
+		const target = %q, label = %q;
+		const digits = el => (el.innerText || '').replace(/\D/g, '');
+		const sections = [...document.querySelectorAll('section, article, li, tr, div')].filter(el =>
+			digits(el).includes(target) &&
+			[...el.querySelectorAll('button, a')].some(b => b.innerText.includes(label)));
+		if (sections.length === 0) return false;
+		sections.sort((a, b) => a.innerText.length - b.innerText.length);
+		[...sections[0].querySelectorAll('button, a')].find(b => b.innerText.includes(label)).click();
+		return true;
+	})()`, nationalNumber(msisdn), label)
+}
+
+// subscriberDocument is a per-subscriber document: the label of the button that
+// downloads it and the short kind used in filenames.
+type subscriberDocument struct {
+	label string
+	kind  string
+}
+
+// downloadSubscriberInvoices downloads the individual invoices (and, if enabled, the
+// Einzelverbindungsnachweis) of the configured subscribers of a Mobilfunk contract
+// instead of the combined contract invoice. Documents that could not be downloaded
+// are returned as failures.
+func (d *Client) downloadSubscriberInvoices(ctx context.Context, contractType, typeName string) ([]provider.Invoice, []provider.Failure) {
+	if err := d.Retry.Do(provider.StageNavigation, func() error {
+		return d.navigateToInvoicePage(ctx, contractType, typeName)
+	}); err != nil {
+		d.debugFailedStep(ctx, contractType+"_"+provider.StageNavigation)
+		err = fmt.Errorf("invoice page not reachable: %v", err)
+		return nil, []provider.Failure{{Type: typeName, Reason: err.Error(), Err: err}}
+	}
+
+	pageText := d.pageText(ctx, contractType)
+
+	period := parseInvoiceInfo(pageText)
+	if period == nil {
+		d.dumpPage(ctx, contractType+"_invoice_info")
+		period = parseArchiveFirstEntry(pageText)
+	}
+	if period == nil {
+		now := d.Now()
+		period = &provider.Invoice{Month: fmt.Sprintf("%02d", now.Month()), Year: fmt.Sprintf("%d", now.Year()), MonthName: MonthNames[now.Month()]}
+	}
+
+	// Subscriber documents are only offered for the latest period
+	if month, year := d.targetPeriod(); d.Month != "" && (period.Month != month || period.Year != year) {
+		err := fmt.Errorf("%w: subscriber invoices for %s/%s are not available", provider.ErrInvoiceNotReady, month, year)
+		return nil, []provider.Failure{{Type: typeName, Reason: err.Error(), Err: err}}
+	}
+
+	documents := []subscriberDocument{{label: "Rechnung", kind: "Rechnung"}}
+	if d.cfg.EVN {
+		documents = append(documents, subscriberDocument{label: "Einzelverbindungsnachweis", kind: "EVN"})
+	}
+
+	var results []provider.Invoice
+	var failures []provider.Failure
+	for _, msisdn := range d.cfg.Subscribers {
+		for _, doc := range documents {
+			slog.Info("Downloading subscriber document", "contract", typeName, "document", doc.kind, "month", period.Month, "year", period.Year, "subscriber", msisdn, "step", provider.StageCapture)
+			pdfData, err := d.captureClick(ctx, contractType+"_"+nationalNumber(msisdn)+"_"+doc.kind, subscriberClickJS(msisdn, doc.label))
+			if err != nil {
+				slog.Warn("Subscriber document download failed", "contract", typeName, "document", doc.kind, "subscriber", msisdn, "step", provider.StageCapture)
+				err = fmt.Errorf("%w: %v", provider.ErrCaptureFailed, err)
+				failures = append(failures, provider.Failure{
+					Type:   subscriberType(typeName, doc.kind, msisdn),
+					Reason: err.Error(),
+					Err:    err,
+				})
+				continue
+			}
+
+			inv := provider.Invoice{
+				Month:     period.Month,
+				Year:      period.Year,
+				MonthName: period.MonthName,
+				Type:      subscriberType(typeName, doc.kind, msisdn),
+				Filename:  subscriberFilename(period, doc.kind, fileTypeName(typeName), msisdn),
+				PDFData:   pdfData,
+			}
+			if doc.kind == "Rechnung" {
+				ApplyPDFDetails(&inv)
+			}
+			results = append(results, inv)
+		}
+	}
+	return results, failures
+}
+
+// subscriberType names a subscriber document, e.g. "Mobilfunk 0172 1234567" for the
+// invoice and "Mobilfunk EVN 0172 1234567" for the Einzelverbindungsnachweis, so each
+// line keeps its own history.
+func subscriberType(typeName, kind, msisdn string) string {
+	if kind == "EVN" {
+		return typeName + " EVN " + msisdn
+	}
+	return typeName + " " + msisdn
+}
+
+// subscriberFilename builds the attachment name of a subscriber document, e.g.
+// "02_2026_Rechnung_Vodafone_Mobilfunk_01721234567.pdf".
+func subscriberFilename(period *provider.Invoice, kind, typeName, msisdn string) string {
+	return fmt.Sprintf("%s_%s_%s_Vodafone_%s_0%s.pdf", period.Month, period.Year, kind, typeName, nationalNumber(msisdn))
+}
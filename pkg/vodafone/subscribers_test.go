@@ -0,0 +1,56 @@
+package vodafone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestNationalNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0172 1234567", "1721234567"},
+		{"+49 172 1234567", "1721234567"},
+		{"+49 (0) 172 1234567", "1721234567"},
+		{"0049172/1234567", "1721234567"},
+		{"0172-123 45 67", "1721234567"},
+		{"1721234567", "1721234567"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if got := nationalNumber(tc.in); got != tc.want {
+			t.Errorf("nationalNumber(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSubscriberClickJS(t *testing.T) {
+	js := subscriberClickJS("+49 172 1234567", "Einzelverbindungsnachweis")
+	if !strings.Contains(js, `"1721234567"`) {
+		t.Errorf("JS does not search for the national number:\n%s", js)
+	}
+	if !strings.Contains(js, `"Einzelverbindungsnachweis"`) {
+		t.Errorf("JS does not click the requested label:\n%s", js)
+	}
+}
+
+func TestSubscriberNaming(t *testing.T) {
+	period := &provider.Invoice{Month: "02", Year: "2026", MonthName: "Februar"}
+
+	if got, want := subscriberFilename(period, "Rechnung", "Mobilfunk", "+49 172 1234567"), "02_2026_Rechnung_Vodafone_Mobilfunk_01721234567.pdf"; got != want {
+		t.Errorf("invoice filename = %q, want %q", got, want)
+	}
+	if got, want := subscriberFilename(period, "EVN", "Mobilfunk", "0172 1234567"), "02_2026_EVN_Vodafone_Mobilfunk_01721234567.pdf"; got != want {
+		t.Errorf("EVN filename = %q, want %q", got, want)
+	}
+	if got, want := subscriberType("Mobilfunk", "Rechnung", "0172 1234567"), "Mobilfunk 0172 1234567"; got != want {
+		t.Errorf("invoice type = %q, want %q", got, want)
+	}
+	if got, want := subscriberType("Mobilfunk", "EVN", "0172 1234567"), "Mobilfunk EVN 0172 1234567"; got != want {
+		t.Errorf("EVN type = %q, want %q", got, want)
+	}
+}
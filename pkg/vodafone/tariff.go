@@ -0,0 +1,71 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+// tariffPricePattern matches the monthly price shown for a tariff on the contract
+// page, e.g. "39,99 € mtl." or "39,99 €/Monat".
+var tariffPricePattern = regexp.MustCompile(`(` + amountPattern + `)\s*€\s*(?:mtl\.|monatlich|/\s*Monat|pro Monat|im Monat)`)
+
+// parseTariffPrice returns the first monthly price on the contract page, or 0.
+func parseTariffPrice(text string) float64 {
+	matches := tariffPricePattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0
+	}
+	price, _ := ParseAmount(matches[1])
+	return price
+}
+
+// tariffMismatch describes how the invoiced base fee differs from the tariff price,
+// or returns "" if they match or either is unknown.
+func tariffMismatch(baseFee, tariff float64) string {
+	if baseFee == 0 || tariff == 0 || math.Abs(baseFee-tariff) < 0.005 {
+		return ""
+	}
+	return fmt.Sprintf("Grundgebühr %s statt Tarifpreis %s", provider.FormatAmount(baseFee), provider.FormatAmount(tariff))
+}
+
+// tariffPrice opens the contract page and reads the tariff's monthly price.
+func (d *Client) tariffPrice(ctx context.Context, contractType, typeName, url string) float64 {
+	if !d.Session.replaying() {
+		if url != "" {
+			d.browser.navigate(ctx, url)
+		} else if err := d.openContractPage(ctx, typeName, 0); err != nil {
+			slog.Warn("Contract page not reachable", "contract", typeName, "err", err)
+			return 0
+		}
+	}
+	return parseTariffPrice(d.pageText(ctx, contractType+"_tariff"))
+}
+
+// CheckTariffs compares the base fee of each contract invoice with the price of the
+// tariff on the contract page and records mismatches on the invoice, e.g. an expired
+// promotion or an unannounced price increase. urls maps a contract type to its contract
+// page; without one, the page is opened via the services page. Subscriber invoices
+// are not checked.
+func (d *Client) CheckTariffs(ctx context.Context, invoices []provider.Invoice, urls map[string]string) {
+	for contractType, typeName := range ContractTypes {
+		for i := range invoices {
+			inv := &invoices[i]
+			if inv.Type != typeName || inv.BaseFee == 0 {
+				continue
+			}
+			slog.Info("Checking tariff", "contract", typeName)
+			tariff := d.tariffPrice(ctx, contractType, typeName, urls[contractType])
+			if tariff == 0 {
+				slog.Warn("No tariff price found on the contract page", "contract", typeName)
+			}
+			if inv.Tariff = tariffMismatch(inv.BaseFee, tariff); inv.Tariff != "" {
+				slog.Warn("Invoice does not match the tariff", "contract", typeName, "month", inv.Month, "year", inv.Year, "reason", inv.Tariff)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package vodafone
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rummeyer/vodafone-downloader/pkg/provider"
+)
+
+func TestParseTariffPrice(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{text: "GigaZuhause 250 Kabel\n39,99 € mtl.", want: 39.99},
+		{text: "Red M\n1.049,00 €/Monat", want: 1049},
+		{text: "Preis: 24,99 € pro Monat\nEinmalig 69,99 €", want: 24.99},
+		{text: "Anschlusspreis 69,99 €"},
+	}
+	for _, tc := range tests {
+		if got := parseTariffPrice(tc.text); got != tc.want {
+			t.Errorf("parseTariffPrice(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestParsePDFBaseFee(t *testing.T) {
+	if got := parsePDFBaseFee("Monatliche Grundgebühr 01.02.–28.02. 44,99 EUR"); got != 44.99 {
+		t.Errorf("parsePDFBaseFee() = %v, want 44.99", got)
+	}
+	if got := parsePDFBaseFee("Rechnungsbetrag 24,98 EUR"); got != 0 {
+		t.Errorf("parsePDFBaseFee() without base fee = %v, want 0", got)
+	}
+}
+
+func TestTariffMismatch(t *testing.T) {
+	tests := []struct {
+		baseFee, tariff float64
+		want            string
+	}{
+		{baseFee: 39.99, tariff: 39.99},
+		{baseFee: 44.99, tariff: 39.99, want: "Grundgebühr 44,99 € statt Tarifpreis 39,99 €"},
+		{baseFee: 44.99},
+		{tariff: 39.99},
+	}
+	for _, tc := range tests {
+		if got := tariffMismatch(tc.baseFee, tc.tariff); got != tc.want {
+			t.Errorf("tariffMismatch(%v, %v) = %q, want %q", tc.baseFee, tc.tariff, got, tc.want)
+		}
+	}
+}
+
+func TestCheckTariffsReplay(t *testing.T) {
+	rec, err := NewSessionRecorder(t.TempDir(), time.Now())
+	if err != nil {
+		t.Fatalf("newSessionRecorder() error: %v", err)
+	}
+	rec.text("kabel_tariff", func() (string, string) { return "GigaZuhause 250\n39,99 € mtl.", "" })
+	rec.Save()
+	s, err := OpenSession(rec.dir)
+	if err != nil {
+		t.Fatalf("openSession() error: %v", err)
+	}
+
+	d := NewClient(Config{})
+	d.Session = s
+	invoices := []provider.Invoice{
+		{Type: "Kabel", BaseFee: 44.99},
+		{Type: "Mobilfunk 0172 1234567", BaseFee: 9.99},
+	}
+	d.CheckTariffs(context.Background(), invoices, nil)
+	if invoices[0].Tariff == "" {
+		t.Error("Kabel: mismatch not flagged")
+	}
+	if invoices[1].Tariff != "" {
+		t.Errorf("subscriber invoice flagged: %q", invoices[1].Tariff)
+	}
+	if want := "Grundgebühr 44,99 € statt Tarifpreis 39,99 €"; invoices[0].Tariff != want {
+		t.Errorf("Kabel: Tariff = %q, want %q", invoices[0].Tariff, want)
+	}
+}
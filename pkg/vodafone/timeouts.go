@@ -0,0 +1,74 @@
+package vodafone
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Timeouts bound the browser session and the waits for the portal's pages.
+type Timeouts struct {
+	Total      time.Duration // a headless browser session
+	PageLoad   time.Duration // until a page or form is expected to have loaded
+	PDFCapture time.Duration // until an invoice PDF is expected after clicking its link
+}
+
+// DefaultTimeouts are used for settings that aren't configured.
+var DefaultTimeouts = Timeouts{Total: 5 * time.Minute, PageLoad: 15 * time.Second, PDFCapture: 5 * time.Second}
+
+// settle is the pause after a navigation or click for the page's scripts to render
+// it, a fifth of the page load timeout.
+func (t Timeouts) settle() time.Duration { return t.PageLoad / 5 }
+
+// submit is the pause after submitting the login or a one-time code, a third of the
+// page load timeout.
+func (t Timeouts) submit() time.Duration { return t.PageLoad / 3 }
+
+// NewTimeouts validates the timeouts section.
+func NewTimeouts(c TimeoutsConfig) (Timeouts, error) {
+	t := DefaultTimeouts
+	for _, setting := range []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{"total", c.Total, &t.Total},
+		{"page_load", c.PageLoad, &t.PageLoad},
+		{"pdf_capture", c.PDFCapture, &t.PDFCapture},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("invalid timeouts.%s: %v", setting.name, err)
+		}
+		if d <= 0 {
+			return Timeouts{}, fmt.Errorf("invalid timeouts.%s: must be positive", setting.name)
+		}
+		*setting.d = d
+	}
+	return t, nil
+}
+
+type timeoutsKey struct{}
+
+// withTimeouts returns a context carrying t, so every step run in the browser
+// context waits as configured.
+func withTimeouts(ctx context.Context, t Timeouts) context.Context {
+	return context.WithValue(ctx, timeoutsKey{}, t)
+}
+
+// stepTimeouts returns the timeouts carried by ctx, the defaults if there are none.
+func stepTimeouts(ctx context.Context) Timeouts {
+	if t, ok := ctx.Value(timeoutsKey{}).(Timeouts); ok {
+		return t
+	}
+	return DefaultTimeouts
+}
+
+type TimeoutsConfig struct {
+	Total      string `yaml:"total"`       // a headless browser session, default 5m
+	PageLoad   string `yaml:"page_load"`   // wait for a page or form to load, default 15s
+	PDFCapture string `yaml:"pdf_capture"` // wait for an invoice PDF after the click, default 5s
+}
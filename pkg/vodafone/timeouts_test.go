@@ -0,0 +1,55 @@
+package vodafone
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TimeoutsConfig
+		want    Timeouts
+		wantErr bool
+	}{
+		{name: "defaults", want: Timeouts{Total: 5 * time.Minute, PageLoad: 15 * time.Second, PDFCapture: 5 * time.Second}},
+		{name: "slow connection", config: TimeoutsConfig{Total: "15m", PageLoad: "45s", PDFCapture: "30s"}, want: Timeouts{Total: 15 * time.Minute, PageLoad: 45 * time.Second, PDFCapture: 30 * time.Second}},
+		{name: "partly set", config: TimeoutsConfig{PDFCapture: "20s"}, want: Timeouts{Total: 5 * time.Minute, PageLoad: 15 * time.Second, PDFCapture: 20 * time.Second}},
+		{name: "no unit", config: TimeoutsConfig{PageLoad: "30"}, wantErr: true},
+		{name: "zero", config: TimeoutsConfig{Total: "0s"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTimeouts(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("newTimeouts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepTimeouts(t *testing.T) {
+	if got := stepTimeouts(context.Background()); got != DefaultTimeouts {
+		t.Errorf("stepTimeouts() without timeouts = %+v, want the defaults", got)
+	}
+	if d := DefaultTimeouts.settle(); d != 3*time.Second {
+		t.Errorf("default settle() = %s, want 3s", d)
+	}
+	if d := DefaultTimeouts.submit(); d != 5*time.Second {
+		t.Errorf("default submit() = %s, want 5s", d)
+	}
+
+	slow := Timeouts{Total: 15 * time.Minute, PageLoad: 45 * time.Second, PDFCapture: 30 * time.Second}
+	ctx, cancel := context.WithTimeout(withTimeouts(context.Background(), slow), time.Minute)
+	defer cancel()
+	if got := stepTimeouts(ctx); got != slow {
+		t.Errorf("stepTimeouts() = %+v, want %+v", got, slow)
+	}
+	if d := stepTimeouts(ctx).settle(); d != 9*time.Second {
+		t.Errorf("settle() = %s, want 9s", d)
+	}
+}
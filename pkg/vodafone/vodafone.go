@@ -0,0 +1,104 @@
+// Package vodafone logs into MeinVodafone with Chrome and downloads the invoices of
+// the account's Kabel, Mobilfunk and DSL contracts. Client implements
+// provider.Provider.
+//
+//	client := vodafone.NewClient(vodafone.Config{User: user, Pass: pass})
+//	ctx, cancel := vodafone.NewBrowserContext("", "", true, vodafone.DefaultTimeouts)
+//	defer cancel()
+//	if err := client.Login(ctx); err != nil {
+//		return err
+//	}
+//	invoices, failures := client.Download(ctx)
+package vodafone
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the release of the downloader, recorded in session bundles.
+const Version = "1.7.0"
+
+// ContractTypes maps the contract types to their names in emails and file names.
+var ContractTypes = map[string]string{
+	"mobilfunk": "Mobilfunk",
+	"kabel":     "Kabel",
+	"dsl":       "DSL",
+}
+
+// DefaultContracts are the contract types downloaded unless vodafone.contracts is set.
+var DefaultContracts = []string{"kabel", "mobilfunk"}
+
+// contractHeadings are the headings of the contract cards on the services page, for
+// contract types whose card isn't headed "<type name>-Vertrag".
+var contractHeadings = map[string][]string{
+	"DSL": {"DSL-Vertrag", "Internet & Festnetz", "Internet und Festnetz"},
+}
+
+// Contracts returns the contract types to download, in the configured order.
+func Contracts(c Config) ([]string, error) {
+	if len(c.Contracts) == 0 {
+		return DefaultContracts, nil
+	}
+	var contracts []string
+	for _, contract := range c.Contracts {
+		contractType := strings.ToLower(contract)
+		if _, ok := ContractTypes[contractType]; !ok {
+			return nil, fmt.Errorf("vodafone.contracts: unknown contract type %q", contract)
+		}
+		contracts = append(contracts, contractType)
+	}
+	return contracts, nil
+}
+
+var months = map[string]string{
+	"Januar": "01", "Februar": "02", "März": "03", "April": "04",
+	"Mai": "05", "Juni": "06", "Juli": "07", "August": "08",
+	"September": "09", "Oktober": "10", "November": "11", "Dezember": "12",
+}
+
+// MonthNames are the German month names, indexed by time.Month.
+var MonthNames = []string{"", "Januar", "Februar", "März", "April", "Mai", "Juni",
+	"Juli", "August", "September", "Oktober", "November", "Dezember"}
+
+// Config is the vodafone section of the config file: the account and how its
+// contracts are downloaded.
+type Config struct {
+	User        string            `yaml:"user"`
+	Pass        string            `yaml:"pass"`
+	Contracts   []string          `yaml:"contracts"`    // contract types to download: mobilfunk, kabel, dsl (default mobilfunk and kabel)
+	Discover    bool              `yaml:"discover"`     // download every contract card of these types found on the services page
+	InvoiceURLs map[string]string `yaml:"invoice_urls"` // contract type -> direct invoice page URL
+	Subscribers []string          `yaml:"subscribers"`  // Mobilfunk phone numbers to download individually
+	EVN         bool              `yaml:"evn"`          // also download each subscriber's Einzelverbindungsnachweis
+	ProfileDir  string            `yaml:"profile_dir"`  // Chrome profile kept between runs, see "login --interactive"
+	CookieFile  string            `yaml:"cookie_file"`  // session cookies kept between runs, a lighter alternative to profile_dir
+	OTPCommand  string            `yaml:"otp_command"`  // prints the current one-time code when 2FA is requested
+	TOTPSecret  string            `yaml:"totp_secret"`  // base32 TOTP secret, generates the code itself
+	Block       []string          `yaml:"block"`        // resource kinds not loaded: images, fonts, media, analytics
+	MemoryLimit string            `yaml:"memory_limit"` // abort when Chrome uses more memory, e.g. "600MB"
+	JSHeap      string            `yaml:"js_heap"`      // JavaScript heap limit per page, e.g. "256MB"
+	Parallel    bool              `yaml:"parallel"`     // download the contracts at the same time, each in a tab of its own
+}
+
+// amountPattern matches a German-formatted euro amount such as "24,98" or "1.234,56".
+const amountPattern = `\d{1,3}(?:\.\d{3})*,\d{2}`
+
+// ParseAmount converts a German-formatted amount ("1.234,56") to a float.
+func ParseAmount(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ".", "")
+	amount, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
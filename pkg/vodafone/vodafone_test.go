@@ -0,0 +1,123 @@
+package vodafone
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMonthsMapCompleteness(t *testing.T) {
+	expectedMonths := map[string]string{
+		"Januar": "01", "Februar": "02", "März": "03", "April": "04",
+		"Mai": "05", "Juni": "06", "Juli": "07", "August": "08",
+		"September": "09", "Oktober": "10", "November": "11", "Dezember": "12",
+	}
+
+	if len(months) != 12 {
+		t.Errorf("months map has %d entries, want 12", len(months))
+	}
+
+	for name, num := range expectedMonths {
+		if got, ok := months[name]; !ok {
+			t.Errorf("months map missing %q", name)
+		} else if got != num {
+			t.Errorf("months[%q] = %q, want %q", name, got, num)
+		}
+	}
+}
+
+func TestMonthNamesCompleteness(t *testing.T) {
+	if len(MonthNames) != 13 {
+		t.Fatalf("monthNames has %d entries, want 13 (index 0 is empty)", len(MonthNames))
+	}
+
+	if MonthNames[0] != "" {
+		t.Errorf("monthNames[0] = %q, want empty string", MonthNames[0])
+	}
+
+	expected := []string{"", "Januar", "Februar", "März", "April", "Mai", "Juni",
+		"Juli", "August", "September", "Oktober", "November", "Dezember"}
+
+	for i, want := range expected {
+		if MonthNames[i] != want {
+			t.Errorf("monthNames[%d] = %q, want %q", i, MonthNames[i], want)
+		}
+	}
+}
+
+func TestContractTypes(t *testing.T) {
+	if len(ContractTypes) != 3 {
+		t.Errorf("contractTypes has %d entries, want 3", len(ContractTypes))
+	}
+
+	if ContractTypes["mobilfunk"] != "Mobilfunk" {
+		t.Errorf("contractTypes[mobilfunk] = %q, want %q", ContractTypes["mobilfunk"], "Mobilfunk")
+	}
+	if ContractTypes["kabel"] != "Kabel" {
+		t.Errorf("contractTypes[kabel] = %q, want %q", ContractTypes["kabel"], "Kabel")
+	}
+	if ContractTypes["dsl"] != "DSL" {
+		t.Errorf("contractTypes[dsl] = %q, want %q", ContractTypes["dsl"], "DSL")
+	}
+}
+
+func TestContracts(t *testing.T) {
+	tests := []struct {
+		name      string
+		contracts []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "default", want: []string{"kabel", "mobilfunk"}},
+		{name: "configured order", contracts: []string{"DSL", "mobilfunk"}, want: []string{"dsl", "mobilfunk"}},
+		{name: "unknown", contracts: []string{"festnetz"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Contracts(Config{Contracts: tc.contracts})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Contracts() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Contracts() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMonthsAndMonthNamesConsistency(t *testing.T) {
+	// Verify that every entry in monthNames (except index 0) has a corresponding months entry
+	for i := 1; i < len(MonthNames); i++ {
+		name := MonthNames[i]
+		num, ok := months[name]
+		if !ok {
+			t.Errorf("monthNames[%d] = %q has no entry in months map", i, name)
+			continue
+		}
+		expected := fmt.Sprintf("%02d", i)
+		if num != expected {
+			t.Errorf("months[%q] = %q, want %q (index %d)", name, num, expected, i)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		{"24,98", 24.98, true},
+		{"1.234,56", 1234.56, true},
+		{" 0,99 ", 0.99, true},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := ParseAmount(tc.in)
+		if ok != tc.wantOK || got != tc.want {
+			t.Errorf("ParseAmount(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}